@@ -1,5 +1,9 @@
 package concurrent
 
+// The Async helpers run fn in a goroutine and return an awaitable for its results.
+//  The result is buffered so the goroutine ends even when it is never awaited,
+//  and a panic of fn is returned as a PanicError.
+
 type ret1 struct {
 	err error
 }
@@ -19,10 +23,10 @@ func Async1Ret1[A any](
 	a A,
 ) await1 {
 
-	ch := make(chan ret1)
+	ch := make(chan ret1, 1)
 
 	go func() {
-		err := fn(a)
+		err := protect(func() error { return fn(a) })
 		ch <- ret1{err: err}
 	}()
 
@@ -45,16 +49,39 @@ func (a await3[R0, R1]) Await() (R0, R1, error) {
 	return r.v0, r.v1, r.err
 }
 
+func Async1Ret3[A any, R0, R1 any](
+	fn func(A) (R0, R1, error),
+	a A,
+) await3[R0, R1] {
+
+	ch := make(chan ret3[R0, R1], 1)
+
+	go func() {
+		var r ret3[R0, R1]
+		r.err = protect(func() (err error) {
+			r.v0, r.v1, err = fn(a)
+			return err
+		})
+		ch <- r
+	}()
+
+	return await3[R0, R1]{ch: ch}
+}
+
 func Async4Ret3[A, B, C, D any, R0, R1 any](
 	fn func(A, B, C, D) (R0, R1, error),
 	a A, b B, c C, d D,
 ) await3[R0, R1] {
 
-	ch := make(chan ret3[R0, R1])
+	ch := make(chan ret3[R0, R1], 1)
 
 	go func() {
-		v0, v1, err := fn(a, b, c, d)
-		ch <- ret3[R0, R1]{v0: v0, v1: v1, err: err}
+		var r ret3[R0, R1]
+		r.err = protect(func() (err error) {
+			r.v0, r.v1, err = fn(a, b, c, d)
+			return err
+		})
+		ch <- r
 	}()
 
 	return await3[R0, R1]{ch: ch}
@@ -65,11 +92,15 @@ func Async5Ret3[A, B, C, D, E any, R0, R1 any](
 	a A, b B, c C, d D, e E,
 ) await3[R0, R1] {
 
-	ch := make(chan ret3[R0, R1])
+	ch := make(chan ret3[R0, R1], 1)
 
 	go func() {
-		v0, v1, err := fn(a, b, c, d, e)
-		ch <- ret3[R0, R1]{v0: v0, v1: v1, err: err}
+		var r ret3[R0, R1]
+		r.err = protect(func() (err error) {
+			r.v0, r.v1, err = fn(a, b, c, d, e)
+			return err
+		})
+		ch <- r
 	}()
 
 	return await3[R0, R1]{ch: ch}
@@ -20,6 +20,21 @@ func TestAsync1Ret1(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAsync1Ret3(t *testing.T) {
+	await := concurrent.Async1Ret3(
+		func(a int) (int, int, error) {
+			return a + 1, a * 2, nil
+		},
+		3,
+	)
+
+	add, mult, err := await.Await()
+
+	require.NoError(t, err)
+	require.Equal(t, 4, add)
+	require.Equal(t, 6, mult)
+}
+
 func TestAsync4Ret3(t *testing.T) {
 	await := concurrent.Async4Ret3(
 		func(a, b, c, d int) (int, int, error) {
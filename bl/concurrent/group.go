@@ -0,0 +1,113 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// DefaultLimit is the number of functions run at once when no limit is given
+const DefaultLimit = 8
+
+// PanicError is returned in place of the error of a function which panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Group runs functions in parallel on a bounded number of workers.
+//
+//	The context given to the functions is cancelled on the first failure, so
+//	outstanding work stops and functions not started yet are skipped. Wait
+//	returns the errors of all failed functions joined, a panic is returned as a
+//	PanicError instead of crashing the program.
+type Group struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	limit  chan struct{}
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	errs    []error
+	skipped bool
+}
+
+// NewGroup returns a group running at most limit functions at once, DefaultLimit when limit isn't positive
+func NewGroup(ctx context.Context, limit int) *Group {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	groupCtx, cancel := context.WithCancel(ctx)
+	return &Group{
+		parent: ctx,
+		ctx:    groupCtx,
+		cancel: cancel,
+		limit:  make(chan struct{}, limit),
+	}
+}
+
+// Go runs fn once a worker is free, it is skipped when the group was cancelled before
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	acquired := false
+	select {
+	case g.limit <- struct{}{}:
+		acquired = true
+	case <-g.ctx.Done():
+	}
+	if g.ctx.Err() != nil {
+		if acquired {
+			<-g.limit
+		}
+		g.mu.Lock()
+		g.skipped = true
+		g.mu.Unlock()
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() { <-g.limit }()
+		err := protect(func() error { return fn(g.ctx) })
+		if err == nil {
+			return
+		}
+		// work cancelled because another function failed isn't a failure of its own
+		if g.ctx.Err() != nil && g.parent.Err() == nil && errors.Is(err, context.Canceled) {
+			return
+		}
+		g.mu.Lock()
+		g.errs = append(g.errs, err)
+		g.mu.Unlock()
+		g.cancel()
+	}()
+}
+
+// Wait waits for the started functions and returns their errors joined,
+//
+//	or the error of the parent context when it was cancelled before all
+//	functions could start.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	if len(g.errs) == 0 && g.skipped {
+		return g.parent.Err()
+	}
+	return errors.Join(g.errs...)
+}
+
+// protect runs fn and returns a PanicError if it panics
+func protect(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
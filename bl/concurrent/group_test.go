@@ -0,0 +1,79 @@
+package concurrent_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/bl/concurrent"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupLimit(t *testing.T) {
+	group := concurrent.NewGroup(context.Background(), 2)
+	var running, most atomic.Int32
+	for i := 0; i < 10; i++ {
+		group.Go(func(ctx context.Context) error {
+			n := running.Add(1)
+			for {
+				m := most.Load()
+				if n <= m || most.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+
+	require.NoError(t, group.Wait())
+	require.Equal(t, int32(2), most.Load())
+}
+
+func TestGroupCancel(t *testing.T) {
+	group := concurrent.NewGroup(context.Background(), 2)
+	failure := errors.New("failure")
+	group.Go(func(ctx context.Context) error {
+		// outstanding work sees the cancellation, its error isn't reported
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	group.Go(func(ctx context.Context) error {
+		return failure
+	})
+	var skipped atomic.Bool
+	group.Go(func(ctx context.Context) error {
+		skipped.Store(true)
+		return nil
+	})
+
+	err := group.Wait()
+	require.ErrorIs(t, err, failure)
+	require.NotErrorIs(t, err, context.Canceled)
+	require.False(t, skipped.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	group = concurrent.NewGroup(ctx, 2)
+	group.Go(func(ctx context.Context) error { return nil })
+	require.ErrorIs(t, group.Wait(), context.Canceled)
+}
+
+func TestGroupPanic(t *testing.T) {
+	group := concurrent.NewGroup(context.Background(), 0)
+	group.Go(func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	var panicErr *concurrent.PanicError
+	require.ErrorAs(t, group.Wait(), &panicErr)
+	require.Equal(t, "boom", panicErr.Value)
+
+	_, _, err := concurrent.Async1Ret3(func(a int) (int, int, error) {
+		panic("async boom")
+	}, 1).Await()
+	require.ErrorAs(t, err, &panicErr)
+}
@@ -1,63 +1,28 @@
 package concurrent
 
-// SliceMap executes a function in parallel for each element in the slice. Returns the output in a slice.
-// The output elements will be in the same order as the input.
-func SliceMap[I any, O any](ins []I, fn func(I) (O, error)) ([]O, error) {
-	type res struct {
-		val   O
-		err   error
-		index int
-	}
-	ch := make(chan res)
-	defer func() { close(ch) }()
-
-	for i, in := range ins {
-		go func() {
-			o, err := fn(in)
-			ch <- res{val: o, err: err, index: i}
-		}()
-	}
-
-	var err error
-	out := make([]O, len(ins))
-	for _ = range ins {
-		res := <-ch
-		if res.err != nil {
-			err = res.err
-		}
-		out[res.index] = res.val
-	}
-
-	return out, err
+import "context"
+
+// SliceMap executes a function in parallel for each element in the slice, at most limit at once. Returns the output in a slice.
+// The output elements will be in the same order as the input. The first failure cancels the context of the other
+// calls and the errors of all failed calls are returned joined, see Group.
+func SliceMap[I any, O any](ctx context.Context, limit int, ins []I, fn func(context.Context, I) (O, error)) ([]O, error) {
+	return SliceMapWithIndex(ctx, limit, ins, func(ctx context.Context, _ int, in I) (O, error) {
+		return fn(ctx, in)
+	})
 }
 
-// SliceMapWithIndex executes a function in parallel for each element in the slice. Returns the output in a slice.
-// The output elements will be in the same order as the input.
-func SliceMapWithIndex[I any, O any](ins []I, fn func(int, I) (O, error)) ([]O, error) {
-	type res struct {
-		val   O
-		err   error
-		index int
-	}
-	ch := make(chan res)
-	defer func() { close(ch) }()
-
-	for i, in := range ins {
-		go func() {
-			o, err := fn(i, in)
-			ch <- res{val: o, err: err, index: i}
-		}()
-	}
-
-	var err error
+// SliceMapWithIndex executes a function in parallel for each element in the slice, at most limit at once. Returns the output in a slice.
+// The output elements will be in the same order as the input. The first failure cancels the context of the other
+// calls and the errors of all failed calls are returned joined, see Group.
+func SliceMapWithIndex[I any, O any](ctx context.Context, limit int, ins []I, fn func(context.Context, int, I) (O, error)) ([]O, error) {
 	out := make([]O, len(ins))
-	for _ = range ins {
-		res := <-ch
-		if res.err != nil {
-			err = res.err
-		}
-		out[res.index] = res.val
+	group := NewGroup(ctx, limit)
+	for i, in := range ins {
+		group.Go(func(ctx context.Context) error {
+			o, err := fn(ctx, i, in)
+			out[i] = o
+			return err
+		})
 	}
-
-	return out, err
+	return out, group.Wait()
 }
@@ -1,6 +1,9 @@
 package concurrent_test
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,7 +13,7 @@ import (
 
 func TestSliceMap(t *testing.T) {
 	in := []int{3, 2, 1}
-	out, err := concurrent.SliceMap(in, func(i int) (int, error) {
+	out, err := concurrent.SliceMap(context.Background(), 2, in, func(ctx context.Context, i int) (int, error) {
 		// Sleep to make functions (more likely) to finish in non-deterministic order
 		time.Sleep(time.Duration(i))
 		return i + 1, nil
@@ -23,7 +26,7 @@ func TestSliceMap(t *testing.T) {
 
 func TestSliceMapWithIndex(t *testing.T) {
 	in := []int{30, 20, 10}
-	out, err := concurrent.SliceMapWithIndex(in, func(index, i int) (int, error) {
+	out, err := concurrent.SliceMapWithIndex(context.Background(), 0, in, func(ctx context.Context, index, i int) (int, error) {
 		// Sleep to make functions (more likely) to finish in non-deterministic order
 		time.Sleep(time.Duration(i + index))
 		return i + index, nil
@@ -33,3 +36,25 @@ func TestSliceMapWithIndex(t *testing.T) {
 
 	require.Equal(t, []int{30, 21, 12}, out)
 }
+
+func TestSliceMapErrors(t *testing.T) {
+	in := []int{1, 2, 3, 4}
+	var started atomic.Int32
+	_, err := concurrent.SliceMap(context.Background(), 1, in, func(ctx context.Context, i int) (int, error) {
+		started.Add(1)
+		return 0, fmt.Errorf("failed %d", i)
+	})
+
+	// with one worker the first failure cancels the calls not started yet
+	require.EqualError(t, err, "failed 1")
+	require.Equal(t, int32(1), started.Load())
+
+	_, err = concurrent.SliceMap(context.Background(), 0, in, func(ctx context.Context, i int) (int, error) {
+		if i%2 == 0 {
+			return 0, fmt.Errorf("failed %d", i)
+		}
+		return i, nil
+	})
+	require.ErrorContains(t, err, "failed 2")
+	require.ErrorContains(t, err, "failed 4")
+}
@@ -6,29 +6,29 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"path"
-	"strconv"
+	"regexp"
 	"strings"
 
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/git"
 	"github.com/ejoffe/spr/git/realgit"
-	"github.com/ejoffe/spr/github"
-	"github.com/ejoffe/spr/github/githubclient"
 	ngit "github.com/go-git/go-git/v5"
 	ngitconfig "github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	gogithub "github.com/google/go-github/v69/github"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
+// GitApi works on the local repository and pushes the branches of PR sets,
+//
+//	pull requests are changed through the github.Forge.
 type GitApi struct {
-	config     *config.Config
-	repo       *ngit.Repository
-	goghclient *gogithub.Client
+	config *config.Config
+	repo   *ngit.Repository
 }
 
-func New(config *config.Config, repo *ngit.Repository, goghclient *gogithub.Client) GitApi {
-	return GitApi{config: config, repo: repo, goghclient: goghclient}
+func New(config *config.Config, repo *ngit.Repository) GitApi {
+	return GitApi{config: config, repo: repo}
 }
 
 // OriginMainRef returns the ref for the default remote and the default branch (often origin/main)
@@ -50,17 +50,13 @@ func (gapi GitApi) OriginBranchRef(ctx context.Context, branch string) (*plumbin
 	return originMainRef, nil
 }
 
-// DeletePullRequest deletes the pull request and the associated branch
-func (gapi GitApi) DeletePullRequest(ctx context.Context, pr *github.PullRequest) error {
-	owner := gapi.config.Repo.GitHubRepoOwner
-	repoName := gapi.config.Repo.GitHubRepoName
-
-	_, _, err := gapi.goghclient.PullRequests.Edit(ctx, owner, repoName, pr.Number, &gogithub.PullRequest{State: gogithub.Ptr("closed")})
+// RemoteBranchHead returns the last fetched head of the remote branch, or an empty string when the branch is unknown
+func (gapi GitApi) RemoteBranchHead(ctx context.Context, branch string) string {
+	ref, err := gapi.OriginBranchRef(ctx, branch)
 	if err != nil {
-		return fmt.Errorf("deleting pr %d %w", pr.Number, err)
+		return ""
 	}
-
-	return gapi.DeleteRemoteBranch(ctx, pr.FromBranch)
+	return ref.Hash().String()
 }
 
 func (gapi GitApi) DeleteRemoteBranch(ctx context.Context, branch string) error {
@@ -94,7 +90,10 @@ func (gapi GitApi) DeleteRemoteBranch(ctx context.Context, branch string) error
 func (gapi GitApi) CreateRemoteBranchWithCherryPick(ctx context.Context, branchName string, destBranchName string, sha string) error {
 	// The "github.com/go-git/go-git/" doesn't support cherry picks so we
 	//have to do this by shelling out to the command line
-	gitshell := realgit.NewGitCmd(gapi.config)
+	gitshell, err := realgit.NewGitCmd(gapi.config)
+	if err != nil {
+		return err
+	}
 
 	destBranchRef, err := gapi.OriginBranchRef(ctx, destBranchName)
 	if err != nil {
@@ -137,7 +136,10 @@ func (gapi GitApi) CreateRemoteBranchWithCherryPick(ctx context.Context, branchN
 	cleanup.worktree = tempDir
 
 	// Create a shell for the new worktree
-	gitworktreeshell := realgit.NewGitCmd(gapi.config)
+	gitworktreeshell, err := realgit.NewGitCmd(gapi.config)
+	if err != nil {
+		return err
+	}
 	gitworktreeshell.SetRootDir(tempDir)
 
 	// Create the local branch if it doesn't already exist
@@ -156,7 +158,7 @@ func (gapi GitApi) CreateRemoteBranchWithCherryPick(ctx context.Context, branchN
 	err = gitworktreeshell.Git(fmt.Sprintf("cherry-pick %s", sha), &output)
 	if err != nil {
 		if strings.Contains(output, "Merge conflict in") {
-			return fmt.Errorf("Unable to add %s to the PR set as an earlier commit is required for it to merge properly.\n", sha)
+			return fmt.Errorf("%w: Unable to add %s to the PR set as an earlier commit is required for it to merge properly", git.ErrRebaseConflict, sha)
 		}
 		return fmt.Errorf("cherry picking %s into %s in worktree %s %w", sha, branchName, tempDir, err)
 	}
@@ -171,13 +173,24 @@ func (gapi GitApi) CreateRemoteBranchWithCherryPick(ctx context.Context, branchN
 }
 
 func (gapi GitApi) AppendCommitId() error {
+	missing, err := gapi.missingCommitId()
+	if err != nil {
+		return err
+	}
+	if !missing {
+		return nil
+	}
+
 	// The "github.com/go-git/go-git/" doesn't (easily) support updating a commit message so we have to do this by
 	// shelling out to the command line
-	gitshell := realgit.NewGitCmd(gapi.config)
+	gitshell, err := realgit.NewGitCmd(gapi.config)
+	if err != nil {
+		return err
+	}
 
 	rewordPath, err := exec.LookPath("spr_reword_helper")
 	if err != nil {
-		fmt.Errorf("can't find spr_reword_helper %w", err)
+		return fmt.Errorf("can't find spr_reword_helper %w", err)
 	}
 	rebaseCommand := fmt.Sprintf(
 		"rebase %s/%s -i --autosquash --autostash",
@@ -186,12 +199,43 @@ func (gapi GitApi) AppendCommitId() error {
 	)
 	err = gitshell.GitWithEditor(rebaseCommand, nil, rewordPath)
 	if err != nil {
-		fmt.Errorf("can't execute spr_reword_helper %w", err)
+		return fmt.Errorf("can't execute spr_reword_helper %w", err)
 	}
 
 	return nil
 }
 
+var commitIdRegex = regexp.MustCompile(`(?m)^commit-id\:[a-f0-9]{8}$`)
+
+// missingCommitId returns true if any of the local commits on top of origin main is missing a commit-id
+func (gapi GitApi) missingCommitId() (bool, error) {
+	headRef, err := gapi.repo.Head()
+	if err != nil {
+		return false, fmt.Errorf("getting repo HEAD %w", err)
+	}
+	originMainRef, err := gapi.OriginMainRef(context.Background())
+	if err != nil {
+		return false, err
+	}
+	commitIter, err := gapi.repo.Log(&ngit.LogOptions{From: headRef.Hash()})
+	if err != nil {
+		return false, fmt.Errorf("getting iterator for commits %w", err)
+	}
+
+	missing := false
+	err = commitIter.ForEach(func(cm *object.Commit) error {
+		if cm.Hash == originMainRef.Hash() {
+			return storer.ErrStop
+		}
+		if !commitIdRegex.MatchString(cm.Message) {
+			missing = true
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return missing, err
+}
+
 func (gapi GitApi) BranchExists(branchName string) (bool, error) {
 	iter, err := gapi.repo.Branches()
 	if err != nil {
@@ -211,177 +255,3 @@ func (gapi GitApi) BranchExists(branchName string) (bool, error) {
 	})
 	return branchExists, nil
 }
-
-func (gapi GitApi) CreatePullRequest(
-	ctx context.Context,
-	commit git.Commit,
-	prevCommit *git.Commit,
-) (*github.PullRequest, error) {
-
-	headRefName, baseRefName := gapi.getBranches(commit, prevCommit)
-
-	body, err := gapi.getBody(commit, nil)
-	if err != nil {
-		return nil, fmt.Errorf("getting body %w", err)
-	}
-
-	owner := gapi.config.Repo.GitHubRepoOwner
-	repoName := gapi.config.Repo.GitHubRepoName
-
-	resp, _, err := gapi.goghclient.PullRequests.Create(ctx, owner, repoName, &gogithub.NewPullRequest{
-		Title:    &commit.Subject,
-		Head:     &headRefName,
-		HeadRepo: &gapi.config.Repo.GitHubRepoName,
-		Base:     &baseRefName,
-		Body:     &body,
-		Draft:    gogithub.Ptr(false), // We always create draft PRs then we do an update (to link them together) with an update.
-	})
-	if err != nil {
-		return nil, fmt.Errorf("creating PR for commit %s: %w", commit.CommitHash, err)
-	}
-
-	pr := &github.PullRequest{
-		ID:         strconv.FormatInt(*resp.ID, 10),
-		Number:     *resp.Number,
-		FromBranch: headRefName,
-		ToBranch:   baseRefName,
-		Commit:     commit,
-		Title:      commit.Subject,
-		MergeStatus: github.PullRequestMergeStatus{
-			ChecksPass:     github.CheckStatusUnknown,
-			ReviewApproved: false,
-			NoConflicts:    false,
-			Stacked:        false,
-		},
-	}
-
-	return pr, nil
-}
-
-// UpdatePullRequest - updates an existing PR.
-// pullRequests is used to create links to related pull requests
-// prevCommit is used to compute the destination branch name.
-func (gapi GitApi) UpdatePullRequest(
-	ctx context.Context,
-	pullRequests []*github.PullRequest,
-	pr *github.PullRequest,
-	commit git.Commit,
-	prevCommit *git.Commit,
-) error {
-	return gapi.updatePullRequest(ctx, pullRequests, pr, commit, prevCommit)
-}
-
-// UpdatePullRequestToMain - updates an existing PR to merge into main/master
-// pullRequests is used to create links to related pull requests
-func (gapi GitApi) UpdatePullRequestToMain(
-	ctx context.Context,
-	pullRequests []*github.PullRequest,
-	pr *github.PullRequest,
-	commit git.Commit,
-) error {
-	return gapi.updatePullRequest(ctx, pullRequests, pr, commit, nil)
-}
-
-// updatePullRequest - updates an existing PR.
-// pullRequests is used to create links to related pull requests
-// prevCommit is used to compute the destination branch name. If it is nil main/master will be used
-func (gapi GitApi) updatePullRequest(
-	ctx context.Context,
-	pullRequests []*github.PullRequest,
-	pr *github.PullRequest,
-	commit git.Commit,
-	prevCommit *git.Commit,
-) error {
-
-	// Note if prevCommit is nil then gapi.config.Repo.GitHubBranch is used
-	headRefName, baseRefName := gapi.getBranches(commit, prevCommit)
-
-	body, err := gapi.getBody(commit, pullRequests)
-	if err != nil {
-		return fmt.Errorf("getting body %w", err)
-	}
-
-	id, err := strconv.ParseInt(pr.ID, 10, 64)
-	if err != nil {
-		return fmt.Errorf("converting ID %s to integer %w", pr.ID, err)
-	}
-	title := &commit.Subject
-	owner := gapi.config.Repo.GitHubRepoOwner
-	repoName := gapi.config.Repo.GitHubRepoName
-
-	head := gogithub.PullRequestBranch{
-		Ref: gogithub.Ptr(headRefName),
-	}
-	base := gogithub.PullRequestBranch{
-		Ref: gogithub.Ptr(baseRefName),
-	}
-	_, _, err = gapi.goghclient.PullRequests.Edit(ctx, owner, repoName, pr.Number, &gogithub.PullRequest{
-		ID:    &id,
-		Title: title,
-		Body:  &body,
-		Draft: &gapi.config.User.CreateDraftPRs,
-		Head:  &head,
-		Base:  &base,
-	})
-	if err != nil {
-		return fmt.Errorf("updating PR for commit %s: %w", commit.CommitHash, err)
-	}
-
-	return nil
-}
-
-func (gapi GitApi) MergePullRequest(
-	ctx context.Context,
-	pr *github.PullRequest,
-) error {
-	owner := gapi.config.Repo.GitHubRepoOwner
-	repoName := gapi.config.Repo.GitHubRepoName
-
-	// Get the merge method
-	mergeMethod := gapi.config.Repo.MergeMethod
-
-	_, _, err := gapi.goghclient.PullRequests.Merge(ctx, owner, repoName, pr.Number, "", &gogithub.PullRequestOptions{
-		MergeMethod: string(mergeMethod),
-	})
-	if err != nil {
-		return fmt.Errorf("unable to merge %d %w", pr.Number, err)
-	}
-
-	return nil
-}
-
-// getBranches returns the head and base branch ref names
-func (gapi GitApi) getBranches(commit git.Commit, prevCommit *git.Commit) (string, string) {
-	baseRefName := gapi.config.Repo.GitHubBranch
-	if prevCommit != nil {
-		baseRefName = git.BranchNameFromCommit(gapi.config, *prevCommit)
-	}
-	headRefName := git.BranchNameFromCommit(gapi.config, commit)
-
-	return headRefName, baseRefName
-}
-
-func (gapi GitApi) getBody(commit git.Commit, pullRequests []*github.PullRequest) (string, error) {
-	body := githubclient.FormatBody(commit, pullRequests, gapi.config.Repo.ShowPrTitlesInStack)
-	if gapi.config.Repo.PRTemplatePath == "" {
-		return body, nil
-	}
-
-	w, err := gapi.repo.Worktree()
-	if err != nil {
-		return "", fmt.Errorf("getting worktree %w", err)
-	}
-	fullTemplatePath := path.Join(w.Filesystem.Root(), gapi.config.Repo.PRTemplatePath)
-	pullRequestTemplateBytes, err := os.ReadFile(fullTemplatePath)
-	if err != nil {
-		return "", fmt.Errorf("reading template file %s: %w", fullTemplatePath, err)
-	}
-	pullRequestTemplate := string(pullRequestTemplateBytes)
-
-	body, err = githubclient.InsertBodyIntoPRTemplate(body, pullRequestTemplate, gapi.config.Repo, nil)
-	if err != nil {
-		return "", fmt.Errorf("inserting body into PR template %s: %w", fullTemplatePath, err)
-	}
-
-	return body, nil
-}
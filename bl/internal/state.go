@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
-	"github.com/ejoffe/spr/bl/concurrent"
 	"github.com/ejoffe/spr/bl/gitapi"
 	"github.com/ejoffe/spr/bl/maputils"
 	"github.com/ejoffe/spr/config"
@@ -17,7 +16,6 @@ import (
 	ngit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
-	gogithub "github.com/google/go-github/v69/github"
 )
 
 // A PRCommit is a commit its associated Pull Request, and metadata.
@@ -53,12 +51,10 @@ type State struct {
 	Commits       []*PRCommit
 	OrphanedPRs   mapset.Set[*github.PullRequest]
 	MutatedPRSets mapset.Set[int]
-}
 
-type PullRequestStatus struct {
-	PullRequest    *gogithub.PullRequest
-	CombinedStatus *gogithub.CombinedStatus
-	Reviews        []*gogithub.PullRequestReview
+	// Truncated is set when not all open pull requests could be listed,
+	//  pull requests can then look orphaned while their commit is still in the stack
+	Truncated bool
 }
 
 func indexColor(i *int) string {
@@ -115,68 +111,16 @@ func (prc PRCommit) String(config *config.Config) string {
 	return github.TrimToTerminal(config, line)
 }
 
-// Generic function to convert a nil pointer to its zero value.
-// Works for any type.
-func derefOrDefault[T any](ptr *T) T {
-	if ptr == nil {
-		var zero T // Zero value of the type T
-		return zero
-	}
-	return *ptr
-}
-
 // NewReadState pulls git and github information and constructs the state of the local unmerged commits.
 // The resulting State contains the ordered and linked commits along with their associated PRs
-func NewReadState(ctx context.Context, config *config.Config, goghclient *gogithub.Client, repo *ngit.Repository) (*State, error) {
-	repoOwner := config.Repo.GitHubRepoOwner
-	repoName := config.Repo.GitHubRepoName
-
-	gitapi := gitapi.New(config, repo, goghclient)
-	gitapi.AppendCommitId()
-
-	prs, _, err := goghclient.PullRequests.List(
-		ctx,
-		repoOwner,
-		repoName,
-		nil,
-	)
+func NewReadState(ctx context.Context, config *config.Config, forge github.Forge, repo *ngit.Repository) (*State, error) {
+	gitapi := gitapi.New(config, repo)
+	err := gitapi.AppendCommitId()
 	if err != nil {
-		return nil, fmt.Errorf("getting pull requests for %s/%s: %w", repoOwner, repoName, err)
+		return nil, fmt.Errorf("adding commit-ids %w", err)
 	}
 
-	prss, err := concurrent.SliceMap(prs, func(pr *gogithub.PullRequest) (PullRequestStatus, error) {
-		getCombinedAwait := concurrent.Async5Ret3(
-			goghclient.Repositories.GetCombinedStatus,
-			ctx, repoOwner, repoName, *pr.Head.SHA, nil,
-		)
-
-		prListReviewsAwait := concurrent.Async5Ret3(
-			goghclient.PullRequests.ListReviews,
-			ctx, repoOwner, repoName, *pr.Number, nil,
-		)
-
-		prGetAwait := concurrent.Async4Ret3(
-			goghclient.PullRequests.Get,
-			ctx, repoOwner, repoName, *pr.Number,
-		)
-
-		combinedStatus, _, err := getCombinedAwait.Await()
-		if err != nil {
-			return PullRequestStatus{}, fmt.Errorf("getting combined status for %s/%s PR:%d: %w", repoOwner, repoName, *pr.Number, err)
-		}
-
-		reviews, _, err := prListReviewsAwait.Await()
-		if err != nil {
-			return PullRequestStatus{}, fmt.Errorf("getting pull request reviews for %s/%s PR:%d: %w", repoOwner, repoName, *pr.Number, err)
-		}
-
-		pr, _, err = prGetAwait.Await()
-		if err != nil {
-			return PullRequestStatus{}, fmt.Errorf("getting pull request details for %s/%s PR:%d: %w", repoOwner, repoName, *pr.Number, err)
-		}
-
-		return PullRequestStatus{PullRequest: pr, CombinedStatus: combinedStatus, Reviews: reviews}, nil
-	})
+	prs, truncated, err := forge.GetOpenPullRequests(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -206,7 +150,12 @@ func NewReadState(ctx context.Context, config *config.Config, goghclient *gogith
 		return nil
 	})
 
-	return NewState(ctx, config, prss, commits)
+	state, err := NewState(ctx, config, prs, commits)
+	if err != nil {
+		return nil, err
+	}
+	state.Truncated = truncated
+	return state, nil
 }
 
 // NewReadState composes git and github information and constructs the state of the local unmerged commits.
@@ -214,11 +163,11 @@ func NewReadState(ctx context.Context, config *config.Config, goghclient *gogith
 func NewState(
 	ctx context.Context,
 	config *config.Config,
-	prss []PullRequestStatus,
+	prs []*github.PullRequest,
 	commits []*object.Commit,
 ) (*State, error) {
 
-	prMap := GeneratePullRequestMap(prss)
+	prMap := GeneratePullRequestMap(config, prs)
 
 	gitCommits := GenerateCommits(commits)
 	for _, gitCommit := range gitCommits {
@@ -248,16 +197,15 @@ func AssignPullRequests(
 	if !ok {
 		prSetMap = map[string]int{}
 	}
-	// Purge any mappings that aren't used
+	// Purge the mappings of commits which are no longer in the stack.
+	//  A commit keeps its PR set when its PR isn't listed, the PR is then created again when the set is updated.
 	purgeMap := maputils.NewGC(prSetMap)
 
 	for _, gitCommit := range gitCommits {
+		if prIndex, ok := purgeMap.Lookup(gitCommit.CommitID); ok {
+			gitCommit.PRIndex = &prIndex
+		}
 		if pr, ok := prGCMap.Lookup(gitCommit.CommitID); ok {
-			var prIndexPtr *int
-			if prIndex, ok := purgeMap.Lookup(gitCommit.CommitID); ok {
-				prIndexPtr = &prIndex
-			}
-			gitCommit.PRIndex = prIndexPtr
 			gitCommit.PullRequest = pr
 			pr.Commit = gitCommit.Commit
 		}
@@ -440,76 +388,25 @@ func PullRequests(commits []*PRCommit) []*github.PullRequest {
 	return pullRequests
 }
 
-func GeneratePullRequestMap(prss []PullRequestStatus) map[string]*github.PullRequest {
-	if prss == nil {
-		return nil
-	}
-
+// GeneratePullRequestMap returns the pull requests on branches named by spr, keyed by the commit-id of the branch
+func GeneratePullRequestMap(config *config.Config, prs []*github.PullRequest) map[string]*github.PullRequest {
 	// Map of commitId -> github.PullRequests
 	prMap := map[string]*github.PullRequest{}
 
-	for _, prs := range prss {
-		pr := prs.PullRequest
-		commitId := CommitIdFromBranch(*pr.Head.Ref)
+	for _, pr := range prs {
+		commitId := CommitIdFromBranch(config, pr.FromBranch)
 		if commitId == "" {
 			continue
 		}
-		fromBranch := derefOrDefault(derefOrDefault(pr.Head).Ref)
-		toBranch := derefOrDefault(derefOrDefault(pr.Base).Ref)
-		ghpr := &github.PullRequest{
-			ID:          fmt.Sprintf("%d", *pr.ID),
-			Number:      derefOrDefault(pr.Number),
-			FromBranch:  fromBranch,
-			ToBranch:    toBranch,
-			Title:       derefOrDefault(pr.Title),
-			Body:        derefOrDefault(pr.Body),
-			MergeStatus: ComputeMergeStatus(prs),
-		}
-		prMap[commitId] = ghpr
+		prMap[commitId] = pr
 	}
 
 	return prMap
 }
 
-func CommitIdFromBranch(branchName string) string {
-	segments := strings.Split(branchName, "/")
-	if len(segments) != 3 {
-		return ""
-	}
-	if segments[0] != "spr" {
-		return ""
-	}
-	commitId := segments[2]
-	if len(commitId) != 8 {
-		return ""
-	}
-	return commitId
-}
-
-func ComputeMergeStatus(prs PullRequestStatus) github.PullRequestMergeStatus {
-	prms := github.PullRequestMergeStatus{}
-	if prs.CombinedStatus == nil || prs.CombinedStatus.State == nil {
-		prms.ChecksPass = github.CheckStatusUnknown
-	} else if prs.CombinedStatus.TotalCount != nil && *prs.CombinedStatus.TotalCount == 0 {
-		prms.ChecksPass = github.CheckStatusPass
-	} else if *prs.CombinedStatus.State == "success" {
-		prms.ChecksPass = github.CheckStatusPass
-	} else if *prs.CombinedStatus.State == "pending" {
-		prms.ChecksPass = github.CheckStatusPending
-	} else if *prs.CombinedStatus.State == "failure" {
-		prms.ChecksPass = github.CheckStatusFail
-	}
-
-	prms.NoConflicts = prs.PullRequest != nil && prs.PullRequest.Mergeable != nil && *prs.PullRequest.Mergeable
-
-	for _, review := range prs.Reviews {
-		if review.State != nil && *review.State == "APPROVED" {
-			prms.ReviewApproved = true
-			break
-		}
-	}
-
-	return prms
+// CommitIdFromBranch returns the commit-id of a pull request branch named from the branch name template
+func CommitIdFromBranch(config *config.Config, branchName string) string {
+	return git.CommitIDFromBranch(config, branchName)
 }
 
 func GenerateCommits(commits []*object.Commit) []*PRCommit {
@@ -536,6 +433,7 @@ func GenerateCommits(commits []*object.Commit) []*PRCommit {
 			Index:       len(commits) - (i + 1),
 			PRIndex:     nil,
 		}
+		c.ParseTrailers()
 		// Point the previous one to us
 		if child != nil {
 			child.Parent = c
@@ -21,6 +21,7 @@ func TestAssignPullRequests(t *testing.T) {
 	config.State.RepoToCommitIdToPRSet[t.Name()] = map[string]int{
 		"11111111": 1,
 		"22222222": 0,
+		"44444444": 0,
 		"99999999": 9,
 	}
 	gitCommits := []*internal.PRCommit{
@@ -42,6 +43,12 @@ func TestAssignPullRequests(t *testing.T) {
 				CommitID:   "33333333",
 			},
 		},
+		{
+			Commit: git.Commit{
+				CommitHash: "H4444444",
+				CommitID:   "44444444",
+			},
+		},
 	}
 
 	prMap := map[string]*github.PullRequest{
@@ -71,6 +78,11 @@ func TestAssignPullRequests(t *testing.T) {
 	require.Equal(t, 0, *gitCommits[1].PRIndex)
 	require.Nil(t, gitCommits[2].PRIndex)
 
+	// A commit whose PR isn't listed stays in its PR set
+	require.Nil(t, gitCommits[3].PullRequest)
+	require.Equal(t, 0, *gitCommits[3].PRIndex)
+	require.Equal(t, 0, config.State.RepoToCommitIdToPRSet[t.Name()]["44444444"])
+
 	// The PR also references the commit
 	require.Equal(t, gitCommits[0].CommitHash, gitCommits[0].PullRequest.Commit.CommitHash)
 	require.Equal(t, gitCommits[1].CommitHash, gitCommits[1].PullRequest.Commit.CommitHash)
@@ -380,135 +392,41 @@ func TestPullRequests(t *testing.T) {
 
 func TestGeneratePullRequestMap(t *testing.T) {
 	t.Run("handles no PRs", func(t *testing.T) {
-		prMap := bl.GeneratePullRequestMap([]bl.PullRequestStatus{})
+		prMap := bl.GeneratePullRequestMap(config.EmptyConfig(), nil)
 		require.Equal(t, map[string]*github.PullRequest{}, prMap)
 	})
 
 	t.Run("computes key based on head branch", func(t *testing.T) {
-		prMap := bl.GeneratePullRequestMap([]bl.PullRequestStatus{
-			{
-				PullRequest: &gogithub.PullRequest{
-					ID: gogithub.Ptr(int64(3)),
-					Head: &gogithub.PullRequestBranch{
-						Ref: gogithub.Ptr("spr/main/0f47588b"),
-					},
-				},
-			},
+		pr := &github.PullRequest{
+			ID:         "PR_3",
+			FromBranch: "spr/main/0f47588b",
+		}
+		prMap := bl.GeneratePullRequestMap(config.EmptyConfig(), []*github.PullRequest{
+			pr,
+			{ID: "PR_4", FromBranch: "feature"},
 		})
 		expected := map[string]*github.PullRequest{
-			"0f47588b": &github.PullRequest{
-				ID:         "3",
-				FromBranch: "spr/main/0f47588b",
-			},
+			"0f47588b": pr,
 		}
 		require.Equal(t, expected, prMap)
 	})
 }
 
 func TestCommitIdFromBranch(t *testing.T) {
-	require.Equal(t, "", bl.CommitIdFromBranch(""))
-	require.Equal(t, "", bl.CommitIdFromBranch("spr/"))
-	require.Equal(t, "", bl.CommitIdFromBranch("spr/main"))
-	require.Equal(t, "", bl.CommitIdFromBranch("spr/main/1234444"))
-	require.Equal(t, "", bl.CommitIdFromBranch("other/main/12344448"))
-	require.Equal(t, "12344448", bl.CommitIdFromBranch("spr/main/12344448"))
-}
-
-func TestComputeMergeStatus(t *testing.T) {
-	tests := []struct {
-		name string
-		prs  bl.PullRequestStatus
-		prms github.PullRequestMergeStatus
-	}{{
-		name: "no status checks",
-		prs: bl.PullRequestStatus{
-			PullRequest: &gogithub.PullRequest{},
-			CombinedStatus: &gogithub.CombinedStatus{
-				State:      gogithub.Ptr("pending"),
-				TotalCount: gogithub.Ptr(0),
-			},
-			Reviews: []*gogithub.PullRequestReview{},
-		},
-		prms: github.PullRequestMergeStatus{
-			ChecksPass:     github.CheckStatusPass,
-			NoConflicts:    false,
-			ReviewApproved: false,
-		},
-	},
-		{
-			name: "nil values",
-			prs: bl.PullRequestStatus{
-				PullRequest:    &gogithub.PullRequest{},
-				CombinedStatus: &gogithub.CombinedStatus{TotalCount: gogithub.Ptr(1)},
-				Reviews:        []*gogithub.PullRequestReview{},
-			},
-			prms: github.PullRequestMergeStatus{
-				ChecksPass:     github.CheckStatusUnknown,
-				NoConflicts:    false,
-				ReviewApproved: false,
-			},
-		}, {
-			name: "nil(er) values",
-			prs: bl.PullRequestStatus{
-				PullRequest:    nil,
-				CombinedStatus: nil,
-				Reviews:        nil,
-			},
-			prms: github.PullRequestMergeStatus{
-				ChecksPass:     github.CheckStatusUnknown,
-				NoConflicts:    false,
-				ReviewApproved: false,
-			},
-		}, {
-			name: "checks pass, approved",
-			prs: bl.PullRequestStatus{
-				PullRequest:    &gogithub.PullRequest{},
-				CombinedStatus: &gogithub.CombinedStatus{State: gogithub.Ptr("success"), TotalCount: gogithub.Ptr(1)},
-				Reviews: []*gogithub.PullRequestReview{
-					{
-						State: gogithub.Ptr("APPROVED"),
-					},
-				},
-			},
-			prms: github.PullRequestMergeStatus{
-				ChecksPass:     github.CheckStatusPass,
-				NoConflicts:    false,
-				ReviewApproved: true,
-			},
-		}, {
-			name: "checks pending, no conflicts",
-			prs: bl.PullRequestStatus{
-				PullRequest: &gogithub.PullRequest{
-					Mergeable: gogithub.Bool(true),
-				},
-				CombinedStatus: &gogithub.CombinedStatus{State: gogithub.Ptr("pending"), TotalCount: gogithub.Ptr(1)},
-				Reviews:        []*gogithub.PullRequestReview{},
-			},
-			prms: github.PullRequestMergeStatus{
-				ChecksPass:     github.CheckStatusPending,
-				NoConflicts:    true,
-				ReviewApproved: false,
-			},
-		}, {
-			name: "checks fail",
-			prs: bl.PullRequestStatus{
-				PullRequest:    &gogithub.PullRequest{},
-				CombinedStatus: &gogithub.CombinedStatus{State: gogithub.Ptr("failure"), TotalCount: gogithub.Ptr(1)},
-				Reviews:        []*gogithub.PullRequestReview{},
-			},
-			prms: github.PullRequestMergeStatus{
-				ChecksPass:     github.CheckStatusFail,
-				NoConflicts:    false,
-				ReviewApproved: false,
-			},
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			require.Equal(t, test.prms, bl.ComputeMergeStatus(test.prs))
-		})
-	}
+	cfg := config.EmptyConfig()
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, ""))
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "spr/"))
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "spr/main"))
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "spr/main/1234444"))
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "other/main/12344448"))
+	require.Equal(t, "12344448", bl.CommitIdFromBranch(cfg, "spr/main/12344448"))
+	require.Equal(t, "12344448", bl.CommitIdFromBranch(cfg, "spr/release/1.2/12344448"))
+
+	cfg.Repo.BranchNameTemplate = "users/{login}/{target}-{commitId}"
+	cfg.User.Login = "me"
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "spr/main/12344448"))
+	require.Equal(t, "", bl.CommitIdFromBranch(cfg, "users/other/main-12344448"))
+	require.Equal(t, "12344448", bl.CommitIdFromBranch(cfg, "users/me/main-12344448"))
 }
 
 func TestGenerateCommits_LinksCommitsAndSetsIndicies(t *testing.T) {
@@ -8,11 +8,9 @@ import (
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/config/config_parser"
 	"github.com/ejoffe/spr/git/realgit"
-	"github.com/ejoffe/spr/github"
 	"github.com/ejoffe/spr/github/githubclient"
 	"github.com/ejoffe/spr/spr"
 	ngit "github.com/go-git/go-git/v5"
-	gogithub "github.com/google/go-github/v69/github"
 	"github.com/jessevdk/go-flags"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -50,7 +48,8 @@ func main() {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
 
-	gitcmd := realgit.NewGitCmd(config.DefaultConfig())
+	gitcmd, err := realgit.NewGitCmd(config.DefaultConfig())
+	check(err)
 	//  check that we are inside a git dir
 	var output string
 	err = gitcmd.Git("status --porcelain", &output)
@@ -61,9 +60,12 @@ func main() {
 	}
 
 	ctx := context.Background()
-	cfg := config_parser.ParseConfig(gitcmd)
-	client := githubclient.NewGitHubClient(ctx, cfg)
-	gitcmd = realgit.NewGitCmd(cfg)
+	cfg, err := config_parser.ParseConfig(gitcmd)
+	check(err)
+	client, err := githubclient.NewGitHubClient(ctx, cfg, nil)
+	check(err)
+	gitcmd, err = realgit.NewGitCmd(cfg)
+	check(err)
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Println(err)
@@ -74,18 +76,22 @@ func main() {
 		fmt.Println(err)
 		os.Exit(2)
 	}
-	goghclient := gogithub.NewClient(nil).WithAuthToken(github.FindToken(cfg.Repo.GitHubHost))
-
-	sd := spr.NewStackedPR(cfg, client, gitcmd, repo, goghclient)
-	sd.AmendCommit(ctx)
+	sd := spr.NewStackedPR(cfg, client, gitcmd, repo)
+	err = sd.AmendCommit(ctx)
+	check(err)
 
 	if opts.Update {
-		sd.UpdatePullRequests(ctx, nil, nil)
+		err = sd.UpdatePullRequests(ctx, nil, nil)
+		check(err)
 	}
 }
 
 func check(err error) {
 	if err != nil {
-		panic(err)
+		if os.Getenv("SPR_DEBUG") == "1" {
+			panic(err)
+		}
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
 	}
 }
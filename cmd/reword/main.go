@@ -13,7 +13,8 @@ import (
 
 func main() {
 	filename := os.Args[1]
-	gitcmd := realgit.NewGitCmd(config.DefaultConfig())
+	gitcmd, err := realgit.NewGitCmd(config.DefaultConfig())
+	check(err)
 	if !strings.HasSuffix(filename, "COMMIT_EDITMSG") {
 		readfile, err := os.Open(filename)
 		check(err)
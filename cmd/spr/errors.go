@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// Exit codes returned by spr, scripts can use them to tell failures apart
+const (
+	exitOK             = 0
+	exitError          = 1
+	exitConfig         = 2
+	exitUnauthorized   = 3
+	exitRebaseConflict = 4
+	exitPushRejected   = 5
+	exitPRNotFound     = 6
+)
+
+// exitCode maps an error returned by a command to the process exit code
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, github.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, git.ErrRebaseConflict):
+		return exitRebaseConflict
+	case errors.Is(err, git.ErrPushRejected):
+		return exitPushRejected
+	case errors.Is(err, github.ErrPullRequestNotFound):
+		return exitPRNotFound
+	default:
+		return exitError
+	}
+}
+
+// exit prints the error and exits with code, or panics when SPR_DEBUG=1
+func exit(err error, code int) {
+	if os.Getenv("SPR_DEBUG") == "1" {
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(code)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, exitOK, exitCode(nil))
+	require.Equal(t, exitError, exitCode(errors.New("failed")))
+	require.Equal(t, exitUnauthorized, exitCode(fmt.Errorf("%w: bad token", github.ErrUnauthorized)))
+	require.Equal(t, exitPRNotFound, exitCode(fmt.Errorf("%w: #12", github.ErrPullRequestNotFound)))
+	require.Equal(t, exitRebaseConflict,
+		exitCode(git.NewCommandError("rebase origin/main", "CONFLICT (content)", errors.New("exit status 1"))))
+	require.Equal(t, exitPushRejected,
+		exitCode(errors.Join(errors.New("stash pop"),
+			git.NewCommandError("push origin a", "[rejected]", errors.New("exit status 1")))))
+}
@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ejoffe/rake"
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/config/config_parser"
+	"github.com/ejoffe/spr/dryrun"
+	"github.com/ejoffe/spr/git"
 	"github.com/ejoffe/spr/git/realgit"
 	"github.com/ejoffe/spr/github"
 	"github.com/ejoffe/spr/github/githubclient"
+	"github.com/ejoffe/spr/gitlab/gitlabclient"
+	"github.com/ejoffe/spr/journal"
+	"github.com/ejoffe/spr/report"
 	"github.com/ejoffe/spr/spr"
+	"github.com/ejoffe/spr/tui"
 	ngit "github.com/go-git/go-git/v5"
-	gogithub "github.com/google/go-github/v69/github"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
@@ -32,39 +43,83 @@ func init() {
 }
 
 func main() {
-	gitcmd := realgit.NewGitCmd(config.DefaultConfig())
+	gitcmd, err := realgit.NewGitCmd(config.DefaultConfig())
+	if err != nil {
+		exit(err, exitConfig)
+	}
 	//  check that we are inside a git dir
 	var output string
-	err := gitcmd.Git("status --porcelain", &output)
+	err = gitcmd.Git("status --porcelain", &output)
 	if err != nil {
 		fmt.Println(output)
-		fmt.Println(err)
-		os.Exit(2)
+		exit(err, exitConfig)
 	}
 
-	cfg := config_parser.ParseConfig(gitcmd)
+	cfg, err := config_parser.ParseConfig(gitcmd)
+	if err != nil {
+		exit(err, exitConfig)
+	}
 
 	err = config_parser.CheckConfig(cfg)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		exit(err, exitConfig)
+	}
+	gitcmd, err = realgit.NewGitCmd(cfg)
+	if err != nil {
+		exit(err, exitConfig)
 	}
-	gitcmd = realgit.NewGitCmd(cfg)
 	wd, err := os.Getwd()
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		exit(err, exitConfig)
 	}
 	repo, err := ngit.PlainOpen(wd)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(2)
+		exit(err, exitConfig)
 	}
-	goghclient := gogithub.NewClient(nil).WithAuthToken(github.FindToken(cfg.Repo.GitHubHost))
+	// github queries are cached on disk, nothing is cached when there is no user cache dir
+	cacheDir, _ := github.CacheDir(cfg)
+	cache := github.NewCacheTransport(cfg, cacheDir, nil)
 
 	ctx := context.Background()
-	client := githubclient.NewGitHubClient(ctx, cfg)
-	stackedpr := spr.NewStackedPR(cfg, client, gitcmd, repo, goghclient)
+	var client github.Forge
+	// maybeStar asks github users to star spr
+	maybeStar := func() {}
+	// clientErr fails every command but auth, which works without a token
+	var clientErr error
+	switch cfg.Repo.Forge {
+	case config.ForgeGitLab:
+		client, clientErr = gitlabclient.NewGitLabClient(ctx, cfg)
+	default:
+		ghclient, ghErr := githubclient.NewGitHubClient(ctx, cfg, cache)
+		client, clientErr = ghclient, ghErr
+		maybeStar = func() { ghclient.MaybeStar(ctx, cfg) }
+	}
+	// setLogin fetches the login used in branch names when it isn't configured
+	setLogin := func() error {
+		if git.BranchNameUsesLogin(cfg) {
+			// a stack checked out from another user keeps its author's branch names
+			if owner := git.StackOwner(gitcmd); owner != "" {
+				cfg.User.Login = owner
+			}
+		}
+		if git.BranchNameUsesLogin(cfg) && cfg.User.Login == "" {
+			login, err := client.GetLogin(ctx)
+			if err != nil {
+				return err
+			}
+			cfg.User.Login = login
+		}
+		return nil
+	}
+	stackedpr := spr.NewStackedPR(cfg, client, gitcmd, repo)
+	journalPath, err := journal.FilePath(gitcmd)
+	if err != nil {
+		exit(err, exitConfig)
+	}
+	stackedpr.Journal = journal.New(journalPath, strings.Join(os.Args[1:], " "))
+	if cfg.Repo.Forge != config.ForgeGitLab {
+		stackedpr.Cache = cache
+	}
 
 	detailFlag := &cli.BoolFlag{
 		Name:  "detail",
@@ -72,6 +127,65 @@ func main() {
 		Usage: "Show detailed status bits output",
 	}
 
+	jsonFlag := &cli.BoolFlag{
+		Name:  "json",
+		Value: false,
+		Usage: "Output the stack status as json",
+	}
+
+	formatFlag := &cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format: text, json or a go template executed for each commit",
+	}
+
+	// setFormat configures machine readable output for commands which print the stack status
+	setFormat := func(c *cli.Context) error {
+		format := c.String("format")
+		if c.Bool("json") {
+			format = "json"
+		}
+		formatter, err := report.NewFormatter(format)
+		if err != nil {
+			return err
+		}
+		if formatter != nil {
+			// keep command logging out of the machine readable output
+			cfg.User.LogGitCommands = false
+			cfg.User.LogGitHubCalls = false
+		}
+		stackedpr.Formatter = formatter
+		return nil
+	}
+
+	dryRunFlag := &cli.BoolFlag{
+		Name:  "dry-run",
+		Value: false,
+		Usage: "Print the branches and pull requests that would change without changing them, not supported with prSetWorkflows",
+	}
+
+	// withDryRun runs fn on a stack which plans the remote changes instead of making them
+	//  when --dry-run is set, and prints the plan.
+	withDryRun := func(c *cli.Context, fn func(sd *spr.Stackediff) error) error {
+		if !c.Bool("dry-run") {
+			return fn(stackedpr)
+		}
+		if cfg.User.PRSetWorkflows {
+			return errors.New("--dry-run is not supported with prSetWorkflows")
+		}
+		plan := dryrun.NewPlan(cfg)
+		sd := spr.NewStackedPR(cfg, plan.GitHub(client), plan.Git(gitcmd), repo)
+		// the status printed after the command shows the current state, not the planned one
+		sd.Output = io.Discard
+		err := errors.Join(fn(sd), plan.Close())
+		if err != nil {
+			return err
+		}
+		if c.Bool("json") || c.String("format") == "json" {
+			return plan.WriteJSON(os.Stdout)
+		}
+		return plan.Write(os.Stdout)
+	}
+
 	cli.AppHelpTemplate = `NAME:
    {{.Name}} - {{.Usage}}
 
@@ -89,7 +203,7 @@ VERSION: fork of {{.Version}}
 
 	app := &cli.App{
 		Name:                 "spr",
-		Usage:                "Stacked Pull Requests on GitHub",
+		Usage:                "Stacked Pull Requests on GitHub and GitLab",
 		HideVersion:          true,
 		Version:              fmt.Sprintf("%s : %s : %s\n", version, date, commit[:8]),
 		EnableBashCompletion: true,
@@ -120,6 +234,11 @@ VERSION: fork of {{.Version}}
 				Value: false,
 				Usage: "Show runtime debug info",
 			},
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Value: false,
+				Usage: "Fetch everything from GitHub instead of using cached responses",
+			},
 		},
 		Before: func(c *cli.Context) error {
 			if c.IsSet("debug") {
@@ -136,7 +255,20 @@ VERSION: fork of {{.Version}}
 				cfg.User.LogGitCommands = true
 				cfg.User.LogGitHubCalls = true
 			}
-			client.MaybeStar(ctx, cfg)
+			if c.Bool("refresh") {
+				cache.Refresh()
+			}
+			if c.Args().First() == "auth" {
+				return nil
+			}
+			if clientErr != nil {
+				return clientErr
+			}
+			err := setLogin()
+			if err != nil {
+				return err
+			}
+			maybeStar()
 			return nil
 		},
 		Commands: []*cli.Command{
@@ -144,30 +276,31 @@ VERSION: fork of {{.Version}}
 				Name:    "status",
 				Aliases: []string{"s", "st"},
 				Usage:   "Show status of open pull requests",
+				Before:  setFormat,
 				Action: func(c *cli.Context) error {
 					if cfg.User.PRSetWorkflows {
-						stackedpr.StatusCommitsAndPRSets(ctx)
-					} else {
-						stackedpr.StatusPullRequests(ctx)
+						return stackedpr.StatusCommitsAndPRSets(ctx)
 					}
-					return nil
+					return stackedpr.StatusPullRequests(ctx)
 				},
 				Flags: []cli.Flag{
 					detailFlag,
+					jsonFlag,
+					formatFlag,
 				},
 			},
 			{
 				Name:  "sync",
 				Usage: "Synchronize local stack with remote",
 				Action: func(c *cli.Context) error {
-					stackedpr.SyncStack(ctx)
-					return nil
+					return stackedpr.SyncStack(ctx)
 				},
 			},
 			{
 				Name:    "update",
 				Aliases: []string{"u", "up"},
 				Usage:   "Update and create pull requests for updated commits in the stack",
+				Before:  setFormat,
 				Action: func(c *cli.Context) error {
 					if cfg.User.PRSetWorkflows {
 						if c.Args().Len() != 1 {
@@ -175,27 +308,29 @@ VERSION: fork of {{.Version}}
 							return nil
 						}
 						selector := c.Args().First()
-						stackedpr.UpdatePRSets(ctx, selector)
-						return nil
+						return stackedpr.UpdatePRSets(ctx, selector)
 					} else {
 						if c.Bool("no-rebase") {
 							os.Setenv("SPR_NOREBASE", "true")
 						}
-						if c.IsSet("count") {
-							count := c.Uint("count")
-							stackedpr.UpdatePullRequests(ctx, c.StringSlice("reviewer"), &count)
-						} else {
-							stackedpr.UpdatePullRequests(ctx, c.StringSlice("reviewer"), nil)
-						}
-						return nil
+						return withDryRun(c, func(sd *spr.Stackediff) error {
+							if c.IsSet("count") {
+								count := c.Uint("count")
+								return sd.UpdatePullRequests(ctx, c.StringSlice("reviewer"), &count)
+							}
+							return sd.UpdatePullRequests(ctx, c.StringSlice("reviewer"), nil)
+						})
 					}
 				},
 				Flags: []cli.Flag{
 					detailFlag,
+					jsonFlag,
+					formatFlag,
+					dryRunFlag,
 					&cli.StringSliceFlag{
 						Name:    "reviewer",
 						Aliases: []string{"r"},
-						Usage:   "Add the specified reviewer to newly created pull requests",
+						Usage:   "Request review from the specified user or org/team on the updated pull requests",
 					},
 					&cli.UintFlag{
 						Name:    "count",
@@ -210,8 +345,9 @@ VERSION: fork of {{.Version}}
 				},
 			},
 			{
-				Name:  "merge",
-				Usage: "Merge all mergeable pull requests",
+				Name:   "merge",
+				Usage:  "Merge all mergeable pull requests",
+				Before: setFormat,
 				Action: func(c *cli.Context) error {
 					if cfg.User.PRSetWorkflows {
 						if c.Args().Len() != 1 {
@@ -219,20 +355,22 @@ VERSION: fork of {{.Version}}
 							return nil
 						}
 						setIndex := c.Args().First()
-						stackedpr.MergePRSet(ctx, setIndex)
-						return nil
+						return stackedpr.MergePRSet(ctx, setIndex)
 					} else {
-						if c.IsSet("count") {
-							count := c.Uint("count")
-							stackedpr.MergePullRequests(ctx, &count)
-						} else {
-							stackedpr.MergePullRequests(ctx, nil)
-						}
-						return nil
+						return withDryRun(c, func(sd *spr.Stackediff) error {
+							if c.IsSet("count") {
+								count := c.Uint("count")
+								return sd.MergePullRequests(ctx, &count)
+							}
+							return sd.MergePullRequests(ctx, nil)
+						})
 					}
 				},
 				Flags: []cli.Flag{
 					detailFlag,
+					jsonFlag,
+					formatFlag,
+					dryRunFlag,
 					&cli.UintFlag{
 						Name:    "count",
 						Aliases: []string{"c"},
@@ -240,12 +378,236 @@ VERSION: fork of {{.Version}}
 					},
 				},
 			},
+			{
+				Name:  "watch",
+				Usage: "Watch the status of the pull requests until the stack is ready to merge",
+				Action: func(c *cli.Context) error {
+					if cfg.User.PRSetWorkflows && c.Bool("merge") {
+						return errors.New("watch --merge is not supported with prSetWorkflows")
+					}
+					// stop polling cleanly on ctrl-c
+					ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+					defer stop()
+					if c.IsSet("count") {
+						count := c.Uint("count")
+						return stackedpr.WatchPullRequests(ctx, c.Duration("interval"), c.Bool("merge"), &count)
+					}
+					return stackedpr.WatchPullRequests(ctx, c.Duration("interval"), c.Bool("merge"), nil)
+				},
+				Flags: []cli.Flag{
+					detailFlag,
+					&cli.DurationFlag{
+						Name:    "interval",
+						Aliases: []string{"i"},
+						Value:   30 * time.Second,
+						Usage:   "Interval between status checks",
+					},
+					&cli.BoolFlag{
+						Name:  "merge",
+						Usage: "Merge the pull requests as soon as they are ready",
+					},
+					&cli.UintFlag{
+						Name:    "count",
+						Aliases: []string{"c"},
+						Usage:   "Watch a specified number of pull requests from the bottom of the stack",
+					},
+				},
+			},
+			{
+				Name:      "checkout",
+				Usage:     "Check out the stack of pull requests ending with the given pull request",
+				ArgsUsage: "<number|url>",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 1 {
+						return errors.New("usage: checkout <number|url>")
+					}
+					return stackedpr.CheckoutPullRequest(ctx, c.Args().First(), c.String("branch"))
+				},
+				Flags: []cli.Flag{
+					detailFlag,
+					&cli.StringFlag{
+						Name:    "branch",
+						Aliases: []string{"b"},
+						Usage:   "Name of the local branch, pr-<number> by default",
+					},
+				},
+			},
+			{
+				Name:  "edit",
+				Usage: "Reorder, drop, squash, reword or move commits of the stack along with their pull requests",
+				Action: func(c *cli.Context) error {
+					if cfg.User.PRSetWorkflows {
+						return errors.New("edit is not supported with prSetWorkflows")
+					}
+					return stackedpr.EditStack(ctx, c.String("branch"))
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "branch",
+						Aliases: []string{"b"},
+						Usage:   "Name of the branch moved commits are put on, <branch>-<commit-id> by default",
+					},
+				},
+			},
+			{
+				Name:      "split",
+				Usage:     "Split the hunks or files picked from a commit into a new commit and pull request below it",
+				ArgsUsage: "<index> [file...]",
+				Action: func(c *cli.Context) error {
+					if cfg.User.PRSetWorkflows {
+						return errors.New("split is not supported with prSetWorkflows")
+					}
+					if c.Args().Len() < 1 {
+						return errors.New("usage: split <index> [file...]")
+					}
+					index, err := strconv.Atoi(c.Args().First())
+					if err != nil {
+						return fmt.Errorf("invalid commit index %q", c.Args().First())
+					}
+					return stackedpr.SplitCommit(ctx, index, c.Args().Tail(), c.String("message"))
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "message",
+						Aliases: []string{"m"},
+						Usage:   "Message of the new commit, asked for in the editor by default",
+					},
+				},
+			},
+			{
+				Name:  "label",
+				Usage: "Add or remove labels on the pull requests of the selected commits",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "add",
+						Usage:     "Add labels to the pull requests of the selected commits",
+						ArgsUsage: "<selector> <label>...",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() < 2 {
+								return errors.New("usage: label add <selector> <label>...")
+							}
+							return stackedpr.LabelPullRequests(ctx, c.Args().First(), c.Args().Tail(), false)
+						},
+					},
+					{
+						Name:      "remove",
+						Aliases:   []string{"rm"},
+						Usage:     "Remove labels from the pull requests of the selected commits",
+						ArgsUsage: "<selector> <label>...",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() < 2 {
+								return errors.New("usage: label remove <selector> <label>...")
+							}
+							return stackedpr.LabelPullRequests(ctx, c.Args().First(), c.Args().Tail(), true)
+						},
+					},
+				},
+			},
+			{
+				Name:      "assign",
+				Usage:     "Assign the pull requests of the selected commits to users",
+				ArgsUsage: "<selector> <login>...",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() < 2 {
+						return errors.New("usage: assign <selector> <login>...")
+					}
+					return stackedpr.AssignPullRequests(ctx, c.Args().First(), c.Args().Tail())
+				},
+			},
+			{
+				Name:      "milestone",
+				Usage:     "Set the milestone of the pull requests of the selected commits",
+				ArgsUsage: "<selector> <title>",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() != 2 {
+						return errors.New("usage: milestone <selector> <title>")
+					}
+					return stackedpr.SetPullRequestsMilestone(ctx, c.Args().First(), c.Args().Get(1))
+				},
+			},
+			{
+				Name:  "undo",
+				Usage: "Undo the branch pushes and pull request changes of the last update or merge",
+				Action: func(c *cli.Context) error {
+					return stackedpr.Undo(ctx)
+				},
+			},
+			{
+				Name:  "tui",
+				Usage: "Interactive full screen view of the stack",
+				Action: func(c *cli.Context) error {
+					// command logging would be drawn over the screen
+					cfg.User.LogGitCommands = false
+					cfg.User.LogGitHubCalls = false
+					gitcmd.SetStderr(io.Discard)
+					return tui.Run(ctx, cfg, tui.NewBackend(cfg, stackedpr), c.Duration("refresh"))
+				},
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "refresh",
+						Value: 30 * time.Second,
+						Usage: "Interval between background refreshes of the stack",
+					},
+				},
+			},
 			{
 				Name:  "check",
 				Usage: "Run pre merge checks (configured by MergeCheck in repository config)",
 				Action: func(c *cli.Context) error {
-					stackedpr.RunMergeCheck(ctx)
-					return nil
+					return stackedpr.RunMergeCheck(ctx)
+				},
+			},
+			{
+				Name:      "checks",
+				Usage:     "List the checks of the pull requests in the stack with their status, duration and details url",
+				ArgsUsage: "[index]",
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() > 1 {
+						return errors.New("usage: checks [index]")
+					}
+					var index *int
+					if c.Args().Len() == 1 {
+						i, err := strconv.Atoi(c.Args().First())
+						if err != nil {
+							return fmt.Errorf("invalid commit index %q", c.Args().First())
+						}
+						index = &i
+					}
+					return stackedpr.ShowChecks(ctx, index)
+				},
+			},
+			{
+				Name:  "auth",
+				Usage: "Log in to GitHub with the device flow, log out or show the token in use",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "hostname",
+						Usage: "GitHub host to authenticate with, the host of the repository by default",
+						Value: cfg.Repo.GitHubHost,
+					},
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "login",
+						Usage: "Authorize spr in the browser and store the token for the host",
+						Action: func(c *cli.Context) error {
+							return stackedpr.AuthLogin(ctx, c.String("hostname"))
+						},
+					},
+					{
+						Name:  "logout",
+						Usage: "Remove the token stored for the host by auth login",
+						Action: func(c *cli.Context) error {
+							return stackedpr.AuthLogout(c.String("hostname"))
+						},
+					},
+					{
+						Name:  "status",
+						Usage: "Show the user, source and scopes of the token used for the host",
+						Action: func(c *cli.Context) error {
+							return stackedpr.AuthStatus(ctx, c.String("hostname"))
+						},
+					},
 				},
 			},
 			{
@@ -257,14 +619,19 @@ VERSION: fork of {{.Version}}
 			},
 		},
 		After: func(c *cli.Context) error {
-			rake.LoadSources(cfg.State,
-				rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
+			err := config_parser.WriteInternalState(cfg.State)
+			if err != nil {
+				return err
+			}
 			if c.IsSet("profile") {
-				stackedpr.ProfilingSummary()
+				return stackedpr.ProfilingSummary()
 			}
 			return nil
 		},
 	}
 
-	app.Run(os.Args)
+	err = app.Run(os.Args)
+	if err != nil {
+		exit(err, exitCode(err))
+	}
 }
@@ -0,0 +1,150 @@
+// Package codeowners finds the owners of files from a repository CODEOWNERS file.
+//
+//	Patterns follow the gitignore rules used by GitHub and GitLab, the last
+//	matching rule of the file wins. GitLab sections and email owners are skipped.
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ejoffe/spr/git"
+)
+
+// Locations are the paths, relative to the repository root, searched for a CODEOWNERS file in order
+var Locations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+}
+
+// CodeOwners is a parsed CODEOWNERS file
+type CodeOwners struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// Load reads the CODEOWNERS file at ref, like origin/main, it returns nil when there is none.
+//
+//	The file is read from git instead of the working tree, so local edits don't change the owners.
+func Load(gitcmd git.GitInterface, ref string) (*CodeOwners, error) {
+	var found string
+	err := gitcmd.Git("ls-tree --name-only "+ref+" -- "+strings.Join(Locations, " "), &found)
+	if err != nil {
+		return nil, err
+	}
+	present := strings.Fields(found)
+	for _, location := range Locations {
+		if !slices.Contains(present, location) {
+			continue
+		}
+		var content string
+		err = gitcmd.Git("show "+ref+":"+location, &content)
+		if err != nil {
+			return nil, err
+		}
+		return Parse(strings.NewReader(content))
+	}
+	return nil, nil
+}
+
+// Parse parses the content of a CODEOWNERS file
+func Parse(r io.Reader) (*CodeOwners, error) {
+	co := &CodeOwners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// gitlab sections, optionally with default owners, only group rules
+		if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			continue
+		}
+		fields := strings.Fields(line)
+		var owners []string
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "@") {
+				owners = append(owners, strings.TrimPrefix(owner, "@"))
+			}
+		}
+		co.rules = append(co.rules, rule{
+			pattern: compile(fields[0]),
+			owners:  owners,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return co, nil
+}
+
+// Owners returns the owners of the file at path, relative to the repository root.
+//
+//	Owners are user logins or org/team names without the leading @, a rule
+//	without owners makes the file unowned.
+func (co *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	for i := len(co.rules) - 1; i >= 0; i-- {
+		if co.rules[i].pattern.MatchString(path) {
+			return co.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Match returns true when the gitignore style pattern matches the file at path, relative to the repository root
+func Match(pattern string, path string) bool {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	return compile(pattern).MatchString(path)
+}
+
+// compile turns a gitignore style pattern into a regular expression matching file paths
+func compile(pattern string) *regexp.Regexp {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// patterns without a slash match at any depth, others from the root
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// a pattern naming a directory matches everything below it
+	if dirOnly {
+		re.WriteString("/.*$")
+	} else {
+		re.WriteString("(?:/.*)?$")
+	}
+	return regexp.MustCompile(re.String())
+}
@@ -0,0 +1,107 @@
+package codeowners
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testFile = `
+# default owners
+*                 @org/everyone
+
+*.go              @gopher # inline comment
+/docs/            @writer
+build/            @builder
+/cmd/**/main.go   @cli
+api/*.proto       @api-team owner@example.com
+/vendor/
+
+[GitLab Section] @ignored
+`
+
+func TestOwners(t *testing.T) {
+	co, err := Parse(strings.NewReader(testFile))
+	require.NoError(t, err)
+
+	for path, expected := range map[string][]string{
+		"readme.md":             {"org/everyone"},
+		"main.go":               {"gopher"},
+		"pkg/deep/file.go":      {"gopher"},
+		"docs/guide.md":         {"writer"},
+		"docs/sub/guide.md":     {"writer"},
+		"other/docs/guide.md":   {"org/everyone"},
+		"build/out.txt":         {"builder"},
+		"src/build/out.txt":     {"builder"},
+		"cmd/main.go":           {"cli"},
+		"cmd/spr/main.go":       {"cli"},
+		"api/service.proto":     {"api-team"},
+		"api/v1/service.proto":  {"org/everyone"},
+		"vendor/lib/lib.go":     nil,
+		"/readme.md":            {"org/everyone"},
+		"build":                 {"org/everyone"},
+		"pkg/build.go/file.txt": {"gopher"},
+	} {
+		require.Equal(t, expected, co.Owners(path), path)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	require.True(t, Match("docs/", "docs/guide.md"))
+	require.True(t, Match("*.proto", "api/v1/service.proto"))
+	require.True(t, Match("/cmd/**/main.go", "/cmd/spr/main.go"))
+	require.False(t, Match("/docs/", "other/docs/guide.md"))
+	require.False(t, Match("*.proto", "api/service.go"))
+}
+
+// dirGit runs git in a directory
+type dirGit struct {
+	dir string
+}
+
+func (g dirGit) GitWithEditor(args string, output *string, editorCmd string) error {
+	return g.Git(args, output)
+}
+
+func (g dirGit) Git(args string, output *string) error {
+	cmd := exec.Command("git", strings.Split(args, " ")...)
+	cmd.Dir = g.dir
+	out, err := cmd.CombinedOutput()
+	if output != nil {
+		*output = strings.TrimSpace(string(out))
+	}
+	return err
+}
+
+func (g dirGit) RootDir() string {
+	return g.dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	gitcmd := dirGit{dir: dir}
+	commit := func() {
+		require.NoError(t, gitcmd.Git("add -A", nil))
+		require.NoError(t, gitcmd.Git("-c user.name=test -c user.email=test@example.com commit --allow-empty -q -m commit", nil))
+	}
+	require.NoError(t, gitcmd.Git("init -q", nil))
+	commit()
+	co, err := Load(gitcmd, "HEAD")
+	require.NoError(t, err)
+	require.Nil(t, co)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @root\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @github\n"), 0644))
+	commit()
+
+	// .github/CODEOWNERS is used first, and uncommitted changes are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @local\n"), 0644))
+	co, err = Load(gitcmd, "HEAD")
+	require.NoError(t, err)
+	require.Equal(t, []string{"github"}, co.Owners("file"))
+}
@@ -1,12 +1,6 @@
 package config
 
-import (
-	"fmt"
-	"strings"
-
-	"github.com/ejoffe/rake"
-	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
-)
+import "github.com/ejoffe/rake"
 
 type Config struct {
 	Repo  *RepoConfig
@@ -14,8 +8,19 @@ type Config struct {
 	State *InternalState
 }
 
+// Forges spr can create pull requests on, set as RepoConfig.Forge
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+)
+
 // Config object to hold spr configuration
 type RepoConfig struct {
+	// Forge is github or gitlab, it is gitlab when the remote host is gitlab.com and
+	//  github for any other host unless set. The GitHub host, owner and name settings
+	//  are used for both forges.
+	Forge string `default:"github" yaml:"forge"`
+
 	GitHubRepoOwner string `yaml:"githubRepoOwner"`
 	GitHubRepoName  string `yaml:"githubRepoName"`
 	GitHubHost      string `default:"github.com" yaml:"githubHost"`
@@ -26,6 +31,10 @@ type RepoConfig struct {
 	RequireChecks   bool `default:"true" yaml:"requireChecks"`
 	RequireApproval bool `default:"true" yaml:"requireApproval"`
 
+	// RequiredChecksOnly counts only the checks required by branch protection
+	//  of GitHubBranch in the checks status, other checks may fail or still run.
+	RequiredChecksOnly bool `default:"false" yaml:"requiredChecksOnly"`
+
 	MergeMethod string `default:"rebase" yaml:"mergeMethod"`
 	MergeQueue  bool   `default:"false" yaml:"mergeQueue"`
 
@@ -39,6 +48,28 @@ type RepoConfig struct {
 
 	ShowPrTitlesInStack    bool `default:"false" yaml:"showPrTitlesInStack"`
 	BranchPushIndividually bool `default:"false" yaml:"branchPushIndividually"`
+
+	// Concurrency is the number of GitHub requests the pull request sets workflow
+	//  runs at once, lower it when hitting the secondary rate limits.
+	Concurrency int `default:"8" yaml:"concurrency"`
+
+	// BranchNameTemplate is the name of pull request branches, the {login},
+	//  {target} and {commitId} placeholders are replaced by the user login,
+	//  the target branch and the commit-id. It must contain {commitId}.
+	BranchNameTemplate string `default:"spr/{target}/{commitId}" yaml:"branchNameTemplate"`
+
+	// DefaultReviewers are requested on every new pull request, as logins or org/team names,
+	//  existing pull requests don't get them.
+	DefaultReviewers []string `yaml:"defaultReviewers,omitempty"`
+	// CodeOwnersReviewers requests review on new pull requests from the owners of the
+	//  files changed by the commit, in the CODEOWNERS file of the target branch.
+	CodeOwnersReviewers bool `default:"false" yaml:"codeOwnersReviewers"`
+
+	// DefaultLabels are added to every new pull request
+	DefaultLabels []string `yaml:"defaultLabels,omitempty"`
+	// PathLabels maps CODEOWNERS style file patterns to labels, the labels are
+	//  added to new pull requests changing a file matching the pattern.
+	PathLabels map[string][]string `yaml:"pathLabels,omitempty"`
 }
 
 type UserConfig struct {
@@ -53,6 +84,16 @@ type UserConfig struct {
 	NoRebase             bool `default:"false" yaml:"noRebase"`
 	DeleteMergedBranches bool `default:"false" yaml:"deleteMergedBranches"`
 	PRSetWorkflows       bool `default:"false" yaml:"prSetWorkflows"`
+
+	// CacheTTL is the number of seconds cached GitHub query responses are used
+	//  before they are fetched again, 0 always fetches them.
+	CacheTTL int `default:"15" yaml:"cacheTTL"`
+
+	// Login is the forge login used in branch names, it is fetched from the forge when not set
+	Login string `yaml:"login,omitempty"`
+
+	// AuthClientID is the client id of the oauth app spr auth login authorizes with the device flow
+	AuthClientID string `yaml:"authClientID,omitempty"`
 }
 
 type InternalState struct {
@@ -88,22 +129,3 @@ func DefaultConfig() *Config {
 	cfg.User.LogGitHubCalls = false
 	return cfg
 }
-
-func (c Config) MergeMethod() (genclient.PullRequestMergeMethod, error) {
-	var mergeMethod genclient.PullRequestMergeMethod
-	var err error
-	switch strings.ToLower(c.Repo.MergeMethod) {
-	case "merge":
-		mergeMethod = genclient.PullRequestMergeMethod_MERGE
-	case "squash":
-		mergeMethod = genclient.PullRequestMergeMethod_SQUASH
-	case "rebase", "":
-		mergeMethod = genclient.PullRequestMergeMethod_REBASE
-	default:
-		err = fmt.Errorf(
-			`unknown merge method %q, choose from "merge", "squash", or "rebase"`,
-			c.Repo.MergeMethod,
-		)
-	}
-	return mergeMethod, err
-}
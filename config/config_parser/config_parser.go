@@ -13,43 +13,56 @@ import (
 	"github.com/ejoffe/spr/git"
 )
 
-func ParseConfig(gitcmd git.GitInterface) *config.Config {
+func ParseConfig(gitcmd git.GitInterface) (*config.Config, error) {
 	cfg := config.EmptyConfig()
 
+	userConfigFilePath, err := UserConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	internalConfigFilePath, err := InternalConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteSource := NewGitHubRemoteSource(cfg, gitcmd)
+	remoteBranchSource := NewRemoteBranchSource(gitcmd)
 	rake.LoadSources(cfg.Repo,
 		rake.DefaultSource(),
-		NewGitHubRemoteSource(cfg, gitcmd),
+		remoteSource,
 		rake.YamlFileSource(RepoConfigFilePath(gitcmd)),
-		NewRemoteBranchSource(gitcmd),
+		remoteBranchSource,
 	)
+	if remoteSource.err != nil {
+		return nil, remoteSource.err
+	}
+	if remoteBranchSource.err != nil {
+		return nil, remoteBranchSource.err
+	}
 	if cfg.Repo.GitHubHost == "" {
-		fmt.Println("unable to auto configure repository host - must be set manually in .spr.yml")
-		os.Exit(2)
+		return nil, errors.New("unable to auto configure repository host - must be set manually in .spr.yml")
 	}
 	if cfg.Repo.GitHubRepoOwner == "" {
-		fmt.Println("unable to auto configure repository owner - must be set manually in .spr.yml")
-		os.Exit(3)
+		return nil, errors.New("unable to auto configure repository owner - must be set manually in .spr.yml")
 	}
-
 	if cfg.Repo.GitHubRepoName == "" {
-		fmt.Println("unable to auto configure repository name - must be set manually in .spr.yml")
-		os.Exit(4)
+		return nil, errors.New("unable to auto configure repository name - must be set manually in .spr.yml")
 	}
 
 	rake.LoadSources(cfg.User,
 		rake.DefaultSource(),
-		rake.YamlFileSource(UserConfigFilePath()),
+		rake.YamlFileSource(userConfigFilePath),
 	)
 
 	rake.LoadSources(cfg.State,
 		rake.DefaultSource(),
-		rake.YamlFileSource(InternalConfigFilePath()),
+		rake.YamlFileSource(internalConfigFilePath),
 	)
 
 	cfg.State.RunCount = cfg.State.RunCount + 1
 
 	rake.LoadSources(cfg.State,
-		rake.YamlFileWriter(InternalConfigFilePath()))
+		rake.YamlFileWriter(internalConfigFilePath))
 
 	// init case : if yaml config files not found : create them
 	if _, err := os.Stat(RepoConfigFilePath(gitcmd)); errors.Is(err, os.ErrNotExist) {
@@ -57,16 +70,25 @@ func ParseConfig(gitcmd git.GitInterface) *config.Config {
 			rake.YamlFileWriter(RepoConfigFilePath(gitcmd)))
 	}
 
-	if _, err := os.Stat(UserConfigFilePath()); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(userConfigFilePath); errors.Is(err, os.ErrNotExist) {
 		rake.LoadSources(cfg.User,
-			rake.YamlFileWriter(UserConfigFilePath()))
+			rake.YamlFileWriter(userConfigFilePath))
 	}
-	return cfg
+	return cfg, nil
 }
 
 func CheckConfig(cfg *config.Config) error {
-	if strings.Contains(cfg.Repo.GitHubBranch, "/") {
-		return errors.New("Remote branch name must not contain backslashes '/'")
+	if cfg.Repo.BranchNameTemplate != "" && !strings.Contains(cfg.Repo.BranchNameTemplate, git.BranchCommitIDPlaceholder) {
+		return fmt.Errorf("branchNameTemplate %q must contain %s", cfg.Repo.BranchNameTemplate, git.BranchCommitIDPlaceholder)
+	}
+	switch cfg.Repo.Forge {
+	case config.ForgeGitHub:
+	case config.ForgeGitLab:
+		if cfg.User.PRSetWorkflows {
+			return errors.New("prSetWorkflows is not supported with the gitlab forge")
+		}
+	default:
+		return fmt.Errorf(`unknown forge %q, choose from "github" or "gitlab"`, cfg.Repo.Forge)
 	}
 	return nil
 }
@@ -77,16 +99,31 @@ func RepoConfigFilePath(gitcmd git.GitInterface) string {
 	return filepath
 }
 
-func UserConfigFilePath() string {
+func UserConfigFilePath() (string, error) {
 	rootdir, err := os.UserHomeDir()
-	check(err)
+	if err != nil {
+		return "", fmt.Errorf("finding user config: %w", err)
+	}
 	filepath := filepath.Clean(path.Join(rootdir, ".spr.yml"))
-	return filepath
+	return filepath, nil
 }
 
-func InternalConfigFilePath() string {
+func InternalConfigFilePath() (string, error) {
 	rootdir, err := os.UserHomeDir()
-	check(err)
+	if err != nil {
+		return "", fmt.Errorf("finding internal state: %w", err)
+	}
 	filepath := filepath.Clean(path.Join(rootdir, ".spr.state"))
-	return filepath
+	return filepath, nil
+}
+
+// WriteInternalState saves the internal state to the user's home directory
+func WriteInternalState(state *config.InternalState) error {
+	internalConfigFilePath, err := InternalConfigFilePath()
+	if err != nil {
+		return err
+	}
+	rake.LoadSources(state,
+		rake.YamlFileWriter(internalConfigFilePath))
+	return nil
 }
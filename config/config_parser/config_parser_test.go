@@ -47,7 +47,7 @@ func TestGetRepoDetailsFromRemote(t *testing.T) {
 	}
 	for i, testCase := range testCases {
 		t.Logf("Testing %v %q", i, testCase.remote)
-		githubHost, repoOwner, repoName, match := getRepoDetailsFromRemote(testCase.remote)
+		githubHost, repoOwner, repoName, forge, match := getRepoDetailsFromRemote(testCase.remote)
 		if githubHost != testCase.githubHost {
 			t.Fatalf("Wrong \"githubHost\" returned for test case %v, expected %q, got %q", i, testCase.githubHost, githubHost)
 		}
@@ -60,6 +60,33 @@ func TestGetRepoDetailsFromRemote(t *testing.T) {
 		if match != testCase.match {
 			t.Fatalf("Wrong \"match\" returned for test case %v, expected %t, got %t", i, testCase.match, match)
 		}
+		if match && forge != config.ForgeGitHub {
+			t.Fatalf("Wrong \"forge\" returned for test case %v, expected %q, got %q", i, config.ForgeGitHub, forge)
+		}
+	}
+}
+
+func TestGetRepoDetailsFromGitLabRemote(t *testing.T) {
+	for _, testCase := range []struct {
+		remote    string
+		host      string
+		repoOwner string
+		repoName  string
+		forge     string
+	}{
+		{"origin  https://gitlab.com/r2/d2.git (push)", "gitlab.com", "r2", "d2", config.ForgeGitLab},
+		{"origin  git@gitlab.com:r2/d2.git (push)", "gitlab.com", "r2", "d2", config.ForgeGitLab},
+		{"origin  git@gitlab.com:group/sub-group/d2.git (push)", "gitlab.com", "group/sub-group", "d2", config.ForgeGitLab},
+		// self-hosted GitLab is selected with the forge setting
+		{"origin  ssh://git@gitlab.example.com/r2/d2 (push)", "gitlab.example.com", "r2", "d2", config.ForgeGitHub},
+		{"origin  git@github.gitlab-mirror.com:r2/d2.git (push)", "github.gitlab-mirror.com", "r2", "d2", config.ForgeGitHub},
+	} {
+		host, repoOwner, repoName, forge, match := getRepoDetailsFromRemote(testCase.remote)
+		assert.True(t, match, testCase.remote)
+		assert.Equal(t, testCase.host, host, testCase.remote)
+		assert.Equal(t, testCase.repoOwner, repoOwner, testCase.remote)
+		assert.Equal(t, testCase.repoName, repoName, testCase.remote)
+		assert.Equal(t, testCase.forge, forge, testCase.remote)
 	}
 }
 
@@ -69,6 +96,7 @@ func TestGitHubRemoteSource(t *testing.T) {
 
 	expect := config.Config{
 		Repo: &config.RepoConfig{
+			Forge:           "github",
 			GitHubRepoOwner: "r2",
 			GitHubRepoName:  "d2",
 			GitHubHost:      "github.com",
@@ -93,3 +121,49 @@ func TestGitHubRemoteSource(t *testing.T) {
 	assert.Equal(t, expect, actual)
 	mock.ExpectationsMet()
 }
+
+func TestCheckConfigForge(t *testing.T) {
+	cfg := config.DefaultConfig()
+	assert.NoError(t, CheckConfig(cfg))
+
+	cfg.Repo.Forge = config.ForgeGitLab
+	assert.NoError(t, CheckConfig(cfg))
+
+	cfg.User.PRSetWorkflows = true
+	assert.Error(t, CheckConfig(cfg))
+
+	cfg.Repo.Forge = "bitbucket"
+	cfg.User.PRSetWorkflows = false
+	assert.Error(t, CheckConfig(cfg))
+}
+
+func TestCheckConfigBranchNameTemplate(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Repo.BranchNameTemplate = "users/{login}/{commitId}"
+	assert.NoError(t, CheckConfig(cfg))
+
+	cfg.Repo.BranchNameTemplate = "users/{login}/{target}"
+	assert.Error(t, CheckConfig(cfg))
+}
+
+func TestSplitUpstream(t *testing.T) {
+	remotes := []string{"origin", "team", "team/fork"}
+	for _, tc := range []struct {
+		upstream string
+		remote   string
+		branch   string
+		match    bool
+	}{
+		{"origin/main", "origin", "main", true},
+		{"origin/release/1.2", "origin", "release/1.2", true},
+		{"team/frontend/main", "team", "frontend/main", true},
+		{"team/fork/main", "team/fork", "main", true},
+		{"other/main", "", "", false},
+		{"origin/", "", "", false},
+	} {
+		remote, branch, match := splitUpstream(tc.upstream, remotes)
+		assert.Equal(t, tc.remote, remote, tc.upstream)
+		assert.Equal(t, tc.branch, branch, tc.upstream)
+		assert.Equal(t, tc.match, match, tc.upstream)
+	}
+}
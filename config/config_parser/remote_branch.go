@@ -1,7 +1,9 @@
 package config_parser
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/git"
@@ -9,6 +11,9 @@ import (
 
 type remoteBranch struct {
 	gitcmd git.GitInterface
+
+	// err is set when Load fails, rake sources can't return errors
+	err error
 }
 
 func NewRemoteBranchSource(gitcmd git.GitInterface) *remoteBranch {
@@ -17,20 +22,50 @@ func NewRemoteBranchSource(gitcmd git.GitInterface) *remoteBranch {
 	}
 }
 
-var _remoteBranchRegex = regexp.MustCompile(`^## ([a-zA-Z0-9_\-/\.]+)\.\.\.([a-zA-Z0-9_\-/\.]+)/([a-zA-Z0-9_\-/\.]+)`)
+var _remoteBranchRegex = regexp.MustCompile(`^## (\S+)\.\.\.(\S+)`)
 
 func (s *remoteBranch) Load(cfg interface{}) {
 	var output string
 	err := s.gitcmd.Git("status -b --porcelain -u no", &output)
-	check(err)
-
+	if err != nil {
+		s.err = fmt.Errorf("reading remote branch: %w", err)
+		return
+	}
 	matches := _remoteBranchRegex.FindStringSubmatch(output)
 	if matches == nil {
 		return
 	}
 
+	var remotes string
+	err = s.gitcmd.Git("remote", &remotes)
+	if err != nil {
+		s.err = fmt.Errorf("reading git remotes: %w", err)
+		return
+	}
+	remote, branch, match := splitUpstream(matches[2], strings.Fields(remotes))
+	if !match {
+		return
+	}
+
 	repoCfg := cfg.(*config.RepoConfig)
 
-	repoCfg.GitHubRemote = matches[2]
-	repoCfg.GitHubBranch = matches[3]
+	repoCfg.GitHubRemote = remote
+	repoCfg.GitHubBranch = branch
+}
+
+// splitUpstream splits an upstream branch like origin/release/1.2 into its remote and branch.
+//
+//	Both remote and branch names can contain slashes, the longest remote
+//	 name the upstream starts with is used.
+func splitUpstream(upstream string, remotes []string) (string, string, bool) {
+	remote := ""
+	for _, r := range remotes {
+		if strings.HasPrefix(upstream, r+"/") && len(r) > len(remote) {
+			remote = r
+		}
+	}
+	if remote == "" || len(upstream) == len(remote)+1 {
+		return "", "", false
+	}
+	return remote, upstream[len(remote)+1:], true
 }
@@ -12,6 +12,9 @@ import (
 type remoteSource struct {
 	gitcmd git.GitInterface
 	config *config.Config
+
+	// err is set when Load fails, rake sources can't return errors
+	err error
 }
 
 func NewGitHubRemoteSource(config *config.Config, gitcmd git.GitInterface) *remoteSource {
@@ -24,12 +27,16 @@ func NewGitHubRemoteSource(config *config.Config, gitcmd git.GitInterface) *remo
 func (s *remoteSource) Load(_ interface{}) {
 	var output string
 	err := s.gitcmd.Git("remote -v", &output)
-	check(err)
+	if err != nil {
+		s.err = fmt.Errorf("reading git remotes: %w", err)
+		return
+	}
 	lines := strings.Split(output, "\n")
 
 	for _, line := range lines {
-		githubHost, repoOwner, repoName, match := getRepoDetailsFromRemote(line)
+		githubHost, repoOwner, repoName, forge, match := getRepoDetailsFromRemote(line)
 		if match {
+			s.config.Repo.Forge = forge
 			s.config.Repo.GitHubHost = githubHost
 			s.config.Repo.GitHubRepoOwner = repoOwner
 			s.config.Repo.GitHubRepoName = repoName
@@ -38,14 +45,22 @@ func (s *remoteSource) Load(_ interface{}) {
 	}
 }
 
-func getRepoDetailsFromRemote(remote string) (string, string, string, bool) {
+// gitLabHost is the host of the remotes detected as the gitlab forge
+const gitLabHost = "gitlab.com"
+
+// getRepoDetailsFromRemote returns the host, owner, name and forge of the origin push remote.
+//
+//	The forge is gitlab for gitlab.com and github for any other host, self-hosted
+//	 GitLab is selected with the forge setting. The owner of a gitlab project can
+//	 be a nested group path like group/subgroup.
+func getRepoDetailsFromRemote(remote string) (string, string, string, string, bool) {
 	// Allows "https://", "ssh://" or no protocol at all (this means ssh)
 	protocolFormat := `(?:(https://)|(ssh://))?`
 	// This may or may not be present in the address
 	userFormat := `(git@)?`
 	// "/" is expected in "http://" or "ssh://" protocol, when no protocol given
 	// it should be ":"
-	repoFormat := `(?P<githubHost>[a-z0-9._\-]+)(/|:)(?P<repoOwner>[\w-]+)/(?P<repoName>[\w-]+)`
+	repoFormat := `(?P<githubHost>[a-z0-9._\-]+)(/|:)(?P<repoOwner>[\w-]+(?:/[\w-]+)*)/(?P<repoName>[\w-]+)`
 	// This is neither required in https access nor in ssh one
 	suffixFormat := `(.git)?`
 	regexFormat := fmt.Sprintf(`^origin\s+%s%s%s%s \(push\)`,
@@ -56,13 +71,12 @@ func getRepoDetailsFromRemote(remote string) (string, string, string, bool) {
 		githubHostIndex := regex.SubexpIndex("githubHost")
 		repoOwnerIndex := regex.SubexpIndex("repoOwner")
 		repoNameIndex := regex.SubexpIndex("repoName")
-		return matches[githubHostIndex], matches[repoOwnerIndex], matches[repoNameIndex], true
-	}
-	return "", "", "", false
-}
-
-func check(err error) {
-	if err != nil {
-		panic(err)
+		host := matches[githubHostIndex]
+		forge := config.ForgeGitHub
+		if host == gitLabHost {
+			forge = config.ForgeGitLab
+		}
+		return host, matches[repoOwnerIndex], matches[repoNameIndex], forge, true
 	}
+	return "", "", "", "", false
 }
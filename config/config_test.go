@@ -3,7 +3,6 @@ package config
 import (
 	"testing"
 
-	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,6 +22,7 @@ func TestEmptyConfig(t *testing.T) {
 func TestDefaultConfig(t *testing.T) {
 	expect := &Config{
 		Repo: &RepoConfig{
+			Forge:                 "github",
 			GitHubRepoOwner:       "",
 			GitHubRepoName:        "",
 			GitHubRemote:          "origin",
@@ -35,6 +35,8 @@ func TestDefaultConfig(t *testing.T) {
 			PRTemplateInsertStart: "",
 			PRTemplateInsertEnd:   "",
 			ShowPrTitlesInStack:   false,
+			BranchNameTemplate:    "spr/{target}/{commitId}",
+			Concurrency:           8,
 		},
 		User: &UserConfig{
 			ShowPRLink:       true,
@@ -42,6 +44,7 @@ func TestDefaultConfig(t *testing.T) {
 			LogGitHubCalls:   false,
 			StatusBitsHeader: true,
 			StatusBitsEmojis: true,
+			CacheTTL:         15,
 		},
 		State: &InternalState{
 			MergeCheckCommit:      map[string]string{},
@@ -51,44 +54,3 @@ func TestDefaultConfig(t *testing.T) {
 	actual := DefaultConfig()
 	assert.Equal(t, expect, actual)
 }
-
-func TestMergeMethodHelper(t *testing.T) {
-	for _, tc := range []struct {
-		configValue string
-		expected    genclient.PullRequestMergeMethod
-	}{
-		{
-			configValue: "rebase",
-			expected:    genclient.PullRequestMergeMethod_REBASE,
-		},
-		{
-			configValue: "",
-			expected:    genclient.PullRequestMergeMethod_REBASE,
-		},
-		{
-			configValue: "Merge",
-			expected:    genclient.PullRequestMergeMethod_MERGE,
-		},
-		{
-			configValue: "SQUASH",
-			expected:    genclient.PullRequestMergeMethod_SQUASH,
-		},
-	} {
-		tcName := tc.configValue
-		if tcName == "" {
-			tcName = "<EMPTY>"
-		}
-		t.Run(tcName, func(t *testing.T) {
-			config := &Config{Repo: &RepoConfig{MergeMethod: tc.configValue}}
-			actual, err := config.MergeMethod()
-			assert.NoError(t, err)
-			assert.Equal(t, tc.expected, actual)
-		})
-	}
-	t.Run("invalid", func(t *testing.T) {
-		config := &Config{Repo: &RepoConfig{MergeMethod: "magic"}}
-		actual, err := config.MergeMethod()
-		assert.Error(t, err)
-		assert.Empty(t, actual)
-	})
-}
@@ -0,0 +1,173 @@
+package dryrun
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ejoffe/spr/git"
+)
+
+// ErrRewordNeeded is returned when commits are missing a commit-id, adding them would rewrite local history
+var ErrRewordNeeded = errors.New("some commits are missing a commit-id, run the command without --dry-run to add them")
+
+// readOnlyCommands are the git commands which are run while planning
+var readOnlyCommands = []string{
+	"fetch",
+	"log",
+	"status",
+	"branch --no-color",
+	"rev-parse",
+	"for-each-ref",
+	"ls-tree",
+	"show",
+	"diff",
+	"diff-tree",
+	"config --get",
+	"config --default= --get",
+	"ls-remote",
+}
+
+// skippedCommands are the git commands which only protect local changes while pushing, they are skipped
+var skippedCommands = []string{
+	"stash",
+}
+
+type planGit struct {
+	plan   *Plan
+	gitcmd git.GitInterface
+
+	// worktree is the temporary worktree holding the rebased stack, empty until a rebase is planned
+	worktree string
+}
+
+func (g *planGit) GitWithEditor(args string, output *string, editorCmd string) error {
+	if isReadOnly(args) {
+		return g.gitcmd.Git(args, output)
+	}
+	return ErrRewordNeeded
+}
+
+func (g *planGit) Git(args string, output *string) error {
+	switch {
+	case isReadOnly(args):
+		// the local branch name is read from the repository, the worktree is detached
+		if g.worktree != "" && !strings.HasPrefix(args, "branch ") {
+			return g.gitcmd.Git("-C "+g.worktree+" "+args, output)
+		}
+		return g.gitcmd.Git(args, output)
+	case strings.HasPrefix(args, "push "):
+		return g.push(args)
+	case strings.HasPrefix(args, "rebase ") && !strings.Contains(args, " -i"):
+		return g.rebase(args)
+	case hasPrefix(args, skippedCommands):
+		return nil
+	default:
+		return fmt.Errorf("git %s would change the repository and can't be planned with --dry-run", args)
+	}
+}
+
+// rebase runs the rebase in a temporary worktree, so the planned pushes show the rebased commits.
+//
+//	Every later read-only command runs in the worktree, Plan.Close removes it.
+func (g *planGit) rebase(args string) error {
+	_, noRebaseFlag := os.LookupEnv("SPR_NOREBASE")
+	if g.plan.config.User.NoRebase || noRebaseFlag {
+		return nil
+	}
+	if g.worktree != "" {
+		return g.gitcmd.Git("-C "+g.worktree+" "+args, nil)
+	}
+
+	dir, err := os.MkdirTemp("", "spr-dry-run-")
+	if err != nil {
+		return err
+	}
+	// git commands are split on spaces
+	if strings.ContainsAny(dir, " \t") {
+		return errors.Join(
+			fmt.Errorf("planning the rebase: the temporary directory %q contains spaces, set TMPDIR to one without", dir),
+			os.Remove(dir))
+	}
+	err = g.gitcmd.Git("worktree add --detach "+dir+" HEAD", nil)
+	if err != nil {
+		return errors.Join(err, os.Remove(dir))
+	}
+	g.worktree = dir
+	g.plan.addCleanup(func() error {
+		return g.gitcmd.Git("worktree remove --force "+dir, nil)
+	})
+	return g.gitcmd.Git("-C "+dir+" "+args, nil)
+}
+
+func (g *planGit) RootDir() string {
+	return g.gitcmd.RootDir()
+}
+
+// push records the branches a push command would update or delete
+func (g *planGit) push(args string) error {
+	var remote string
+	var refs []string
+	deleteRefs := false
+	for _, arg := range strings.Fields(args)[1:] {
+		switch {
+		case arg == "--delete" || arg == "-d":
+			deleteRefs = true
+		case strings.HasPrefix(arg, "-"):
+		case remote == "":
+			remote = arg
+		default:
+			refs = append(refs, arg)
+		}
+	}
+
+	var pushes []BranchPush
+	for _, ref := range refs {
+		var push BranchPush
+		if deleteRefs {
+			push = BranchPush{Branch: ref, Delete: true}
+		} else {
+			src, dst, found := strings.Cut(ref, ":")
+			if !found {
+				dst = src
+			}
+			push = BranchPush{Branch: strings.TrimPrefix(dst, "refs/heads/"), NewSHA: src}
+		}
+		oldSHA, err := g.remoteHead(remote, push.Branch)
+		if err != nil {
+			return err
+		}
+		push.OldSHA = oldSHA
+		pushes = append(pushes, push)
+	}
+
+	g.plan.mu.Lock()
+	defer g.plan.mu.Unlock()
+	g.plan.changes.Pushes = append(g.plan.changes.Pushes, pushes...)
+	return nil
+}
+
+// remoteHead returns the fetched head of the remote branch, or an empty string when it doesn't exist
+func (g *planGit) remoteHead(remote string, branch string) (string, error) {
+	var output string
+	err := g.gitcmd.Git(fmt.Sprintf("for-each-ref --format=%%(objectname) refs/remotes/%s/%s", remote, branch), &output)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func isReadOnly(args string) bool {
+	return hasPrefix(args, readOnlyCommands)
+}
+
+// hasPrefix returns true when args run one of the commands
+func hasPrefix(args string, commands []string) bool {
+	for _, command := range commands {
+		if args == command || strings.HasPrefix(args, command+" ") {
+			return true
+		}
+	}
+	return false
+}
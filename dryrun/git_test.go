@@ -0,0 +1,60 @@
+package dryrun
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingGit records the commands it runs and answers ref lookups from heads
+type recordingGit struct {
+	commands []string
+	heads    map[string]string
+}
+
+func (g *recordingGit) GitWithEditor(args string, output *string, editorCmd string) error {
+	return g.Git(args, output)
+}
+
+func (g *recordingGit) Git(args string, output *string) error {
+	g.commands = append(g.commands, args)
+	if output != nil {
+		*output = g.heads[strings.TrimPrefix(args, "for-each-ref --format=%(objectname) ")]
+	}
+	return nil
+}
+
+func (g *recordingGit) RootDir() string {
+	return ""
+}
+
+func TestPlanGit(t *testing.T) {
+	inner := &recordingGit{heads: map[string]string{
+		"refs/remotes/origin/spr/main/00000001": "aaa",
+	}}
+	plan := NewPlan(config.EmptyConfig())
+	gitcmd := plan.Git(inner)
+
+	require.NoError(t, gitcmd.Git("fetch", nil))
+	require.NoError(t, gitcmd.Git("diff-tree --no-commit-id --name-only -r bbb", nil))
+	require.NoError(t, gitcmd.Git("stash", nil))
+	require.NoError(t, gitcmd.Git("push --force --atomic origin bbb:refs/heads/spr/main/00000001 ccc:refs/heads/spr/main/00000002", nil))
+	require.NoError(t, gitcmd.Git("push origin --delete spr/main/00000001", nil))
+	require.ErrorIs(t, gitcmd.GitWithEditor("rebase origin/main -i --autosquash --autostash", nil, "true"), ErrRewordNeeded)
+	require.Error(t, gitcmd.Git("commit --amend --no-edit", nil))
+
+	require.Equal(t, []string{
+		"fetch",
+		"diff-tree --no-commit-id --name-only -r bbb",
+		"for-each-ref --format=%(objectname) refs/remotes/origin/spr/main/00000001",
+		"for-each-ref --format=%(objectname) refs/remotes/origin/spr/main/00000002",
+		"for-each-ref --format=%(objectname) refs/remotes/origin/spr/main/00000001",
+	}, inner.commands)
+	require.Equal(t, []BranchPush{
+		{Branch: "spr/main/00000001", OldSHA: "aaa", NewSHA: "bbb"},
+		{Branch: "spr/main/00000002", NewSHA: "ccc"},
+		{Branch: "spr/main/00000001", OldSHA: "aaa", Delete: true},
+	}, plan.Changes().Pushes)
+}
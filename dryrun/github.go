@@ -0,0 +1,205 @@
+package dryrun
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+type planGitHub struct {
+	plan   *Plan
+	client github.Forge
+}
+
+func (c *planGitHub) GetInfo(ctx context.Context, gitcmd git.GitInterface) (*github.ForgeInfo, error) {
+	return c.client.GetInfo(ctx, gitcmd)
+}
+
+func (c *planGitHub) GetOpenPullRequests(ctx context.Context) ([]*github.PullRequest, bool, error) {
+	return c.client.GetOpenPullRequests(ctx)
+}
+
+func (c *planGitHub) GetChecks(ctx context.Context, pr *github.PullRequest) ([]github.Check, error) {
+	return c.client.GetChecks(ctx, pr)
+}
+
+func (c *planGitHub) GetPullRequestStack(ctx context.Context, number int) ([]*github.PullRequest, error) {
+	return c.client.GetPullRequestStack(ctx, number)
+}
+
+func (c *planGitHub) GetLogin(ctx context.Context) (string, error) {
+	return c.client.GetLogin(ctx)
+}
+
+func (c *planGitHub) GetAssignableUsers(ctx context.Context) ([]github.RepoAssignee, error) {
+	users, err := c.client.GetAssignableUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	for _, user := range users {
+		c.plan.assignees[user.ID] = user.Login
+	}
+	return users, nil
+}
+
+// CreatePullRequest records the pull request and returns a placeholder for it without a number
+func (c *planGitHub) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
+	info *github.ForgeInfo, commit git.Commit, prevCommit *git.Commit) (*github.PullRequest, error) {
+	pr := &github.PullRequest{
+		FromBranch: git.BranchNameFromCommit(c.plan.config, commit),
+		ToBranch:   c.baseBranch(prevCommit),
+		Commit:     commit,
+		Title:      commit.Subject,
+		Body:       commit.Body,
+	}
+
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	planned := &PullRequest{Title: pr.Title, FromBranch: pr.FromBranch, ToBranch: pr.ToBranch}
+	c.plan.changes.Create = append(c.plan.changes.Create, planned)
+	c.plan.created[pr] = planned
+	return pr, nil
+}
+
+// UpdatePullRequest records a change of the pull request base branch, title and body updates aren't planned
+func (c *planGitHub) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface,
+	pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) error {
+	if pr.InQueue {
+		return nil
+	}
+
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	if _, created := c.plan.created[pr]; created {
+		return nil
+	}
+	planned, found := c.plan.retargets[pr.Number]
+	if !found {
+		planned = &PullRequest{
+			Number:      pr.Number,
+			Title:       pr.Title,
+			FromBranch:  pr.FromBranch,
+			OldToBranch: pr.ToBranch,
+		}
+		c.plan.retargets[pr.Number] = planned
+	}
+	planned.ToBranch = c.baseBranch(prevCommit)
+	return nil
+}
+
+// GetTeamID passes through to the real client and remembers the team name for the plan
+func (c *planGitHub) GetTeamID(ctx context.Context, team string) (string, error) {
+	id, err := c.client.GetTeamID(ctx, team)
+	if err != nil {
+		return "", err
+	}
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	c.plan.assignees[id] = team
+	return id, nil
+}
+
+// AddReviewers records the reviewers on the created pull request, or as a review request on an existing one
+func (c *planGitHub) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string, teamIDs []string) error {
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	planned, created := c.plan.created[pr]
+	if !created {
+		planned = c.plan.reviews[pr.Number]
+		if planned == nil {
+			planned = &PullRequest{
+				Number:     pr.Number,
+				Title:      pr.Title,
+				FromBranch: pr.FromBranch,
+				ToBranch:   pr.ToBranch,
+			}
+			c.plan.reviews[pr.Number] = planned
+		}
+	}
+	for _, id := range append(slices.Clone(userIDs), teamIDs...) {
+		login, found := c.plan.assignees[id]
+		if !found {
+			login = id
+		}
+		planned.Reviewers = append(planned.Reviewers, login)
+	}
+	return nil
+}
+
+// AddLabels records the labels of created pull requests, labels of existing ones aren't planned
+func (c *planGitHub) AddLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	if planned, created := c.plan.created[pr]; created {
+		planned.Labels = append(planned.Labels, labels...)
+	}
+	return nil
+}
+
+// RemoveLabels does nothing, labels are only removed by the label command which isn't planned
+func (c *planGitHub) RemoveLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	return nil
+}
+
+// AddAssignees does nothing, assignees aren't planned
+func (c *planGitHub) AddAssignees(ctx context.Context, pr *github.PullRequest, logins []string) error {
+	return nil
+}
+
+// SetMilestone does nothing, milestones aren't planned
+func (c *planGitHub) SetMilestone(ctx context.Context, pr *github.PullRequest, title string) error {
+	return nil
+}
+
+// CommentPullRequest does nothing, comments are only added along with closes which are planned
+func (c *planGitHub) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) error {
+	return nil
+}
+
+func (c *planGitHub) MergePullRequest(ctx context.Context, pr *github.PullRequest, mergeMethod github.MergeMethod) error {
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	toBranch := pr.ToBranch
+	if planned, found := c.plan.retargets[pr.Number]; found {
+		toBranch = planned.ToBranch
+	}
+	c.plan.changes.Merge = &Merge{
+		PullRequest: PullRequest{
+			Number:     pr.Number,
+			Title:      pr.Title,
+			FromBranch: pr.FromBranch,
+			ToBranch:   toBranch,
+		},
+		Method: string(mergeMethod),
+	}
+	return nil
+}
+
+func (c *planGitHub) ClosePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	c.plan.mu.Lock()
+	defer c.plan.mu.Unlock()
+	c.plan.changes.Close = append(c.plan.changes.Close, &PullRequest{
+		Number:     pr.Number,
+		Title:      pr.Title,
+		FromBranch: pr.FromBranch,
+		ToBranch:   pr.ToBranch,
+	})
+	return nil
+}
+
+// RestorePullRequest does nothing, restores are only done by undo which isn't planned
+func (c *planGitHub) RestorePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	return nil
+}
+
+// baseBranch returns the base branch of a pull request stacked on prevCommit
+func (c *planGitHub) baseBranch(prevCommit *git.Commit) string {
+	if prevCommit == nil {
+		return c.plan.config.Repo.GitHubBranch
+	}
+	return git.BranchNameFromCommit(c.plan.config, *prevCommit)
+}
@@ -0,0 +1,242 @@
+// Package dryrun records the remote changes spr would make instead of making them.
+//
+//	A Plan hands out git and github implementations which pass reads through
+//	 to the real ones and record branch pushes and pull request mutations.
+//	Local history is never rewritten while planning, rebases run in a temporary worktree.
+package dryrun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// BranchPush is a branch which would be force pushed or deleted
+type BranchPush struct {
+	Branch string `json:"branch"`
+
+	// OldSHA is the current remote head of the branch, empty when the branch doesn't exist yet
+	OldSHA string `json:"oldSha,omitempty"`
+
+	// NewSHA is the commit which would be pushed, empty when the branch would be deleted
+	NewSHA string `json:"newSha,omitempty"`
+	Delete bool   `json:"delete,omitempty"`
+}
+
+// PullRequest is a pull request which would be created, retargeted, closed or merged
+type PullRequest struct {
+	// Number is zero for pull requests which would be created
+	Number     int    `json:"number,omitempty"`
+	Title      string `json:"title"`
+	FromBranch string `json:"fromBranch"`
+
+	// ToBranch is the base branch the pull request would have
+	ToBranch string `json:"toBranch"`
+
+	// OldToBranch is the current base branch of a retargeted pull request
+	OldToBranch string `json:"oldToBranch,omitempty"`
+
+	Reviewers []string `json:"reviewers,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+}
+
+// Merge is the pull request which would be merged
+type Merge struct {
+	PullRequest
+	Method string `json:"method"`
+}
+
+// Changes are the remote changes a command would make
+type Changes struct {
+	Pushes   []BranchPush   `json:"pushes"`
+	Create   []*PullRequest `json:"create"`
+	Retarget []*PullRequest `json:"retarget"`
+	Close    []*PullRequest `json:"close"`
+	Merge    *Merge         `json:"merge,omitempty"`
+
+	// Review are existing pull requests review would be requested on
+	Review []*PullRequest `json:"review"`
+}
+
+// Empty returns true when there are no changes
+func (c Changes) Empty() bool {
+	return len(c.Pushes) == 0 && len(c.Create) == 0 && len(c.Retarget) == 0 &&
+		len(c.Close) == 0 && c.Merge == nil && len(c.Review) == 0
+}
+
+// Plan records the remote changes of a command run with the git and github implementations it returns
+type Plan struct {
+	config  *config.Config
+	changes Changes
+
+	// created maps the placeholder pull requests returned for creations to their plan entry
+	created map[*github.PullRequest]*PullRequest
+
+	// retargets holds the base branch changes by pull request number
+	retargets map[int]*PullRequest
+
+	// assignees maps github user and team ids to logins and team names, so planned reviewers can be shown by name
+	assignees map[string]string
+
+	// reviews holds the review requests on existing pull requests by pull request number
+	reviews map[int]*PullRequest
+
+	// cleanup removes the temporary worktrees rebases were planned in
+	cleanup []func() error
+
+	mu sync.Mutex
+}
+
+// NewPlan returns an empty plan
+func NewPlan(cfg *config.Config) *Plan {
+	return &Plan{
+		config: cfg,
+		changes: Changes{
+			Pushes: []BranchPush{},
+			Create: []*PullRequest{},
+			Close:  []*PullRequest{},
+			Review: []*PullRequest{},
+		},
+		created:   map[*github.PullRequest]*PullRequest{},
+		retargets: map[int]*PullRequest{},
+		assignees: map[string]string{},
+		reviews:   map[int]*PullRequest{},
+	}
+}
+
+// Git returns a git implementation which records pushes in the plan.
+//
+//	Rebases run in a temporary worktree, so the pushes show the rebased commits,
+//	 other commands changing the repository fail. Close removes the worktree.
+func (p *Plan) Git(gitcmd git.GitInterface) git.GitInterface {
+	return &planGit{plan: p, gitcmd: gitcmd}
+}
+
+// Close removes the temporary worktrees used while planning
+func (p *Plan) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var err error
+	for _, cleanup := range p.cleanup {
+		err = errors.Join(err, cleanup())
+	}
+	p.cleanup = nil
+	return err
+}
+
+func (p *Plan) addCleanup(cleanup func() error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cleanup = append(p.cleanup, cleanup)
+}
+
+// GitHub returns a github implementation which records pull request changes in the plan
+func (p *Plan) GitHub(client github.Forge) github.Forge {
+	return &planGitHub{plan: p, client: client}
+}
+
+// Changes returns the changes recorded so far.
+//
+//	A pull request can be updated more than once by a command, only pull
+//	 requests whose final base differs from their current base are retargeted.
+func (p *Plan) Changes() Changes {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := p.changes
+	changes.Retarget = []*PullRequest{}
+	for _, pr := range p.retargets {
+		if pr.ToBranch != pr.OldToBranch {
+			changes.Retarget = append(changes.Retarget, pr)
+		}
+	}
+	sort.Slice(changes.Retarget, func(i, j int) bool {
+		return changes.Retarget[i].Number < changes.Retarget[j].Number
+	})
+	changes.Review = []*PullRequest{}
+	for _, pr := range p.reviews {
+		changes.Review = append(changes.Review, pr)
+	}
+	sort.Slice(changes.Review, func(i, j int) bool {
+		return changes.Review[i].Number < changes.Review[j].Number
+	})
+	return changes
+}
+
+// Write prints the plan in a human readable form, with shortened commit hashes
+func (p *Plan) Write(w io.Writer) error {
+	changes := p.Changes()
+	if changes.Empty() {
+		_, err := fmt.Fprintf(w, "nothing to do\n")
+		return err
+	}
+
+	var lines []string
+	for _, push := range changes.Pushes {
+		if push.Delete {
+			lines = append(lines, fmt.Sprintf("delete branch %s %s", push.Branch, orNew(push.OldSHA)))
+		} else {
+			lines = append(lines, fmt.Sprintf("push %s %s → %s", push.Branch, orNew(push.OldSHA), short(push.NewSHA)))
+		}
+	}
+	for _, pr := range changes.Create {
+		line := fmt.Sprintf("create pull request %s → %s : %s", pr.FromBranch, pr.ToBranch, pr.Title)
+		if len(pr.Reviewers) > 0 {
+			line += fmt.Sprintf(" (reviewers: %s)", strings.Join(pr.Reviewers, ", "))
+		}
+		if len(pr.Labels) > 0 {
+			line += fmt.Sprintf(" (labels: %s)", strings.Join(pr.Labels, ", "))
+		}
+		lines = append(lines, line)
+	}
+	for _, pr := range changes.Retarget {
+		lines = append(lines, fmt.Sprintf("retarget #%d %s → %s : %s", pr.Number, pr.OldToBranch, pr.ToBranch, pr.Title))
+	}
+	for _, pr := range changes.Review {
+		lines = append(lines, fmt.Sprintf("request review #%d from %s : %s", pr.Number, strings.Join(pr.Reviewers, ", "), pr.Title))
+	}
+	for _, pr := range changes.Close {
+		lines = append(lines, fmt.Sprintf("close #%d : %s", pr.Number, pr.Title))
+	}
+	if changes.Merge != nil {
+		lines = append(lines, fmt.Sprintf("merge #%d into %s (%s) : %s",
+			changes.Merge.Number, changes.Merge.ToBranch, changes.Merge.Method, changes.Merge.Title))
+	}
+	for _, line := range lines {
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON prints the plan as json
+func (p *Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p.Changes())
+}
+
+// orNew shortens sha for display, or marks a branch which doesn't exist yet
+func orNew(sha string) string {
+	if sha == "" {
+		return "(new)"
+	}
+	return short(sha)
+}
+
+func short(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
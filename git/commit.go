@@ -3,7 +3,6 @@ package git
 type GitInterface interface {
 	GitWithEditor(args string, output *string, editorCmd string) error
 	Git(args string, output *string) error
-	MustGit(args string, output *string)
 	RootDir() string
 }
 
@@ -25,4 +24,17 @@ type Commit struct {
 
 	// WIP is true if the commit is still work in progress.
 	WIP bool
+
+	// Reviewers, Labels and Assignees are set from the Reviewers:, Labels: and
+	//  Assignees: trailers of the commit message and added to its pull request.
+	Reviewers []string
+	Labels    []string
+	Assignees []string
+
+	// Draft is set from the Draft: trailer, nil when the commit message has none
+	//  and the user config decides if a new pull request is a draft.
+	Draft *bool
+
+	// Milestone is the title of the milestone set from the Milestone: trailer.
+	Milestone string
 }
@@ -9,6 +9,7 @@ import (
 
 func TestParseLocalCommitStack(t *testing.T) {
 	var buffer bytes.Buffer
+	draft := true
 	tests := []struct {
 		name            string
 		inputCommitLog  string
@@ -92,6 +93,35 @@ Date:   Wed May 21 19:52:51 1980 -0700
 			},
 			expectedValid: true,
 		},
+		{
+			name: "SingleValidCommitWithTrailers",
+			inputCommitLog: `
+commit d89e0e460ed817c81641f32b1a506b60164b4403 (HEAD -> master)
+Author: Han Solo
+Date:   Wed May 21 19:53:12 1980 -0700
+
+	Supergalactic speed
+
+	Super universe body.
+
+	Reviewers: @leia, chewie
+	Labels: backend
+	Draft: true
+	commit-id:053f6d16
+`,
+			expectedCommits: []Commit{
+				{
+					CommitHash: "d89e0e460ed817c81641f32b1a506b60164b4403",
+					CommitID:   "053f6d16",
+					Subject:    "Supergalactic speed",
+					Body:       "Super universe body.",
+					Reviewers:  []string{"leia", "chewie"},
+					Labels:     []string{"backend"},
+					Draft:      &draft,
+				},
+			},
+			expectedValid: true,
+		},
 		{
 			name: "SingleCommitMissingCommitID",
 			inputCommitLog: `
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrRebaseConflict is returned when a rebase stops because of merge conflicts
+	ErrRebaseConflict = errors.New("rebase conflict")
+
+	// ErrPushRejected is returned when the remote rejects a push
+	ErrPushRejected = errors.New("push rejected")
+)
+
+// CommandError is returned when a git command fails
+type CommandError struct {
+	// Args are the arguments passed to git
+	Args string
+
+	// Output is the combined stdout and stderr of the command
+	Output string
+
+	// Err is the error returned when running the command
+	Err error
+
+	// Kind is ErrRebaseConflict or ErrPushRejected when the failure was recognized, otherwise nil
+	Kind error
+}
+
+// NewCommandError builds a CommandError and recognizes rebase conflicts and rejected pushes
+func NewCommandError(args string, output string, err error) *CommandError {
+	cmdErr := &CommandError{
+		Args:   args,
+		Output: output,
+		Err:    err,
+	}
+	fields := strings.Fields(args)
+	// commands run in another worktree with -C <dir>
+	if len(fields) > 2 && fields[0] == "-C" {
+		fields = fields[2:]
+	}
+	command := ""
+	if len(fields) > 0 {
+		command = fields[0]
+	}
+	switch command {
+	case "rebase", "cherry-pick":
+		if strings.Contains(output, "CONFLICT") || strings.Contains(output, "could not apply") {
+			cmdErr.Kind = ErrRebaseConflict
+		}
+	case "push":
+		if strings.Contains(output, "[rejected]") || strings.Contains(output, "[remote rejected]") ||
+			strings.Contains(output, "failed to push") {
+			cmdErr.Kind = ErrPushRejected
+		}
+	}
+	return cmdErr
+}
+
+func (e *CommandError) Error() string {
+	if e.Kind != nil {
+		return fmt.Sprintf("git %s: %s: %s", e.Args, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("git %s: %s", e.Args, e.Err)
+}
+
+func (e *CommandError) Unwrap() []error {
+	if e.Kind != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Err}
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandErrorKind(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+	tests := []struct {
+		args   string
+		output string
+		kind   error
+	}{
+		{args: "rebase origin/main --autostash", output: "CONFLICT (content): Merge conflict in a.txt", kind: ErrRebaseConflict},
+		{args: "-C /tmp/worktree rebase origin/main", output: "CONFLICT (content): Merge conflict in a.txt", kind: ErrRebaseConflict},
+		{args: "cherry-pick abc", output: "error: could not apply abc... subject", kind: ErrRebaseConflict},
+		{args: "push --force origin a:a", output: " ! [rejected]        a -> a (fetch first)", kind: ErrPushRejected},
+		{args: "push origin a:a", output: " ! [remote rejected] a -> a (pre-receive hook declined)", kind: ErrPushRejected},
+		{args: "push origin a:a", output: "error: failed to push some refs to 'origin'", kind: ErrPushRejected},
+		{args: "rebase origin/main", output: "fatal: invalid upstream 'origin/main'", kind: nil},
+		{args: "fetch", output: "CONFLICT", kind: nil},
+	}
+
+	for _, tc := range tests {
+		err := NewCommandError(tc.args, tc.output, exitErr)
+		require.Equal(t, tc.kind, err.Kind, tc.args)
+		require.ErrorIs(t, err, exitErr)
+		if tc.kind != nil {
+			require.ErrorIs(t, err, tc.kind)
+		} else {
+			require.NotErrorIs(t, err, ErrRebaseConflict)
+			require.NotErrorIs(t, err, ErrPushRejected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -11,74 +12,208 @@ import (
 )
 
 // GetLocalBranchName returns the current local git branch
-func GetLocalBranchName(gitcmd GitInterface) string {
+func GetLocalBranchName(gitcmd GitInterface) (string, error) {
 	var output string
 	err := gitcmd.Git("branch --no-color", &output)
-	check(err)
+	if err != nil {
+		return "", err
+	}
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
 		if strings.HasPrefix(line, "* ") {
-			return line[2:]
+			return line[2:], nil
 		}
 	}
-	panic("cannot determine local git branch name")
+	return "", errors.New("cannot determine local git branch name")
+}
+
+// Placeholders of the RepoConfig.BranchNameTemplate
+const (
+	BranchLoginPlaceholder    = "{login}"
+	BranchTargetPlaceholder   = "{target}"
+	BranchCommitIDPlaceholder = "{commitId}"
+)
+
+// DefaultBranchNameTemplate is used when RepoConfig.BranchNameTemplate is not set
+const DefaultBranchNameTemplate = "spr/" + BranchTargetPlaceholder + "/" + BranchCommitIDPlaceholder
+
+func branchNameTemplate(cfg *config.Config) string {
+	if cfg.Repo.BranchNameTemplate == "" {
+		return DefaultBranchNameTemplate
+	}
+	return cfg.Repo.BranchNameTemplate
+}
+
+// BranchNameUsesLogin returns true when pull request branch names contain the user login
+func BranchNameUsesLogin(cfg *config.Config) bool {
+	return strings.Contains(branchNameTemplate(cfg), BranchLoginPlaceholder)
 }
 
 func BranchNameFromCommit(cfg *config.Config, commit Commit) string {
 	return BranchNameFromCommitId(cfg, commit.CommitID)
 }
 
+// BranchNameFromCommitId returns the pull request branch name of the commit from the branch name template
 func BranchNameFromCommitId(cfg *config.Config, commitId string) string {
-	remoteBranchName := cfg.Repo.GitHubBranch
-	return "spr/" + remoteBranchName + "/" + commitId
+	return strings.NewReplacer(
+		BranchLoginPlaceholder, cfg.User.Login,
+		BranchTargetPlaceholder, cfg.Repo.GitHubBranch,
+		BranchCommitIDPlaceholder, commitId,
+	).Replace(branchNameTemplate(cfg))
+}
+
+// BranchNameRegex returns a regexp matching pull request branch names made from the template.
+//
+//	The commit-id is the commitId submatch. Any target branch is matched, and any
+//	login unless the user login is known, so branches of other users are skipped.
+func BranchNameRegex(cfg *config.Config) *regexp.Regexp {
+	return branchNameRegex(cfg, cfg.User.Login)
+}
+
+func branchNameRegex(cfg *config.Config, login string) *regexp.Regexp {
+	loginRegex := `[^/]+`
+	if login != "" {
+		loginRegex = regexp.QuoteMeta(login)
+	}
+	loginGroup := `(?P<login>` + loginRegex + `)`
+	var re strings.Builder
+	re.WriteString("^")
+	for template := branchNameTemplate(cfg); template != ""; {
+		switch {
+		case strings.HasPrefix(template, BranchLoginPlaceholder):
+			// only the first login is captured, group names must be unique
+			re.WriteString(loginGroup)
+			loginGroup = loginRegex
+			template = template[len(BranchLoginPlaceholder):]
+		case strings.HasPrefix(template, BranchTargetPlaceholder):
+			re.WriteString(`.+`)
+			template = template[len(BranchTargetPlaceholder):]
+		case strings.HasPrefix(template, BranchCommitIDPlaceholder):
+			re.WriteString(`(?P<commitId>[a-f0-9]{8})`)
+			template = template[len(BranchCommitIDPlaceholder):]
+		default:
+			re.WriteString(regexp.QuoteMeta(template[:1]))
+			template = template[1:]
+		}
+	}
+	re.WriteString("$")
+	return regexp.MustCompile(re.String())
+}
+
+// CommitIDFromBranch returns the commit-id of a pull request branch name, or "" for other branches
+func CommitIDFromBranch(cfg *config.Config, branchName string) string {
+	_, commitID, _ := parseBranchName(BranchNameRegex(cfg), branchName)
+	return commitID
+}
+
+// ParseBranchName parses the pull request branch name of any user.
+//
+//	It returns the login in the branch name, empty when the template has none,
+//	and the commit-id. ok is false when branchName is not a pull request branch.
+func ParseBranchName(cfg *config.Config, branchName string) (login string, commitID string, ok bool) {
+	return parseBranchName(branchNameRegex(cfg, ""), branchName)
+}
+
+func parseBranchName(re *regexp.Regexp, branchName string) (string, string, bool) {
+	matches := re.FindStringSubmatch(branchName)
+	if matches == nil {
+		return "", "", false
+	}
+	var login string
+	if i := re.SubexpIndex("login"); i >= 0 {
+		login = matches[i]
+	}
+	return login, matches[re.SubexpIndex("commitId")], true
 }
 
-var BranchNameRegex = regexp.MustCompile(`spr/([a-zA-Z0-9_\-/\.]+)/([a-f0-9]{8})$`)
+// stackOwnerConfig is the git config key of a local branch holding the login of the
+//
+//	author of a checked out stack, it is used in the branch names of that stack.
+const stackOwnerConfig = "branch.%s.sprStackOwner"
+
+// SetStackOwner records login as the author of the stack checked out on the local branch
+func SetStackOwner(gitcmd GitInterface, branch string, login string) error {
+	return gitcmd.Git(fmt.Sprintf("config "+stackOwnerConfig+" %s", branch, login), nil)
+}
+
+// StackOwner returns the author of the stack checked out on the current branch,
+//
+//	or "" when the stack was not checked out from another user.
+func StackOwner(gitcmd GitInterface) string {
+	branch, err := GetLocalBranchName(gitcmd)
+	if err != nil {
+		return ""
+	}
+	var login string
+	err = gitcmd.Git(fmt.Sprintf("config --default= --get "+stackOwnerConfig, branch), &login)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(login)
+}
 
 // GetLocalTopCommit returns the top unmerged commit in the stack
 //
 // return nil if there are no unmerged commits in the stack
-func GetLocalTopCommit(cfg *config.Config, gitcmd GitInterface) *Commit {
-	commits := GetLocalCommitStack(cfg, gitcmd)
+func GetLocalTopCommit(cfg *config.Config, gitcmd GitInterface) (*Commit, error) {
+	commits, err := GetLocalCommitStack(cfg, gitcmd)
+	if err != nil {
+		return nil, err
+	}
 	if len(commits) == 0 {
-		return nil
+		return nil, nil
 	}
-	return &commits[len(commits)-1]
+	return &commits[len(commits)-1], nil
 }
 
-func DeleteRemoteBranch(cfg *config.Config, gitcmd GitInterface, branchName string) {
+func DeleteRemoteBranch(cfg *config.Config, gitcmd GitInterface, branchName string) error {
 	command := fmt.Sprintf("push origin --delete %s", branchName)
-	err := gitcmd.Git(command, nil)
-	check(err)
+	return gitcmd.Git(command, nil)
+}
+
+// RestoreRemoteBranch force pushes the remote branch back to the given commit hash
+func RestoreRemoteBranch(cfg *config.Config, gitcmd GitInterface, branchName string, commitHash string) error {
+	command := fmt.Sprintf("push --force %s %s:refs/heads/%s", cfg.Repo.GitHubRemote, commitHash, branchName)
+	return gitcmd.Git(command, nil)
 }
 
 // GetLocalCommitStack returns a list of unmerged commits
 //
 //	the list is ordered with the bottom commit in the stack first
-func GetLocalCommitStack(cfg *config.Config, gitcmd GitInterface) []Commit {
+func GetLocalCommitStack(cfg *config.Config, gitcmd GitInterface) ([]Commit, error) {
 	var commitLog string
 	logCommand := fmt.Sprintf("log --format=medium --no-color %s/%s..HEAD",
 		cfg.Repo.GitHubRemote, cfg.Repo.GitHubBranch)
-	gitcmd.MustGit(logCommand, &commitLog)
+	err := gitcmd.Git(logCommand, &commitLog)
+	if err != nil {
+		return nil, err
+	}
 	commits, valid := parseLocalCommitStack(commitLog)
 	if !valid {
 		// if not valid - run rebase to add commit ids
 		rewordPath, err := exec.LookPath("spr_reword_helper")
-		check(err)
+		if err != nil {
+			return nil, fmt.Errorf("adding missing commit-ids: %w", err)
+		}
 		rebaseCommand := fmt.Sprintf("rebase %s/%s -i --autosquash --autostash",
 			cfg.Repo.GitHubRemote, cfg.Repo.GitHubBranch)
-		gitcmd.GitWithEditor(rebaseCommand, nil, rewordPath)
+		err = gitcmd.GitWithEditor(rebaseCommand, nil, rewordPath)
+		if err != nil {
+			return nil, fmt.Errorf("adding missing commit-ids: %w", err)
+		}
 
-		gitcmd.MustGit(logCommand, &commitLog)
+		err = gitcmd.Git(logCommand, &commitLog)
+		if err != nil {
+			return nil, err
+		}
 		commits, valid = parseLocalCommitStack(commitLog)
 		if !valid {
-			// if still not valid - panic
 			errMsg := "unable to fetch local commits\n"
-			errMsg += " most likely this is an issue with missing commit-id in the commit body\n"
-			panic(errMsg)
+			errMsg += " most likely this is an issue with missing commit-id in the commit body"
+			return nil, errors.New(errMsg)
 		}
 	}
-	return commits
+	return commits, nil
 }
 
 func parseLocalCommitStack(commitLog string) ([]Commit, bool) {
@@ -131,7 +266,7 @@ func parseLocalCommitStack(commitLog string) ([]Commit, bool) {
 		if matches != nil {
 			log.Debug().Interface("matches", matches).Msg("parseLocalCommitStack :: commitIdMatch")
 			scannedCommit.CommitID = matches[1]
-			scannedCommit.Body = strings.TrimSpace(scannedCommit.Body)
+			scannedCommit.ParseTrailers()
 
 			if strings.HasPrefix(scannedCommit.Subject, "WIP") {
 				scannedCommit.WIP = true
@@ -165,9 +300,3 @@ func parseLocalCommitStack(commitLog string) ([]Commit, bool) {
 	log.Debug().Interface("commits", commits).Msg("parseLocalCommitStack")
 	return commits, true
 }
-
-func check(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
@@ -1,23 +1,53 @@
 package git
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/ejoffe/spr/config"
+)
 
 func TestBranchNameRegex(t *testing.T) {
 	tests := []struct {
-		input  string
-		branch string
-		commit string
+		template string
+		login    string
+		input    string
+		commit   string
 	}{
-		{input: "spr/b1/deadbeef", branch: "b1", commit: "deadbeef"},
+		{input: "spr/b1/deadbeef", commit: "deadbeef"},
+		{input: "spr/release/1.0/deadbeef", commit: "deadbeef"},
+		{input: "spr/b1/deadbee"},
+		{input: "other/spr/b1/deadbeef"},
+		{template: "users/{login}/{commitId}", input: "users/someone/deadbeef", commit: "deadbeef"},
+		{template: "users/{login}/{commitId}", login: "me", input: "users/someone/deadbeef"},
+		{template: "users/{login}/{commitId}", login: "me", input: "users/me/deadbeef", commit: "deadbeef"},
+		{template: "{target}.{commitId}", input: "main.deadbeef", commit: "deadbeef"},
+		{template: "{target}.{commitId}", input: "main-deadbeef"},
 	}
 
 	for _, tc := range tests {
-		matches := BranchNameRegex.FindStringSubmatch(tc.input)
-		if tc.branch != matches[1] {
-			t.Fatalf("expected: '%v', actual: '%v'", tc.branch, matches[1])
-		}
-		if tc.commit != matches[2] {
-			t.Fatalf("expected: '%v', actual: '%v'", tc.commit, matches[2])
+		cfg := config.EmptyConfig()
+		cfg.Repo.BranchNameTemplate = tc.template
+		cfg.User.Login = tc.login
+		commit := CommitIDFromBranch(cfg, tc.input)
+		if tc.commit != commit {
+			t.Fatalf("%s %s: expected: '%v', actual: '%v'", tc.template, tc.input, tc.commit, commit)
 		}
 	}
 }
+
+func TestBranchNameFromCommitId(t *testing.T) {
+	cfg := config.EmptyConfig()
+	cfg.Repo.GitHubBranch = "main"
+	if actual := BranchNameFromCommitId(cfg, "deadbeef"); actual != "spr/main/deadbeef" {
+		t.Fatalf("expected: 'spr/main/deadbeef', actual: '%v'", actual)
+	}
+
+	cfg.Repo.BranchNameTemplate = "users/{login}/{target}/{commitId}"
+	cfg.User.Login = "me"
+	if actual := BranchNameFromCommitId(cfg, "deadbeef"); actual != "users/me/main/deadbeef" {
+		t.Fatalf("expected: 'users/me/main/deadbeef', actual: '%v'", actual)
+	}
+	if !BranchNameUsesLogin(cfg) {
+		t.Fatalf("expected the template to use the login")
+	}
+}
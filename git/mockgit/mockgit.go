@@ -47,13 +47,6 @@ func (m *Mock) ExpectationsMet() {
 	m.assert.Empty(m.response, fmt.Sprintf("expected additional git responses: %v", m.response))
 }
 
-func (m *Mock) MustGit(argStr string, output *string) {
-	err := m.Git(argStr, output)
-	if err != nil {
-		panic(err)
-	}
-}
-
 func (m *Mock) RootDir() string {
 	return ""
 }
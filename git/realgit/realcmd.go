@@ -8,11 +8,12 @@ import (
 	"strings"
 
 	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
 	"github.com/rs/zerolog/log"
 )
 
 // NewGitCmd returns a new git cmd instance
-func NewGitCmd(cfg *config.Config) *gitcmd {
+func NewGitCmd(cfg *config.Config) (*gitcmd, error) {
 	initcmd := &gitcmd{
 		config: cfg,
 		stderr: os.Stderr,
@@ -20,30 +21,32 @@ func NewGitCmd(cfg *config.Config) *gitcmd {
 	var rootdir string
 	err := initcmd.Git("rev-parse --show-toplevel", &rootdir)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+		return nil, err
+	}
+	rootdir, err = maybeAdjustPathPerPlatform(rootdir)
+	if err != nil {
+		return nil, err
 	}
-	rootdir = strings.TrimSpace(maybeAdjustPathPerPlatform(rootdir))
 
 	return &gitcmd{
 		config:  cfg,
-		rootdir: rootdir,
+		rootdir: strings.TrimSpace(rootdir),
 		stderr:  os.Stderr,
-	}
+	}, nil
 }
 
-func maybeAdjustPathPerPlatform(rawRootDir string) string {
+func maybeAdjustPathPerPlatform(rawRootDir string) (string, error) {
 	if strings.HasPrefix(rawRootDir, "/cygdrive") {
 		// This is safe to run also on "proper" Windows paths
 		cmd := exec.Command("cygpath", []string{"-w", rawRootDir}...)
 		out, err := cmd.CombinedOutput()
 		if err != nil {
-			panic(err)
+			return "", fmt.Errorf("cygpath %s: %w", rawRootDir, err)
 		}
-		return string(out)
+		return string(out), nil
 	}
 
-	return rawRootDir
+	return rawRootDir, nil
 }
 
 type gitcmd struct {
@@ -56,13 +59,6 @@ func (c *gitcmd) Git(argStr string, output *string) error {
 	return c.GitWithEditor(argStr, output, "/usr/bin/true")
 }
 
-func (c *gitcmd) MustGit(argStr string, output *string) {
-	err := c.Git(argStr, output)
-	if err != nil {
-		panic(err)
-	}
-}
-
 func (c *gitcmd) GitWithEditor(argStr string, output *string, editorCmd string) error {
 	// runs a git command
 	//  if output is not nil it will be set to the output of the command
@@ -94,19 +90,13 @@ func (c *gitcmd) GitWithEditor(argStr string, output *string, editorCmd string)
 		}
 	}
 
+	out, err := cmd.CombinedOutput()
 	if output != nil {
-		out, err := cmd.CombinedOutput()
 		*output = strings.TrimSpace(string(out))
-		if err != nil {
-			fmt.Fprintf(c.stderr, "git error: %s", string(out))
-			return err
-		}
-	} else {
-		out, err := cmd.CombinedOutput()
-		if err != nil {
-			fmt.Fprintf(c.stderr, "git error: %s", string(out))
-			return err
-		}
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "git error: %s", string(out))
+		return git.NewCommandError(argStr, string(out), err)
 	}
 	return nil
 }
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Commit message trailers setting pull request attributes
+const (
+	ReviewersTrailer = "Reviewers"
+	LabelsTrailer    = "Labels"
+	AssigneesTrailer = "Assignees"
+	DraftTrailer     = "Draft"
+	MilestoneTrailer = "Milestone"
+)
+
+var (
+	trailerRegex         = regexp.MustCompile(`^([A-Za-z0-9-]+):\s*(.*)$`)
+	trailerCommitIDRegex = regexp.MustCompile(`^commit-id:[a-f0-9]{8}$`)
+	trailerListRegex     = regexp.MustCompile(`[\s,]+`)
+)
+
+// ParseTrailers moves the pull request trailers of the commit body into the commit fields.
+//
+//	Trailers are read from the last paragraph of the body when all its lines are
+//	trailers, like git interpret-trailers does. The Reviewers, Labels, Assignees,
+//	Draft and Milestone trailers and the commit-id line are removed from the body,
+//	other trailers like Signed-off-by are kept.
+func (c *Commit) ParseTrailers() {
+	lines := strings.Split(strings.TrimSpace(c.Body), "\n")
+	start := len(lines)
+	for start > 0 && trailerRegex.MatchString(strings.TrimSpace(lines[start-1])) {
+		start--
+	}
+	if start == len(lines) || (start > 0 && strings.TrimSpace(lines[start-1]) != "") {
+		c.Body = strings.TrimSpace(c.Body)
+		return
+	}
+
+	kept := lines[:start]
+	for _, line := range lines[start:] {
+		line = strings.TrimSpace(line)
+		if trailerCommitIDRegex.MatchString(line) {
+			continue
+		}
+		matches := trailerRegex.FindStringSubmatch(line)
+		key, value := matches[1], strings.TrimSpace(matches[2])
+		switch {
+		case strings.EqualFold(key, ReviewersTrailer):
+			c.Reviewers = append(c.Reviewers, trailerList(value)...)
+		case strings.EqualFold(key, LabelsTrailer):
+			c.Labels = append(c.Labels, trailerList(value)...)
+		case strings.EqualFold(key, AssigneesTrailer):
+			c.Assignees = append(c.Assignees, trailerList(value)...)
+		case strings.EqualFold(key, MilestoneTrailer):
+			c.Milestone = value
+		case strings.EqualFold(key, DraftTrailer):
+			draft, err := strconv.ParseBool(value)
+			if err != nil {
+				kept = append(kept, line)
+				continue
+			}
+			c.Draft = &draft
+		default:
+			kept = append(kept, line)
+		}
+	}
+	c.Body = strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// trailerList splits a comma or space separated trailer value, the @ of logins is dropped
+func trailerList(value string) []string {
+	var list []string
+	for _, item := range trailerListRegex.Split(value, -1) {
+		if item = strings.TrimPrefix(item, "@"); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrailers(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name     string
+		body     string
+		expected Commit
+	}{
+		{
+			name:     "NoTrailers",
+			body:     "Some body.\n\nMore body.",
+			expected: Commit{Body: "Some body.\n\nMore body."},
+		},
+		{
+			name:     "OnlyTrailers",
+			body:     "Labels: backend, bug\nAssignees: @han\ncommit-id:053f6d16\n",
+			expected: Commit{Labels: []string{"backend", "bug"}, Assignees: []string{"han"}},
+		},
+		{
+			name: "OtherTrailersAreKept",
+			body: "Some body.\n\nSigned-off-by: Han Solo\nMilestone: v1.0\nDraft: false",
+			expected: Commit{
+				Body:      "Some body.\n\nSigned-off-by: Han Solo",
+				Milestone: "v1.0",
+				Draft:     &no,
+			},
+		},
+		{
+			name: "TrailersOnlyInLastParagraph",
+			body: "Labels: backend\n\nSome body.\nReviewers: leia",
+			expected: Commit{
+				Body: "Labels: backend\n\nSome body.\nReviewers: leia",
+			},
+		},
+		{
+			name: "CaseInsensitiveKeys",
+			body: "Some body.\n\nreviewers: leia org/team\nDRAFT: yes\ndraft: 1",
+			expected: Commit{
+				Body:      "Some body.\n\nDRAFT: yes",
+				Reviewers: []string{"leia", "org/team"},
+				Draft:     &yes,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		commit := Commit{Body: tc.body}
+		commit.ParseTrailers()
+		require.Equal(t, tc.expected, commit, tc.name)
+	}
+}
@@ -1,9 +1,16 @@
 package github
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
@@ -23,64 +30,224 @@ type ghCLIConfig map[string]struct {
 	GitProtocol string `yaml:"git_protocol"`
 }
 
-// Finds the github oath token
+// spr auth login config, keyed by host like the gh cli config
+type sprHostsConfig map[string]storedToken
+
+type storedToken struct {
+	User       string `yaml:"user"`
+	OauthToken string `yaml:"oauth_token"`
+}
+
+// Token is an oauth token along with where it was found
+type Token struct {
+	Value string
+
+	// Source names where the token was found, like GH_TOKEN or the gh cli config
+	Source string
+}
+
+// FindToken returns the oauth token of the github host, empty when none is found
 func FindToken(githubHost string) string {
-	// Try environment variable first
-	token := os.Getenv("GITHUB_TOKEN")
-	if token != "" {
-		return token
+	return LookupToken(githubHost).Value
+}
+
+// LookupToken returns the first oauth token of the github host found in the environment,
+//
+//	the spr hosts file written by spr auth login, the gh cli config, the hub cli
+//	config and git credential fill, in that order. GH_TOKEN and GITHUB_TOKEN are
+//	read from the environment, for hosts other than github.com GH_ENTERPRISE_TOKEN
+//	and GITHUB_ENTERPRISE_TOKEN first. The Value is empty when no token is found.
+func LookupToken(githubHost string) Token {
+	host := AuthHost(githubHost)
+
+	envs := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if host != "github.com" {
+		envs = append([]string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}, envs...)
+	}
+	for _, env := range envs {
+		if token := os.Getenv(env); token != "" {
+			return Token{Value: token, Source: env}
+		}
+	}
+
+	// Try ~/.config/spr/hosts.yml
+	file, err := HostsFile()
+	if err == nil {
+		var hosts sprHostsConfig
+		err = readYAML(file, &hosts)
+		if err == nil && hosts[host].OauthToken != "" {
+			return Token{Value: hosts[host].OauthToken, Source: file}
+		}
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warn().Err(err).Msg("failed to read spr hosts file")
 	}
 
 	// Try ~/.config/gh/hosts.yml
 	cfg, err := readGhCLIConfig()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Warn().Err(err).Msg("failed to read gh cli config file")
-	} else {
-		for host, user := range *cfg {
-			if host == githubHost {
-				return user.OauthToken
-			}
-		}
+	} else if err == nil && (*cfg)[host].OauthToken != "" {
+		return Token{Value: (*cfg)[host].OauthToken, Source: "gh cli config"}
 	}
 
 	// Try ~/.config/hub
 	hubCfg, err := readHubCLIConfig()
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Warn().Err(err).Msg("failed to read hub config file")
-		return ""
-	}
-
-	if c, ok := hubCfg["github.com"]; ok {
-		if len(c) == 0 {
-			log.Warn().Msg("no token found in hub config file")
-			return ""
-		}
+	} else if c := hubCfg[host]; len(c) > 0 {
 		if len(c) > 1 {
 			log.Warn().Msgf("multiple tokens found in hub config file, using first one: %s", c[0].User)
 		}
+		if c[0].OauthToken != "" {
+			return Token{Value: c[0].OauthToken, Source: "hub config"}
+		}
+	}
+
+	if token := gitCredential(githubHost); token != "" {
+		return Token{Value: token, Source: "git credential"}
+	}
+	return Token{}
+}
 
-		return c[0].OauthToken
+// AuthHost returns the host name tokens of githubHost are stored under,
+//
+//	githubHost can also be a url like http://localhost:8080.
+func AuthHost(githubHost string) string {
+	u, err := url.Parse(githubHost)
+	if err == nil && u.Host != "" {
+		return u.Host
 	}
+	return githubHost
+}
 
+// hostURL returns the scheme and host of githubHost, https unless githubHost is a url
+func hostURL(githubHost string) (scheme string, host string) {
+	u, err := url.Parse(githubHost)
+	if err == nil && u.Host != "" {
+		return u.Scheme, u.Host
+	}
+	return "https", githubHost
+}
+
+// gitCredential returns the password git credential fill finds for the host.
+//
+//	Prompting is disabled, only stored credentials and helpers are used.
+func gitCredential(githubHost string) string {
+	scheme, host := hostURL(githubHost)
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", scheme, host))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if err != nil {
+		log.Debug().Err(err).Msg("git credential fill found no credential")
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if password, ok := strings.CutPrefix(line, "password="); ok {
+			return password
+		}
+	}
 	return ""
 }
 
-func readGhCLIConfig() (*ghCLIConfig, error) {
-	homeDir, err := os.UserHomeDir()
+// HostsFile returns the file spr auth login stores tokens in, under the user config dir
+func HostsFile() (string, error) {
+	dir, err := os.UserConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", err
 	}
+	return filepath.Join(dir, "spr", "hosts.yml"), nil
+}
+
+// StoreToken stores the token of the user for the github host in the hosts file,
+//
+//	which is only readable by the user.
+func StoreToken(githubHost string, user string, token string) error {
+	return updateHostsFile(func(hosts sprHostsConfig) {
+		hosts[AuthHost(githubHost)] = storedToken{User: user, OauthToken: token}
+	})
+}
+
+// DeleteToken removes the token of the github host from the hosts file, returns false when there was none
+func DeleteToken(githubHost string) (bool, error) {
+	deleted := false
+	err := updateHostsFile(func(hosts sprHostsConfig) {
+		_, deleted = hosts[AuthHost(githubHost)]
+		delete(hosts, AuthHost(githubHost))
+	})
+	return deleted, err
+}
 
-	f, err := os.Open(path.Join(homeDir, ".config", "gh", "hosts.yml"))
+func updateHostsFile(update func(hosts sprHostsConfig)) error {
+	file, err := HostsFile()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open gh cli config file: %w", err)
+		return err
 	}
+	hosts := sprHostsConfig{}
+	err = readYAML(file, &hosts)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if hosts == nil {
+		hosts = sprHostsConfig{}
+	}
+	update(hosts)
 
-	var cfg ghCLIConfig
-	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse hub config file: %w", err)
+	data, err := yaml.Marshal(hosts)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+	// the token is written to a new file so it is never readable by others, even briefly
+	tmp, err := os.CreateTemp(filepath.Dir(file), "hosts.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Chmod(0600), tmp.Close())
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func readYAML(file string, v interface{}) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = yaml.NewDecoder(f).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return nil
+}
+
+// readGhCLIConfig reads the hosts of the gh cli config dir, GH_CONFIG_DIR when set
+func readGhCLIConfig() (*ghCLIConfig, error) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get user home directory: %w", err)
+			}
+			configDir = path.Join(homeDir, ".config")
+		}
+		dir = path.Join(configDir, "gh")
 	}
 
+	var cfg ghCLIConfig
+	if err := readYAML(path.Join(dir, "hosts.yml"), &cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
@@ -92,15 +259,9 @@ func readHubCLIConfig() (hubCLIConfig, error) {
 		return nil, fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
-	f, err := os.Open(path.Join(homeDir, ".config", "hub"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to open hub config file: %w", err)
-	}
-
 	var cfg hubCLIConfig
-	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse hub config file: %w", err)
+	if err := readYAML(path.Join(homeDir, ".config", "hub"), &cfg); err != nil {
+		return nil, err
 	}
-
 	return cfg, nil
 }
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// isolateAuth removes the tokens of the environment, config files and git credential helpers
+func isolateAuth(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GH_CONFIG_DIR", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN", "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"} {
+		t.Setenv(env, "")
+	}
+	return home
+}
+
+func TestLookupToken(t *testing.T) {
+	home := isolateAuth(t)
+	require.Equal(t, Token{}, LookupToken("github.com"))
+
+	// git credential helpers are the last resort
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "credential.helper")
+	t.Setenv("GIT_CONFIG_VALUE_0", "!f() { echo username=x-access-token; echo password=credential-token; }; f")
+	require.Equal(t, Token{Value: "credential-token", Source: "git credential"}, LookupToken("github.com"))
+
+	// hub tokens are looked up by host
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".config", "gh"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "hub"), []byte(
+		"github.com:\n- user: me\n  oauth_token: hub-token\nghe.example.com:\n- user: me\n  oauth_token: hub-ghe-token\n"), 0600))
+	require.Equal(t, Token{Value: "hub-token", Source: "hub config"}, LookupToken("github.com"))
+	require.Equal(t, Token{Value: "hub-ghe-token", Source: "hub config"}, LookupToken("https://ghe.example.com"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "gh", "hosts.yml"), []byte(
+		"github.com:\n  user: me\n  oauth_token: gh-token\n"), 0600))
+	require.Equal(t, Token{Value: "gh-token", Source: "gh cli config"}, LookupToken("github.com"))
+	require.Equal(t, "hub-ghe-token", LookupToken("ghe.example.com").Value)
+
+	// tokens stored by spr auth login come before the gh and hub configs
+	require.NoError(t, StoreToken("http://ghe.example.com:8080", "me", "spr-token"))
+	file, err := HostsFile()
+	require.NoError(t, err)
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.Equal(t, Token{Value: "spr-token", Source: file}, LookupToken("http://ghe.example.com:8080"))
+	require.Equal(t, "hub-ghe-token", LookupToken("ghe.example.com").Value)
+
+	// GH_ENTERPRISE_TOKEN is only used for enterprise hosts, GH_TOKEN for all of them
+	t.Setenv("GITHUB_TOKEN", "github-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "enterprise-token")
+	require.Equal(t, Token{Value: "github-token", Source: "GITHUB_TOKEN"}, LookupToken("github.com"))
+	require.Equal(t, Token{Value: "enterprise-token", Source: "GH_ENTERPRISE_TOKEN"}, LookupToken("ghe.example.com"))
+	t.Setenv("GH_TOKEN", "gh-env-token")
+	require.Equal(t, Token{Value: "gh-env-token", Source: "GH_TOKEN"}, LookupToken("github.com"))
+
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+	deleted, err := DeleteToken("http://ghe.example.com:8080")
+	require.NoError(t, err)
+	require.True(t, deleted)
+	deleted, err = DeleteToken("http://ghe.example.com:8080")
+	require.NoError(t, err)
+	require.False(t, deleted)
+	require.Equal(t, "credential-token", LookupToken("http://ghe.example.com:8080").Value)
+}
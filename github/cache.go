@@ -0,0 +1,257 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// cache entries not used for cacheMaxAge are removed
+	cacheMaxAge = 7 * 24 * time.Hour
+
+	// invalidatedFile is touched after every change made through the cache, entries stored before are fetched again
+	invalidatedFile = "invalidated"
+)
+
+// CacheDir returns the directory of the cached GitHub responses of the repository, under the user cache dir
+func CacheDir(cfg *config.Config) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	host := strings.NewReplacer("://", "_", "/", "_", ":", "_").Replace(cfg.Repo.GitHubHost)
+	return filepath.Join(dir, "spr", host, cfg.Repo.GitHubRepoOwner, cfg.Repo.GitHubRepoName), nil
+}
+
+// CacheTransport stores the responses of GitHub GraphQL queries on disk.
+//
+//	Responses younger than the UserConfig CacheTTL are returned without a request,
+//	older ones are fetched again. GitHub doesn't answer GraphQL queries with an ETag,
+//	so responses can't be revalidated with conditional requests. Every other request
+//	but a GET, like a mutation, marks all stored responses to be fetched again, so
+//	changes spr makes are seen right away. Pushes don't go through the transport,
+//	Invalidate is called after them. GET requests aren't cached.
+type CacheTransport struct {
+	base http.RoundTripper
+	dir  string
+	ttl  time.Duration
+
+	// refresh skips the stored responses, new responses are still stored
+	refresh atomic.Bool
+}
+
+// cacheEntry is a stored response, the rate limit headers aren't stored
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Stored time.Time   `json:"stored"`
+}
+
+// NewCacheTransport returns a CacheTransport storing responses in dir and sending requests with base,
+//
+//	http.DefaultTransport when nil. Nothing is cached when dir is empty.
+func NewCacheTransport(cfg *config.Config, dir string, base http.RoundTripper) *CacheTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &CacheTransport{
+		base: base,
+		dir:  dir,
+		ttl:  time.Duration(cfg.User.CacheTTL) * time.Second,
+	}
+	t.prune()
+	return t
+}
+
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.dir == "" {
+		return t.base.RoundTrip(req)
+	}
+	query := req.Method == http.MethodPost && isIdempotent(req)
+	if !query {
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && req.Method != http.MethodGet && resp.StatusCode < http.StatusBadRequest {
+			t.Invalidate()
+		}
+		return resp, err
+	}
+
+	key := cacheKey(req)
+	if !t.refresh.Load() {
+		entry := t.load(key)
+		if entry != nil && entry.Stored.After(t.invalidated()) && time.Since(entry.Stored) < t.ttl {
+			return entry.response(req), nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	// GraphQL errors are answered with 200 OK, they aren't stored
+	if bytes.Contains(body, []byte(`"errors"`)) {
+		return resp, nil
+	}
+	header := resp.Header.Clone()
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-ratelimit-") {
+			header.Del(name)
+		}
+	}
+	t.store(key, &cacheEntry{
+		URL:    req.URL.String(),
+		Header: header,
+		Body:   body,
+		Stored: time.Now(),
+	})
+	return resp, nil
+}
+
+// response returns the stored response to req
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheKey identifies a query by its url and body, responses to different tokens are kept apart
+func cacheKey(req *http.Request) string {
+	var body []byte
+	if req.GetBody != nil {
+		if r, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(r)
+			r.Close()
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		req.Method,
+		req.URL.String(),
+		req.Header.Get("Accept"),
+		req.Header.Get("Authorization"),
+		string(body),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *CacheTransport) load(key string) *cacheEntry {
+	data, err := os.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	return &entry
+}
+
+// store writes the entry to a temporary file renamed in place, so concurrent readers never see part of it
+func (t *CacheTransport) store(key string, entry *cacheEntry) {
+	err := t.write(key, entry)
+	if err != nil {
+		log.Debug().Err(err).Msg("storing cached github response failed")
+	}
+}
+
+func (t *CacheTransport) write(key string, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(t.dir, 0700)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(t.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(t.dir, key+".json"))
+}
+
+// Refresh makes the following queries skip the stored responses, new responses are still stored.
+//
+//	It is used for the reads deciding what to merge, which must not be stale.
+func (t *CacheTransport) Refresh() {
+	if t != nil {
+		t.refresh.Store(true)
+	}
+}
+
+// invalidated returns when the stored responses were last marked to be fetched again
+func (t *CacheTransport) invalidated() time.Time {
+	info, err := os.Stat(filepath.Join(t.dir, invalidatedFile))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Invalidate marks all stored responses to be fetched again, nothing happens when t is nil
+func (t *CacheTransport) Invalidate() {
+	if t == nil || t.dir == "" {
+		return
+	}
+	err := os.MkdirAll(t.dir, 0700)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(t.dir, invalidatedFile), nil, 0600)
+	}
+	if err == nil {
+		now := time.Now()
+		err = os.Chtimes(filepath.Join(t.dir, invalidatedFile), now, now)
+	}
+	if err != nil {
+		log.Debug().Err(err).Msg("invalidating cached github responses failed")
+	}
+}
+
+// prune removes the entries which weren't used for cacheMaxAge
+func (t *CacheTransport) prune() {
+	if t.dir == "" {
+		return
+	}
+	files, err := os.ReadDir(t.dir)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err == nil && file.Name() != invalidatedFile && time.Since(info.ModTime()) > cacheMaxAge {
+			os.Remove(filepath.Join(t.dir, file.Name()))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var queries, gets int
+	body := `{"data":{"v":1}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		switch {
+		case r.Method == http.MethodGet:
+			gets++
+			w.Write([]byte("rest"))
+		case strings.Contains(string(data), "query Q"):
+			queries++
+			w.Write([]byte(body))
+		case strings.Contains(string(data), "query E"):
+			queries++
+			w.Write([]byte(`{"errors":[{"message":"boom"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.EmptyConfig()
+	cfg.User.CacheTTL = 60
+	dir := t.TempDir()
+	transport := NewCacheTransport(cfg, dir, nil)
+	client := &http.Client{Transport: transport}
+	post := func(client *http.Client, query string) *http.Response {
+		resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(query))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp
+	}
+	read := func(resp *http.Response) string {
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// queries are answered from the cache within the ttl
+	require.Equal(t, `{"data":{"v":1}}`, read(post(client, `{"query":"query Q {}"}`)))
+	require.Equal(t, `{"data":{"v":1}}`, read(post(client, `{"query":"query Q {}"}`)))
+	require.Equal(t, 1, queries)
+
+	// GET requests aren't cached and don't change anything
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/repos/o/r/pulls/1")
+		require.NoError(t, err)
+		require.Equal(t, "rest", read(resp))
+	}
+	require.Equal(t, 2, gets)
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 1, queries)
+
+	// a mutation or a REST change fetches them again
+	post(client, `{"query":"mutation M {}"}`)
+	body = `{"data":{"v":2}}`
+	require.Equal(t, `{"data":{"v":2}}`, read(post(client, `{"query":"query Q {}"}`)))
+	require.Equal(t, 2, queries)
+	_, err := client.Post(server.URL+"/repos/o/r/pulls", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 3, queries)
+
+	// and so do stale ones
+	transport.ttl = 0
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 4, queries)
+
+	// and after an invalidation, like after a push
+	transport.ttl = time.Minute
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 4, queries)
+	transport.Invalidate()
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 5, queries)
+
+	// errors aren't stored
+	post(client, `{"query":"query E {}"}`)
+	post(client, `{"query":"query E {}"}`)
+	require.Equal(t, 7, queries)
+
+	// responses of other processes are shared through the directory, without rate limits
+	other := &http.Client{Transport: NewCacheTransport(cfg, dir, nil)}
+	resp := post(other, `{"query":"query Q {}"}`)
+	require.Empty(t, resp.Header.Get("X-RateLimit-Remaining"))
+	require.Equal(t, `{"data":{"v":2}}`, read(resp))
+	require.Equal(t, 7, queries)
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	_, err = os.Stat(filepath.Join(dir, invalidatedFile))
+	require.NoError(t, err)
+
+	// refresh skips the cache
+	transport.Refresh()
+	post(client, `{"query":"query Q {}"}`)
+	post(client, `{"query":"query Q {}"}`)
+	require.Equal(t, 9, queries)
+
+	// a nil transport is never invalidated or refreshed
+	var none *CacheTransport
+	none.Invalidate()
+	none.Refresh()
+}
@@ -0,0 +1,107 @@
+package github
+
+import (
+	"slices"
+	"time"
+)
+
+// Check is a check run or commit status reported on the head commit of a pull request
+type Check struct {
+	// Name is the check run name or the commit status context
+	Name string
+
+	// Suite is the workflow or app which ran the check, empty for commit statuses
+	Suite string
+
+	Status CheckStatus
+
+	// Required is true when branch protection of the target branch requires the check to pass
+	Required bool
+
+	StartedAt   time.Time
+	CompletedAt time.Time
+
+	// DetailsURL links to the logs or the page of the check
+	DetailsURL string
+}
+
+// Duration returns how long the check ran, or has been running for when it isn't completed.
+//
+//	It is zero when the check didn't start.
+func (c Check) Duration() time.Duration {
+	if c.StartedAt.IsZero() {
+		return 0
+	}
+	if c.CompletedAt.IsZero() {
+		return time.Since(c.StartedAt).Truncate(time.Second)
+	}
+	return c.CompletedAt.Sub(c.StartedAt)
+}
+
+// CheckRunStatus returns the status of a check run from its GraphQL status and conclusion
+func CheckRunStatus(status string, conclusion string) CheckStatus {
+	if status != "COMPLETED" {
+		return CheckStatusPending
+	}
+	switch conclusion {
+	case "SUCCESS", "NEUTRAL", "SKIPPED":
+		return CheckStatusPass
+	default:
+		return CheckStatusFail
+	}
+}
+
+// CommitStatusState returns the status of a commit status from its GraphQL state
+func CommitStatusState(state string) CheckStatus {
+	switch state {
+	case "SUCCESS":
+		return CheckStatusPass
+	case "PENDING", "EXPECTED":
+		return CheckStatusPending
+	default:
+		return CheckStatusFail
+	}
+}
+
+// MarkRequiredChecks sets Required on the checks named by one of the required contexts of branch protection
+func MarkRequiredChecks(checks []Check, required []string) {
+	for i := range checks {
+		checks[i].Required = slices.Contains(required, checks[i].Name)
+	}
+}
+
+// MissingRequiredChecks returns a pending required check for each required context of branch protection
+//
+//	which none of the checks reported yet, GitHub doesn't merge until they report.
+func MissingRequiredChecks(checks []Check, required []string) []Check {
+	var missing []Check
+	for _, name := range required {
+		reported := slices.ContainsFunc(checks, func(check Check) bool {
+			return check.Name == name
+		})
+		if !reported {
+			missing = append(missing, Check{Name: name, Status: CheckStatusPending, Required: true})
+		}
+	}
+	return missing
+}
+
+// ChecksStatus combines the status of the checks, failing when any check failed
+//
+//	and pending when any is still running. With requiredOnly only the required
+//	checks count. Pull requests without checks to wait for pass.
+func ChecksStatus(checks []Check, requiredOnly bool) CheckStatus {
+	status := CheckStatusPass
+	for _, check := range checks {
+		if requiredOnly && !check.Required {
+			continue
+		}
+		switch check.Status {
+		case CheckStatusFail:
+			return CheckStatusFail
+		case CheckStatusPending, CheckStatusUnknown:
+			status = CheckStatusPending
+		}
+	}
+	return status
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksStatus(t *testing.T) {
+	check := func(name string, status CheckStatus) Check {
+		return Check{Name: name, Status: status}
+	}
+	require.Equal(t, CheckStatusPass, ChecksStatus(nil, false))
+	require.Equal(t, CheckStatusPass, ChecksStatus([]Check{check("a", CheckStatusPass)}, false))
+	require.Equal(t, CheckStatusPending, ChecksStatus([]Check{
+		check("a", CheckStatusPass), check("b", CheckStatusPending)}, false))
+	require.Equal(t, CheckStatusFail, ChecksStatus([]Check{
+		check("a", CheckStatusPending), check("b", CheckStatusFail), check("c", CheckStatusPass)}, false))
+
+	// only required checks count with requiredOnly
+	checks := []Check{check("build", CheckStatusPass), check("lint", CheckStatusFail), check("e2e", CheckStatusPending)}
+	MarkRequiredChecks(checks, []string{"build"})
+	require.True(t, checks[0].Required)
+	require.False(t, checks[1].Required)
+	require.Equal(t, CheckStatusFail, ChecksStatus(checks, false))
+	require.Equal(t, CheckStatusPass, ChecksStatus(checks, true))
+	MarkRequiredChecks(checks, []string{"build", "e2e"})
+	require.Equal(t, CheckStatusPending, ChecksStatus(checks, true))
+
+	// a required check which didn't report yet is pending
+	checks = []Check{check("lint", CheckStatusPass)}
+	MarkRequiredChecks(checks, []string{"build"})
+	require.Equal(t, CheckStatusPass, ChecksStatus(checks, true))
+	missing := MissingRequiredChecks(checks, []string{"build", "lint"})
+	require.Equal(t, []Check{{Name: "build", Status: CheckStatusPending, Required: true}}, missing)
+	require.Equal(t, CheckStatusPending, ChecksStatus(append(checks, missing...), true))
+	require.Equal(t, CheckStatusPending, ChecksStatus(append(checks, missing...), false))
+}
+
+func TestCheckRunStatus(t *testing.T) {
+	require.Equal(t, CheckStatusPending, CheckRunStatus("IN_PROGRESS", ""))
+	require.Equal(t, CheckStatusPending, CheckRunStatus("QUEUED", ""))
+	require.Equal(t, CheckStatusPass, CheckRunStatus("COMPLETED", "SUCCESS"))
+	require.Equal(t, CheckStatusPass, CheckRunStatus("COMPLETED", "SKIPPED"))
+	require.Equal(t, CheckStatusFail, CheckRunStatus("COMPLETED", "TIMED_OUT"))
+	require.Equal(t, CheckStatusFail, CheckRunStatus("COMPLETED", "CANCELLED"))
+
+	require.Equal(t, CheckStatusPass, CommitStatusState("SUCCESS"))
+	require.Equal(t, CheckStatusPending, CommitStatusState("EXPECTED"))
+	require.Equal(t, CheckStatusFail, CommitStatusState("ERROR"))
+}
+
+func TestCheckDuration(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.Zero(t, Check{}.Duration())
+	require.Equal(t, 95*time.Second, Check{StartedAt: start, CompletedAt: start.Add(95 * time.Second)}.Duration())
+	require.Greater(t, Check{StartedAt: time.Now().Add(-time.Minute)}.Duration(), 59*time.Second)
+}
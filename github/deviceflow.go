@@ -0,0 +1,209 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuthScopes are the oauth scopes spr asks for, repo to push branches and change pull requests
+//
+//	and read:org to request reviews from teams.
+var AuthScopes = []string{"repo", "read:org"}
+
+// impliedScopes are the scopes granting the same access as a scope spr asks for
+var impliedScopes = map[string][]string{
+	"read:org": {"write:org", "admin:org"},
+}
+
+// DeviceCode is the code the user enters at VerificationURI to authorize the oauth app
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// DeviceFlow gets an oauth token with the OAuth device flow of a GitHub host.
+//
+//	The oauth app of ClientID needs the device flow enabled, see
+//	https://docs.github.com/en/apps/oauth-apps/building-oauth-apps/authorizing-oauth-apps#device-flow
+type DeviceFlow struct {
+	ClientID string
+
+	baseURL string
+	http    *http.Client
+
+	// sleep waits for d unless ctx is done first
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewDeviceFlow returns the device flow of the oauth app with clientID on the github host
+func NewDeviceFlow(githubHost string, clientID string) *DeviceFlow {
+	scheme, host := hostURL(githubHost)
+	return &DeviceFlow{
+		ClientID: clientID,
+		baseURL:  scheme + "://" + host,
+		http:     http.DefaultClient,
+		sleep:    sleep,
+	}
+}
+
+// RequestCode starts the device flow asking for AuthScopes
+func (f *DeviceFlow) RequestCode(ctx context.Context) (*DeviceCode, error) {
+	var code DeviceCode
+	err := f.post(ctx, "/login/device/code", url.Values{
+		"client_id": {f.ClientID},
+		"scope":     {strings.Join(AuthScopes, " ")},
+	}, &code)
+	if err != nil {
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	}
+	if code.DeviceCode == "" {
+		return nil, fmt.Errorf("requesting device code: no code returned, is the device flow enabled for oauth app %s?", f.ClientID)
+	}
+	return &code, nil
+}
+
+// PollToken waits until the user entered the code and returns the oauth token,
+//
+//	it fails when the user denies access or the code expires.
+func (f *DeviceFlow) PollToken(ctx context.Context, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		err := f.sleep(ctx, interval)
+		if err != nil {
+			return "", err
+		}
+		var resp struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+			Interval    int    `json:"interval"`
+		}
+		err = f.post(ctx, "/login/oauth/access_token", url.Values{
+			"client_id":   {f.ClientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &resp)
+		if err != nil {
+			return "", fmt.Errorf("polling oauth token: %w", err)
+		}
+		switch resp.Error {
+		case "":
+			return resp.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+			if resp.Interval > 0 {
+				interval = time.Duration(resp.Interval) * time.Second
+			}
+		case "expired_token":
+			return "", errors.New("the device code expired, run spr auth login again")
+		case "access_denied":
+			return "", errors.New("authorization was denied")
+		default:
+			return "", fmt.Errorf("polling oauth token: %s: %s", resp.Error, resp.Description)
+		}
+	}
+}
+
+func (f *DeviceFlow) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s", req.URL, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// TokenInfo is the user an oauth token authenticates and the scopes granted to it
+type TokenInfo struct {
+	Login string
+
+	// Scopes is nil when the token doesn't report its scopes, like fine-grained and app tokens
+	Scopes []string
+}
+
+// MissingScopes returns the AuthScopes which weren't granted, nil when the scopes aren't known
+func (i *TokenInfo) MissingScopes() []string {
+	if i.Scopes == nil {
+		return nil
+	}
+	var missing []string
+	for _, scope := range AuthScopes {
+		granted := slices.ContainsFunc(append([]string{scope}, impliedScopes[scope]...), func(s string) bool {
+			return slices.Contains(i.Scopes, s)
+		})
+		if !granted {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// ValidateToken returns the user and scopes of the token from the REST api of the github host,
+//
+//	fails with ErrUnauthorized when the token is rejected.
+func ValidateToken(ctx context.Context, githubHost string, token string) (*TokenInfo, error) {
+	scheme, host := hostURL(githubHost)
+	api := fmt.Sprintf("%s://%s/api/v3", scheme, host)
+	if host == "github.com" {
+		api = "https://api.github.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api+"/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("%w: the token of %s was rejected", ErrUnauthorized, AuthHost(githubHost))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("validating token: %s %s", req.URL, resp.Status)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&user)
+	if err != nil {
+		return nil, fmt.Errorf("validating token: %w", err)
+	}
+	info := &TokenInfo{Login: user.Login}
+	if header, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(header, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+		slices.Sort(info.Scopes)
+	}
+	return info, nil
+}
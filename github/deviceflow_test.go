@@ -0,0 +1,97 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceFlow(t *testing.T) {
+	var polls []string
+	var waits []time.Duration
+	responses := []map[string]interface{}{
+		{"error": "authorization_pending"},
+		{"error": "slow_down", "interval": 10},
+		{"access_token": "granted-token"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/device/code", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "client", r.FormValue("client_id"))
+		require.Equal(t, "repo read:org", r.FormValue("scope"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code": "device", "user_code": "ABCD-1234",
+			"verification_uri": "https://example.com/login/device", "interval": 5,
+		})
+	})
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		polls = append(polls, r.FormValue("device_code"))
+		json.NewEncoder(w).Encode(responses[len(polls)-1])
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+	flow := NewDeviceFlow(server.URL, "client")
+	flow.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	code, err := flow.RequestCode(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ABCD-1234", code.UserCode)
+
+	token, err := flow.PollToken(ctx, code)
+	require.NoError(t, err)
+	require.Equal(t, "granted-token", token)
+	require.Equal(t, []string{"device", "device", "device"}, polls)
+	require.Equal(t, []time.Duration{5 * time.Second, 5 * time.Second, 10 * time.Second}, waits)
+
+	responses = []map[string]interface{}{{"error": "access_denied"}}
+	polls = nil
+	_, err = flow.PollToken(ctx, code)
+	require.ErrorContains(t, err, "denied")
+}
+
+func TestValidateToken(t *testing.T) {
+	scopes := "repo, workflow"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v3/user", r.URL.Path)
+		if r.Header.Get("Authorization") != "token good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if scopes != "-" {
+			w.Header().Set("X-OAuth-Scopes", scopes)
+		}
+		w.Write([]byte(`{"login":"me"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	info, err := ValidateToken(ctx, server.URL, "good")
+	require.NoError(t, err)
+	require.Equal(t, &TokenInfo{Login: "me", Scopes: []string{"repo", "workflow"}}, info)
+	require.Equal(t, []string{"read:org"}, info.MissingScopes())
+
+	// admin:org includes read:org
+	scopes = "admin:org, repo"
+	info, err = ValidateToken(ctx, server.URL, "good")
+	require.NoError(t, err)
+	require.Empty(t, info.MissingScopes())
+
+	// fine-grained tokens don't report scopes
+	scopes = "-"
+	info, err = ValidateToken(ctx, server.URL, "good")
+	require.NoError(t, err)
+	require.Nil(t, info.Scopes)
+	require.Nil(t, info.MissingScopes())
+
+	_, err = ValidateToken(ctx, server.URL, "bad")
+	require.True(t, errors.Is(err, ErrUnauthorized))
+}
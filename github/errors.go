@@ -0,0 +1,40 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized is returned when github rejects or is missing the oauth token
+	ErrUnauthorized = errors.New("github authentication failed")
+
+	// ErrPullRequestNotFound is returned when a pull request does not exist or can't be accessed
+	ErrPullRequestNotFound = errors.New("pull request not found")
+
+	// ErrListingTruncated is returned instead of closing pull requests when not all pull requests could be listed
+	ErrListingTruncated = errors.New("pull request listing was truncated")
+)
+
+// ClassifyError wraps err with ErrUnauthorized or ErrPullRequestNotFound when the
+//
+//	github graphql response shows the request failed for one of those reasons.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrPullRequestNotFound) {
+		return err
+	}
+
+	msg := err.Error()
+	if strings.Contains(msg, "401 Unauthorized") || strings.Contains(msg, "Bad credentials") {
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	if strings.Contains(msg, "Could not resolve to a PullRequest") ||
+		strings.Contains(msg, "Could not resolve to a node") {
+		return fmt.Errorf("%w: %w", ErrPullRequestNotFound, err)
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package github
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	require.Nil(t, ClassifyError(nil))
+
+	err := ClassifyError(errors.New("non-200 OK status code: 401 Unauthorized body: \"Bad credentials\""))
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	err = ClassifyError(errors.New("Could not resolve to a PullRequest with the global id of 'PR_1'"))
+	require.ErrorIs(t, err, ErrPullRequestNotFound)
+
+	err = ClassifyError(errors.New("Could not resolve to a node with the global id of 'PR_2'"))
+	require.ErrorIs(t, err, ErrPullRequestNotFound)
+
+	other := errors.New("something else")
+	require.Equal(t, other, ClassifyError(other))
+
+	// classifying twice doesn't wrap again
+	err = ClassifyError(errors.New("non-200 OK status code: 401 Unauthorized"))
+	require.Equal(t, err, ClassifyError(err))
+}
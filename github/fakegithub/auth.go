@@ -0,0 +1,63 @@
+package fakegithub
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// registerAuth adds the oauth device flow and the authenticated user endpoint
+func (s *Server) registerAuth(mux *http.ServeMux) {
+	mux.HandleFunc("POST /login/device/code", s.deviceCode)
+	mux.HandleFunc("POST /login/oauth/access_token", s.accessToken)
+	mux.HandleFunc("GET /api/v3/user", s.authenticatedUser)
+}
+
+func (s *Server) deviceCode(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := fmt.Sprintf("device-%d", len(s.devicePolls)+1)
+	s.devicePolls[code] = 0
+	writeJSON(w, http.StatusOK, object{
+		"device_code":      code,
+		"user_code":        "ABCD-1234",
+		"verification_uri": s.URL + "/login/device",
+		"expires_in":       900,
+		"interval":         1,
+	})
+}
+
+// accessToken answers the first poll of a device code as pending, later polls grant Token
+func (s *Server) accessToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := r.FormValue("device_code")
+	polls, ok := s.devicePolls[code]
+	switch {
+	case r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" || r.FormValue("client_id") == "":
+		writeJSON(w, http.StatusOK, object{"error": "unsupported_grant_type"})
+	case !ok:
+		writeJSON(w, http.StatusOK, object{"error": "expired_token"})
+	case polls == 0:
+		s.devicePolls[code]++
+		writeJSON(w, http.StatusOK, object{"error": "authorization_pending"})
+	default:
+		delete(s.devicePolls, code)
+		writeJSON(w, http.StatusOK, object{"access_token": s.Token, "token_type": "bearer"})
+	}
+}
+
+func (s *Server) authenticatedUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+	if token != s.Token {
+		writeJSON(w, http.StatusUnauthorized, object{"message": "Bad credentials"})
+		return
+	}
+	w.Header().Set("X-OAuth-Scopes", strings.Join(s.TokenScopes, ", "))
+	writeJSON(w, http.StatusOK, object{"login": s.Login})
+}
@@ -0,0 +1,710 @@
+package fakegithub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// DefaultOwner is the repository owner used by New
+	DefaultOwner = "spr-owner"
+
+	// DefaultRepoName is the repository name used by New
+	DefaultRepoName = "spr-repo"
+
+	// DefaultLogin is the login of the authenticated viewer
+	DefaultLogin = "spr-user"
+
+	// DefaultBranch is the branch the remote repository is initialized with
+	DefaultBranch = "main"
+)
+
+// Pull request states
+const (
+	StateOpen   = "OPEN"
+	StateClosed = "CLOSED"
+	StateMerged = "MERGED"
+)
+
+// Server is an in-process stand in for the GitHub GraphQL and REST apis.
+//
+//	The server is paired with a local bare git repository which is used as
+//	the git remote. Branches pushed to the remote are visible to pull requests
+//	and merging a pull request updates the base branch in the remote.
+type Server struct {
+	// URL is the base url of the fake, use it as the repo GitHubHost.
+	URL string
+
+	// RemoteDir is the path of the bare git repository used as the remote.
+	RemoteDir string
+
+	Owner string
+	Name  string
+	Login string
+
+	// Users is the list of assignable users of the repository
+	Users []User
+
+	// Teams is the list of teams of the repository owner organization
+	Teams []Team
+
+	// Labels is the list of labels of the repository
+	Labels []Label
+
+	// Milestones is the list of open milestones of the repository
+	Milestones []Milestone
+
+	// RequiredChecks are the check names branch protection of the default branch requires to pass
+	RequiredChecks []string
+
+	// RequiredApprovals is the number of approvals branch protection of the default branch requires
+	RequiredApprovals int
+
+	// MaxPageSize caps the number of items returned in one page of a listing,
+	//  lower it to exercise pagination. Defaults to 100 like GitHub.
+	MaxPageSize int
+
+	// Token is the oauth token granted by the device flow, the only token the user endpoint accepts
+	Token string
+
+	// TokenScopes are the oauth scopes the user endpoint reports for Token
+	TokenScopes []string
+
+	t      testing.TB
+	server *httptest.Server
+
+	mu           sync.Mutex
+	pullRequests []*PullRequest
+	statuses     map[string]string
+	checkRuns    map[string][]CheckRun
+
+	// devicePolls counts the token polls of each device code, the first one is pending
+	devicePolls map[string]int
+}
+
+// User is a GitHub user
+type User struct {
+	ID    string
+	Login string
+	Name  string
+}
+
+// Team is a GitHub organization team
+type Team struct {
+	ID   string
+	Slug string
+}
+
+// Review is a review submitted on a pull request
+type Review struct {
+	Author string
+
+	// State is APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	State string
+
+	// DismissedState is the State of a dismissed review before it was dismissed
+	DismissedState string
+
+	// CommitID is the head of the pull request when the review was submitted
+	CommitID string
+}
+
+// Label is a repository label
+type Label struct {
+	ID   string
+	Name string
+}
+
+// Milestone is a repository milestone
+type Milestone struct {
+	ID     string
+	Number int
+	Title  string
+}
+
+// CheckRun is a check run reported on a commit
+type CheckRun struct {
+	Name string
+
+	// Workflow is the name of the GitHub Actions workflow the check run belongs to
+	Workflow string
+
+	// Status is QUEUED, IN_PROGRESS or COMPLETED
+	Status string
+
+	// Conclusion is the result (SUCCESS, FAILURE, ...) of a completed check run
+	Conclusion string
+
+	StartedAt   time.Time
+	CompletedAt time.Time
+	DetailsURL  string
+}
+
+// PullRequest is the fake server side representation of a pull request
+type PullRequest struct {
+	Number      int
+	Title       string
+	Body        string
+	Author      string
+	BaseRefName string
+	HeadRefName string
+	State       string
+	Draft       bool
+	MergeMethod string
+	AutoMerge   bool
+
+	// ReviewerIDs are the user ids review was requested from
+	ReviewerIDs []string
+
+	// TeamReviewerIDs are the team ids review was requested from
+	TeamReviewerIDs []string
+
+	// ReviewRequests counts the review requests, GitHub notifies the reviewers on each one
+	ReviewRequests int
+
+	// Reviews are the reviews in submission order
+	Reviews []Review
+
+	// Comments are the bodies of all comments added to the pull request
+	Comments []string
+
+	// Labels are the label names of the pull request
+	Labels []string
+
+	// Assignees are the logins of the users assigned to the pull request
+	Assignees []string
+
+	// Milestone is the title of the milestone of the pull request
+	Milestone string
+}
+
+// ID returns the GraphQL node id of the pull request
+func (pr *PullRequest) ID() string {
+	return fmt.Sprintf("PR_%d", pr.Number)
+}
+
+// DatabaseID returns the REST id of the pull request
+func (pr *PullRequest) DatabaseID() int64 {
+	return int64(1000 + pr.Number)
+}
+
+// New creates a fake GitHub server along with a bare remote repository
+//
+//	holding a single initial commit on the default branch.
+//	The server is shut down and all files are removed when the test ends.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		RemoteDir: filepath.Join(t.TempDir(), "remote.git"),
+		Owner:     DefaultOwner,
+		Name:      DefaultRepoName,
+		Login:     DefaultLogin,
+		Users: []User{
+			{ID: "U_" + DefaultLogin, Login: DefaultLogin, Name: "Spr User"},
+			{ID: "U_reviewer", Login: "reviewer", Name: "Re Viewer"},
+		},
+		Teams: []Team{
+			{ID: "T_reviewers", Slug: "reviewers"},
+		},
+		Labels: []Label{
+			{ID: "L_backend", Name: "backend"},
+			{ID: "L_bug", Name: "bug"},
+		},
+		Milestones: []Milestone{
+			{ID: "M_1", Number: 1, Title: "v1.0"},
+		},
+		MaxPageSize: 100,
+		Token:       "fake-token",
+		TokenScopes: []string{"repo", "read:org"},
+		t:           t,
+		devicePolls: map[string]int{},
+		statuses:    map[string]string{},
+		checkRuns:   map[string][]CheckRun{},
+	}
+
+	s.mustRun("", "init", "--bare", "--initial-branch="+DefaultBranch, s.RemoteDir)
+
+	seed := t.TempDir()
+	s.mustRun(seed, "clone", s.RemoteDir, ".")
+	configureUser(s, seed)
+	err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("fake github repo\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mustRun(seed, "add", "README.md")
+	s.mustRun(seed, "commit", "-m", "initial commit")
+	s.mustRun(seed, "push", "origin", "HEAD:"+DefaultBranch)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/graphql", s.serveGraphQL)
+	s.registerREST(mux)
+	s.registerAuth(mux)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+// Clone clones the remote into a new temporary directory and returns its path.
+//
+//	The clone has a committer identity configured so commits can be created.
+func (s *Server) Clone(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	s.mustRun(dir, "clone", s.RemoteDir, ".")
+	configureUser(s, dir)
+	return dir
+}
+
+// RESTBaseURL returns the base url to use for go-github enterprise clients
+func (s *Server) RESTBaseURL() string {
+	return s.URL + "/api/v3/"
+}
+
+// PullRequests returns a copy of all pull requests ordered by number
+func (s *Server) PullRequests() []PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prs []PullRequest
+	for _, pr := range s.pullRequests {
+		prs = append(prs, *pr)
+	}
+	return prs
+}
+
+// OpenPullRequests returns a copy of the open pull requests ordered by number
+func (s *Server) OpenPullRequests() []PullRequest {
+	var open []PullRequest
+	for _, pr := range s.PullRequests() {
+		if pr.State == StateOpen {
+			open = append(open, pr)
+		}
+	}
+	return open
+}
+
+// PullRequest returns a copy of the pull request with the given number
+func (s *Server) PullRequest(number int) (PullRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findByNumber(number)
+	if pr == nil {
+		return PullRequest{}, false
+	}
+	return *pr, true
+}
+
+// Approve adds an approving review by the reviewer user to the given pull request
+func (s *Server) Approve(number int) {
+	s.AddReview(number, "reviewer", "APPROVED")
+}
+
+// AddReview adds a review of the current head of the given pull request,
+//
+//	which answers the pending review request of the author.
+func (s *Server) AddReview(number int, author string, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findByNumber(number)
+	if pr == nil {
+		s.t.Fatalf("fakegithub: review unknown pull request %d", number)
+	}
+	s.addReview(pr, author, state)
+}
+
+// ApproveAll adds an approving review to all open pull requests at once,
+//
+//	clients never see only some of them approved.
+func (s *Server) ApproveAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, pr := range s.pullRequests {
+		if pr.State == StateOpen {
+			s.addReview(pr, "reviewer", "APPROVED")
+		}
+	}
+}
+
+// Close closes the given pull request, like it was closed on GitHub
+func (s *Server) Close(number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findByNumber(number)
+	if pr == nil {
+		s.t.Fatalf("fakegithub: close unknown pull request %d", number)
+	}
+	if err := s.closePullRequest(pr); err != nil {
+		s.t.Fatalf("fakegithub: %v", err)
+	}
+}
+
+// DismissReviews dismisses the approvals and change requests of the author on the given pull request
+func (s *Server) DismissReviews(number int, author string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pr := s.findByNumber(number)
+	if pr == nil {
+		s.t.Fatalf("fakegithub: dismiss reviews of unknown pull request %d", number)
+	}
+	for i, review := range pr.Reviews {
+		if review.Author == author && (review.State == "APPROVED" || review.State == "CHANGES_REQUESTED") {
+			pr.Reviews[i].DismissedState = review.State
+			pr.Reviews[i].State = "DISMISSED"
+		}
+	}
+}
+
+func (s *Server) addReview(pr *PullRequest, author string, state string) {
+	pr.Reviews = append(pr.Reviews, Review{
+		Author:   author,
+		State:    state,
+		CommitID: s.BranchHead(pr.HeadRefName),
+	})
+	pr.ReviewerIDs = slices.DeleteFunc(pr.ReviewerIDs, func(id string) bool {
+		return id == s.userID(author)
+	})
+}
+
+// SetCommitStatus sets the combined check state (SUCCESS, PENDING, FAILURE)
+//
+//	of the given commit sha. Commits without a status are reported as passing.
+func (s *Server) SetCommitStatus(sha string, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[sha] = state
+}
+
+// AddCheckRun reports a check run on the given commit sha,
+//
+//	the check runs count in the status check rollup of the commit.
+func (s *Server) AddCheckRun(sha string, run CheckRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkRuns[sha] = append(s.checkRuns[sha], run)
+}
+
+// BranchHead returns the commit sha the given remote branch points to
+//
+//	or an empty string if the branch doesn't exist.
+func (s *Server) BranchHead(branch string) string {
+	out, err := s.git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// Branches returns the names of all remote branches
+func (s *Server) Branches() []string {
+	out, err := s.git("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if out == "" {
+		return nil
+	}
+	branches := strings.Split(out, "\n")
+	sort.Strings(branches)
+	return branches
+}
+
+func (s *Server) findByNumber(number int) *PullRequest {
+	for _, pr := range s.pullRequests {
+		if pr.Number == number {
+			return pr
+		}
+	}
+	return nil
+}
+
+func (s *Server) findByID(id string) *PullRequest {
+	for _, pr := range s.pullRequests {
+		if pr.ID() == id {
+			return pr
+		}
+	}
+	return nil
+}
+
+func (s *Server) repositoryID() string {
+	return fmt.Sprintf("R_%s_%s", s.Owner, s.Name)
+}
+
+// createPullRequest must be called with the lock held
+func (s *Server) createPullRequest(title, body, base, head string, draft bool) (*PullRequest, error) {
+	if s.BranchHead(base) == "" {
+		return nil, fmt.Errorf("base branch %q does not exist", base)
+	}
+	if s.BranchHead(head) == "" {
+		return nil, fmt.Errorf("head branch %q does not exist", head)
+	}
+	for _, pr := range s.pullRequests {
+		if pr.State == StateOpen && pr.HeadRefName == head {
+			return nil, fmt.Errorf("a pull request already exists for %s", head)
+		}
+	}
+	pr := &PullRequest{
+		Number:      len(s.pullRequests) + 1,
+		Title:       title,
+		Body:        body,
+		Author:      s.Login,
+		BaseRefName: base,
+		HeadRefName: head,
+		State:       StateOpen,
+		Draft:       draft,
+	}
+	s.pullRequests = append(s.pullRequests, pr)
+	return pr, nil
+}
+
+// updatePullRequest must be called with the lock held
+func (s *Server) updatePullRequest(pr *PullRequest, title, body, base *string) error {
+	if pr.State != StateOpen {
+		return fmt.Errorf("pull request %d is not open", pr.Number)
+	}
+	if base != nil {
+		if s.BranchHead(*base) == "" {
+			return fmt.Errorf("base branch %q does not exist", *base)
+		}
+		pr.BaseRefName = *base
+	}
+	if title != nil {
+		pr.Title = *title
+	}
+	if body != nil {
+		pr.Body = *body
+	}
+	return nil
+}
+
+// addLabels must be called with the lock held, labels are label names
+func (s *Server) addLabels(pr *PullRequest, labels []string) error {
+	for _, name := range labels {
+		if !slices.ContainsFunc(s.Labels, func(l Label) bool { return l.Name == name }) {
+			return fmt.Errorf("label %q does not exist", name)
+		}
+		if !slices.Contains(pr.Labels, name) {
+			pr.Labels = append(pr.Labels, name)
+		}
+	}
+	return nil
+}
+
+// removeLabels must be called with the lock held, labels are label names
+func (s *Server) removeLabels(pr *PullRequest, labels []string) {
+	pr.Labels = slices.DeleteFunc(pr.Labels, func(name string) bool { return slices.Contains(labels, name) })
+}
+
+// addAssignees must be called with the lock held, assignees are user logins
+func (s *Server) addAssignees(pr *PullRequest, assignees []string) error {
+	for _, login := range assignees {
+		if s.userID(login) == "" {
+			return fmt.Errorf("user %q can't be assigned", login)
+		}
+		if !slices.Contains(pr.Assignees, login) {
+			pr.Assignees = append(pr.Assignees, login)
+		}
+	}
+	return nil
+}
+
+// milestone returns the milestone matching fn
+func (s *Server) milestone(fn func(Milestone) bool) (Milestone, error) {
+	i := slices.IndexFunc(s.Milestones, fn)
+	if i < 0 {
+		return Milestone{}, fmt.Errorf("milestone does not exist")
+	}
+	return s.Milestones[i], nil
+}
+
+// mergePullRequest must be called with the lock held
+func (s *Server) mergePullRequest(pr *PullRequest, method string) error {
+	if pr.State != StateOpen {
+		return fmt.Errorf("pull request %d is not open", pr.Number)
+	}
+	if s.mergeable(pr) != "MERGEABLE" {
+		return fmt.Errorf("pull request %d is not mergeable", pr.Number)
+	}
+
+	method = strings.ToLower(method)
+	base := s.BranchHead(pr.BaseRefName)
+	head := s.BranchHead(pr.HeadRefName)
+	var merged string
+	var err error
+	switch method {
+	case "", "merge":
+		merged, err = s.git("commit-tree", head+"^{tree}", "-p", base, "-p", head,
+			"-m", fmt.Sprintf("Merge pull request #%d from %s", pr.Number, pr.HeadRefName))
+	case "squash":
+		merged, err = s.git("commit-tree", head+"^{tree}", "-p", base,
+			"-m", fmt.Sprintf("%s (#%d)", pr.Title, pr.Number))
+	case "rebase":
+		if _, ancestorErr := s.git("merge-base", "--is-ancestor", base, head); ancestorErr != nil {
+			return fmt.Errorf("pull request %d can't be rebased", pr.Number)
+		}
+		merged = head
+	default:
+		return fmt.Errorf("unknown merge method %q", method)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.git("update-ref", "refs/heads/"+pr.BaseRefName, merged, base)
+	if err != nil {
+		return err
+	}
+	pr.State = StateMerged
+	pr.MergeMethod = method
+	return nil
+}
+
+// closePullRequest must be called with the lock held
+func (s *Server) closePullRequest(pr *PullRequest) error {
+	switch pr.State {
+	case StateOpen:
+		pr.State = StateClosed
+		return nil
+	case StateMerged:
+		// closing an already merged pull request is a no-op
+		return nil
+	default:
+		return fmt.Errorf("pull request %d is already closed", pr.Number)
+	}
+}
+
+// reopenPullRequest must be called with the lock held
+func (s *Server) reopenPullRequest(pr *PullRequest) error {
+	switch pr.State {
+	case StateOpen:
+		return nil
+	case StateMerged:
+		return fmt.Errorf("pull request %d is merged and can't be reopened", pr.Number)
+	}
+	if s.BranchHead(pr.HeadRefName) == "" {
+		return fmt.Errorf("pull request %d can't be reopened, branch %q was deleted", pr.Number, pr.HeadRefName)
+	}
+	pr.State = StateOpen
+	return nil
+}
+
+// mergeable computes the GitHub MergeableState of the pull request
+func (s *Server) mergeable(pr *PullRequest) string {
+	base := s.BranchHead(pr.BaseRefName)
+	head := s.BranchHead(pr.HeadRefName)
+	if base == "" || head == "" {
+		return "UNKNOWN"
+	}
+	if _, err := s.git("merge-base", "--is-ancestor", base, head); err == nil {
+		return "MERGEABLE"
+	}
+	if _, err := s.git("merge-tree", "--write-tree", base, head); err != nil {
+		return "CONFLICTING"
+	}
+	return "MERGEABLE"
+}
+
+// reviewDecision returns CHANGES_REQUESTED when the latest review of any reviewer requests changes,
+//
+//	APPROVED when the required approvals, at least one, were given and REVIEW_REQUIRED otherwise.
+func (s *Server) reviewDecision(pr *PullRequest) string {
+	if len(pr.Reviews) == 0 {
+		return ""
+	}
+	latest := map[string]string{}
+	for _, review := range pr.Reviews {
+		if review.State != "COMMENTED" {
+			latest[review.Author] = review.State
+		}
+	}
+	approvals := 0
+	for _, state := range latest {
+		switch state {
+		case "CHANGES_REQUESTED":
+			return state
+		case "APPROVED":
+			approvals++
+		}
+	}
+	if approvals >= max(s.RequiredApprovals, 1) {
+		return "APPROVED"
+	}
+	return "REVIEW_REQUIRED"
+}
+
+type commit struct {
+	oid     string
+	subject string
+	body    string
+}
+
+// commits returns the pull request commits with the oldest commit first
+func (s *Server) commits(pr *PullRequest) []commit {
+	head := s.BranchHead(pr.HeadRefName)
+	if head == "" {
+		return nil
+	}
+	revRange := head
+	if base := s.BranchHead(pr.BaseRefName); base != "" {
+		revRange = base + ".." + head
+	}
+	out, err := s.git("log", "--reverse", "--format=%H%x1f%s%x1f%b%x1e", revRange)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var commits []commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, commit{
+			oid:     fields[0],
+			subject: fields[1],
+			body:    strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits
+}
+
+func (s *Server) git(args ...string) (string, error) {
+	args = append([]string{"--git-dir", s.RemoteDir}, args...)
+	cmd := exec.Command("git", args...)
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func (s *Server) mustRun(dir string, args ...string) {
+	s.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		s.t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func configureUser(s *Server, dir string) {
+	s.mustRun(dir, "config", "user.name", "Spr User")
+	s.mustRun(dir, "config", "user.email", "spr-user@example.com")
+	s.mustRun(dir, "config", "commit.gpgsign", "false")
+}
@@ -0,0 +1,889 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
+)
+
+type graphQLRequest struct {
+	OperationName string                     `json:"operationName"`
+	Query         string                     `json:"query"`
+	Variables     map[string]json.RawMessage `json:"variables"`
+}
+
+type object map[string]interface{}
+
+// serveGraphQL dispatches on the operation name of the generated genclient operations
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeGraphQLError(w, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data object
+	var err error
+	switch req.OperationName {
+	case "PullRequests", "PullRequestsWithMergeQueue":
+		data, err = s.queryPullRequests(req)
+	case "ViewerPullRequests":
+		data, err = s.queryViewerPullRequests(req)
+	case "PullRequestsByHead":
+		data, err = s.queryPullRequestsByHead(req)
+	case "PullRequestBranches":
+		data, err = s.queryPullRequestBranches(req)
+	case "PullRequestCommits":
+		data, err = s.queryPullRequestCommits(req)
+	case "PullRequestReviews":
+		data, err = s.queryPullRequestConnection(req, "reviews", s.reviewNodes)
+	case "PullRequestDismissals":
+		data, err = s.queryPullRequestConnection(req, "timelineItems", s.dismissalNodes)
+	case "PullRequestReviewRequests":
+		data, err = s.queryPullRequestConnection(req, "reviewRequests", s.reviewRequestNodes)
+	case "PullRequestChecks":
+		data, err = s.queryPullRequestChecks(req)
+	case "AssignableUsers":
+		data = s.queryAssignableUsers()
+	case "RepositoryID":
+		data = object{"repository": object{"id": s.repositoryID()}}
+	case "Viewer":
+		data = object{"viewer": object{"login": s.Login}}
+	case "TeamID":
+		data, err = s.queryTeamID(req)
+	case "LabelID":
+		data, err = s.queryLabelID(req)
+	case "Milestones":
+		data, err = s.queryMilestones(req)
+	case "CreatePullRequest":
+		data, err = s.mutateCreatePullRequest(req)
+	case "UpdatePullRequest":
+		data, err = s.mutateUpdatePullRequest(req)
+	case "AddReviewers":
+		data, err = s.mutateAddReviewers(req)
+	case "AddLabels":
+		data, err = s.mutateAddLabels(req)
+	case "RemoveLabels":
+		data, err = s.mutateRemoveLabels(req)
+	case "AddAssignees":
+		data, err = s.mutateAddAssignees(req)
+	case "ConvertPullRequestToDraft":
+		data, err = s.mutateDraft(req, true)
+	case "MarkPullRequestReadyForReview":
+		data, err = s.mutateDraft(req, false)
+	case "CommentPullRequest":
+		data, err = s.mutateCommentPullRequest(req)
+	case "MergePullRequest", "AutoMergePullRequest":
+		data, err = s.mutateMergePullRequest(req)
+	case "ClosePullRequest":
+		data, err = s.mutateClosePullRequest(req)
+	default:
+		err = fmt.Errorf("fakegithub: unsupported operation %q", req.OperationName)
+	}
+	if err != nil {
+		writeGraphQLError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, object{"data": data})
+}
+
+func (s *Server) queryPullRequests(req graphQLRequest) (object, error) {
+	var open []*PullRequest
+	for _, pr := range s.pullRequests {
+		if pr.State == StateOpen && pr.Author == s.Login {
+			open = append(open, pr)
+		}
+	}
+	page, pageInfo, err := graphQLPage(s, req, open)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []object{}
+	for _, pr := range page {
+		node, err := s.pullRequestNode(pr)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return object{
+		"viewer": object{
+			"login":        s.Login,
+			"pullRequests": object{"nodes": nodes, "pageInfo": pageInfo},
+		},
+		"repository": object{"id": s.repositoryID(), "ref": s.defaultBranchRef()},
+	}, nil
+}
+
+// queryViewerPullRequests lists the open pull requests of the repository, of all authors
+func (s *Server) queryViewerPullRequests(req graphQLRequest) (object, error) {
+	var open []*PullRequest
+	for _, pr := range s.pullRequests {
+		if pr.State == StateOpen {
+			open = append(open, pr)
+		}
+	}
+	page, pageInfo, err := graphQLPage(s, req, open)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []object{}
+	for _, pr := range page {
+		node, err := s.pullRequestNode(pr)
+		if err != nil {
+			return nil, err
+		}
+		// only the last commit of each pull request is selected, with its checks
+		node["commits"] = object{"nodes": s.lastCommitWithChecks(pr)}
+		nodes = append(nodes, node)
+	}
+
+	return object{
+		"viewer": object{"login": s.Login},
+		"repository": object{
+			"ref":          s.defaultBranchRef(),
+			"pullRequests": object{"nodes": nodes, "pageInfo": pageInfo},
+		},
+	}, nil
+}
+
+func (s *Server) queryPullRequestChecks(req graphQLRequest) (object, error) {
+	var number int
+	if err := json.Unmarshal(req.Variables["number"], &number); err != nil {
+		return nil, fmt.Errorf("PullRequestChecks: invalid number: %w", err)
+	}
+	var pullRequest interface{}
+	if pr := s.findByNumber(number); pr != nil {
+		pullRequest = object{"commits": object{"nodes": s.lastCommitWithChecks(pr)}}
+	}
+	return object{"repository": object{"ref": s.defaultBranchRef(), "pullRequest": pullRequest}}, nil
+}
+
+// defaultBranchRef renders the default branch with its branch protection rule, when checks or approvals are required
+func (s *Server) defaultBranchRef() object {
+	var rule interface{}
+	if len(s.RequiredChecks) > 0 || s.RequiredApprovals > 0 {
+		rule = object{
+			"requiredStatusCheckContexts":  s.RequiredChecks,
+			"requiredApprovingReviewCount": s.RequiredApprovals,
+		}
+	}
+	return object{"branchProtectionRule": rule}
+}
+
+// lastCommitWithChecks returns the last commit node of the pull request with its check suites and statuses
+func (s *Server) lastCommitWithChecks(pr *PullRequest) []object {
+	commits := s.commitNodes(pr)
+	if len(commits) == 0 {
+		return commits
+	}
+	last := commits[len(commits)-1]["commit"].(object)
+	oid := last["oid"].(string)
+
+	var suites []object
+	workflows := map[string]int{}
+	for _, run := range s.checkRuns[oid] {
+		i, ok := workflows[run.Workflow]
+		if !ok {
+			i = len(suites)
+			workflows[run.Workflow] = i
+			suites = append(suites, object{
+				"app":         object{"name": "GitHub Actions"},
+				"workflowRun": object{"workflow": object{"name": run.Workflow}},
+				"checkRuns":   object{"nodes": []object{}},
+			})
+		}
+		runs := suites[i]["checkRuns"].(object)
+		runs["nodes"] = append(runs["nodes"].([]object), object{
+			"name":        run.Name,
+			"status":      run.Status,
+			"conclusion":  nullable(run.Conclusion),
+			"startedAt":   timestamp(run.StartedAt),
+			"completedAt": timestamp(run.CompletedAt),
+			"detailsUrl":  nullable(run.DetailsURL),
+		})
+	}
+	// like GitHub only the first page of each connection is listed, totalCount counts them all
+	limit := min(100, s.MaxPageSize)
+	for _, suite := range suites {
+		runs := suite["checkRuns"].(object)
+		nodes := runs["nodes"].([]object)
+		runs["totalCount"] = len(nodes)
+		runs["nodes"] = nodes[:min(limit, len(nodes))]
+	}
+	last["checkSuites"] = object{"totalCount": len(suites), "nodes": suites[:min(limit, len(suites))]}
+
+	contexts := []object{}
+	if state, ok := s.statuses[oid]; ok {
+		contexts = append(contexts, object{
+			"context":   "fake/ci",
+			"state":     state,
+			"targetUrl": nil,
+		})
+	}
+	last["status"] = object{"contexts": contexts}
+	return commits[len(commits)-1:]
+}
+
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func timestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// pullRequestNode renders a pull request with its first page of commits
+func (s *Server) pullRequestNode(pr *PullRequest) (object, error) {
+	var reviewDecision interface{}
+	if decision := s.reviewDecision(pr); decision != "" {
+		reviewDecision = decision
+	}
+	var mergeQueueEntry interface{}
+	if pr.AutoMerge {
+		mergeQueueEntry = object{"id": "MQE_" + pr.ID()}
+	}
+
+	commits, commitsPageInfo, err := graphQLPage(s, graphQLRequest{}, s.commitNodes(pr))
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, reviewsPageInfo, err := graphQLPage(s, graphQLRequest{}, s.reviewNodes(pr))
+	if err != nil {
+		return nil, err
+	}
+	dismissals, dismissalsPageInfo, err := graphQLPage(s, graphQLRequest{}, s.dismissalNodes(pr))
+	if err != nil {
+		return nil, err
+	}
+	reviewRequests, reviewRequestsPageInfo, err := graphQLPage(s, graphQLRequest{}, s.reviewRequestNodes(pr))
+	if err != nil {
+		return nil, err
+	}
+
+	return object{
+		"id":              pr.ID(),
+		"number":          pr.Number,
+		"title":           pr.Title,
+		"body":            pr.Body,
+		"baseRefName":     pr.BaseRefName,
+		"headRefName":     pr.HeadRefName,
+		"author":          object{"login": pr.Author},
+		"isDraft":         pr.Draft,
+		"mergeable":       s.mergeable(pr),
+		"reviewDecision":  reviewDecision,
+		"repository":      object{"id": s.repositoryID()},
+		"mergeQueueEntry": mergeQueueEntry,
+		"reviews":         object{"nodes": reviews, "pageInfo": reviewsPageInfo},
+		"timelineItems":   object{"nodes": dismissals, "pageInfo": dismissalsPageInfo},
+		"reviewRequests":  object{"nodes": reviewRequests, "pageInfo": reviewRequestsPageInfo},
+		"commits":         object{"nodes": commits, "pageInfo": commitsPageInfo},
+	}, nil
+}
+
+// queryPullRequestsByHead returns the open pull requests of any author with the head branch
+func (s *Server) queryPullRequestsByHead(req graphQLRequest) (object, error) {
+	var head string
+	if err := json.Unmarshal(req.Variables["head_ref"], &head); err != nil {
+		return nil, fmt.Errorf("PullRequestsByHead: invalid head_ref: %w", err)
+	}
+	nodes := []object{}
+	for _, pr := range s.pullRequests {
+		if pr.State == StateOpen && pr.HeadRefName == head {
+			node, err := s.pullRequestNode(pr)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return object{"repository": object{"pullRequests": object{
+		"nodes":    nodes,
+		"pageInfo": object{"hasNextPage": false, "endCursor": nil},
+	}}}, nil
+}
+
+func (s *Server) queryPullRequestBranches(req graphQLRequest) (object, error) {
+	var number int
+	if err := json.Unmarshal(req.Variables["number"], &number); err != nil {
+		return nil, fmt.Errorf("PullRequestBranches: invalid number: %w", err)
+	}
+	pr := s.findByNumber(number)
+	if pr == nil {
+		return object{"repository": object{"pullRequest": nil}}, nil
+	}
+	return object{"repository": object{"pullRequest": object{
+		"number":      pr.Number,
+		"title":       pr.Title,
+		"state":       pr.State,
+		"baseRefName": pr.BaseRefName,
+		"headRefName": pr.HeadRefName,
+	}}}, nil
+}
+
+func (s *Server) queryPullRequestCommits(req graphQLRequest) (object, error) {
+	var number int
+	if err := json.Unmarshal(req.Variables["number"], &number); err != nil {
+		return nil, fmt.Errorf("%s: invalid number: %w", req.OperationName, err)
+	}
+	pr := s.findByNumber(number)
+	if pr == nil {
+		return object{"repository": object{"pullRequest": nil}}, nil
+	}
+	commits, pageInfo, err := graphQLPage(s, req, s.commitNodes(pr))
+	if err != nil {
+		return nil, err
+	}
+	return object{
+		"repository": object{
+			"pullRequest": object{
+				"commits": object{"nodes": commits, "pageInfo": pageInfo},
+			},
+		},
+	}, nil
+}
+
+// queryPullRequestConnection returns the page of the connection of the pull request named
+//
+//	by the number variable, nodes returns all the nodes of the connection.
+func (s *Server) queryPullRequestConnection(req graphQLRequest, connection string,
+	nodes func(pr *PullRequest) []object) (object, error) {
+	var number int
+	if err := json.Unmarshal(req.Variables["number"], &number); err != nil {
+		return nil, fmt.Errorf("%s: invalid number: %w", req.OperationName, err)
+	}
+	pr := s.findByNumber(number)
+	if pr == nil {
+		return object{"repository": object{"pullRequest": nil}}, nil
+	}
+	page, pageInfo, err := graphQLPage(s, req, nodes(pr))
+	if err != nil {
+		return nil, err
+	}
+	return object{
+		"repository": object{
+			"pullRequest": object{
+				connection: object{"nodes": page, "pageInfo": pageInfo},
+			},
+		},
+	}, nil
+}
+
+// reviewNodes returns the reviews of the pull request as graphql nodes, oldest first
+func (s *Server) reviewNodes(pr *PullRequest) []object {
+	nodes := []object{}
+	for i, review := range pr.Reviews {
+		nodes = append(nodes, object{
+			"id":     reviewID(pr, i),
+			"author": object{"login": review.Author},
+			"state":  review.State,
+			"commit": object{"oid": review.CommitID},
+		})
+	}
+	return nodes
+}
+
+// dismissalNodes returns the review dismissal events of the pull request as graphql nodes
+func (s *Server) dismissalNodes(pr *PullRequest) []object {
+	nodes := []object{}
+	for i, review := range pr.Reviews {
+		if review.State == "DISMISSED" {
+			nodes = append(nodes, object{
+				"previousReviewState": review.DismissedState,
+				"review":              object{"id": reviewID(pr, i)},
+			})
+		}
+	}
+	return nodes
+}
+
+// reviewRequestNodes returns the pending review requests of the pull request as graphql nodes
+func (s *Server) reviewRequestNodes(pr *PullRequest) []object {
+	nodes := []object{}
+	for _, user := range s.Users {
+		if slices.Contains(pr.ReviewerIDs, user.ID) {
+			nodes = append(nodes, object{"requestedReviewer": object{"login": user.Login}})
+		}
+	}
+	for _, team := range s.Teams {
+		if slices.Contains(pr.TeamReviewerIDs, team.ID) {
+			nodes = append(nodes, object{"requestedReviewer": object{"slug": team.Slug}})
+		}
+	}
+	return nodes
+}
+
+// reviewID returns the node id of the review at index in the reviews of the pull request
+func reviewID(pr *PullRequest, index int) string {
+	return fmt.Sprintf("PRR_%d_%d", pr.Number, index)
+}
+
+// commitNodes returns the commits of the pull request as graphql nodes, oldest first
+func (s *Server) commitNodes(pr *PullRequest) []object {
+	nodes := []object{}
+	for _, c := range s.commits(pr) {
+		var rollup interface{}
+		if state := s.rollupState(c.oid); state != "" {
+			rollup = object{"state": state}
+		}
+		nodes = append(nodes, object{
+			"commit": object{
+				"oid":               c.oid,
+				"messageHeadline":   c.subject,
+				"messageBody":       c.body,
+				"statusCheckRollup": rollup,
+			},
+		})
+	}
+	return nodes
+}
+
+// rollupState combines the commit status and the check runs of the commit like GitHub's
+//
+//	status check rollup, it is empty when nothing was reported on the commit.
+func (s *Server) rollupState(oid string) string {
+	state := s.statuses[oid]
+	for _, run := range s.checkRuns[oid] {
+		switch {
+		case run.Status != "COMPLETED":
+			if state != "FAILURE" && state != "ERROR" {
+				state = "PENDING"
+			}
+		case run.Conclusion == "SUCCESS" || run.Conclusion == "NEUTRAL" || run.Conclusion == "SKIPPED":
+			if state == "" {
+				state = "SUCCESS"
+			}
+		default:
+			state = "FAILURE"
+		}
+	}
+	return state
+}
+
+// graphQLPage returns the page of items after the end_cursor variable of the request,
+//
+//	cursors are the offset of the next item.
+func graphQLPage[T any](s *Server, req graphQLRequest, items []T) ([]T, object, error) {
+	start := 0
+	if raw, ok := req.Variables["end_cursor"]; ok {
+		var cursor *string
+		if err := json.Unmarshal(raw, &cursor); err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid cursor: %w", req.OperationName, err)
+		}
+		if cursor != nil {
+			offset, err := strconv.Atoi(*cursor)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: invalid cursor %q", req.OperationName, *cursor)
+			}
+			start = min(offset, len(items))
+		}
+	}
+	end := min(start+min(100, s.MaxPageSize), len(items))
+	pageInfo := object{"hasNextPage": end < len(items), "endCursor": nil}
+	if end > start {
+		pageInfo["endCursor"] = strconv.Itoa(end)
+	}
+	return items[start:end], pageInfo, nil
+}
+
+func (s *Server) queryAssignableUsers() object {
+	nodes := []object{}
+	for _, u := range s.Users {
+		nodes = append(nodes, object{"id": u.ID, "login": u.Login, "name": u.Name})
+	}
+	return object{
+		"repository": object{
+			"assignableUsers": object{
+				"nodes":    nodes,
+				"pageInfo": object{"hasNextPage": false, "endCursor": nil},
+			},
+		},
+	}
+}
+
+func (s *Server) queryTeamID(req graphQLRequest) (object, error) {
+	var org, slug string
+	if err := json.Unmarshal(req.Variables["org"], &org); err != nil {
+		return nil, fmt.Errorf("TeamID: invalid org: %w", err)
+	}
+	if err := json.Unmarshal(req.Variables["slug"], &slug); err != nil {
+		return nil, fmt.Errorf("TeamID: invalid slug: %w", err)
+	}
+	if org != s.Owner {
+		return object{"organization": nil}, nil
+	}
+	var team interface{}
+	for _, t := range s.Teams {
+		if t.Slug == slug {
+			team = object{"id": t.ID}
+		}
+	}
+	return object{"organization": object{"team": team}}, nil
+}
+
+func (s *Server) queryLabelID(req graphQLRequest) (object, error) {
+	var name string
+	if err := json.Unmarshal(req.Variables["name"], &name); err != nil {
+		return nil, fmt.Errorf("LabelID: invalid name: %w", err)
+	}
+	var label interface{}
+	for _, l := range s.Labels {
+		if strings.EqualFold(l.Name, name) {
+			label = object{"id": l.ID}
+		}
+	}
+	return object{"repository": object{"label": label}}, nil
+}
+
+func (s *Server) queryMilestones(req graphQLRequest) (object, error) {
+	var title string
+	if err := json.Unmarshal(req.Variables["title"], &title); err != nil {
+		return nil, fmt.Errorf("Milestones: invalid title: %w", err)
+	}
+	nodes := []object{}
+	for _, m := range s.Milestones {
+		if strings.Contains(strings.ToLower(m.Title), strings.ToLower(title)) {
+			nodes = append(nodes, object{"id": m.ID, "title": m.Title})
+		}
+	}
+	return object{"repository": object{"milestones": object{"nodes": nodes}}}, nil
+}
+
+func (s *Server) mutateCreatePullRequest(req graphQLRequest) (object, error) {
+	var input genclient.CreatePullRequestInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	if input.RepositoryId != s.repositoryID() {
+		return nil, fmt.Errorf("could not resolve to a repository with id %q", input.RepositoryId)
+	}
+	var body string
+	if input.Body != nil {
+		body = *input.Body
+	}
+	draft := input.Draft != nil && *input.Draft
+	pr, err := s.createPullRequest(input.Title, body, input.BaseRefName, input.HeadRefName, draft)
+	if err != nil {
+		return nil, err
+	}
+	return object{
+		"createPullRequest": object{
+			"pullRequest": object{"id": pr.ID(), "number": pr.Number},
+		},
+	}, nil
+}
+
+func (s *Server) mutateUpdatePullRequest(req graphQLRequest) (object, error) {
+	var input genclient.UpdatePullRequestInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.PullRequestId)
+	if err != nil {
+		return nil, err
+	}
+	if input.State != nil {
+		switch *input.State {
+		case genclient.PullRequestUpdateState_OPEN:
+			err = s.reopenPullRequest(pr)
+		case genclient.PullRequestUpdateState_CLOSED:
+			err = s.closePullRequest(pr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if pr.State == StateOpen {
+		err = s.updatePullRequest(pr, input.Title, input.Body, input.BaseRefName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if input.MilestoneId != nil {
+		milestone, err := s.milestone(func(m Milestone) bool { return m.ID == *input.MilestoneId })
+		if err != nil {
+			return nil, err
+		}
+		pr.Milestone = milestone.Title
+	}
+	return object{
+		"updatePullRequest": object{
+			"pullRequest": object{"number": pr.Number},
+		},
+	}, nil
+}
+
+func (s *Server) mutateAddReviewers(req graphQLRequest) (object, error) {
+	var input genclient.RequestReviewsInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.PullRequestId)
+	if err != nil {
+		return nil, err
+	}
+	if input.Union == nil || !*input.Union {
+		pr.ReviewerIDs = nil
+		pr.TeamReviewerIDs = nil
+	}
+	pr.ReviewRequests++
+	if input.UserIds != nil {
+		for _, id := range *input.UserIds {
+			if !s.isUser(id) {
+				return nil, fmt.Errorf("could not resolve to a user with id %q", id)
+			}
+			if id == s.userID(pr.Author) {
+				return nil, fmt.Errorf("review cannot be requested from pull request author")
+			}
+			if !slices.Contains(pr.ReviewerIDs, id) {
+				pr.ReviewerIDs = append(pr.ReviewerIDs, id)
+			}
+		}
+	}
+	if input.TeamIds != nil {
+		for _, id := range *input.TeamIds {
+			if !s.isTeam(id) {
+				return nil, fmt.Errorf("could not resolve to a team with id %q", id)
+			}
+			if !slices.Contains(pr.TeamReviewerIDs, id) {
+				pr.TeamReviewerIDs = append(pr.TeamReviewerIDs, id)
+			}
+		}
+	}
+	return object{
+		"requestReviews": object{
+			"pullRequest": object{"id": pr.ID()},
+		},
+	}, nil
+}
+
+func (s *Server) mutateAddLabels(req graphQLRequest) (object, error) {
+	var input genclient.AddLabelsToLabelableInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.LabelableId)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, id := range input.LabelIds {
+		i := slices.IndexFunc(s.Labels, func(l Label) bool { return l.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("could not resolve to a label with id %q", id)
+		}
+		names = append(names, s.Labels[i].Name)
+	}
+	if err := s.addLabels(pr, names); err != nil {
+		return nil, err
+	}
+	return object{"addLabelsToLabelable": object{"clientMutationId": nil}}, nil
+}
+
+func (s *Server) mutateRemoveLabels(req graphQLRequest) (object, error) {
+	var input genclient.RemoveLabelsFromLabelableInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.LabelableId)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, id := range input.LabelIds {
+		i := slices.IndexFunc(s.Labels, func(l Label) bool { return l.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("could not resolve to a label with id %q", id)
+		}
+		names = append(names, s.Labels[i].Name)
+	}
+	s.removeLabels(pr, names)
+	return object{"removeLabelsFromLabelable": object{"clientMutationId": nil}}, nil
+}
+
+func (s *Server) mutateAddAssignees(req graphQLRequest) (object, error) {
+	var input genclient.AddAssigneesToAssignableInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.AssignableId)
+	if err != nil {
+		return nil, err
+	}
+	var logins []string
+	for _, id := range input.AssigneeIds {
+		i := slices.IndexFunc(s.Users, func(u User) bool { return u.ID == id })
+		if i < 0 {
+			return nil, fmt.Errorf("could not resolve to a user with id %q", id)
+		}
+		logins = append(logins, s.Users[i].Login)
+	}
+	if err := s.addAssignees(pr, logins); err != nil {
+		return nil, err
+	}
+	return object{"addAssigneesToAssignable": object{"clientMutationId": nil}}, nil
+}
+
+// mutateDraft converts the pull request to a draft or marks it ready for review
+func (s *Server) mutateDraft(req graphQLRequest, draft bool) (object, error) {
+	var input struct {
+		PullRequestId string `json:"pullRequestId"`
+	}
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.PullRequestId)
+	if err != nil {
+		return nil, err
+	}
+	if pr.Draft == draft {
+		return nil, fmt.Errorf("pull request %d draft state is already %v", pr.Number, draft)
+	}
+	pr.Draft = draft
+	name := "markPullRequestReadyForReview"
+	if draft {
+		name = "convertPullRequestToDraft"
+	}
+	return object{name: object{"clientMutationId": nil}}, nil
+}
+
+func (s *Server) mutateCommentPullRequest(req graphQLRequest) (object, error) {
+	var input genclient.AddCommentInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+	pr.Comments = append(pr.Comments, input.Body)
+	return object{
+		"addComment": object{"clientMutationId": nil},
+	}, nil
+}
+
+func (s *Server) mutateMergePullRequest(req graphQLRequest) (object, error) {
+	var input genclient.MergePullRequestInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.PullRequestId)
+	if err != nil {
+		return nil, err
+	}
+	method := string(genclient.PullRequestMergeMethod_MERGE)
+	if input.MergeMethod != nil {
+		method = string(*input.MergeMethod)
+	}
+	pr.AutoMerge = req.OperationName == "AutoMergePullRequest"
+	if err := s.mergePullRequest(pr, method); err != nil {
+		return nil, err
+	}
+	field := "mergePullRequest"
+	if pr.AutoMerge {
+		field = "enablePullRequestAutoMerge"
+	}
+	return object{
+		field: object{
+			"pullRequest": object{"number": pr.Number},
+		},
+	}, nil
+}
+
+func (s *Server) mutateClosePullRequest(req graphQLRequest) (object, error) {
+	var input genclient.ClosePullRequestInput
+	if err := decodeInput(req, &input); err != nil {
+		return nil, err
+	}
+	pr, err := s.lookupPullRequest(input.PullRequestId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.closePullRequest(pr); err != nil {
+		return nil, err
+	}
+	return object{
+		"closePullRequest": object{
+			"pullRequest": object{"number": pr.Number},
+		},
+	}, nil
+}
+
+func (s *Server) lookupPullRequest(id string) (*PullRequest, error) {
+	pr := s.findByID(id)
+	if pr == nil {
+		return nil, fmt.Errorf("could not resolve to a node with the global id of %q", id)
+	}
+	return pr, nil
+}
+
+func (s *Server) isUser(id string) bool {
+	for _, u := range s.Users {
+		if u.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) isTeam(id string) bool {
+	for _, t := range s.Teams {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// userID returns the id of the user with the given login
+func (s *Server) userID(login string) string {
+	for _, u := range s.Users {
+		if u.Login == login {
+			return u.ID
+		}
+	}
+	return ""
+}
+
+func decodeInput(req graphQLRequest, input interface{}) error {
+	raw, ok := req.Variables["input"]
+	if !ok {
+		return fmt.Errorf("%s: missing input variable", req.OperationName)
+	}
+	if err := json.Unmarshal(raw, input); err != nil {
+		return fmt.Errorf("%s: invalid input: %w", req.OperationName, err)
+	}
+	return nil
+}
+
+func writeGraphQLError(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusOK, object{
+		"data":   nil,
+		"errors": []object{{"message": err.Error()}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,413 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	gogithub "github.com/google/go-github/v69/github"
+)
+
+// registerREST adds the REST v3 endpoints tests use through go-github, like seeding pull requests of other users
+func (s *Server) registerREST(mux *http.ServeMux) {
+	repo := "/api/v3/repos/{owner}/{repo}"
+	mux.HandleFunc("GET "+repo+"/pulls", s.restHandler(s.listPullRequests))
+	mux.HandleFunc("POST "+repo+"/pulls", s.restHandler(s.createPullRequestREST))
+	mux.HandleFunc("GET "+repo+"/pulls/{number}", s.restHandler(s.getPullRequest))
+	mux.HandleFunc("PATCH "+repo+"/pulls/{number}", s.restHandler(s.editPullRequest))
+	mux.HandleFunc("PUT "+repo+"/pulls/{number}/merge", s.restHandler(s.mergePullRequestREST))
+	mux.HandleFunc("GET "+repo+"/pulls/{number}/reviews", s.restHandler(s.listReviews))
+	mux.HandleFunc("POST "+repo+"/pulls/{number}/requested_reviewers", s.restHandler(s.requestReviewersREST))
+	mux.HandleFunc("GET "+repo+"/commits/{ref}/status", s.restHandler(s.getCombinedStatus))
+	mux.HandleFunc("PATCH "+repo+"/issues/{number}", s.restHandler(s.editIssue))
+	mux.HandleFunc("POST "+repo+"/issues/{number}/labels", s.restHandler(s.addLabelsREST))
+	mux.HandleFunc("DELETE "+repo+"/issues/{number}/labels/{name}", s.restHandler(s.removeLabelREST))
+	mux.HandleFunc("POST "+repo+"/issues/{number}/assignees", s.restHandler(s.addAssigneesREST))
+	mux.HandleFunc("GET "+repo+"/milestones", s.restHandler(s.listMilestones))
+}
+
+type restError struct {
+	status int
+	err    error
+}
+
+func (e *restError) Error() string {
+	return e.err.Error()
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &restError{status: http.StatusNotFound, err: fmt.Errorf(format, args...)}
+}
+
+func unprocessable(err error) error {
+	return &restError{status: http.StatusUnprocessableEntity, err: err}
+}
+
+// restHandler checks the repository path, serializes access to the server state and encodes the result
+func (s *Server) restHandler(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		var res interface{}
+		var err error
+		if r.PathValue("owner") != s.Owner || r.PathValue("repo") != s.Name {
+			err = notFound("repository %s/%s not found", r.PathValue("owner"), r.PathValue("repo"))
+		} else {
+			res, err = fn(r)
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if rerr, ok := err.(*restError); ok {
+				status = rerr.status
+			}
+			writeJSON(w, status, object{"message": err.Error()})
+			return
+		}
+		if page, ok := res.(restPage); ok {
+			if page.next != 0 {
+				next := *r.URL
+				query := next.Query()
+				query.Set("page", strconv.Itoa(page.next))
+				next.RawQuery = query.Encode()
+				w.Header().Set("Link", fmt.Sprintf("<%s%s>; rel=\"next\"", s.URL, next.RequestURI()))
+			}
+			res = page.items
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// restPage is one page of a listing, restHandler links to the next page when there is one
+type restPage struct {
+	items interface{}
+	next  int
+}
+
+// paginate returns the page of items asked for by the page and per_page parameters,
+//
+//	along with the number of the next page or 0 when it's the last page.
+func paginate[T any](s *Server, r *http.Request, items []T) ([]T, int) {
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 30
+	}
+	perPage = min(perPage, s.MaxPageSize)
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	if end < len(items) {
+		return items[start:end], page + 1
+	}
+	return items[start:end], 0
+}
+
+func (s *Server) pullRequestFromPath(r *http.Request) (*PullRequest, error) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		return nil, notFound("invalid pull request number %q", r.PathValue("number"))
+	}
+	pr := s.findByNumber(number)
+	if pr == nil {
+		return nil, notFound("pull request %d not found", number)
+	}
+	return pr, nil
+}
+
+func (s *Server) restPullRequest(pr *PullRequest, details bool) *gogithub.PullRequest {
+	state := "open"
+	if pr.State != StateOpen {
+		state = "closed"
+	}
+	res := &gogithub.PullRequest{
+		ID:     gogithub.Ptr(pr.DatabaseID()),
+		NodeID: gogithub.Ptr(pr.ID()),
+		Number: gogithub.Ptr(pr.Number),
+		State:  gogithub.Ptr(state),
+		Title:  gogithub.Ptr(pr.Title),
+		Body:   gogithub.Ptr(pr.Body),
+		Draft:  gogithub.Ptr(pr.Draft),
+		User:   &gogithub.User{Login: gogithub.Ptr(pr.Author)},
+		Head: &gogithub.PullRequestBranch{
+			Ref: gogithub.Ptr(pr.HeadRefName),
+			SHA: gogithub.Ptr(s.BranchHead(pr.HeadRefName)),
+		},
+		Base: &gogithub.PullRequestBranch{
+			Ref: gogithub.Ptr(pr.BaseRefName),
+			SHA: gogithub.Ptr(s.BranchHead(pr.BaseRefName)),
+		},
+	}
+	if details {
+		res.Merged = gogithub.Ptr(pr.State == StateMerged)
+		res.Mergeable = gogithub.Ptr(s.mergeable(pr) == "MERGEABLE")
+	}
+	return res
+}
+
+func (s *Server) listPullRequests(r *http.Request) (interface{}, error) {
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var prs []*PullRequest
+	for _, pr := range s.pullRequests {
+		open := pr.State == StateOpen
+		if (state == "open" && !open) || (state == "closed" && open) {
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	page, next := paginate(s, r, prs)
+	res := []*gogithub.PullRequest{}
+	for _, pr := range page {
+		res = append(res, s.restPullRequest(pr, false))
+	}
+	return restPage{items: res, next: next}, nil
+}
+
+func (s *Server) createPullRequestREST(r *http.Request) (interface{}, error) {
+	var input gogithub.NewPullRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	pr, err := s.createPullRequest(input.GetTitle(), input.GetBody(), input.GetBase(), input.GetHead(), input.GetDraft())
+	if err != nil {
+		return nil, unprocessable(err)
+	}
+	return s.restPullRequest(pr, true), nil
+}
+
+func (s *Server) getPullRequest(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.restPullRequest(pr, true), nil
+}
+
+func (s *Server) editPullRequest(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Title *string `json:"title"`
+		Body  *string `json:"body"`
+		State *string `json:"state"`
+		Base  *string `json:"base"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	if input.State != nil && strings.EqualFold(*input.State, "closed") {
+		err = s.closePullRequest(pr)
+	} else {
+		if input.State != nil && strings.EqualFold(*input.State, "open") {
+			err = s.reopenPullRequest(pr)
+		}
+		if err == nil {
+			err = s.updatePullRequest(pr, input.Title, input.Body, input.Base)
+		}
+	}
+	if err != nil {
+		return nil, unprocessable(err)
+	}
+	return s.restPullRequest(pr, true), nil
+}
+
+func (s *Server) mergePullRequestREST(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		MergeMethod string `json:"merge_method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	if err := s.mergePullRequest(pr, input.MergeMethod); err != nil {
+		return nil, &restError{status: http.StatusMethodNotAllowed, err: err}
+	}
+	return &gogithub.PullRequestMergeResult{
+		SHA:     gogithub.Ptr(s.BranchHead(pr.BaseRefName)),
+		Merged:  gogithub.Ptr(true),
+		Message: gogithub.Ptr("Pull Request successfully merged"),
+	}, nil
+}
+
+func (s *Server) listReviews(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	res := []*gogithub.PullRequestReview{}
+	for i, review := range pr.Reviews {
+		res = append(res, &gogithub.PullRequestReview{
+			ID:       gogithub.Ptr(int64(i + 1)),
+			User:     &gogithub.User{Login: gogithub.Ptr(review.Author)},
+			State:    gogithub.Ptr(review.State),
+			CommitID: gogithub.Ptr(review.CommitID),
+		})
+	}
+	page, next := paginate(s, r, res)
+	return restPage{items: page, next: next}, nil
+}
+
+func (s *Server) getCombinedStatus(r *http.Request) (interface{}, error) {
+	sha := r.PathValue("ref")
+	if head := s.BranchHead(sha); head != "" {
+		sha = head
+	}
+	state, ok := s.statuses[sha]
+	if !ok {
+		return &gogithub.CombinedStatus{
+			SHA:        gogithub.Ptr(sha),
+			State:      gogithub.Ptr("pending"),
+			TotalCount: gogithub.Ptr(0),
+		}, nil
+	}
+	return &gogithub.CombinedStatus{
+		SHA:        gogithub.Ptr(sha),
+		State:      gogithub.Ptr(strings.ToLower(state)),
+		TotalCount: gogithub.Ptr(1),
+		Statuses: []*gogithub.RepoStatus{
+			{State: gogithub.Ptr(strings.ToLower(state)), Context: gogithub.Ptr("fake/ci")},
+		},
+	}, nil
+}
+
+func (s *Server) requestReviewersREST(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input gogithub.ReviewersRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	for _, login := range input.Reviewers {
+		id := s.userID(login)
+		if id == "" {
+			return nil, unprocessable(fmt.Errorf("reviewer %q is not a collaborator", login))
+		}
+		if login == pr.Author {
+			return nil, unprocessable(fmt.Errorf("review cannot be requested from pull request author"))
+		}
+		if !slices.Contains(pr.ReviewerIDs, id) {
+			pr.ReviewerIDs = append(pr.ReviewerIDs, id)
+		}
+	}
+	for _, slug := range input.TeamReviewers {
+		i := slices.IndexFunc(s.Teams, func(t Team) bool { return t.Slug == slug })
+		if i < 0 {
+			return nil, unprocessable(fmt.Errorf("team %q not found", slug))
+		}
+		if !slices.Contains(pr.TeamReviewerIDs, s.Teams[i].ID) {
+			pr.TeamReviewerIDs = append(pr.TeamReviewerIDs, s.Teams[i].ID)
+		}
+	}
+	return s.restPullRequest(pr, true), nil
+}
+
+// restIssue returns the issue view of a pull request
+func (s *Server) restIssue(pr *PullRequest) *gogithub.Issue {
+	issue := &gogithub.Issue{
+		Number: gogithub.Ptr(pr.Number),
+		Title:  gogithub.Ptr(pr.Title),
+	}
+	for _, name := range pr.Labels {
+		issue.Labels = append(issue.Labels, &gogithub.Label{Name: gogithub.Ptr(name)})
+	}
+	for _, login := range pr.Assignees {
+		issue.Assignees = append(issue.Assignees, &gogithub.User{Login: gogithub.Ptr(login)})
+	}
+	return issue
+}
+
+func (s *Server) editIssue(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Milestone *int `json:"milestone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	if input.Milestone != nil {
+		milestone, err := s.milestone(func(m Milestone) bool { return m.Number == *input.Milestone })
+		if err != nil {
+			return nil, unprocessable(err)
+		}
+		pr.Milestone = milestone.Title
+	}
+	return s.restIssue(pr), nil
+}
+
+func (s *Server) addLabelsREST(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+		return nil, unprocessable(err)
+	}
+	if err := s.addLabels(pr, labels); err != nil {
+		return nil, unprocessable(err)
+	}
+	return s.restIssue(pr).Labels, nil
+}
+
+func (s *Server) removeLabelREST(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	name := r.PathValue("name")
+	if !slices.Contains(pr.Labels, name) {
+		return nil, notFound("label %q is not on pull request %d", name, pr.Number)
+	}
+	s.removeLabels(pr, []string{name})
+	return s.restIssue(pr).Labels, nil
+}
+
+func (s *Server) addAssigneesREST(r *http.Request) (interface{}, error) {
+	pr, err := s.pullRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Assignees []string `json:"assignees"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, unprocessable(err)
+	}
+	if err := s.addAssignees(pr, input.Assignees); err != nil {
+		return nil, unprocessable(err)
+	}
+	return s.restIssue(pr), nil
+}
+
+func (s *Server) listMilestones(r *http.Request) (interface{}, error) {
+	page, next := paginate(s, r, s.Milestones)
+	res := []*gogithub.Milestone{}
+	for _, m := range page {
+		res = append(res, &gogithub.Milestone{
+			NodeID: gogithub.Ptr(m.ID),
+			Number: gogithub.Ptr(m.Number),
+			Title:  gogithub.Ptr(m.Title),
+			State:  gogithub.Ptr("open"),
+		})
+	}
+	return restPage{items: res, next: next}, nil
+}
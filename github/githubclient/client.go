@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/git"
@@ -22,33 +26,32 @@ import (
 //go:generate go run github.com/inigolabs/fezzik --config fezzik.yaml
 
 const tokenHelpText = `
-No GitHub OAuth token found! You can either create one
-at https://%s/settings/tokens and set the GITHUB_TOKEN environment variable,
-or use the official "gh" CLI (https://cli.github.com) config to log in:
+No GitHub OAuth token found! Log in with the device flow:
 
-	$ gh auth login --insecure-storage
+	$ spr auth login
 
-Alternatively, configure a token manually in ~/.config/hub:
-
-	github.com:
-	- user: <your username>
-	  oauth_token: <your token>
-	  protocol: https
-
-This configuration file is shared with GitHub's "hub" CLI (https://hub.github.com/),
-so if you already use that, spr will automatically pick up your token.
+or create a token with the "repo" and "read:org" scopes at
+https://%s/settings/tokens and set the GH_TOKEN environment variable.
+Tokens of the official "gh" CLI (https://cli.github.com), GitHub's "hub"
+CLI (https://hub.github.com/) and git credential helpers are also picked
+up, see "spr auth status" for the token in use.
 `
 
-func NewGitHubClient(ctx context.Context, config *config.Config) *client {
+// NewGitHubClient returns a client of the GitHub GraphQL api sending authorized requests with base,
+//
+//	http.DefaultTransport when nil. Failed and rate limited requests are retried.
+func NewGitHubClient(ctx context.Context, config *config.Config, base http.RoundTripper) (*client, error) {
 	token := github.FindToken(config.Repo.GitHubHost)
 	if token == "" {
-		fmt.Printf(tokenHelpText, config.Repo.GitHubHost)
-		os.Exit(3)
+		return nil, fmt.Errorf("%w: no token found\n%s",
+			github.ErrUnauthorized, fmt.Sprintf(tokenHelpText, config.Repo.GitHubHost))
 	}
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	tc := &http.Client{Transport: &unauthorizedTransport{
+		base: github.NewRetryTransport(config, &oauth2.Transport{Source: ts, Base: base}),
+	}}
 
 	var api genclient.Client
 	if strings.HasSuffix(config.Repo.GitHubHost, "github.com") {
@@ -56,7 +59,9 @@ func NewGitHubClient(ctx context.Context, config *config.Config) *client {
 	} else {
 		var scheme, host string
 		gitHubRemoteUrl, err := url.Parse(config.Repo.GitHubHost)
-		check(err)
+		if err != nil {
+			return nil, fmt.Errorf("parsing github host %q: %w", config.Repo.GitHubHost, err)
+		}
 		if gitHubRemoteUrl.Host == "" {
 			host = config.Repo.GitHubHost
 			scheme = "https"
@@ -69,44 +74,77 @@ func NewGitHubClient(ctx context.Context, config *config.Config) *client {
 	return &client{
 		config: config,
 		api:    api,
-	}
+	}, nil
 }
 
 type client struct {
 	config *config.Config
 	api    genclient.Client
+
+	mu  sync.Mutex
+	ids map[string]string
 }
 
-func (c *client) GetInfo(ctx context.Context, gitcmd git.GitInterface) *github.GitHubInfo {
+// unauthorizedTransport fails requests rejected with 401, instead of letting
+//
+//	the graphql client decode the error message as an empty response.
+type unauthorizedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *unauthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		errmsg := "401 Unauthorized\n"
+		errmsg += " run spr auth status to see which token is used\n"
+		errmsg += " and spr auth login to get a new one"
+		return nil, fmt.Errorf("%w: %s", github.ErrUnauthorized, errmsg)
+	}
+	return resp, nil
+}
+
+func (c *client) GetInfo(ctx context.Context, gitcmd git.GitInterface) (*github.ForgeInfo, error) {
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github fetch pull requests\n")
 	}
 
-	var pullRequestConnection fezzik_types.PullRequestConnection
-	var loginName string
-	var repoID string
-	if c.config.Repo.MergeQueue {
-		resp, err := c.api.PullRequestsWithMergeQueue(ctx,
-			c.config.Repo.GitHubRepoOwner,
-			c.config.Repo.GitHubRepoName)
-		check(err)
-		pullRequestConnection = resp.Viewer.PullRequests
-		loginName = resp.Viewer.Login
-		repoID = resp.Repository.Id
-	} else {
-		resp, err := c.api.PullRequests(ctx,
-			c.config.Repo.GitHubRepoOwner,
-			c.config.Repo.GitHubRepoName)
-		check(err)
-		pullRequestConnection = resp.Viewer.PullRequests
-		loginName = resp.Viewer.Login
-		repoID = resp.Repository.Id
+	pullRequestConnection, loginName, repoID, protection, truncated, err := c.fetchPullRequests(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	targetBranch := c.config.Repo.GitHubBranch
-	localCommitStack := git.GetLocalCommitStack(c.config, gitcmd)
+	localCommitStack, err := git.GetLocalCommitStack(c.config, gitcmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// only a stack checked out with spr checkout has pull requests opened by other users
+	if git.StackOwner(gitcmd) != "" {
+		othersTruncated, err := c.fetchOtherUsersPullRequests(ctx, localCommitStack, &pullRequestConnection)
+		if err != nil {
+			return nil, err
+		}
+		truncated = truncated || othersTruncated
+	}
 
-	pullRequests := matchPullRequestStack(c.config.Repo, targetBranch, localCommitStack, pullRequestConnection)
+	pullRequests, err := matchPullRequestStack(c.config, localCommitStack, pullRequestConnection, protection)
+	if err != nil {
+		return nil, err
+	}
+	if c.config.Repo.RequiredChecksOnly {
+		// the listing only has the status of all checks combined
+		for _, pr := range pullRequests {
+			checks, err := c.GetChecks(ctx, pr)
+			if err != nil {
+				return nil, err
+			}
+			pr.MergeStatus.ChecksPass = github.ChecksStatus(checks, true)
+		}
+	}
 	for _, pr := range pullRequests {
 		if pr.Ready(c.config) {
 			pr.MergeStatus.Stacked = true
@@ -115,30 +153,413 @@ func (c *client) GetInfo(ctx context.Context, gitcmd git.GitInterface) *github.G
 		}
 	}
 
-	info := &github.GitHubInfo{
+	localBranch, err := git.GetLocalBranchName(gitcmd)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &github.ForgeInfo{
 		UserName:     loginName,
 		RepositoryID: repoID,
-		LocalBranch:  git.GetLocalBranchName(gitcmd),
+		LocalBranch:  localBranch,
 		PullRequests: pullRequests,
+		Truncated:    truncated,
 	}
 
 	log.Debug().Interface("Info", info).Msg("GetInfo")
-	return info
+	return info, nil
+}
+
+// fetchPullRequests pages through the open pull requests of the viewer and their commits,
+//
+//	returns them along with the viewer login, the repository id and the branch
+//	protection of the target branch. truncated is set when there were more than
+//	github.MaxPages pages.
+func (c *client) fetchPullRequests(ctx context.Context) (
+	connection fezzik_types.PullRequestConnection, loginName string, repoID string,
+	protection branchProtection, truncated bool, err error) {
+	nodes := fezzik_types.PullRequestsViewerPullRequestsNodes{}
+	var endCursor *string
+	for page := 0; ; page++ {
+		if page == github.MaxPages {
+			truncated = true
+			break
+		}
+		var pageConnection fezzik_types.PullRequestConnection
+		if c.config.Repo.MergeQueue {
+			resp, err := c.api.PullRequestsWithMergeQueue(ctx,
+				c.config.Repo.GitHubRepoOwner,
+				c.config.Repo.GitHubRepoName, baseRef(c.config), endCursor)
+			if err != nil {
+				return connection, "", "", protection, false, fmt.Errorf("fetching pull requests: %w", github.ClassifyError(err))
+			}
+			pageConnection = resp.Viewer.PullRequests
+			loginName = resp.Viewer.Login
+			repoID = resp.Repository.Id
+			if ref := resp.Repository.Ref; ref != nil && ref.BranchProtectionRule != nil {
+				protection.requiredApprovals = derefInt(ref.BranchProtectionRule.RequiredApprovingReviewCount)
+			}
+		} else {
+			resp, err := c.api.PullRequests(ctx,
+				c.config.Repo.GitHubRepoOwner,
+				c.config.Repo.GitHubRepoName, baseRef(c.config), endCursor)
+			if err != nil {
+				return connection, "", "", protection, false, fmt.Errorf("fetching pull requests: %w", github.ClassifyError(err))
+			}
+			pageConnection = resp.Viewer.PullRequests
+			loginName = resp.Viewer.Login
+			repoID = resp.Repository.Id
+			if ref := resp.Repository.Ref; ref != nil && ref.BranchProtectionRule != nil {
+				protection.requiredApprovals = derefInt(ref.BranchProtectionRule.RequiredApprovingReviewCount)
+			}
+		}
+		if pageConnection.Nodes != nil {
+			nodes = append(nodes, *pageConnection.Nodes...)
+		}
+		if !pageConnection.PageInfo.HasNextPage {
+			break
+		}
+		endCursor = pageConnection.PageInfo.EndCursor
+	}
+
+	for _, node := range nodes {
+		commitsTruncated, err := c.fetchRemainingCommits(ctx, node.Number, &node.Commits)
+		if err != nil {
+			return connection, "", "", protection, false, err
+		}
+		reviewsTruncated, err := c.fetchRemainingReviews(ctx, node.Number, node.Reviews, node.TimelineItems, node.ReviewRequests)
+		if err != nil {
+			return connection, "", "", protection, false, err
+		}
+		truncated = truncated || commitsTruncated || reviewsTruncated
+	}
+
+	connection.Nodes = &nodes
+	return connection, loginName, repoID, protection, truncated, nil
+}
+
+// GetOpenPullRequests returns the open pull requests of the viewer in the repository which are on
+//
+//	branches named by spr, with their merge status and head commit, ordered by number.
+//	The open pull requests of the repository are paged through 100 at a time, unlike
+//	search results the listing includes pull requests opened moments ago.
+func (c *client) GetOpenPullRequests(ctx context.Context) ([]*github.PullRequest, bool, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github fetch pull requests\n")
+	}
+	var nodes fezzik_types.PullRequestsViewerPullRequestsNodes
+	var protection branchProtection
+	var endCursor *string
+	truncated := false
+	for page := 0; ; page++ {
+		if page == github.MaxPages {
+			truncated = true
+			break
+		}
+		resp, err := c.api.ViewerPullRequests(ctx,
+			c.config.Repo.GitHubRepoOwner,
+			c.config.Repo.GitHubRepoName, baseRef(c.config), endCursor)
+		if err != nil {
+			return nil, false, fmt.Errorf("fetching pull requests: %w", github.ClassifyError(err))
+		}
+		if resp.Repository == nil {
+			return nil, false, fmt.Errorf("fetching pull requests: repository %s/%s not found",
+				c.config.Repo.GitHubRepoOwner, c.config.Repo.GitHubRepoName)
+		}
+		if ref := resp.Repository.Ref; ref != nil && ref.BranchProtectionRule != nil {
+			protection = branchProtection{
+				requiredChecks:    requiredContexts(ref.BranchProtectionRule.RequiredStatusCheckContexts),
+				requiredApprovals: derefInt(ref.BranchProtectionRule.RequiredApprovingReviewCount),
+			}
+		}
+		connection := resp.Repository.PullRequests
+		if connection.Nodes != nil {
+			for _, node := range *connection.Nodes {
+				// only the viewer's pull requests are part of the PR sets
+				if node.Author != nil && node.Author.Login == resp.Viewer.Login {
+					nodes = append(nodes, node)
+				}
+			}
+		}
+		if !connection.PageInfo.HasNextPage {
+			break
+		}
+		endCursor = connection.PageInfo.EndCursor
+	}
+
+	for _, node := range nodes {
+		reviewsTruncated, err := c.fetchRemainingReviews(ctx, node.Number, node.Reviews, node.TimelineItems, node.ReviewRequests)
+		if err != nil {
+			return nil, false, err
+		}
+		truncated = truncated || reviewsTruncated
+	}
+
+	var pullRequests []*github.PullRequest
+	for _, pr := range pullRequestsByCommitID(c.config, nodes, protection) {
+		pullRequests = append(pullRequests, pr)
+	}
+	slices.SortFunc(pullRequests, func(a, b *github.PullRequest) int {
+		return a.Number - b.Number
+	})
+	return pullRequests, truncated, nil
+}
+
+// fetchOtherUsersPullRequests adds the pull requests of local commits which were opened by
+//
+//	other users, like a stack checked out with spr checkout, to connection. Pull requests
+//	are looked up by branch from the bottom of the stack up to the first commit without one.
+func (c *client) fetchOtherUsersPullRequests(ctx context.Context,
+	localCommitStack []git.Commit, connection *fezzik_types.PullRequestConnection) (bool, error) {
+	heads := map[string]bool{}
+	for _, node := range *connection.Nodes {
+		heads[node.HeadRefName] = true
+	}
+	truncated := false
+	for _, commit := range localCommitStack {
+		branch := git.BranchNameFromCommit(c.config, commit)
+		if heads[branch] {
+			continue
+		}
+		nodes, err := c.fetchPullRequestsByHead(ctx, branch)
+		if err != nil {
+			return false, err
+		}
+		if len(nodes) == 0 {
+			break
+		}
+		for _, node := range nodes {
+			commitsTruncated, err := c.fetchRemainingCommits(ctx, node.Number, &node.Commits)
+			if err != nil {
+				return false, err
+			}
+			reviewsTruncated, err := c.fetchRemainingReviews(ctx, node.Number, node.Reviews, node.TimelineItems, node.ReviewRequests)
+			if err != nil {
+				return false, err
+			}
+			truncated = truncated || commitsTruncated || reviewsTruncated
+		}
+		*connection.Nodes = append(*connection.Nodes, nodes...)
+	}
+	return truncated, nil
+}
+
+// fetchPullRequestsByHead returns the open pull request of the branch, the result is empty when there is none
+func (c *client) fetchPullRequestsByHead(ctx context.Context, branch string) (fezzik_types.PullRequestsViewerPullRequestsNodes, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github fetch pull request of %s\n", branch)
+	}
+	resp, err := c.api.PullRequestsByHead(ctx,
+		c.config.Repo.GitHubRepoOwner,
+		c.config.Repo.GitHubRepoName, branch)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pull request of %s: %w", branch, github.ClassifyError(err))
+	}
+	if resp.Repository == nil || resp.Repository.PullRequests.Nodes == nil {
+		return nil, nil
+	}
+	return *resp.Repository.PullRequests.Nodes, nil
+}
+
+// GetPullRequestStack returns the open pull request with the given number and the pull requests below it
+func (c *client) GetPullRequestStack(ctx context.Context, number int) ([]*github.PullRequest, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github fetch pull request #%d\n", number)
+	}
+	resp, err := c.api.PullRequestBranches(ctx,
+		c.config.Repo.GitHubRepoOwner,
+		c.config.Repo.GitHubRepoName, number)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pull request #%d: %w", number, github.ClassifyError(err))
+	}
+	if resp.Repository == nil || resp.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("fetching pull request #%d: %w", number, github.ErrPullRequestNotFound)
+	}
+	pr := resp.Repository.PullRequest
+	if pr.State != genclient.PullRequestState_OPEN {
+		return nil, fmt.Errorf("pull request #%d is %s", number, strings.ToLower(string(pr.State)))
+	}
+	top := &github.PullRequest{
+		Number:     pr.Number,
+		Title:      pr.Title,
+		FromBranch: pr.HeadRefName,
+		ToBranch:   pr.BaseRefName,
+	}
+	return github.WalkStack(c.config, top, func(branch string) (*github.PullRequest, error) {
+		nodes, err := c.fetchPullRequestsByHead(ctx, branch)
+		if err != nil || len(nodes) == 0 {
+			return nil, err
+		}
+		return &github.PullRequest{
+			ID:         nodes[0].Id,
+			Number:     nodes[0].Number,
+			Title:      nodes[0].Title,
+			FromBranch: nodes[0].HeadRefName,
+			ToBranch:   nodes[0].BaseRefName,
+		}, nil
+	})
+}
+
+// fetchRemainingCommits appends the commits of the pull request which come after the first page to commits
+func (c *client) fetchRemainingCommits(ctx context.Context, number int, commits *fezzik_types.PullRequestsViewerPullRequestsNodesCommits) (bool, error) {
+	if commits.Nodes == nil {
+		commits.Nodes = &fezzik_types.PullRequestsViewerPullRequestsNodesCommitsNodes{}
+	}
+	for page := 1; commits.PageInfo.HasNextPage; page++ {
+		if page == github.MaxPages {
+			return true, nil
+		}
+		resp, err := c.api.PullRequestCommits(ctx,
+			c.config.Repo.GitHubRepoOwner,
+			c.config.Repo.GitHubRepoName,
+			number, commits.PageInfo.EndCursor)
+		if err != nil {
+			return false, fmt.Errorf("fetching commits of pull request #%d: %w", number, github.ClassifyError(err))
+		}
+		if resp.Repository == nil || resp.Repository.PullRequest == nil {
+			return false, fmt.Errorf("fetching commits of pull request #%d: %w", number, github.ErrPullRequestNotFound)
+		}
+		next := resp.Repository.PullRequest.Commits
+		if next.Nodes != nil {
+			*commits.Nodes = append(*commits.Nodes, *next.Nodes...)
+		}
+		commits.PageInfo = next.PageInfo
+	}
+	return false, nil
+}
+
+// fetchRemainingReviews appends the reviews, review dismissals and review requests of the pull
+//
+//	request which come after the first page, truncated is set when one of them had more
+//	than github.MaxPages pages.
+func (c *client) fetchRemainingReviews(ctx context.Context, number int,
+	reviews *fezzik_types.PullRequestsViewerPullRequestsNodesReviews,
+	dismissals *fezzik_types.PullRequestsViewerPullRequestsNodesTimelineItems,
+	requests *fezzik_types.PullRequestsViewerPullRequestsNodesReviewRequests) (bool, error) {
+	notFound := func(what string) error {
+		return fmt.Errorf("fetching %s of pull request #%d: %w", what, number, github.ErrPullRequestNotFound)
+	}
+	truncated := false
+	if reviews != nil {
+		pagesTruncated, err := fetchRemainingPages(&reviews.PageInfo, func(cursor *string) (fezzik_types.PageInfo, error) {
+			resp, err := c.api.PullRequestReviews(ctx,
+				c.config.Repo.GitHubRepoOwner,
+				c.config.Repo.GitHubRepoName,
+				number, cursor)
+			if err != nil {
+				return fezzik_types.PageInfo{}, fmt.Errorf("fetching reviews of pull request #%d: %w", number, github.ClassifyError(err))
+			}
+			if resp.Repository == nil || resp.Repository.PullRequest == nil || resp.Repository.PullRequest.Reviews == nil {
+				return fezzik_types.PageInfo{}, notFound("reviews")
+			}
+			next := resp.Repository.PullRequest.Reviews
+			appendNodes(&reviews.Nodes, next.Nodes)
+			return next.PageInfo, nil
+		})
+		if err != nil {
+			return false, err
+		}
+		truncated = truncated || pagesTruncated
+	}
+	if dismissals != nil {
+		pagesTruncated, err := fetchRemainingPages(&dismissals.PageInfo, func(cursor *string) (fezzik_types.PageInfo, error) {
+			resp, err := c.api.PullRequestDismissals(ctx,
+				c.config.Repo.GitHubRepoOwner,
+				c.config.Repo.GitHubRepoName,
+				number, cursor)
+			if err != nil {
+				return fezzik_types.PageInfo{}, fmt.Errorf("fetching review dismissals of pull request #%d: %w", number, github.ClassifyError(err))
+			}
+			if resp.Repository == nil || resp.Repository.PullRequest == nil {
+				return fezzik_types.PageInfo{}, notFound("review dismissals")
+			}
+			next := resp.Repository.PullRequest.TimelineItems
+			appendNodes(&dismissals.Nodes, next.Nodes)
+			return next.PageInfo, nil
+		})
+		if err != nil {
+			return false, err
+		}
+		truncated = truncated || pagesTruncated
+	}
+	if requests != nil {
+		pagesTruncated, err := fetchRemainingPages(&requests.PageInfo, func(cursor *string) (fezzik_types.PageInfo, error) {
+			resp, err := c.api.PullRequestReviewRequests(ctx,
+				c.config.Repo.GitHubRepoOwner,
+				c.config.Repo.GitHubRepoName,
+				number, cursor)
+			if err != nil {
+				return fezzik_types.PageInfo{}, fmt.Errorf("fetching review requests of pull request #%d: %w", number, github.ClassifyError(err))
+			}
+			if resp.Repository == nil || resp.Repository.PullRequest == nil || resp.Repository.PullRequest.ReviewRequests == nil {
+				return fezzik_types.PageInfo{}, notFound("review requests")
+			}
+			next := resp.Repository.PullRequest.ReviewRequests
+			appendNodes(&requests.Nodes, next.Nodes)
+			return next.PageInfo, nil
+		})
+		if err != nil {
+			return false, err
+		}
+		truncated = truncated || pagesTruncated
+	}
+	return truncated, nil
+}
+
+// fetchRemainingPages calls next with the end cursor of pageInfo until there are no more pages,
+//
+//	next appends the nodes of the page and returns its page info. truncated is set when
+//	there were more than github.MaxPages pages.
+func fetchRemainingPages(pageInfo *fezzik_types.PageInfo, next func(cursor *string) (fezzik_types.PageInfo, error)) (bool, error) {
+	for page := 1; pageInfo.HasNextPage; page++ {
+		if page == github.MaxPages {
+			return true, nil
+		}
+		nextPageInfo, err := next(pageInfo.EndCursor)
+		if err != nil {
+			return false, err
+		}
+		*pageInfo = nextPageInfo
+	}
+	return false, nil
+}
+
+// appendNodes appends the nodes of the next page to nodes
+func appendNodes[T any](nodes **[]T, next *[]T) {
+	if next == nil {
+		return
+	}
+	if *nodes == nil {
+		*nodes = &[]T{}
+	}
+	**nodes = append(**nodes, *next...)
 }
 
 func matchPullRequestStack(
-	repoConfig *config.RepoConfig,
-	targetBranch string,
+	cfg *config.Config,
 	localCommitStack []git.Commit,
-	allPullRequests fezzik_types.PullRequestConnection) []*github.PullRequest {
+	allPullRequests fezzik_types.PullRequestConnection,
+	protection branchProtection) ([]*github.PullRequest, error) {
 
 	if len(localCommitStack) == 0 || allPullRequests.Nodes == nil {
-		return []*github.PullRequest{}
+		return []*github.PullRequest{}, nil
 	}
 
+	return github.MatchStack(cfg, localCommitStack, pullRequestsByCommitID(cfg, *allPullRequests.Nodes, protection))
+}
+
+// pullRequestsByCommitID returns the pull requests of the nodes on branches named by spr,
+//
+//	keyed by the commit-id of the branch, with the merge status of their head commit.
+//	The checks status is combined from the check runs and commit statuses when the
+//	query selected them, otherwise the status check rollup of the head commit is used.
+//	The review status is counted from the reviews against the approvals required
+//	by protection.
+func pullRequestsByCommitID(cfg *config.Config, nodes fezzik_types.PullRequestsViewerPullRequestsNodes,
+	protection branchProtection) map[string]*github.PullRequest {
 	// pullRequestMap is a map from commit-id to pull request
 	pullRequestMap := make(map[string]*github.PullRequest)
-	for _, node := range *allPullRequests.Nodes {
+	for _, node := range nodes {
 		var commits []git.Commit
 		for _, v := range *node.Commits.Nodes {
 			for _, line := range strings.Split(v.Commit.MessageBody, "\n") {
@@ -162,84 +583,286 @@ func matchPullRequestStack(
 			ToBranch:   node.BaseRefName,
 			Commits:    commits,
 			InQueue:    node.MergeQueueEntry != nil,
+			Draft:      node.IsDraft,
 		}
 
-		matches := git.BranchNameRegex.FindStringSubmatch(node.HeadRefName)
-		if matches != nil {
+		commitID := git.CommitIDFromBranch(cfg, node.HeadRefName)
+		if commitID != "" {
 			commit := (*node.Commits.Nodes)[len(*node.Commits.Nodes)-1].Commit
 			pullRequest.Commit = git.Commit{
-				CommitID:   matches[2],
+				CommitID:   commitID,
 				CommitHash: commit.Oid,
 				Subject:    commit.MessageHeadline,
 				Body:       commit.MessageBody,
 			}
+			pullRequest.Commit.ParseTrailers()
 
 			checkStatus := github.CheckStatusPass
-			if commit.StatusCheckRollup != nil {
-				switch commit.StatusCheckRollup.State {
-				case "SUCCESS":
-					checkStatus = github.CheckStatusPass
-				case "PENDING":
-					checkStatus = github.CheckStatusPending
-				default:
-					checkStatus = github.CheckStatusFail
+			if commit.CheckSuites != nil || commit.Status != nil {
+				checks := commitChecks(&commit)
+				github.MarkRequiredChecks(checks, protection.requiredChecks)
+				unlisted := unlistedChecks(&commit)
+				if len(unlisted) > 0 && !cfg.Repo.RequiredChecksOnly && commit.StatusCheckRollup != nil {
+					// the rollup accounts for all the checks, listed or not
+					checkStatus = rollupStatus(commit.StatusCheckRollup.State)
+				} else {
+					checks = append(checks, unlisted...)
+					if len(unlisted) == 0 {
+						checks = append(checks, github.MissingRequiredChecks(checks, protection.requiredChecks)...)
+					}
+					checkStatus = github.ChecksStatus(checks, cfg.Repo.RequiredChecksOnly)
 				}
+			} else if commit.StatusCheckRollup != nil {
+				checkStatus = rollupStatus(commit.StatusCheckRollup.State)
+			}
+
+			approved := node.ReviewDecision != nil && *node.ReviewDecision == "APPROVED"
+			var reviews github.ReviewStatus
+			if node.Reviews != nil {
+				reviews = reviewStatus(cfg, node.Reviews, node.TimelineItems, node.ReviewRequests, commit.Oid, protection.requiredApprovals)
+				// the review decision also accounts for code owners, which aren't selected
+				approved = reviews.Approved() && (node.ReviewDecision == nil || approved)
 			}
 
 			pullRequest.MergeStatus = github.PullRequestMergeStatus{
 				ChecksPass:     checkStatus,
-				ReviewApproved: node.ReviewDecision != nil && *node.ReviewDecision == "APPROVED",
+				ReviewApproved: approved,
 				NoConflicts:    node.Mergeable == "MERGEABLE",
+				Reviews:        reviews,
 			}
 
 			pullRequestMap[pullRequest.Commit.CommitID] = pullRequest
 		}
 	}
+	return pullRequestMap
+}
 
-	var pullRequests []*github.PullRequest
+// GetChecks returns the check runs and commit statuses of the head commit of the pull request
+func (c *client) GetChecks(ctx context.Context, pr *github.PullRequest) ([]github.Check, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github fetch checks #%d\n", pr.Number)
+	}
+	resp, err := c.api.PullRequestChecks(ctx,
+		c.config.Repo.GitHubRepoOwner,
+		c.config.Repo.GitHubRepoName,
+		baseRef(c.config), pr.Number)
+	if err != nil {
+		return nil, fmt.Errorf("fetching checks of #%d: %w", pr.Number, github.ClassifyError(err))
+	}
+	if resp.Repository == nil || resp.Repository.PullRequest == nil {
+		return nil, fmt.Errorf("fetching checks of #%d: pull request not found", pr.Number)
+	}
+	commits := resp.Repository.PullRequest.Commits.Nodes
+	if commits == nil || len(*commits) == 0 {
+		return nil, nil
+	}
+	commit := &(*commits)[len(*commits)-1].Commit
+	checks := commitChecks(commit)
+	unlisted := unlistedChecks(commit)
+	if ref := resp.Repository.Ref; ref != nil && ref.BranchProtectionRule != nil {
+		required := requiredContexts(ref.BranchProtectionRule.RequiredStatusCheckContexts)
+		github.MarkRequiredChecks(checks, required)
+		// a required check could be among the unlisted ones
+		if len(unlisted) == 0 {
+			checks = append(checks, github.MissingRequiredChecks(checks, required)...)
+		}
+	}
+	return append(checks, unlisted...), nil
+}
 
-	// find top pr
-	var currpr *github.PullRequest
-	var found bool
-	for i := len(localCommitStack) - 1; i >= 0; i-- {
-		currpr, found = pullRequestMap[localCommitStack[i].CommitID]
-		if found {
-			break
+// reviewStatus returns the review status of a pull request from its reviews, review dismissals
+//
+//	and pending review requests, requested teams are named org/team like in the
+//	reviewers of the config.
+func reviewStatus(cfg *config.Config, reviews *fezzik_types.PullRequestsViewerPullRequestsNodesReviews,
+	dismissals *fezzik_types.PullRequestsViewerPullRequestsNodesTimelineItems,
+	requests *fezzik_types.PullRequestsViewerPullRequestsNodesReviewRequests,
+	head string, requiredApprovals int) github.ReviewStatus {
+	dismissedState := map[string]string{}
+	if dismissals != nil && dismissals.Nodes != nil {
+		for _, dismissal := range *dismissals.Nodes {
+			if dismissal.Review != nil {
+				dismissedState[dismissal.Review.Id] = dismissal.PreviousReviewState
+			}
 		}
 	}
+	var submitted []github.Review
+	if reviews.Nodes != nil {
+		for _, review := range *reviews.Nodes {
+			// reviews of deleted users have no author
+			if review.Author == nil {
+				continue
+			}
+			r := github.Review{
+				Author:         review.Author.Login,
+				State:          review.State,
+				DismissedState: dismissedState[review.Id],
+			}
+			if review.Commit != nil {
+				r.CommitHash = review.Commit.Oid
+			}
+			submitted = append(submitted, r)
+		}
+	}
+	var pending []string
+	if requests != nil && requests.Nodes != nil {
+		for _, request := range *requests.Nodes {
+			switch {
+			case request.RequestedReviewer == nil:
+			case request.RequestedReviewer.Login != "":
+				pending = append(pending, request.RequestedReviewer.Login)
+			case request.RequestedReviewer.Slug != "":
+				pending = append(pending, cfg.Repo.GitHubRepoOwner+"/"+request.RequestedReviewer.Slug)
+			}
+		}
+	}
+	return github.NewReviewStatus(submitted, head, requiredApprovals, pending)
+}
+
+// branchProtection are the requirements of the branch protection rule of the target branch
+type branchProtection struct {
+	// requiredChecks are the names of the checks which have to pass, only selected by the ViewerPullRequests query
+	requiredChecks []string
+
+	requiredApprovals int
+}
 
-	// The list of commits from the command line actually starts at the
-	//  most recent commit. In order to reverse the list we use a
-	//  custom prepend function instead of append
-	prepend := func(l []*github.PullRequest, pr *github.PullRequest) []*github.PullRequest {
-		l = append(l, &github.PullRequest{})
-		copy(l[1:], l)
-		l[0] = pr
-		return l
+// baseRef is the qualified name of the target branch, its branch protection decides which checks are required
+//
+//	for the whole stack, as the pull requests above the bottom one target unprotected spr branches.
+func baseRef(cfg *config.Config) string {
+	return "refs/heads/" + cfg.Repo.GitHubBranch
+}
+
+func requiredContexts(contexts *[]*string) []string {
+	var required []string
+	if contexts != nil {
+		for _, name := range *contexts {
+			if name != nil {
+				required = append(required, *name)
+			}
+		}
 	}
+	return required
+}
 
-	// build pr stack
-	for currpr != nil {
-		pullRequests = prepend(pullRequests, currpr)
-		if currpr.ToBranch == targetBranch {
-			break
+// commitChecks returns the check runs of the check suites and the commit statuses of the commit
+func commitChecks(commit *fezzik_types.PullRequestsViewerPullRequestsNodesCommitsNodesCommit) []github.Check {
+	var checks []github.Check
+	if commit.CheckSuites != nil && commit.CheckSuites.Nodes != nil {
+		for _, suite := range *commit.CheckSuites.Nodes {
+			if suite.CheckRuns == nil || suite.CheckRuns.Nodes == nil {
+				continue
+			}
+			for _, run := range *suite.CheckRuns.Nodes {
+				checks = append(checks, github.Check{
+					Name:        run.Name,
+					Suite:       checkSuiteName(suite),
+					Status:      github.CheckRunStatus(run.Status, derefString(run.Conclusion)),
+					StartedAt:   parseTime(run.StartedAt),
+					CompletedAt: parseTime(run.CompletedAt),
+					DetailsURL:  derefString(run.DetailsUrl),
+				})
+			}
+		}
+	}
+	if commit.Status != nil {
+		for _, status := range commit.Status.Contexts {
+			checks = append(checks, github.Check{
+				Name:       status.Context,
+				Status:     github.CommitStatusState(string(status.State)),
+				DetailsURL: derefString(status.TargetUrl),
+			})
 		}
+	}
+	return checks
+}
+
+// checkSuiteName returns the name of the workflow or app which ran the check suite
+func checkSuiteName(suite *fezzik_types.PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuite) string {
+	if suite.WorkflowRun != nil {
+		return suite.WorkflowRun.Workflow.Name
+	}
+	if suite.App != nil {
+		return suite.App.Name
+	}
+	return ""
+}
 
-		matches := git.BranchNameRegex.FindStringSubmatch(currpr.ToBranch)
-		if matches == nil {
-			panic(fmt.Errorf("invalid base branch for pull request:%s", currpr.ToBranch))
+// unlistedChecks returns a check of unknown status for each check suite with check runs
+//
+//	beyond the first page and one for the check suites beyond the first page.
+//	They could be required, so they are marked Required and keep the checks pending.
+func unlistedChecks(commit *fezzik_types.PullRequestsViewerPullRequestsNodesCommitsNodesCommit) []github.Check {
+	if commit.CheckSuites == nil || commit.CheckSuites.Nodes == nil {
+		return nil
+	}
+	var checks []github.Check
+	suites := *commit.CheckSuites.Nodes
+	for _, suite := range suites {
+		if suite.CheckRuns == nil || suite.CheckRuns.Nodes == nil {
+			continue
 		}
-		nextCommitID := matches[2]
+		if n := suite.CheckRuns.TotalCount - len(*suite.CheckRuns.Nodes); n > 0 {
+			checks = append(checks, github.Check{
+				Name:     fmt.Sprintf("%d more check runs not listed", n),
+				Suite:    checkSuiteName(suite),
+				Status:   github.CheckStatusUnknown,
+				Required: true,
+			})
+		}
+	}
+	if n := commit.CheckSuites.TotalCount - len(suites); n > 0 {
+		checks = append(checks, github.Check{
+			Name:     fmt.Sprintf("%d more check suites not listed", n),
+			Status:   github.CheckStatusUnknown,
+			Required: true,
+		})
+	}
+	return checks
+}
 
-		currpr = pullRequestMap[nextCommitID]
+// rollupStatus returns the status of the status check rollup state of a commit
+func rollupStatus(state fezzik_types.StatusState) github.CheckStatus {
+	switch state {
+	case "SUCCESS":
+		return github.CheckStatusPass
+	case "PENDING":
+		return github.CheckStatusPending
+	default:
+		return github.CheckStatusFail
 	}
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
 
-	return pullRequests
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// parseTime parses a GraphQL DateTime, missing or invalid times are zero
+func parseTime(s *string) time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 // GetAssignableUsers is taken from github.com/cli/cli/api and is the approach used by the official gh
 // client to resolve user IDs to "ID" values for the update PR API calls. See api.RepoAssignableUsers.
-func (c *client) GetAssignableUsers(ctx context.Context) []github.RepoAssignee {
+func (c *client) GetAssignableUsers(ctx context.Context) ([]github.RepoAssignee, error) {
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github get assignable users\n")
 	}
@@ -251,8 +874,7 @@ func (c *client) GetAssignableUsers(ctx context.Context) []github.RepoAssignee {
 			c.config.Repo.GitHubRepoOwner,
 			c.config.Repo.GitHubRepoName, endCursor)
 		if err != nil {
-			log.Fatal().Err(err).Msg("get assignable users failed")
-			return nil
+			return nil, fmt.Errorf("get assignable users failed: %w", github.ClassifyError(err))
 		}
 
 		for _, node := range *resp.Repository.AssignableUsers.Nodes {
@@ -271,11 +893,11 @@ func (c *client) GetAssignableUsers(ctx context.Context) []github.RepoAssignee {
 		endCursor = resp.Repository.AssignableUsers.PageInfo.EndCursor
 	}
 
-	return users
+	return users, nil
 }
 
 func (c *client) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
-	info *github.GitHubInfo, commit git.Commit, prevCommit *git.Commit) *github.PullRequest {
+	info *github.ForgeInfo, commit git.Commit, prevCommit *git.Commit) (*github.PullRequest, error) {
 
 	baseRefName := c.config.Repo.GitHubBranch
 	if prevCommit != nil {
@@ -287,26 +909,46 @@ func (c *client) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
 		Str("FromBranch", headRefName).Str("ToBranch", baseRefName).
 		Msg("CreatePullRequest")
 
-	body := FormatBody(commit, info.PullRequests, c.config.Repo.ShowPrTitlesInStack)
+	var stack []*github.PullRequest
+	var repositoryID string
+	if info != nil {
+		stack = info.PullRequests
+		repositoryID = info.RepositoryID
+	}
+	if repositoryID == "" {
+		var err error
+		repositoryID, err = c.repositoryID(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	body := FormatBody(commit, stack, c.config.Repo.ShowPrTitlesInStack)
 	if c.config.Repo.PRTemplatePath != "" {
-		pullRequestTemplate, err := readPRTemplate(gitcmd, c.config.Repo.PRTemplatePath)
+		pullRequestTemplate, err := ReadPRTemplate(gitcmd, c.config.Repo.PRTemplatePath)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to read PR template")
+			return nil, fmt.Errorf("failed to read PR template: %w", err)
 		}
 		body, err = InsertBodyIntoPRTemplate(body, pullRequestTemplate, c.config.Repo, nil)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to insert body into PR template")
+			return nil, fmt.Errorf("failed to insert body into PR template: %w", err)
 		}
 	}
+	draft := c.config.User.CreateDraftPRs
+	if commit.Draft != nil {
+		draft = *commit.Draft
+	}
 	resp, err := c.api.CreatePullRequest(ctx, genclient.CreatePullRequestInput{
-		RepositoryId: info.RepositoryID,
+		RepositoryId: repositoryID,
 		BaseRefName:  baseRefName,
 		HeadRefName:  headRefName,
 		Title:        commit.Subject,
 		Body:         &body,
-		Draft:        &c.config.User.CreateDraftPRs,
+		Draft:        &draft,
 	})
-	check(err)
+	if err != nil {
+		return nil, fmt.Errorf("pull request create failed for commit %s: %w", commit.CommitID, github.ClassifyError(err))
+	}
 
 	pr := &github.PullRequest{
 		ID:         resp.CreatePullRequest.PullRequest.Id,
@@ -315,6 +957,7 @@ func (c *client) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
 		ToBranch:   baseRefName,
 		Commit:     commit,
 		Title:      commit.Subject,
+		Draft:      draft,
 		MergeStatus: github.PullRequestMergeStatus{
 			ChecksPass:     github.CheckStatusUnknown,
 			ReviewApproved: false,
@@ -327,13 +970,13 @@ func (c *client) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
 		fmt.Printf("> github create %d : %s\n", pr.Number, pr.Title)
 	}
 
-	return pr
+	return pr, nil
 }
 
-func formatStackMarkdown(commit git.Commit, stack []*github.PullRequest, showPrTitlesInStack bool) string {
+func formatStackMarkdown(commit git.Commit, stack []*github.PullRequest, showPrTitlesInStack bool, refPrefix string) string {
 	var buf bytes.Buffer
 	for i := len(stack) - 1; i >= 0; i-- {
-		isCurrent := stack[i].Commit == commit
+		isCurrent := stack[i].Commit.CommitID == commit.CommitID
 		var suffix string
 		if isCurrent {
 			suffix = " ⬅"
@@ -347,29 +990,36 @@ func formatStackMarkdown(commit git.Commit, stack []*github.PullRequest, showPrT
 			prTitle = ""
 		}
 
-		buf.WriteString(fmt.Sprintf("- %s#%d%s\n", prTitle, stack[i].Number, suffix))
+		buf.WriteString(fmt.Sprintf("- %s%s%d%s\n", prTitle, refPrefix, stack[i].Number, suffix))
 	}
 
 	return buf.String()
 }
 
 func FormatBody(commit git.Commit, stack []*github.PullRequest, showPrTitlesInStack bool) string {
+	return FormatStackBody(commit, stack, showPrTitlesInStack, "#")
+}
+
+// FormatStackBody returns the commit body followed by the list of pull requests in the stack,
+//
+//	refPrefix is prepended to pull request numbers, # on GitHub and ! for GitLab merge requests.
+func FormatStackBody(commit git.Commit, stack []*github.PullRequest, showPrTitlesInStack bool, refPrefix string) string {
 	if len(stack) <= 1 {
 		return strings.TrimSpace(commit.Body)
 	}
 
 	if commit.Body == "" {
 		return fmt.Sprintf("**Stack**:\n%s",
-			addManualMergeNotice(formatStackMarkdown(commit, stack, showPrTitlesInStack)))
+			addManualMergeNotice(formatStackMarkdown(commit, stack, showPrTitlesInStack, refPrefix)))
 	}
 
 	return fmt.Sprintf("%s\n\n---\n\n**Stack**:\n%s",
 		commit.Body,
-		addManualMergeNotice(formatStackMarkdown(commit, stack, showPrTitlesInStack)))
+		addManualMergeNotice(formatStackMarkdown(commit, stack, showPrTitlesInStack, refPrefix)))
 }
 
-// Reads the specified PR template file and returns it as a string
-func readPRTemplate(gitcmd git.GitInterface, templatePath string) (string, error) {
+// ReadPRTemplate reads the specified PR template file and returns it as a string
+func ReadPRTemplate(gitcmd git.GitInterface, templatePath string) (string, error) {
 	repoRootDir := gitcmd.RootDir()
 	fullTemplatePath := filepath.Clean(path.Join(repoRootDir, templatePath))
 	pullRequestTemplateBytes, err := os.ReadFile(fullTemplatePath)
@@ -436,7 +1086,7 @@ func addManualMergeNotice(body string) string {
 		"Do not merge manually using the UI - doing so may have unexpected results.*"
 }
 
-func (c *client) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) {
+func (c *client) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) error {
 
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github update %d : %s\n", pr.Number, pr.Title)
@@ -453,13 +1103,13 @@ func (c *client) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface,
 
 	body := FormatBody(commit, pullRequests, c.config.Repo.ShowPrTitlesInStack)
 	if c.config.Repo.PRTemplatePath != "" {
-		pullRequestTemplate, err := readPRTemplate(gitcmd, c.config.Repo.PRTemplatePath)
+		pullRequestTemplate, err := ReadPRTemplate(gitcmd, c.config.Repo.PRTemplatePath)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to read PR template")
+			return fmt.Errorf("failed to read PR template: %w", err)
 		}
 		body, err = InsertBodyIntoPRTemplate(body, pullRequestTemplate, c.config.Repo, pr)
 		if err != nil {
-			log.Fatal().Err(err).Msg("failed to insert body into PR template")
+			return fmt.Errorf("failed to insert body into PR template: %w", err)
 		}
 	}
 	title := &commit.Subject
@@ -479,130 +1129,153 @@ func (c *client) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface,
 		input.Body = nil
 	}
 
+	if commit.Milestone != "" {
+		milestoneID, err := c.milestoneID(ctx, commit.Milestone)
+		if err != nil {
+			return err
+		}
+		input.MilestoneId = &milestoneID
+	}
+
 	_, err := c.api.UpdatePullRequest(ctx, input)
+	if err != nil {
+		return fmt.Errorf("pull request update failed for #%d: %w", pr.Number, github.ClassifyError(err))
+	}
+	return c.applyTrailers(ctx, pr, commit)
+}
 
+// GetLogin returns the login of the user the token belongs to
+func (c *client) GetLogin(ctx context.Context) (string, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github get login\n")
+	}
+	resp, err := c.api.Viewer(ctx)
 	if err != nil {
-		log.Fatal().
-			Str("id", pr.ID).
-			Int("number", pr.Number).
-			Str("title", pr.Title).
-			Err(err).
-			Msg("pull request update failed")
+		return "", fmt.Errorf("get login failed: %w", github.ClassifyError(err))
 	}
+	return resp.Viewer.Login, nil
+}
+
+// GetTeamID returns the GraphQL id of the team named org/team, which is used
+// to request reviews from the team.
+func (c *client) GetTeamID(ctx context.Context, team string) (string, error) {
+	org, slug, found := strings.Cut(team, "/")
+	if !found || org == "" || slug == "" {
+		return "", fmt.Errorf("invalid team %q, teams are named org/team", team)
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github get team %s\n", team)
+	}
+	resp, err := c.api.TeamID(ctx, org, slug)
+	if err != nil {
+		return "", fmt.Errorf("get team %s failed: %w", team, github.ClassifyError(err))
+	}
+	if resp.Organization == nil || resp.Organization.Team == nil {
+		return "", fmt.Errorf("unable to add reviewer, team %q not found", team)
+	}
+	return resp.Organization.Team.Id, nil
 }
 
 // AddReviewers adds reviewers to the provided pull request using the requestReviews() API call. It
-// takes github user IDs (ID type) as its input. These can be found by first querying the AssignableUsers
-// for the repo, and then mapping login name to ID.
-func (c *client) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string) {
-	log.Debug().Strs("userIDs", userIDs).Msg("AddReviewers")
+// takes github user and team IDs (ID type) as its input. These can be found by first querying the
+// AssignableUsers for the repo and mapping login name to ID, and with GetTeamID for teams.
+// Reviewers which were requested before are kept.
+func (c *client) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string, teamIDs []string) error {
+	log.Debug().Strs("userIDs", userIDs).Strs("teamIDs", teamIDs).Msg("AddReviewers")
 	if c.config.User.LogGitHubCalls {
-		fmt.Printf("> github add reviewers %d : %s - %+v\n", pr.Number, pr.Title, userIDs)
+		fmt.Printf("> github add reviewers %d : %s - %+v %+v\n", pr.Number, pr.Title, userIDs, teamIDs)
 	}
-	union := false
-	_, err := c.api.AddReviewers(ctx, genclient.RequestReviewsInput{
+	union := true
+	input := genclient.RequestReviewsInput{
 		PullRequestId: pr.ID,
 		Union:         &union,
 		UserIds:       &userIDs,
-	})
+	}
+	if len(teamIDs) > 0 {
+		input.TeamIds = &teamIDs
+	}
+	_, err := c.api.AddReviewers(ctx, input)
 	if err != nil {
-		log.Fatal().
-			Str("id", pr.ID).
-			Int("number", pr.Number).
-			Str("title", pr.Title).
-			Strs("userIDs", userIDs).
-			Err(err).
-			Msg("add reviewers failed")
+		return fmt.Errorf("add reviewers %v failed for #%d: %w", slices.Concat(userIDs, teamIDs), pr.Number, github.ClassifyError(err))
 	}
+	return nil
 }
 
-func (c *client) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) {
+func (c *client) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) error {
 	_, err := c.api.CommentPullRequest(ctx, genclient.AddCommentInput{
 		SubjectId: pr.ID,
 		Body:      comment,
 	})
 	if err != nil {
-		log.Fatal().
-			Str("id", pr.ID).
-			Int("number", pr.Number).
-			Str("title", pr.Title).
-			Err(err).
-			Msg("pull request update failed")
+		return fmt.Errorf("pull request comment failed for #%d: %w", pr.Number, github.ClassifyError(err))
 	}
 
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github add comment %d : %s\n", pr.Number, pr.Title)
 	}
+	return nil
 }
 
 func (c *client) MergePullRequest(ctx context.Context,
-	pr *github.PullRequest, mergeMethod genclient.PullRequestMergeMethod) {
+	pr *github.PullRequest, mergeMethod github.MergeMethod) error {
 	log.Debug().
 		Interface("PR", pr).
 		Str("mergeMethod", string(mergeMethod)).
 		Msg("MergePullRequest")
 
+	method := genclient.PullRequestMergeMethod(mergeMethod)
 	var err error
 	if c.config.Repo.MergeQueue {
 		_, err = c.api.AutoMergePullRequest(ctx, genclient.EnablePullRequestAutoMergeInput{
 			PullRequestId: pr.ID,
-			MergeMethod:   &mergeMethod,
+			MergeMethod:   &method,
 		})
 	} else {
 		_, err = c.api.MergePullRequest(ctx, genclient.MergePullRequestInput{
 			PullRequestId: pr.ID,
-			MergeMethod:   &mergeMethod,
+			MergeMethod:   &method,
 		})
 	}
 	if err != nil {
-		log.Fatal().
-			Str("id", pr.ID).
-			Int("number", pr.Number).
-			Str("title", pr.Title).
-			Err(err).
-			Msg("pull request merge failed")
+		return fmt.Errorf("pull request merge failed for #%d: %w", pr.Number, github.ClassifyError(err))
 	}
-	check(err)
 
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github merge %d : %s\n", pr.Number, pr.Title)
 	}
+	return nil
 }
 
-func (c *client) ClosePullRequest(ctx context.Context, pr *github.PullRequest) {
+func (c *client) ClosePullRequest(ctx context.Context, pr *github.PullRequest) error {
 	log.Debug().Interface("PR", pr).Msg("ClosePullRequest")
 	_, err := c.api.ClosePullRequest(ctx, genclient.ClosePullRequestInput{
 		PullRequestId: pr.ID,
 	})
 	if err != nil {
-		log.Fatal().
-			Str("id", pr.ID).
-			Int("number", pr.Number).
-			Str("title", pr.Title).
-			Err(err).
-			Msg("pull request close failed")
+		return fmt.Errorf("pull request close failed for #%d: %w", pr.Number, github.ClassifyError(err))
 	}
 
 	if c.config.User.LogGitHubCalls {
 		fmt.Printf("> github close %d : %s\n", pr.Number, pr.Title)
 	}
+	return nil
 }
 
-func (c *client) GetClient() genclient.Client {
-	return c.api
-}
-
-func check(err error) {
+func (c *client) RestorePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	log.Debug().Interface("PR", pr).Msg("RestorePullRequest")
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github restore %d : %s\n", pr.Number, pr.Title)
+	}
+	state := genclient.PullRequestUpdateState_OPEN
+	_, err := c.api.UpdatePullRequest(ctx, genclient.UpdatePullRequestInput{
+		PullRequestId: pr.ID,
+		State:         &state,
+		BaseRefName:   &pr.ToBranch,
+		Title:         &pr.Title,
+		Body:          &pr.Body,
+	})
 	if err != nil {
-		msg := err.Error()
-		if strings.Contains(msg, "401 Unauthorized") {
-			errmsg := "error : 401 Unauthorized\n"
-			errmsg += " make sure GITHUB_TOKEN env variable is set with a valid token\n"
-			errmsg += " to create a valid token goto: https://github.com/settings/tokens\n"
-			fmt.Fprint(os.Stderr, errmsg)
-			os.Exit(-1)
-		} else {
-			panic(err)
-		}
+		return fmt.Errorf("pull request restore failed for #%d: %w", pr.Number, github.ClassifyError(err))
 	}
+	return nil
 }
@@ -522,9 +522,11 @@ func TestMatchPullRequestStack(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		repoConfig := &config.RepoConfig{}
+		cfg := config.EmptyConfig()
+		cfg.Repo.GitHubBranch = "master"
 		t.Run(tc.name, func(t *testing.T) {
-			actual := matchPullRequestStack(repoConfig, "master", tc.commits, tc.prs)
+			actual, err := matchPullRequestStack(cfg, tc.commits, tc.prs, branchProtection{})
+			require.NoError(t, err)
 			require.Equal(t, tc.expect, actual)
 		})
 	}
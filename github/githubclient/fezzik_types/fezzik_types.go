@@ -27,7 +27,13 @@ const (
 )
 
 type PullRequestConnection struct {
-	Nodes *PullRequestsViewerPullRequestsNodes
+	Nodes    *PullRequestsViewerPullRequestsNodes
+	PageInfo PageInfo
+}
+
+type PageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
 }
 
 type PullRequestsViewerPullRequestsNodes []*struct {
@@ -37,13 +43,25 @@ type PullRequestsViewerPullRequestsNodes []*struct {
 	Body            string
 	BaseRefName     string
 	HeadRefName     string
+	Author          *PullRequestsViewerPullRequestsNodesAuthor
+	IsDraft         bool
 	Mergeable       MergeableState
 	ReviewDecision  *PullRequestReviewDecision
 	Repository      PullRequestsViewerPullRequestsNodesRepository
 	MergeQueueEntry *PullRequestsViewerPullRequestsNodesMergeQueueEntry
+	Reviews         *PullRequestsViewerPullRequestsNodesReviews
+	TimelineItems   *PullRequestsViewerPullRequestsNodesTimelineItems
+	ReviewRequests  *PullRequestsViewerPullRequestsNodesReviewRequests
 	Commits         PullRequestsViewerPullRequestsNodesCommits
 }
 
+// PullRequestsViewerPullRequestsNodesAuthor is the author of a pull request, only selected
+//
+//	by the ViewerPullRequests query which lists the pull requests of all authors.
+type PullRequestsViewerPullRequestsNodesAuthor struct {
+	Login string
+}
+
 type PullRequestsViewerPullRequestsNodesRepository struct {
 	Id string
 }
@@ -52,8 +70,73 @@ type PullRequestsViewerPullRequestsNodesMergeQueueEntry struct {
 	Id string
 }
 
+// PullRequestReviewConnection binds the reviews of the PullRequestReviews query
+//
+//	to the same type as the reviews of the PullRequests query.
+type PullRequestReviewConnection = PullRequestsViewerPullRequestsNodesReviews
+
+// PullRequestsViewerPullRequestsNodesReviews are the reviews of a pull request. The author
+//
+//	is an interface and can only be selected because the pull request connection
+//	is bound here instead of generated.
+type PullRequestsViewerPullRequestsNodesReviews struct {
+	Nodes *[]*struct {
+		Id     string
+		Author *struct {
+			Login string
+		}
+		State  string
+		Commit *struct {
+			Oid string
+		}
+	}
+	PageInfo PageInfo
+}
+
+// PullRequestTimelineItemsConnection binds the review dismissals of the PullRequestDismissals
+//
+//	query to the same type as the review dismissals of the PullRequests query.
+type PullRequestTimelineItemsConnection = PullRequestsViewerPullRequestsNodesTimelineItems
+
+// PullRequestsViewerPullRequestsNodesTimelineItems are the review dismissals of a pull request,
+//
+//	a dismissed review only has the state it had before in its dismissal event.
+type PullRequestsViewerPullRequestsNodesTimelineItems struct {
+	Nodes *[]*struct {
+		PreviousReviewState string
+		Review              *struct {
+			Id string
+		}
+	}
+	PageInfo PageInfo
+}
+
+// ReviewRequestConnection binds the review requests of the PullRequestReviewRequests
+//
+//	query to the same type as the review requests of the PullRequests query.
+type ReviewRequestConnection = PullRequestsViewerPullRequestsNodesReviewRequests
+
+// PullRequestsViewerPullRequestsNodesReviewRequests are the pending review requests of a pull request.
+//
+//	Requested users have a Login and teams a Slug.
+type PullRequestsViewerPullRequestsNodesReviewRequests struct {
+	Nodes *[]*struct {
+		RequestedReviewer *struct {
+			Login string
+			Slug  string
+		}
+	}
+	PageInfo PageInfo
+}
+
+// PullRequestCommitConnection binds the commits of the PullRequestCommits query
+//
+//	to the same type as the commits of the PullRequests query.
+type PullRequestCommitConnection = PullRequestsViewerPullRequestsNodesCommits
+
 type PullRequestsViewerPullRequestsNodesCommits struct {
-	Nodes *PullRequestsViewerPullRequestsNodesCommitsNodes
+	Nodes    *PullRequestsViewerPullRequestsNodesCommitsNodes
+	PageInfo PageInfo
 }
 
 type PullRequestsViewerPullRequestsNodesCommitsNodes []*struct {
@@ -65,8 +148,53 @@ type PullRequestsViewerPullRequestsNodesCommitsNodesCommit struct {
 	MessageHeadline   string
 	MessageBody       string
 	StatusCheckRollup *PullRequestsViewerPullRequestsNodesCommitsNodesCommitStatusCheckRollup
+	CheckSuites       *PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuites
+	Status            *PullRequestsViewerPullRequestsNodesCommitsNodesCommitStatus
 }
 
 type PullRequestsViewerPullRequestsNodesCommitsNodesCommitStatusCheckRollup struct {
 	State StatusState
 }
+
+// PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuites are the check suites
+//
+//	of a commit, only selected by the ViewerPullRequests and PullRequestChecks queries.
+type PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuites struct {
+	TotalCount int
+	Nodes      *[]*PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuite
+}
+
+type PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckSuite struct {
+	App *struct {
+		Name string
+	}
+	WorkflowRun *struct {
+		Workflow struct {
+			Name string
+		}
+	}
+	CheckRuns *struct {
+		TotalCount int
+		Nodes      *[]*PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckRun
+	}
+}
+
+type PullRequestsViewerPullRequestsNodesCommitsNodesCommitCheckRun struct {
+	Name        string
+	Status      string
+	Conclusion  *string
+	StartedAt   *string
+	CompletedAt *string
+	DetailsUrl  *string
+}
+
+// PullRequestsViewerPullRequestsNodesCommitsNodesCommitStatus are the commit statuses
+//
+//	of a commit, only selected by the ViewerPullRequests and PullRequestChecks queries.
+type PullRequestsViewerPullRequestsNodesCommitsNodesCommitStatus struct {
+	Contexts []struct {
+		Context   string
+		State     StatusState
+		TargetUrl *string
+	}
+}
@@ -14,71 +14,191 @@ type Client interface {
 	PullRequests(ctx context.Context,
 		repoOwner string,
 		repoName string,
+		baseRef string,
+		endCursor *string,
 	) (*PullRequestsResponse, error)
 
-	// PullRequestsWithMergeQueue from github/githubclient/queries.graphql:40
+	// PullRequestsWithMergeQueue from github/githubclient/queries.graphql:93
 	PullRequestsWithMergeQueue(ctx context.Context,
 		repoOwner string,
 		repoName string,
+		baseRef string,
+		endCursor *string,
 	) (*PullRequestsWithMergeQueueResponse, error)
 
-	// AssignableUsers from github/githubclient/queries.graphql:82
+	// ViewerPullRequests from github/githubclient/queries.graphql:188
+	ViewerPullRequests(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		baseRef string,
+		endCursor *string,
+	) (*ViewerPullRequestsResponse, error)
+
+	// PullRequestCommits from github/githubclient/queries.graphql:216
+	PullRequestCommits(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		number int,
+		endCursor *string,
+	) (*PullRequestCommitsResponse, error)
+
+	// PullRequestReviews from github/githubclient/queries.graphql:244
+	PullRequestReviews(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		number int,
+		endCursor *string,
+	) (*PullRequestReviewsResponse, error)
+
+	// PullRequestDismissals from github/githubclient/queries.graphql:272
+	PullRequestDismissals(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		number int,
+		endCursor *string,
+	) (*PullRequestDismissalsResponse, error)
+
+	// PullRequestReviewRequests from github/githubclient/queries.graphql:293
+	PullRequestReviewRequests(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		number int,
+		endCursor *string,
+	) (*PullRequestReviewRequestsResponse, error)
+
+	// PullRequestsByHead from github/githubclient/queries.graphql:317
+	PullRequestsByHead(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		headRef string,
+	) (*PullRequestsByHeadResponse, error)
+
+	// PullRequestBranches from github/githubclient/queries.graphql:399
+	PullRequestBranches(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		number int,
+	) (*PullRequestBranchesResponse, error)
+
+	// AssignableUsers from github/githubclient/queries.graphql:415
 	AssignableUsers(ctx context.Context,
 		repoOwner string,
 		repoName string,
 		endCursor *string,
 	) (*AssignableUsersResponse, error)
 
-	// CreatePullRequest from github/githubclient/queries.graphql:102
+	// RepositoryID from github/githubclient/queries.graphql:435
+	RepositoryID(ctx context.Context,
+		repoOwner string,
+		repoName string,
+	) (*RepositoryIDResponse, error)
+
+	// Viewer from github/githubclient/queries.graphql:444
+	Viewer(ctx context.Context) (*ViewerResponse, error)
+
+	// TeamID from github/githubclient/queries.graphql:450
+	TeamID(ctx context.Context,
+		org string,
+		slug string,
+	) (*TeamIDResponse, error)
+
+	// LabelID from github/githubclient/queries.graphql:461
+	LabelID(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		name string,
+	) (*LabelIDResponse, error)
+
+	// Milestones from github/githubclient/queries.graphql:473
+	Milestones(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		title string,
+	) (*MilestonesResponse, error)
+
+	// CreatePullRequest from github/githubclient/queries.graphql:488
 	CreatePullRequest(ctx context.Context,
 		input CreatePullRequestInput,
 	) (*CreatePullRequestResponse, error)
 
-	// UpdatePullRequest from github/githubclient/queries.graphql:115
+	// UpdatePullRequest from github/githubclient/queries.graphql:501
 	UpdatePullRequest(ctx context.Context,
 		input UpdatePullRequestInput,
 	) (*UpdatePullRequestResponse, error)
 
-	// AddReviewers from github/githubclient/queries.graphql:127
+	// AddReviewers from github/githubclient/queries.graphql:513
 	AddReviewers(ctx context.Context,
 		input RequestReviewsInput,
 	) (*AddReviewersResponse, error)
 
-	// CommentPullRequest from github/githubclient/queries.graphql:139
+	// AddLabels from github/githubclient/queries.graphql:525
+	AddLabels(ctx context.Context,
+		input AddLabelsToLabelableInput,
+	) (*AddLabelsResponse, error)
+
+	// RemoveLabels from github/githubclient/queries.graphql:535
+	RemoveLabels(ctx context.Context,
+		input RemoveLabelsFromLabelableInput,
+	) (*RemoveLabelsResponse, error)
+
+	// AddAssignees from github/githubclient/queries.graphql:545
+	AddAssignees(ctx context.Context,
+		input AddAssigneesToAssignableInput,
+	) (*AddAssigneesResponse, error)
+
+	// ConvertPullRequestToDraft from github/githubclient/queries.graphql:555
+	ConvertPullRequestToDraft(ctx context.Context,
+		input ConvertPullRequestToDraftInput,
+	) (*ConvertPullRequestToDraftResponse, error)
+
+	// MarkPullRequestReadyForReview from github/githubclient/queries.graphql:565
+	MarkPullRequestReadyForReview(ctx context.Context,
+		input MarkPullRequestReadyForReviewInput,
+	) (*MarkPullRequestReadyForReviewResponse, error)
+
+	// CommentPullRequest from github/githubclient/queries.graphql:575
 	CommentPullRequest(ctx context.Context,
 		input AddCommentInput,
 	) (*CommentPullRequestResponse, error)
 
-	// MergePullRequest from github/githubclient/queries.graphql:149
+	// MergePullRequest from github/githubclient/queries.graphql:585
 	MergePullRequest(ctx context.Context,
 		input MergePullRequestInput,
 	) (*MergePullRequestResponse, error)
 
-	// AutoMergePullRequest from github/githubclient/queries.graphql:161
+	// AutoMergePullRequest from github/githubclient/queries.graphql:597
 	AutoMergePullRequest(ctx context.Context,
 		input EnablePullRequestAutoMergeInput,
 	) (*AutoMergePullRequestResponse, error)
 
-	// ClosePullRequest from github/githubclient/queries.graphql:173
+	// ClosePullRequest from github/githubclient/queries.graphql:609
 	ClosePullRequest(ctx context.Context,
 		input ClosePullRequestInput,
 	) (*ClosePullRequestResponse, error)
 
-	// StarCheck from github/githubclient/queries.graphql:185
+	// StarCheck from github/githubclient/queries.graphql:621
 	StarCheck(ctx context.Context,
 		after *string,
 	) (*StarCheckResponse, error)
 
-	// StarGetRepo from github/githubclient/queries.graphql:201
+	// StarGetRepo from github/githubclient/queries.graphql:637
 	StarGetRepo(ctx context.Context,
 		owner string,
 		name string,
 	) (*StarGetRepoResponse, error)
 
-	// StarAdd from github/githubclient/queries.graphql:210
+	// StarAdd from github/githubclient/queries.graphql:646
 	StarAdd(ctx context.Context,
 		input AddStarInput,
 	) (*StarAddResponse, error)
+
+	// PullRequestChecks from github/githubclient/queries.graphql:654
+	PullRequestChecks(ctx context.Context,
+		repoOwner string,
+		repoName string,
+		baseRef string,
+		number int,
+	) (*PullRequestChecksResponse, error)
 }
 
 func NewClient(url string, httpclient *http.Client) Client {
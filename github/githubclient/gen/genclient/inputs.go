@@ -2127,12 +2127,24 @@ const (
 	__TypeKind_NON_NULL     __TypeKind = "NON_NULL"
 )
 
+type AddAssigneesToAssignableInput struct {
+	AssignableId     string   `json:"assignableId"`
+	AssigneeIds      []string `json:"assigneeIds"`
+	ClientMutationId *string  `json:"clientMutationId,omitempty"`
+}
+
 type AddCommentInput struct {
 	Body             string  `json:"body"`
 	ClientMutationId *string `json:"clientMutationId,omitempty"`
 	SubjectId        string  `json:"subjectId"`
 }
 
+type AddLabelsToLabelableInput struct {
+	ClientMutationId *string  `json:"clientMutationId,omitempty"`
+	LabelIds         []string `json:"labelIds"`
+	LabelableId      string   `json:"labelableId"`
+}
+
 type AddStarInput struct {
 	ClientMutationId *string `json:"clientMutationId,omitempty"`
 	StarrableId      string  `json:"starrableId"`
@@ -2143,6 +2155,11 @@ type ClosePullRequestInput struct {
 	PullRequestId    string  `json:"pullRequestId"`
 }
 
+type ConvertPullRequestToDraftInput struct {
+	ClientMutationId *string `json:"clientMutationId,omitempty"`
+	PullRequestId    string  `json:"pullRequestId"`
+}
+
 type CreatePullRequestInput struct {
 	BaseRefName         string  `json:"baseRefName"`
 	Body                *string `json:"body,omitempty"`
@@ -2165,6 +2182,11 @@ type EnablePullRequestAutoMergeInput struct {
 	PullRequestId    string                  `json:"pullRequestId"`
 }
 
+type MarkPullRequestReadyForReviewInput struct {
+	ClientMutationId *string `json:"clientMutationId,omitempty"`
+	PullRequestId    string  `json:"pullRequestId"`
+}
+
 type MergePullRequestInput struct {
 	AuthorEmail      *string                 `json:"authorEmail,omitempty"`
 	ClientMutationId *string                 `json:"clientMutationId,omitempty"`
@@ -2175,6 +2197,12 @@ type MergePullRequestInput struct {
 	PullRequestId    string                  `json:"pullRequestId"`
 }
 
+type RemoveLabelsFromLabelableInput struct {
+	ClientMutationId *string  `json:"clientMutationId,omitempty"`
+	LabelIds         []string `json:"labelIds"`
+	LabelableId      string   `json:"labelableId"`
+}
+
 type RequestReviewsInput struct {
 	ClientMutationId *string   `json:"clientMutationId,omitempty"`
 	PullRequestId    string    `json:"pullRequestId"`
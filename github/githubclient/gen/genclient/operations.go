@@ -15,7 +15,16 @@ type PullRequestsViewer struct {
 }
 
 type PullRequestsRepository struct {
-	Id string
+	Id  string
+	Ref *PullRequestsRepositoryRef
+}
+
+type PullRequestsRepositoryRef struct {
+	BranchProtectionRule *PullRequestsRepositoryRefBranchProtectionRule
+}
+
+type PullRequestsRepositoryRefBranchProtectionRule struct {
+	RequiredApprovingReviewCount *int
 }
 
 // PullRequestsResponse response type for PullRequests
@@ -28,13 +37,15 @@ type PullRequestsResponse struct {
 func (c *gqlclient) PullRequests(ctx context.Context,
 	repoOwner string,
 	repoName string,
+	baseRef string,
+	endCursor *string,
 ) (*PullRequestsResponse, error) {
 
 	var pullRequestsOperation string = `
-	query PullRequests ($repo_owner: String!, $repo_name: String!) {
+	query PullRequests ($repo_owner: String!, $repo_name: String!, $base_ref: String!, $end_cursor: String) {
 	viewer {
 		login
-		pullRequests(first: 100, states: [OPEN]) {
+		pullRequests(first: 100, states: [OPEN], after: $end_cursor) {
 			nodes {
 				id
 				number
@@ -42,11 +53,49 @@ func (c *gqlclient) PullRequests(ctx context.Context,
 				body
 				baseRefName
 				headRefName
+				isDraft
 				mergeable
 				reviewDecision
 				repository {
 					id
 				}
+				reviews(first: 100) {
+					nodes {
+						id
+						author {
+							login
+						}
+						state
+						commit {
+							oid
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				timelineItems(first: 100, itemTypes: [REVIEW_DISMISSED_EVENT]) {
+					nodes {
+						... DismissedReview
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				reviewRequests(first: 100) {
+					nodes {
+						requestedReviewer {
+							... RequestedUser
+							... RequestedTeam
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
 				commits(first: 100) {
 					nodes {
 						commit {
@@ -58,14 +107,39 @@ func (c *gqlclient) PullRequests(ctx context.Context,
 							}
 						}
 					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
 				}
 			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
 		}
 	}
 	repository(owner: $repo_owner, name: $repo_name) {
 		id
+		ref(qualifiedName: $base_ref) {
+			branchProtectionRule {
+				requiredApprovingReviewCount
+			}
+		}
+	}
+}
+fragment DismissedReview on ReviewDismissedEvent {
+	previousReviewState
+	review {
+		id
 	}
 }
+fragment RequestedUser on User {
+	login
+}
+fragment RequestedTeam on Team {
+	slug
+}
 `
 
 	gqlreq := &client.GQLRequest{
@@ -74,6 +148,8 @@ func (c *gqlclient) PullRequests(ctx context.Context,
 		Variables: map[string]interface{}{
 			"repo_owner": repoOwner,
 			"repo_name":  repoName,
+			"base_ref":   baseRef,
+			"end_cursor": endCursor,
 		},
 	}
 
@@ -104,7 +180,16 @@ type PullRequestsWithMergeQueueViewer struct {
 }
 
 type PullRequestsWithMergeQueueRepository struct {
-	Id string
+	Id  string
+	Ref *PullRequestsWithMergeQueueRepositoryRef
+}
+
+type PullRequestsWithMergeQueueRepositoryRef struct {
+	BranchProtectionRule *PullRequestsWithMergeQueueRepositoryRefBranchProtectionRule
+}
+
+type PullRequestsWithMergeQueueRepositoryRefBranchProtectionRule struct {
+	RequiredApprovingReviewCount *int
 }
 
 // PullRequestsWithMergeQueueResponse response type for PullRequestsWithMergeQueue
@@ -113,17 +198,19 @@ type PullRequestsWithMergeQueueResponse struct {
 	Repository *PullRequestsWithMergeQueueRepository
 }
 
-// PullRequestsWithMergeQueue from github/githubclient/queries.graphql:40
+// PullRequestsWithMergeQueue from github/githubclient/queries.graphql:93
 func (c *gqlclient) PullRequestsWithMergeQueue(ctx context.Context,
 	repoOwner string,
 	repoName string,
+	baseRef string,
+	endCursor *string,
 ) (*PullRequestsWithMergeQueueResponse, error) {
 
 	var pullRequestsWithMergeQueueOperation string = `
-	query PullRequestsWithMergeQueue ($repo_owner: String!, $repo_name: String!) {
+	query PullRequestsWithMergeQueue ($repo_owner: String!, $repo_name: String!, $base_ref: String!, $end_cursor: String) {
 	viewer {
 		login
-		pullRequests(first: 100, states: [OPEN]) {
+		pullRequests(first: 100, states: [OPEN], after: $end_cursor) {
 			nodes {
 				id
 				number
@@ -131,11 +218,49 @@ func (c *gqlclient) PullRequestsWithMergeQueue(ctx context.Context,
 				body
 				baseRefName
 				headRefName
+				isDraft
 				mergeable
 				reviewDecision
 				repository {
 					id
 				}
+				reviews(first: 100) {
+					nodes {
+						id
+						author {
+							login
+						}
+						state
+						commit {
+							oid
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				timelineItems(first: 100, itemTypes: [REVIEW_DISMISSED_EVENT]) {
+					nodes {
+						... DismissedReview
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				reviewRequests(first: 100) {
+					nodes {
+						requestedReviewer {
+							... RequestedUser
+							... RequestedTeam
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
 				mergeQueueEntry {
 					id
 				}
@@ -150,14 +275,39 @@ func (c *gqlclient) PullRequestsWithMergeQueue(ctx context.Context,
 							}
 						}
 					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
 				}
 			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
 		}
 	}
 	repository(owner: $repo_owner, name: $repo_name) {
 		id
+		ref(qualifiedName: $base_ref) {
+			branchProtectionRule {
+				requiredApprovingReviewCount
+			}
+		}
+	}
+}
+fragment DismissedReview on ReviewDismissedEvent {
+	previousReviewState
+	review {
+		id
 	}
 }
+fragment RequestedUser on User {
+	login
+}
+fragment RequestedTeam on Team {
+	slug
+}
 `
 
 	gqlreq := &client.GQLRequest{
@@ -166,6 +316,8 @@ func (c *gqlclient) PullRequestsWithMergeQueue(ctx context.Context,
 		Variables: map[string]interface{}{
 			"repo_owner": repoOwner,
 			"repo_name":  repoName,
+			"base_ref":   baseRef,
+			"end_cursor": endCursor,
 		},
 	}
 
@@ -190,50 +342,1026 @@ func (c *gqlclient) PullRequestsWithMergeQueue(ctx context.Context,
 	return data, resp.Errors
 }
 
-type AssignableUsersRepository struct {
-	AssignableUsers AssignableUsersRepositoryAssignableUsers
+type ViewerPullRequestsViewer struct {
+	Login string
 }
 
-type AssignableUsersRepositoryAssignableUsers struct {
-	Nodes    *AssignableUsersRepositoryAssignableUsersNodes
-	PageInfo AssignableUsersRepositoryAssignableUsersPageInfo
+type ViewerPullRequestsRepository struct {
+	Ref          *ViewerPullRequestsRepositoryRef
+	PullRequests fezzik_types.PullRequestConnection
+}
+
+type ViewerPullRequestsRepositoryRef struct {
+	BranchProtectionRule *ViewerPullRequestsRepositoryRefBranchProtectionRule
+}
+
+type ViewerPullRequestsRepositoryRefBranchProtectionRule struct {
+	RequiredStatusCheckContexts  *[]*string
+	RequiredApprovingReviewCount *int
+}
+
+// ViewerPullRequestsResponse response type for ViewerPullRequests
+type ViewerPullRequestsResponse struct {
+	Viewer     ViewerPullRequestsViewer
+	Repository *ViewerPullRequestsRepository
+}
+
+// ViewerPullRequests from github/githubclient/queries.graphql:188
+func (c *gqlclient) ViewerPullRequests(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	baseRef string,
+	endCursor *string,
+) (*ViewerPullRequestsResponse, error) {
+
+	var viewerPullRequestsOperation string = `
+	query ViewerPullRequests ($repo_owner: String!, $repo_name: String!, $base_ref: String!, $end_cursor: String) {
+	viewer {
+		login
+	}
+	repository(owner: $repo_owner, name: $repo_name) {
+		ref(qualifiedName: $base_ref) {
+			branchProtectionRule {
+				requiredStatusCheckContexts
+				requiredApprovingReviewCount
+			}
+		}
+		pullRequests(first: 100, states: [OPEN], after: $end_cursor) {
+			nodes {
+				... ViewerPullRequest
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+fragment ViewerPullRequest on PullRequest {
+	id
+	number
+	title
+	body
+	baseRefName
+	headRefName
+	author {
+		login
+	}
+	isDraft
+	mergeable
+	reviewDecision
+	reviews(first: 100) {
+		nodes {
+			id
+			author {
+				login
+			}
+			state
+			commit {
+				oid
+			}
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+	timelineItems(first: 100, itemTypes: [REVIEW_DISMISSED_EVENT]) {
+		nodes {
+			... DismissedReview
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+	reviewRequests(first: 100) {
+		nodes {
+			requestedReviewer {
+				... RequestedUser
+				... RequestedTeam
+			}
+		}
+		pageInfo {
+			hasNextPage
+			endCursor
+		}
+	}
+	commits(last: 1) {
+		nodes {
+			commit {
+				oid
+				messageHeadline
+				messageBody
+				statusCheckRollup {
+					state
+				}
+				checkSuites(first: 20) {
+					totalCount
+					nodes {
+						checkRuns(first: 50) {
+							totalCount
+							nodes {
+								name
+								status
+								conclusion
+							}
+						}
+					}
+				}
+				status {
+					contexts {
+						context
+						state
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "ViewerPullRequests",
+		Query:         viewerPullRequestsOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"base_ref":   baseRef,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &ViewerPullRequestsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *ViewerPullRequestsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*ViewerPullRequestsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestCommitsRepository struct {
+	PullRequest *PullRequestCommitsRepositoryPullRequest
+}
+
+type PullRequestCommitsRepositoryPullRequest struct {
+	Commits fezzik_types.PullRequestCommitConnection
+}
+
+// PullRequestCommitsResponse response type for PullRequestCommits
+type PullRequestCommitsResponse struct {
+	Repository *PullRequestCommitsRepository
+}
+
+// PullRequestCommits from github/githubclient/queries.graphql:216
+func (c *gqlclient) PullRequestCommits(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	number int,
+	endCursor *string,
+) (*PullRequestCommitsResponse, error) {
+
+	var pullRequestCommitsOperation string = `
+	query PullRequestCommits ($repo_owner: String!, $repo_name: String!, $number: Int!, $end_cursor: String) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequest(number: $number) {
+			commits(first: 100, after: $end_cursor) {
+				nodes {
+					commit {
+						oid
+						messageHeadline
+						messageBody
+						statusCheckRollup {
+							state
+						}
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestCommits",
+		Query:         pullRequestCommitsOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"number":     number,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestCommitsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestCommitsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestCommitsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestReviewsRepository struct {
+	PullRequest *PullRequestReviewsRepositoryPullRequest
+}
+
+type PullRequestReviewsRepositoryPullRequest struct {
+	Reviews *fezzik_types.PullRequestReviewConnection
+}
+
+// PullRequestReviewsResponse response type for PullRequestReviews
+type PullRequestReviewsResponse struct {
+	Repository *PullRequestReviewsRepository
+}
+
+// PullRequestReviews from github/githubclient/queries.graphql:244
+func (c *gqlclient) PullRequestReviews(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	number int,
+	endCursor *string,
+) (*PullRequestReviewsResponse, error) {
+
+	var pullRequestReviewsOperation string = `
+	query PullRequestReviews ($repo_owner: String!, $repo_name: String!, $number: Int!, $end_cursor: String) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequest(number: $number) {
+			reviews(first: 100, after: $end_cursor) {
+				nodes {
+					id
+					author {
+						login
+					}
+					state
+					commit {
+						oid
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestReviews",
+		Query:         pullRequestReviewsOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"number":     number,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestReviewsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestReviewsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestReviewsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestDismissalsRepository struct {
+	PullRequest *PullRequestDismissalsRepositoryPullRequest
+}
+
+type PullRequestDismissalsRepositoryPullRequest struct {
+	TimelineItems fezzik_types.PullRequestTimelineItemsConnection
+}
+
+// PullRequestDismissalsResponse response type for PullRequestDismissals
+type PullRequestDismissalsResponse struct {
+	Repository *PullRequestDismissalsRepository
+}
+
+// PullRequestDismissals from github/githubclient/queries.graphql:272
+func (c *gqlclient) PullRequestDismissals(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	number int,
+	endCursor *string,
+) (*PullRequestDismissalsResponse, error) {
+
+	var pullRequestDismissalsOperation string = `
+	query PullRequestDismissals ($repo_owner: String!, $repo_name: String!, $number: Int!, $end_cursor: String) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequest(number: $number) {
+			timelineItems(first: 100, after: $end_cursor, itemTypes: [REVIEW_DISMISSED_EVENT]) {
+				nodes {
+					... DismissedReview
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+}
+fragment DismissedReview on ReviewDismissedEvent {
+	previousReviewState
+	review {
+		id
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestDismissals",
+		Query:         pullRequestDismissalsOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"number":     number,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestDismissalsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestDismissalsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestDismissalsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestReviewRequestsRepository struct {
+	PullRequest *PullRequestReviewRequestsRepositoryPullRequest
+}
+
+type PullRequestReviewRequestsRepositoryPullRequest struct {
+	ReviewRequests *fezzik_types.ReviewRequestConnection
+}
+
+// PullRequestReviewRequestsResponse response type for PullRequestReviewRequests
+type PullRequestReviewRequestsResponse struct {
+	Repository *PullRequestReviewRequestsRepository
+}
+
+// PullRequestReviewRequests from github/githubclient/queries.graphql:293
+func (c *gqlclient) PullRequestReviewRequests(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	number int,
+	endCursor *string,
+) (*PullRequestReviewRequestsResponse, error) {
+
+	var pullRequestReviewRequestsOperation string = `
+	query PullRequestReviewRequests ($repo_owner: String!, $repo_name: String!, $number: Int!, $end_cursor: String) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequest(number: $number) {
+			reviewRequests(first: 100, after: $end_cursor) {
+				nodes {
+					requestedReviewer {
+						... RequestedUser
+						... RequestedTeam
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+}
+fragment RequestedUser on User {
+	login
+}
+fragment RequestedTeam on Team {
+	slug
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestReviewRequests",
+		Query:         pullRequestReviewRequestsOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"number":     number,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestReviewRequestsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestReviewRequestsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestReviewRequestsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestsByHeadRepository struct {
+	PullRequests fezzik_types.PullRequestConnection
+}
+
+// PullRequestsByHeadResponse response type for PullRequestsByHead
+type PullRequestsByHeadResponse struct {
+	Repository *PullRequestsByHeadRepository
+}
+
+// PullRequestsByHead from github/githubclient/queries.graphql:317
+func (c *gqlclient) PullRequestsByHead(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	headRef string,
+) (*PullRequestsByHeadResponse, error) {
+
+	var pullRequestsByHeadOperation string = `
+	query PullRequestsByHead ($repo_owner: String!, $repo_name: String!, $head_ref: String!) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequests(first: 1, states: [OPEN], headRefName: $head_ref) {
+			nodes {
+				id
+				number
+				title
+				body
+				baseRefName
+				headRefName
+				isDraft
+				mergeable
+				reviewDecision
+				repository {
+					id
+				}
+				reviews(first: 100) {
+					nodes {
+						id
+						author {
+							login
+						}
+						state
+						commit {
+							oid
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				timelineItems(first: 100, itemTypes: [REVIEW_DISMISSED_EVENT]) {
+					nodes {
+						... DismissedReview
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				reviewRequests(first: 100) {
+					nodes {
+						requestedReviewer {
+							... RequestedUser
+							... RequestedTeam
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+				commits(first: 100) {
+					nodes {
+						commit {
+							oid
+							messageHeadline
+							messageBody
+							statusCheckRollup {
+								state
+							}
+						}
+					}
+					pageInfo {
+						hasNextPage
+						endCursor
+					}
+				}
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+fragment DismissedReview on ReviewDismissedEvent {
+	previousReviewState
+	review {
+		id
+	}
+}
+fragment RequestedUser on User {
+	login
+}
+fragment RequestedTeam on Team {
+	slug
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestsByHead",
+		Query:         pullRequestsByHeadOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"head_ref":   headRef,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestsByHeadResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestsByHeadResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestsByHeadResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type PullRequestBranchesRepository struct {
+	PullRequest *PullRequestBranchesRepositoryPullRequest
+}
+
+type PullRequestBranchesRepositoryPullRequest struct {
+	Number      int
+	Title       string
+	State       PullRequestState
+	BaseRefName string
+	HeadRefName string
+}
+
+// PullRequestBranchesResponse response type for PullRequestBranches
+type PullRequestBranchesResponse struct {
+	Repository *PullRequestBranchesRepository
+}
+
+// PullRequestBranches from github/githubclient/queries.graphql:399
+func (c *gqlclient) PullRequestBranches(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	number int,
+) (*PullRequestBranchesResponse, error) {
+
+	var pullRequestBranchesOperation string = `
+	query PullRequestBranches ($repo_owner: String!, $repo_name: String!, $number: Int!) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		pullRequest(number: $number) {
+			number
+			title
+			state
+			baseRefName
+			headRefName
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestBranches",
+		Query:         pullRequestBranchesOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"number":     number,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestBranchesResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestBranchesResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestBranchesResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type AssignableUsersRepository struct {
+	AssignableUsers AssignableUsersRepositoryAssignableUsers
+}
+
+type AssignableUsersRepositoryAssignableUsers struct {
+	Nodes    *AssignableUsersRepositoryAssignableUsersNodes
+	PageInfo fezzik_types.PageInfo
+}
+
+type AssignableUsersRepositoryAssignableUsersNodes []*struct {
+	Id    string
+	Login string
+	Name  *string
+}
+
+// AssignableUsersResponse response type for AssignableUsers
+type AssignableUsersResponse struct {
+	Repository *AssignableUsersRepository
+}
+
+// AssignableUsers from github/githubclient/queries.graphql:415
+func (c *gqlclient) AssignableUsers(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	endCursor *string,
+) (*AssignableUsersResponse, error) {
+
+	var assignableUsersOperation string = `
+	query AssignableUsers ($repo_owner: String!, $repo_name: String!, $end_cursor: String) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		assignableUsers(first: 100, after: $end_cursor) {
+			nodes {
+				id
+				login
+				name
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "AssignableUsers",
+		Query:         assignableUsersOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"end_cursor": endCursor,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &AssignableUsersResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *AssignableUsersResponse
+	if resp.Data != nil {
+		data = resp.Data.(*AssignableUsersResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type RepositoryIDRepository struct {
+	Id string
+}
+
+// RepositoryIDResponse response type for RepositoryID
+type RepositoryIDResponse struct {
+	Repository *RepositoryIDRepository
+}
+
+// RepositoryID from github/githubclient/queries.graphql:435
+func (c *gqlclient) RepositoryID(ctx context.Context,
+	repoOwner string,
+	repoName string,
+) (*RepositoryIDResponse, error) {
+
+	var repositoryIDOperation string = `
+	query RepositoryID ($repo_owner: String!, $repo_name: String!) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		id
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "RepositoryID",
+		Query:         repositoryIDOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &RepositoryIDResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *RepositoryIDResponse
+	if resp.Data != nil {
+		data = resp.Data.(*RepositoryIDResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type ViewerViewer struct {
+	Login string
+}
+
+// ViewerResponse response type for Viewer
+type ViewerResponse struct {
+	Viewer ViewerViewer
+}
+
+// Viewer from github/githubclient/queries.graphql:444
+func (c *gqlclient) Viewer(ctx context.Context) (*ViewerResponse, error) {
+
+	var viewerOperation string = `
+	query Viewer {
+	viewer {
+		login
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "Viewer",
+		Query:         viewerOperation,
+		Variables:     map[string]interface{}{},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &ViewerResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *ViewerResponse
+	if resp.Data != nil {
+		data = resp.Data.(*ViewerResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type TeamIDOrganization struct {
+	Team *TeamIDOrganizationTeam
+}
+
+type TeamIDOrganizationTeam struct {
+	Id string
+}
+
+// TeamIDResponse response type for TeamID
+type TeamIDResponse struct {
+	Organization *TeamIDOrganization
+}
+
+// TeamID from github/githubclient/queries.graphql:450
+func (c *gqlclient) TeamID(ctx context.Context,
+	org string,
+	slug string,
+) (*TeamIDResponse, error) {
+
+	var teamIDOperation string = `
+	query TeamID ($org: String!, $slug: String!) {
+	organization(login: $org) {
+		team(slug: $slug) {
+			id
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "TeamID",
+		Query:         teamIDOperation,
+		Variables: map[string]interface{}{
+			"org":  org,
+			"slug": slug,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &TeamIDResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *TeamIDResponse
+	if resp.Data != nil {
+		data = resp.Data.(*TeamIDResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type LabelIDRepository struct {
+	Label *LabelIDRepositoryLabel
+}
+
+type LabelIDRepositoryLabel struct {
+	Id string
+}
+
+// LabelIDResponse response type for LabelID
+type LabelIDResponse struct {
+	Repository *LabelIDRepository
+}
+
+// LabelID from github/githubclient/queries.graphql:461
+func (c *gqlclient) LabelID(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	name string,
+) (*LabelIDResponse, error) {
+
+	var labelIDOperation string = `
+	query LabelID ($repo_owner: String!, $repo_name: String!, $name: String!) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		label(name: $name) {
+			id
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "LabelID",
+		Query:         labelIDOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"name":       name,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &LabelIDResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *LabelIDResponse
+	if resp.Data != nil {
+		data = resp.Data.(*LabelIDResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type MilestonesRepository struct {
+	Milestones *MilestonesRepositoryMilestones
 }
 
-type AssignableUsersRepositoryAssignableUsersNodes []*struct {
-	Id    string
-	Login string
-	Name  *string
+type MilestonesRepositoryMilestones struct {
+	Nodes *MilestonesRepositoryMilestonesNodes
 }
 
-type AssignableUsersRepositoryAssignableUsersPageInfo struct {
-	HasNextPage bool
-	EndCursor   *string
+type MilestonesRepositoryMilestonesNodes []*struct {
+	Id    string
+	Title string
 }
 
-// AssignableUsersResponse response type for AssignableUsers
-type AssignableUsersResponse struct {
-	Repository *AssignableUsersRepository
+// MilestonesResponse response type for Milestones
+type MilestonesResponse struct {
+	Repository *MilestonesRepository
 }
 
-// AssignableUsers from github/githubclient/queries.graphql:82
-func (c *gqlclient) AssignableUsers(ctx context.Context,
+// Milestones from github/githubclient/queries.graphql:473
+func (c *gqlclient) Milestones(ctx context.Context,
 	repoOwner string,
 	repoName string,
-	endCursor *string,
-) (*AssignableUsersResponse, error) {
+	title string,
+) (*MilestonesResponse, error) {
 
-	var assignableUsersOperation string = `
-	query AssignableUsers ($repo_owner: String!, $repo_name: String!, $end_cursor: String) {
+	var milestonesOperation string = `
+	query Milestones ($repo_owner: String!, $repo_name: String!, $title: String!) {
 	repository(owner: $repo_owner, name: $repo_name) {
-		assignableUsers(first: 100, after: $end_cursor) {
+		milestones(first: 100, states: [OPEN], query: $title) {
 			nodes {
 				id
-				login
-				name
-			}
-			pageInfo {
-				hasNextPage
-				endCursor
+				title
 			}
 		}
 	}
@@ -241,17 +1369,17 @@ func (c *gqlclient) AssignableUsers(ctx context.Context,
 `
 
 	gqlreq := &client.GQLRequest{
-		OperationName: "AssignableUsers",
-		Query:         assignableUsersOperation,
+		OperationName: "Milestones",
+		Query:         milestonesOperation,
 		Variables: map[string]interface{}{
 			"repo_owner": repoOwner,
 			"repo_name":  repoName,
-			"end_cursor": endCursor,
+			"title":      title,
 		},
 	}
 
 	resp := &client.GQLResponse{
-		Data: &AssignableUsersResponse{},
+		Data: &MilestonesResponse{},
 	}
 
 	err := c.gql.Query(ctx, gqlreq, resp)
@@ -259,9 +1387,9 @@ func (c *gqlclient) AssignableUsers(ctx context.Context,
 		return nil, err
 	}
 
-	var data *AssignableUsersResponse
+	var data *MilestonesResponse
 	if resp.Data != nil {
-		data = resp.Data.(*AssignableUsersResponse)
+		data = resp.Data.(*MilestonesResponse)
 	}
 
 	if resp.Errors == nil {
@@ -285,7 +1413,7 @@ type CreatePullRequestResponse struct {
 	CreatePullRequest *CreatePullRequestCreatePullRequest
 }
 
-// CreatePullRequest from github/githubclient/queries.graphql:102
+// CreatePullRequest from github/githubclient/queries.graphql:488
 func (c *gqlclient) CreatePullRequest(ctx context.Context,
 	input CreatePullRequestInput,
 ) (*CreatePullRequestResponse, error) {
@@ -343,7 +1471,7 @@ type UpdatePullRequestResponse struct {
 	UpdatePullRequest *UpdatePullRequestUpdatePullRequest
 }
 
-// UpdatePullRequest from github/githubclient/queries.graphql:115
+// UpdatePullRequest from github/githubclient/queries.graphql:501
 func (c *gqlclient) UpdatePullRequest(ctx context.Context,
 	input UpdatePullRequestInput,
 ) (*UpdatePullRequestResponse, error) {
@@ -400,7 +1528,7 @@ type AddReviewersResponse struct {
 	RequestReviews *AddReviewersRequestReviews
 }
 
-// AddReviewers from github/githubclient/queries.graphql:127
+// AddReviewers from github/githubclient/queries.graphql:513
 func (c *gqlclient) AddReviewers(ctx context.Context,
 	input RequestReviewsInput,
 ) (*AddReviewersResponse, error) {
@@ -444,6 +1572,261 @@ func (c *gqlclient) AddReviewers(ctx context.Context,
 	return data, resp.Errors
 }
 
+type AddLabelsAddLabelsToLabelable struct {
+	ClientMutationId *string
+}
+
+// AddLabelsResponse response type for AddLabels
+type AddLabelsResponse struct {
+	AddLabelsToLabelable *AddLabelsAddLabelsToLabelable
+}
+
+// AddLabels from github/githubclient/queries.graphql:525
+func (c *gqlclient) AddLabels(ctx context.Context,
+	input AddLabelsToLabelableInput,
+) (*AddLabelsResponse, error) {
+
+	var addLabelsOperation string = `
+	mutation AddLabels ($input: AddLabelsToLabelableInput!) {
+	addLabelsToLabelable(input: $input) {
+		clientMutationId
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "AddLabels",
+		Query:         addLabelsOperation,
+		Variables: map[string]interface{}{
+			"input": input,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &AddLabelsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *AddLabelsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*AddLabelsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type RemoveLabelsRemoveLabelsFromLabelable struct {
+	ClientMutationId *string
+}
+
+// RemoveLabelsResponse response type for RemoveLabels
+type RemoveLabelsResponse struct {
+	RemoveLabelsFromLabelable *RemoveLabelsRemoveLabelsFromLabelable
+}
+
+// RemoveLabels from github/githubclient/queries.graphql:535
+func (c *gqlclient) RemoveLabels(ctx context.Context,
+	input RemoveLabelsFromLabelableInput,
+) (*RemoveLabelsResponse, error) {
+
+	var removeLabelsOperation string = `
+	mutation RemoveLabels ($input: RemoveLabelsFromLabelableInput!) {
+	removeLabelsFromLabelable(input: $input) {
+		clientMutationId
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "RemoveLabels",
+		Query:         removeLabelsOperation,
+		Variables: map[string]interface{}{
+			"input": input,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &RemoveLabelsResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *RemoveLabelsResponse
+	if resp.Data != nil {
+		data = resp.Data.(*RemoveLabelsResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type AddAssigneesAddAssigneesToAssignable struct {
+	ClientMutationId *string
+}
+
+// AddAssigneesResponse response type for AddAssignees
+type AddAssigneesResponse struct {
+	AddAssigneesToAssignable *AddAssigneesAddAssigneesToAssignable
+}
+
+// AddAssignees from github/githubclient/queries.graphql:545
+func (c *gqlclient) AddAssignees(ctx context.Context,
+	input AddAssigneesToAssignableInput,
+) (*AddAssigneesResponse, error) {
+
+	var addAssigneesOperation string = `
+	mutation AddAssignees ($input: AddAssigneesToAssignableInput!) {
+	addAssigneesToAssignable(input: $input) {
+		clientMutationId
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "AddAssignees",
+		Query:         addAssigneesOperation,
+		Variables: map[string]interface{}{
+			"input": input,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &AddAssigneesResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *AddAssigneesResponse
+	if resp.Data != nil {
+		data = resp.Data.(*AddAssigneesResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type ConvertPullRequestToDraftConvertPullRequestToDraft struct {
+	ClientMutationId *string
+}
+
+// ConvertPullRequestToDraftResponse response type for ConvertPullRequestToDraft
+type ConvertPullRequestToDraftResponse struct {
+	ConvertPullRequestToDraft *ConvertPullRequestToDraftConvertPullRequestToDraft
+}
+
+// ConvertPullRequestToDraft from github/githubclient/queries.graphql:555
+func (c *gqlclient) ConvertPullRequestToDraft(ctx context.Context,
+	input ConvertPullRequestToDraftInput,
+) (*ConvertPullRequestToDraftResponse, error) {
+
+	var convertPullRequestToDraftOperation string = `
+	mutation ConvertPullRequestToDraft ($input: ConvertPullRequestToDraftInput!) {
+	convertPullRequestToDraft(input: $input) {
+		clientMutationId
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "ConvertPullRequestToDraft",
+		Query:         convertPullRequestToDraftOperation,
+		Variables: map[string]interface{}{
+			"input": input,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &ConvertPullRequestToDraftResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *ConvertPullRequestToDraftResponse
+	if resp.Data != nil {
+		data = resp.Data.(*ConvertPullRequestToDraftResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
+type MarkPullRequestReadyForReviewMarkPullRequestReadyForReview struct {
+	ClientMutationId *string
+}
+
+// MarkPullRequestReadyForReviewResponse response type for MarkPullRequestReadyForReview
+type MarkPullRequestReadyForReviewResponse struct {
+	MarkPullRequestReadyForReview *MarkPullRequestReadyForReviewMarkPullRequestReadyForReview
+}
+
+// MarkPullRequestReadyForReview from github/githubclient/queries.graphql:565
+func (c *gqlclient) MarkPullRequestReadyForReview(ctx context.Context,
+	input MarkPullRequestReadyForReviewInput,
+) (*MarkPullRequestReadyForReviewResponse, error) {
+
+	var markPullRequestReadyForReviewOperation string = `
+	mutation MarkPullRequestReadyForReview ($input: MarkPullRequestReadyForReviewInput!) {
+	markPullRequestReadyForReview(input: $input) {
+		clientMutationId
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "MarkPullRequestReadyForReview",
+		Query:         markPullRequestReadyForReviewOperation,
+		Variables: map[string]interface{}{
+			"input": input,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &MarkPullRequestReadyForReviewResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *MarkPullRequestReadyForReviewResponse
+	if resp.Data != nil {
+		data = resp.Data.(*MarkPullRequestReadyForReviewResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
+
 type CommentPullRequestAddComment struct {
 	ClientMutationId *string
 }
@@ -453,7 +1836,7 @@ type CommentPullRequestResponse struct {
 	AddComment *CommentPullRequestAddComment
 }
 
-// CommentPullRequest from github/githubclient/queries.graphql:139
+// CommentPullRequest from github/githubclient/queries.graphql:575
 func (c *gqlclient) CommentPullRequest(ctx context.Context,
 	input AddCommentInput,
 ) (*CommentPullRequestResponse, error) {
@@ -508,7 +1891,7 @@ type MergePullRequestResponse struct {
 	MergePullRequest *MergePullRequestMergePullRequest
 }
 
-// MergePullRequest from github/githubclient/queries.graphql:149
+// MergePullRequest from github/githubclient/queries.graphql:585
 func (c *gqlclient) MergePullRequest(ctx context.Context,
 	input MergePullRequestInput,
 ) (*MergePullRequestResponse, error) {
@@ -565,7 +1948,7 @@ type AutoMergePullRequestResponse struct {
 	EnablePullRequestAutoMerge *AutoMergePullRequestEnablePullRequestAutoMerge
 }
 
-// AutoMergePullRequest from github/githubclient/queries.graphql:161
+// AutoMergePullRequest from github/githubclient/queries.graphql:597
 func (c *gqlclient) AutoMergePullRequest(ctx context.Context,
 	input EnablePullRequestAutoMergeInput,
 ) (*AutoMergePullRequestResponse, error) {
@@ -622,7 +2005,7 @@ type ClosePullRequestResponse struct {
 	ClosePullRequest *ClosePullRequestClosePullRequest
 }
 
-// ClosePullRequest from github/githubclient/queries.graphql:173
+// ClosePullRequest from github/githubclient/queries.graphql:609
 func (c *gqlclient) ClosePullRequest(ctx context.Context,
 	input ClosePullRequestInput,
 ) (*ClosePullRequestResponse, error) {
@@ -689,7 +2072,7 @@ type StarCheckResponse struct {
 	Viewer StarCheckViewer
 }
 
-// StarCheck from github/githubclient/queries.graphql:185
+// StarCheck from github/githubclient/queries.graphql:621
 func (c *gqlclient) StarCheck(ctx context.Context,
 	after *string,
 ) (*StarCheckResponse, error) {
@@ -748,7 +2131,7 @@ type StarGetRepoResponse struct {
 	Repository *StarGetRepoRepository
 }
 
-// StarGetRepo from github/githubclient/queries.graphql:201
+// StarGetRepo from github/githubclient/queries.graphql:637
 func (c *gqlclient) StarGetRepo(ctx context.Context,
 	owner string,
 	name string,
@@ -801,7 +2184,7 @@ type StarAddResponse struct {
 	AddStar *StarAddAddStar
 }
 
-// StarAdd from github/githubclient/queries.graphql:210
+// StarAdd from github/githubclient/queries.graphql:646
 func (c *gqlclient) StarAdd(ctx context.Context,
 	input AddStarInput,
 ) (*StarAddResponse, error) {
@@ -842,3 +2225,117 @@ func (c *gqlclient) StarAdd(ctx context.Context,
 
 	return data, resp.Errors
 }
+
+type PullRequestChecksRepository struct {
+	Ref         *PullRequestChecksRepositoryRef
+	PullRequest *PullRequestChecksRepositoryPullRequest
+}
+
+type PullRequestChecksRepositoryRef struct {
+	BranchProtectionRule *PullRequestChecksRepositoryRefBranchProtectionRule
+}
+
+type PullRequestChecksRepositoryRefBranchProtectionRule struct {
+	RequiredStatusCheckContexts *[]*string
+}
+
+type PullRequestChecksRepositoryPullRequest struct {
+	Commits fezzik_types.PullRequestCommitConnection
+}
+
+// PullRequestChecksResponse response type for PullRequestChecks
+type PullRequestChecksResponse struct {
+	Repository *PullRequestChecksRepository
+}
+
+// PullRequestChecks from github/githubclient/queries.graphql:654
+func (c *gqlclient) PullRequestChecks(ctx context.Context,
+	repoOwner string,
+	repoName string,
+	baseRef string,
+	number int,
+) (*PullRequestChecksResponse, error) {
+
+	var pullRequestChecksOperation string = `
+	query PullRequestChecks ($repo_owner: String!, $repo_name: String!, $base_ref: String!, $number: Int!) {
+	repository(owner: $repo_owner, name: $repo_name) {
+		ref(qualifiedName: $base_ref) {
+			branchProtectionRule {
+				requiredStatusCheckContexts
+			}
+		}
+		pullRequest(number: $number) {
+			commits(last: 1) {
+				nodes {
+					commit {
+						oid
+						checkSuites(first: 100) {
+							totalCount
+							nodes {
+								app {
+									name
+								}
+								workflowRun {
+									workflow {
+										name
+									}
+								}
+								checkRuns(first: 100) {
+									totalCount
+									nodes {
+										name
+										status
+										conclusion
+										startedAt
+										completedAt
+										detailsUrl
+									}
+								}
+							}
+						}
+						status {
+							contexts {
+								context
+								state
+								targetUrl
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+	gqlreq := &client.GQLRequest{
+		OperationName: "PullRequestChecks",
+		Query:         pullRequestChecksOperation,
+		Variables: map[string]interface{}{
+			"repo_owner": repoOwner,
+			"repo_name":  repoName,
+			"base_ref":   baseRef,
+			"number":     number,
+		},
+	}
+
+	resp := &client.GQLResponse{
+		Data: &PullRequestChecksResponse{},
+	}
+
+	err := c.gql.Query(ctx, gqlreq, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *PullRequestChecksResponse
+	if resp.Data != nil {
+		data = resp.Data.(*PullRequestChecksResponse)
+	}
+
+	if resp.Errors == nil {
+		return data, nil
+	}
+
+	return data, resp.Errors
+}
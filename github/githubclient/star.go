@@ -7,7 +7,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/ejoffe/rake"
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/config/config_parser"
 	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
@@ -31,8 +30,7 @@ func (c *client) MaybeStar(ctx context.Context, cfg *config.Config) {
 			line = strings.TrimSpace(line)
 			if line != "n" {
 				cfg.State.Stargazer = true
-				rake.LoadSources(cfg.State,
-					rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
+				writeState(cfg)
 				fmt.Println("Thank You! Happy Coding!")
 			}
 		}
@@ -40,8 +38,7 @@ func (c *client) MaybeStar(ctx context.Context, cfg *config.Config) {
 		if starred {
 			log.Debug().Bool("stargazer", true).Msg("MaybeStar")
 			cfg.State.Stargazer = true
-			rake.LoadSources(cfg.State,
-				rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
+			writeState(cfg)
 		} else {
 			log.Debug().Bool("stargazer", false).Msg("MaybeStar")
 			fmt.Print("enjoying git spr? add a GitHub star? [Y/n]:")
@@ -50,10 +47,13 @@ func (c *client) MaybeStar(ctx context.Context, cfg *config.Config) {
 			line = strings.TrimSpace(line)
 			if line != "n" {
 				log.Debug().Msg("MaybeStar : adding star")
-				c.addStar(ctx)
+				err := c.addStar(ctx)
+				if err != nil {
+					log.Warn().Err(err).Msg("MaybeStar : adding star failed")
+					return
+				}
 				cfg.State.Stargazer = true
-				rake.LoadSources(cfg.State,
-					rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
+				writeState(cfg)
 				fmt.Println("Thank You! Happy Coding!")
 			}
 		}
@@ -95,12 +95,22 @@ func (c *client) isStar(ctx context.Context) (bool, error) {
 	}
 }
 
-func (c *client) addStar(ctx context.Context) {
+func (c *client) addStar(ctx context.Context) error {
 	resp, err := c.api.StarGetRepo(ctx, sprRepoOwner, sprRepoName)
-	check(err)
+	if err != nil {
+		return err
+	}
 
 	_, err = c.api.StarAdd(ctx, genclient.AddStarInput{
 		StarrableId: resp.Repository.Id,
 	})
-	check(err)
+	return err
+}
+
+// writeState saves the stargazer state, failing to save it only means the prompt shows up again
+func writeState(cfg *config.Config) {
+	err := config_parser.WriteInternalState(cfg.State)
+	if err != nil {
+		log.Warn().Err(err).Msg("MaybeStar : saving state failed")
+	}
 }
@@ -0,0 +1,236 @@
+package githubclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
+)
+
+// cachedID returns the id of the repository, or of a label, assignee or milestone named in a commit trailer,
+//
+//	lookup is only called the first time a name is seen. Pull requests are updated
+//	in parallel so the cache is locked.
+func (c *client) cachedID(ctx context.Context, kind string, name string,
+	lookup func(ctx context.Context, name string) (string, error)) (string, error) {
+	key := kind + ":" + strings.ToLower(name)
+	c.mu.Lock()
+	id, found := c.ids[key]
+	c.mu.Unlock()
+	if found {
+		return id, nil
+	}
+	id, err := lookup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	if c.ids == nil {
+		c.ids = map[string]string{}
+	}
+	c.ids[key] = id
+	c.mu.Unlock()
+	return id, nil
+}
+
+// repositoryID returns the id of the repository, pull requests are created in it
+func (c *client) repositoryID(ctx context.Context) (string, error) {
+	owner, name := c.config.Repo.GitHubRepoOwner, c.config.Repo.GitHubRepoName
+	return c.cachedID(ctx, "repository", owner+"/"+name, func(ctx context.Context, _ string) (string, error) {
+		if c.config.User.LogGitHubCalls {
+			fmt.Printf("> github get repository %s/%s\n", owner, name)
+		}
+		resp, err := c.api.RepositoryID(ctx, owner, name)
+		if err != nil {
+			return "", fmt.Errorf("get repository %s/%s failed: %w", owner, name, github.ClassifyError(err))
+		}
+		if resp.Repository == nil {
+			return "", fmt.Errorf("repository %s/%s not found", owner, name)
+		}
+		return resp.Repository.Id, nil
+	})
+}
+
+func (c *client) labelID(ctx context.Context, name string) (string, error) {
+	return c.cachedID(ctx, "label", name, func(ctx context.Context, name string) (string, error) {
+		if c.config.User.LogGitHubCalls {
+			fmt.Printf("> github get label %s\n", name)
+		}
+		resp, err := c.api.LabelID(ctx, c.config.Repo.GitHubRepoOwner, c.config.Repo.GitHubRepoName, name)
+		if err != nil {
+			return "", fmt.Errorf("get label %s failed: %w", name, github.ClassifyError(err))
+		}
+		if resp.Repository == nil || resp.Repository.Label == nil {
+			return "", fmt.Errorf("label %q not found", name)
+		}
+		return resp.Repository.Label.Id, nil
+	})
+}
+
+func (c *client) assigneeID(ctx context.Context, login string) (string, error) {
+	return c.cachedID(ctx, "user", login, func(ctx context.Context, login string) (string, error) {
+		users, err := c.GetAssignableUsers(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, u := range users {
+			if strings.EqualFold(login, u.Login) {
+				return u.ID, nil
+			}
+		}
+		return "", fmt.Errorf("unable to add assignee, user %q not found", login)
+	})
+}
+
+func (c *client) milestoneID(ctx context.Context, title string) (string, error) {
+	return c.cachedID(ctx, "milestone", title, func(ctx context.Context, title string) (string, error) {
+		if c.config.User.LogGitHubCalls {
+			fmt.Printf("> github get milestone %s\n", title)
+		}
+		resp, err := c.api.Milestones(ctx, c.config.Repo.GitHubRepoOwner, c.config.Repo.GitHubRepoName, title)
+		if err != nil {
+			return "", fmt.Errorf("get milestone %s failed: %w", title, github.ClassifyError(err))
+		}
+		if resp.Repository != nil && resp.Repository.Milestones != nil && resp.Repository.Milestones.Nodes != nil {
+			for _, node := range *resp.Repository.Milestones.Nodes {
+				if strings.EqualFold(title, node.Title) {
+					return node.Id, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("unable to set milestone, open milestone %q not found", title)
+	})
+}
+
+// AddLabels adds the named labels to the pull request, labels must exist in the repository
+func (c *client) AddLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	labelIDs, err := c.labelIDs(ctx, labels)
+	if err != nil {
+		return err
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github add labels %d : %s - %v\n", pr.Number, pr.Title, labels)
+	}
+	_, err = c.api.AddLabels(ctx, genclient.AddLabelsToLabelableInput{
+		LabelableId: pr.ID,
+		LabelIds:    labelIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("add labels %v failed for #%d: %w", labels, pr.Number, github.ClassifyError(err))
+	}
+	return nil
+}
+
+// RemoveLabels removes the named labels from the pull request
+func (c *client) RemoveLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	labelIDs, err := c.labelIDs(ctx, labels)
+	if err != nil {
+		return err
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github remove labels %d : %s - %v\n", pr.Number, pr.Title, labels)
+	}
+	_, err = c.api.RemoveLabels(ctx, genclient.RemoveLabelsFromLabelableInput{
+		LabelableId: pr.ID,
+		LabelIds:    labelIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("remove labels %v failed for #%d: %w", labels, pr.Number, github.ClassifyError(err))
+	}
+	return nil
+}
+
+func (c *client) labelIDs(ctx context.Context, labels []string) ([]string, error) {
+	var labelIDs []string
+	for _, name := range labels {
+		id, err := c.labelID(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		labelIDs = append(labelIDs, id)
+	}
+	return labelIDs, nil
+}
+
+// AddAssignees assigns the pull request to the users with the given logins
+func (c *client) AddAssignees(ctx context.Context, pr *github.PullRequest, logins []string) error {
+	var assigneeIDs []string
+	for _, login := range logins {
+		id, err := c.assigneeID(ctx, login)
+		if err != nil {
+			return err
+		}
+		assigneeIDs = append(assigneeIDs, id)
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github add assignees %d : %s - %v\n", pr.Number, pr.Title, logins)
+	}
+	_, err := c.api.AddAssignees(ctx, genclient.AddAssigneesToAssignableInput{
+		AssignableId: pr.ID,
+		AssigneeIds:  assigneeIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("add assignees %v failed for #%d: %w", logins, pr.Number, github.ClassifyError(err))
+	}
+	return nil
+}
+
+// SetMilestone sets the milestone of the pull request to the open milestone with the given title
+func (c *client) SetMilestone(ctx context.Context, pr *github.PullRequest, title string) error {
+	milestoneID, err := c.milestoneID(ctx, title)
+	if err != nil {
+		return err
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> github set milestone %d : %s - %s\n", pr.Number, pr.Title, title)
+	}
+	_, err = c.api.UpdatePullRequest(ctx, genclient.UpdatePullRequestInput{
+		PullRequestId: pr.ID,
+		MilestoneId:   &milestoneID,
+	})
+	if err != nil {
+		return fmt.Errorf("set milestone %s failed for #%d: %w", title, pr.Number, github.ClassifyError(err))
+	}
+	return nil
+}
+
+// applyTrailers adds the labels and assignees of the commit trailers to the pull request
+//
+//	and converts it to a draft or marks it ready for review following the Draft:
+//	trailer. Labels and assignees already on the pull request are kept.
+func (c *client) applyTrailers(ctx context.Context, pr *github.PullRequest, commit git.Commit) error {
+	if len(commit.Labels) > 0 {
+		err := c.AddLabels(ctx, pr, commit.Labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(commit.Assignees) > 0 {
+		err := c.AddAssignees(ctx, pr, commit.Assignees)
+		if err != nil {
+			return err
+		}
+	}
+
+	if commit.Draft != nil && *commit.Draft != pr.Draft {
+		var err error
+		if *commit.Draft {
+			_, err = c.api.ConvertPullRequestToDraft(ctx, genclient.ConvertPullRequestToDraftInput{
+				PullRequestId: pr.ID,
+			})
+		} else {
+			_, err = c.api.MarkPullRequestReadyForReview(ctx, genclient.MarkPullRequestReadyForReviewInput{
+				PullRequestId: pr.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("changing draft state of #%d failed: %w", pr.Number, github.ClassifyError(err))
+		}
+		pr.Draft = *commit.Draft
+	}
+	return nil
+}
@@ -4,43 +4,89 @@ import (
 	"context"
 
 	"github.com/ejoffe/spr/git"
-	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
 )
 
-type GitHubInterface interface {
-	// GetInfo returns the list of pull requests from GitHub which match the local stack of commits
-	GetInfo(ctx context.Context, gitcmd git.GitInterface) *GitHubInfo
+// Forge is the code hosting service pull requests are created on.
+//
+//	GitHub pull requests and GitLab merge requests are both represented as
+//	PullRequest, numbered by the pull request number or merge request iid.
+type Forge interface {
+	// GetInfo returns the list of pull requests from the forge which match the local stack of commits
+	GetInfo(ctx context.Context, gitcmd git.GitInterface) (*ForgeInfo, error)
 
-	// GetAssignableUsers returns a list of valid GitHub users that can review the pull request
-	GetAssignableUsers(ctx context.Context) []RepoAssignee
+	// GetOpenPullRequests returns the open pull requests of the authenticated user on branches named by spr,
+	//  with their merge status and head commit. truncated is set when not all of them could be listed
+	GetOpenPullRequests(ctx context.Context) (pullRequests []*PullRequest, truncated bool, err error)
 
-	// CreatePullRequest creates a pull request
-	CreatePullRequest(ctx context.Context, gitcmd git.GitInterface, info *GitHubInfo, commit git.Commit, prevCommit *git.Commit) *PullRequest
+	// GetChecks returns the checks of the head commit of the pull request, with the
+	//  checks required to pass before merging marked as Required
+	GetChecks(ctx context.Context, pr *PullRequest) ([]Check, error)
 
-	// UpdatePullRequest updates a pull request with current commit
-	UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*PullRequest, pr *PullRequest, commit git.Commit, prevCommit *git.Commit)
+	// GetPullRequestStack returns the open pull request with the given number and the pull
+	//  requests below it, found through their base branches, ordered from the bottom of the stack
+	GetPullRequestStack(ctx context.Context, number int) ([]*PullRequest, error)
 
-	// AddReviewers adds a reviewer to the given pull request
-	AddReviewers(ctx context.Context, pr *PullRequest, userIDs []string)
+	// GetLogin returns the login of the authenticated user
+	GetLogin(ctx context.Context) (string, error)
+
+	// GetAssignableUsers returns a list of valid users that can review the pull request
+	GetAssignableUsers(ctx context.Context) ([]RepoAssignee, error)
+
+	// GetTeamID returns the id of the team named org/team, teams can be requested to review pull requests
+	GetTeamID(ctx context.Context, team string) (string, error)
+
+	// CreatePullRequest creates a pull request, as a draft when the commit has a Draft: true trailer.
+	//  info may be nil when the other pull requests of the stack aren't known yet
+	CreatePullRequest(ctx context.Context, gitcmd git.GitInterface, info *ForgeInfo, commit git.Commit, prevCommit *git.Commit) (*PullRequest, error)
+
+	// UpdatePullRequest updates a pull request with current commit, the labels, assignees,
+	//  milestone and draft state of the commit trailers are applied to the pull request
+	UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*PullRequest, pr *PullRequest, commit git.Commit, prevCommit *git.Commit) error
+
+	// AddReviewers requests review of the given pull request from users and teams,
+	//  reviewers already requested are kept
+	AddReviewers(ctx context.Context, pr *PullRequest, userIDs []string, teamIDs []string) error
+
+	// AddLabels adds the named labels to the pull request, labels already on it are kept
+	AddLabels(ctx context.Context, pr *PullRequest, labels []string) error
+
+	// RemoveLabels removes the named labels from the pull request
+	RemoveLabels(ctx context.Context, pr *PullRequest, labels []string) error
+
+	// AddAssignees assigns the pull request to the given user logins, assignees already on it are kept
+	AddAssignees(ctx context.Context, pr *PullRequest, logins []string) error
+
+	// SetMilestone sets the milestone of the pull request to the open milestone with the given title
+	SetMilestone(ctx context.Context, pr *PullRequest, title string) error
 
 	// CommentPullRequest add a comment to the given pull request
-	CommentPullRequest(ctx context.Context, pr *PullRequest, comment string)
+	CommentPullRequest(ctx context.Context, pr *PullRequest, comment string) error
 
 	// MergePullRequest merged the given pull request
-	MergePullRequest(ctx context.Context, pr *PullRequest, mergeMethod genclient.PullRequestMergeMethod)
+	MergePullRequest(ctx context.Context, pr *PullRequest, mergeMethod MergeMethod) error
 
 	// ClosePullRequest closes the given pull request
-	ClosePullRequest(ctx context.Context, pr *PullRequest)
+	ClosePullRequest(ctx context.Context, pr *PullRequest) error
 
-	// GetClient returns the genclient.Client
-	GetClient() genclient.Client
+	// RestorePullRequest reopens the given pull request and sets its base branch, title and body from pr
+	RestorePullRequest(ctx context.Context, pr *PullRequest) error
 }
 
-type GitHubInfo struct {
+// MaxPages is the number of pages fetched when listing pull requests, commits or reviews.
+//
+//	Listings which have more pages are cut and reported as truncated.
+const MaxPages = 50
+
+// ForgeInfo has the pull requests of the local stack, as returned by Forge.GetInfo
+type ForgeInfo struct {
 	UserName     string
 	RepositoryID string
 	LocalBranch  string
 	PullRequests []*PullRequest
+
+	// Truncated is set when there were more pull requests or commits than could be listed,
+	//  some pull requests may then be missing from PullRequests
+	Truncated bool
 }
 
 type RepoAssignee struct {
@@ -49,6 +95,6 @@ type RepoAssignee struct {
 	Name  string
 }
 
-func (i *GitHubInfo) Key() string {
+func (i *ForgeInfo) Key() string {
 	return i.RepositoryID + "_" + i.LocalBranch
 }
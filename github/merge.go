@@ -0,0 +1,32 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeMethod is how a pull request is merged into its base branch
+type MergeMethod string
+
+const (
+	MergeMethodMerge  MergeMethod = "MERGE"
+	MergeMethodSquash MergeMethod = "SQUASH"
+	MergeMethodRebase MergeMethod = "REBASE"
+)
+
+// ParseMergeMethod returns the merge method of the mergeMethod setting, rebase when it's empty
+func ParseMergeMethod(method string) (MergeMethod, error) {
+	switch strings.ToLower(method) {
+	case "merge":
+		return MergeMethodMerge, nil
+	case "squash":
+		return MergeMethodSquash, nil
+	case "rebase", "":
+		return MergeMethodRebase, nil
+	default:
+		return "", fmt.Errorf(
+			`unknown merge method %q, choose from "merge", "squash", or "rebase"`,
+			method,
+		)
+	}
+}
@@ -0,0 +1,46 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMergeMethod(t *testing.T) {
+	for _, tc := range []struct {
+		configValue string
+		expected    MergeMethod
+	}{
+		{
+			configValue: "rebase",
+			expected:    MergeMethodRebase,
+		},
+		{
+			configValue: "",
+			expected:    MergeMethodRebase,
+		},
+		{
+			configValue: "Merge",
+			expected:    MergeMethodMerge,
+		},
+		{
+			configValue: "SQUASH",
+			expected:    MergeMethodSquash,
+		},
+	} {
+		tcName := tc.configValue
+		if tcName == "" {
+			tcName = "<EMPTY>"
+		}
+		t.Run(tcName, func(t *testing.T) {
+			actual, err := ParseMergeMethod(tc.configValue)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+	t.Run("invalid", func(t *testing.T) {
+		actual, err := ParseMergeMethod("magic")
+		assert.Error(t, err)
+		assert.Empty(t, actual)
+	})
+}
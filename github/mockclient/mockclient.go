@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 	"sync"
 	"testing"
 
 	"github.com/ejoffe/spr/git"
 	"github.com/ejoffe/spr/github"
-	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,21 +27,22 @@ func NewMockClient(t *testing.T) *MockClient {
 
 type MockClient struct {
 	assert       *require.Assertions
-	Info         *github.GitHubInfo
+	Info         *github.ForgeInfo
+	Checks       map[int][]github.Check
 	expect       []expectation
 	expectMutex  sync.Mutex
 	Synchronized bool // When true code is executed without goroutines. Allows test to be deterministic
 }
 
-func (c *MockClient) GetInfo(ctx context.Context, gitcmd git.GitInterface) *github.GitHubInfo {
+func (c *MockClient) GetInfo(ctx context.Context, gitcmd git.GitInterface) (*github.ForgeInfo, error) {
 	fmt.Printf("HUB: GetInfo\n")
 	c.verifyExpectation(expectation{
 		op: getInfoOP,
 	})
-	return c.Info
+	return c.Info, nil
 }
 
-func (c *MockClient) GetAssignableUsers(ctx context.Context) []github.RepoAssignee {
+func (c *MockClient) GetAssignableUsers(ctx context.Context) ([]github.RepoAssignee, error) {
 	fmt.Printf("HUB: GetAssignableUsers\n")
 	c.verifyExpectation(expectation{
 		op: getAssignableUsersOP,
@@ -52,11 +53,11 @@ func (c *MockClient) GetAssignableUsers(ctx context.Context) []github.RepoAssign
 			Login: NobodyLogin,
 			Name:  "No Body",
 		},
-	}
+	}, nil
 }
 
-func (c *MockClient) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface, info *github.GitHubInfo,
-	commit git.Commit, prevCommit *git.Commit) *github.PullRequest {
+func (c *MockClient) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface, info *github.ForgeInfo,
+	commit git.Commit, prevCommit *git.Commit) (*github.PullRequest, error) {
 	fmt.Printf("HUB: CreatePullRequest\n")
 	c.verifyExpectation(expectation{
 		op:     createPullRequestOP,
@@ -79,54 +80,143 @@ func (c *MockClient) CreatePullRequest(ctx context.Context, gitcmd git.GitInterf
 			NoConflicts:    true,
 			Stacked:        true,
 		},
-	}
+	}, nil
 }
 
-func (c *MockClient) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) {
+func (c *MockClient) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) error {
 	fmt.Printf("HUB: UpdatePullRequest\n")
 	c.verifyExpectation(expectation{
 		op:     updatePullRequestOP,
 		commit: commit,
 		prev:   prevCommit,
 	})
+	return nil
+}
+
+func (c *MockClient) GetOpenPullRequests(ctx context.Context) ([]*github.PullRequest, bool, error) {
+	fmt.Printf("HUB: GetOpenPullRequests\n")
+	c.verifyExpectation(expectation{
+		op: getOpenPullRequestsOP,
+	})
+	return c.Info.PullRequests, false, nil
+}
+
+func (c *MockClient) GetChecks(ctx context.Context, pr *github.PullRequest) ([]github.Check, error) {
+	fmt.Printf("HUB: GetChecks %d\n", pr.Number)
+	c.verifyExpectation(expectation{
+		op: getChecksOP,
+	})
+	return c.Checks[pr.Number], nil
+}
+
+func (c *MockClient) GetPullRequestStack(ctx context.Context, number int) ([]*github.PullRequest, error) {
+	fmt.Printf("HUB: GetPullRequestStack\n")
+	c.verifyExpectation(expectation{
+		op: getPullRequestStackOP,
+	})
+	return c.Info.PullRequests, nil
 }
 
-func (c *MockClient) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string) {
+func (c *MockClient) GetLogin(ctx context.Context) (string, error) {
+	fmt.Printf("HUB: GetLogin\n")
+	c.verifyExpectation(expectation{
+		op: getLoginOP,
+	})
+	return NobodyLogin, nil
+}
+
+func (c *MockClient) GetTeamID(ctx context.Context, team string) (string, error) {
+	fmt.Printf("HUB: GetTeamID\n")
+	c.verifyExpectation(expectation{
+		op: getTeamIDOP,
+	})
+	return "T_" + team, nil
+}
+
+func (c *MockClient) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string, teamIDs []string) error {
 	c.verifyExpectation(expectation{
 		op:      addReviewersOP,
-		userIDs: userIDs,
+		userIDs: append(slices.Clone(userIDs), teamIDs...),
+	})
+	return nil
+}
+
+func (c *MockClient) AddLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	fmt.Printf("HUB: AddLabels\n")
+	c.verifyExpectation(expectation{
+		op:     addLabelsOP,
+		commit: pr.Commit,
+		names:  labels,
 	})
+	return nil
 }
 
-func (c *MockClient) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) {
+func (c *MockClient) RemoveLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	fmt.Printf("HUB: RemoveLabels\n")
+	c.verifyExpectation(expectation{
+		op:     removeLabelsOP,
+		commit: pr.Commit,
+		names:  labels,
+	})
+	return nil
+}
+
+func (c *MockClient) AddAssignees(ctx context.Context, pr *github.PullRequest, logins []string) error {
+	fmt.Printf("HUB: AddAssignees\n")
+	c.verifyExpectation(expectation{
+		op:     addAssigneesOP,
+		commit: pr.Commit,
+		names:  logins,
+	})
+	return nil
+}
+
+func (c *MockClient) SetMilestone(ctx context.Context, pr *github.PullRequest, title string) error {
+	fmt.Printf("HUB: SetMilestone\n")
+	c.verifyExpectation(expectation{
+		op:     setMilestoneOP,
+		commit: pr.Commit,
+		names:  []string{title},
+	})
+	return nil
+}
+
+func (c *MockClient) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) error {
 	fmt.Printf("HUB: CommentPullRequest\n")
 	c.verifyExpectation(expectation{
 		op:     commentPullRequestOP,
 		commit: pr.Commit,
 	})
+	return nil
 }
 
 func (c *MockClient) MergePullRequest(ctx context.Context,
-	pr *github.PullRequest, mergeMethod genclient.PullRequestMergeMethod) {
+	pr *github.PullRequest, mergeMethod github.MergeMethod) error {
 	fmt.Printf("HUB: MergePullRequest, method=%q\n", mergeMethod)
 	c.verifyExpectation(expectation{
 		op:          mergePullRequestOP,
 		commit:      pr.Commit,
 		mergeMethod: mergeMethod,
 	})
+	return nil
 }
 
-func (c *MockClient) ClosePullRequest(ctx context.Context, pr *github.PullRequest) {
+func (c *MockClient) ClosePullRequest(ctx context.Context, pr *github.PullRequest) error {
 	fmt.Printf("HUB: ClosePullRequest\n")
 	c.verifyExpectation(expectation{
 		op:     closePullRequestOP,
 		commit: pr.Commit,
 	})
+	return nil
 }
 
-func (c *MockClient) GetClient() genclient.Client {
-	// This client can't be used it is just to satisfy the interface
-	return genclient.NewClient("", nil)
+func (c *MockClient) RestorePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	fmt.Printf("HUB: RestorePullRequest\n")
+	c.verifyExpectation(expectation{
+		op:       restorePullRequestOP,
+		prNumber: pr.Number,
+	})
+	return nil
 }
 
 func (c *MockClient) ExpectGetInfo() {
@@ -147,6 +237,42 @@ func (c *MockClient) ExpectGetAssignableUsers() {
 	})
 }
 
+func (c *MockClient) ExpectGetOpenPullRequests() {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op: getOpenPullRequestsOP,
+	})
+}
+
+func (c *MockClient) ExpectGetChecks() {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op: getChecksOP,
+	})
+}
+
+func (c *MockClient) ExpectGetLogin() {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op: getLoginOP,
+	})
+}
+
+func (c *MockClient) ExpectGetTeamID() {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op: getTeamIDOP,
+	})
+}
+
 func (c *MockClient) ExpectCreatePullRequest(commit git.Commit, prev *git.Commit) {
 	c.expectMutex.Lock()
 	defer c.expectMutex.Unlock()
@@ -179,6 +305,50 @@ func (c *MockClient) ExpectAddReviewers(userIDs []string) {
 	})
 }
 
+func (c *MockClient) ExpectAddLabels(commit git.Commit, labels []string) {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op:     addLabelsOP,
+		commit: commit,
+		names:  labels,
+	})
+}
+
+func (c *MockClient) ExpectRemoveLabels(commit git.Commit, labels []string) {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op:     removeLabelsOP,
+		commit: commit,
+		names:  labels,
+	})
+}
+
+func (c *MockClient) ExpectAddAssignees(commit git.Commit, logins []string) {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op:     addAssigneesOP,
+		commit: commit,
+		names:  logins,
+	})
+}
+
+func (c *MockClient) ExpectSetMilestone(commit git.Commit, title string) {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op:     setMilestoneOP,
+		commit: commit,
+		names:  []string{title},
+	})
+}
+
 func (c *MockClient) ExpectCommentPullRequest(commit git.Commit) {
 	c.expectMutex.Lock()
 	defer c.expectMutex.Unlock()
@@ -189,7 +359,7 @@ func (c *MockClient) ExpectCommentPullRequest(commit git.Commit) {
 	})
 }
 
-func (c *MockClient) ExpectMergePullRequest(commit git.Commit, mergeMethod genclient.PullRequestMergeMethod) {
+func (c *MockClient) ExpectMergePullRequest(commit git.Commit, mergeMethod github.MergeMethod) {
 	c.expectMutex.Lock()
 	defer c.expectMutex.Unlock()
 
@@ -210,6 +380,16 @@ func (c *MockClient) ExpectClosePullRequest(commit git.Commit) {
 	})
 }
 
+func (c *MockClient) ExpectRestorePullRequest(number int) {
+	c.expectMutex.Lock()
+	defer c.expectMutex.Unlock()
+
+	c.expect = append(c.expect, expectation{
+		op:       restorePullRequestOP,
+		prNumber: number,
+	})
+}
+
 func (c *MockClient) verifyExpectation(actual expectation) {
 	c.expectMutex.Lock()
 	defer c.expectMutex.Unlock()
@@ -248,20 +428,32 @@ func (c *MockClient) ExpectationsMet() {
 type operation string
 
 const (
-	getInfoOP            operation = "GetInfo"
-	getAssignableUsersOP operation = "GetAssignableUsers"
-	createPullRequestOP  operation = "CreatePullRequest"
-	updatePullRequestOP  operation = "UpdatePullRequest"
-	addReviewersOP       operation = "AddReviewers"
-	commentPullRequestOP operation = "CommentPullRequest"
-	mergePullRequestOP   operation = "MergePullRequest"
-	closePullRequestOP   operation = "ClosePullRequest"
+	getInfoOP             operation = "GetInfo"
+	getAssignableUsersOP  operation = "GetAssignableUsers"
+	getTeamIDOP           operation = "GetTeamID"
+	getLoginOP            operation = "GetLogin"
+	getPullRequestStackOP operation = "GetPullRequestStack"
+	getOpenPullRequestsOP operation = "GetOpenPullRequests"
+	getChecksOP           operation = "GetChecks"
+	createPullRequestOP   operation = "CreatePullRequest"
+	updatePullRequestOP   operation = "UpdatePullRequest"
+	addReviewersOP        operation = "AddReviewers"
+	addLabelsOP           operation = "AddLabels"
+	removeLabelsOP        operation = "RemoveLabels"
+	addAssigneesOP        operation = "AddAssignees"
+	setMilestoneOP        operation = "SetMilestone"
+	commentPullRequestOP  operation = "CommentPullRequest"
+	mergePullRequestOP    operation = "MergePullRequest"
+	closePullRequestOP    operation = "ClosePullRequest"
+	restorePullRequestOP  operation = "RestorePullRequest"
 )
 
 type expectation struct {
 	op          operation
 	commit      git.Commit
 	prev        *git.Commit
-	mergeMethod genclient.PullRequestMergeMethod
+	mergeMethod github.MergeMethod
 	userIDs     []string
+	names       []string
+	prNumber    int
 }
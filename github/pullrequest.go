@@ -24,13 +24,14 @@ type PullRequest struct {
 	Merged      bool
 	Commits     []git.Commit
 	InQueue     bool
+	Draft       bool
 }
 
-type checkStatus int
+type CheckStatus int
 
 const (
 	// CheckStatusUnknown
-	CheckStatusUnknown checkStatus = iota
+	CheckStatusUnknown CheckStatus = iota
 
 	// CheckStatusPending when checks are still running
 	CheckStatusPending
@@ -45,11 +46,14 @@ const (
 // PullRequestMergeStatus is the merge status of a pull request
 type PullRequestMergeStatus struct {
 	// ChecksPass is the status of GitHub checks
-	ChecksPass checkStatus
+	ChecksPass CheckStatus
 
 	// ReviewApproved is true when a pull request is approved by a fellow reviewer
 	ReviewApproved bool
 
+	// Reviews has the approvals, change requests and pending reviewers behind ReviewApproved
+	Reviews ReviewStatus
+
 	// NoConflicts is true when there are no merge conflicts
 	NoConflicts bool
 
@@ -62,6 +66,10 @@ func (pr *PullRequest) Mergeable(config *config.Config) bool {
 	if !pr.MergeStatus.NoConflicts {
 		return false
 	}
+	// a reviewer asking for changes blocks the merge even when approval isn't required
+	if len(pr.MergeStatus.Reviews.ChangesRequestedBy) > 0 {
+		return false
+	}
 	if !pr.MergeStatus.Stacked {
 		return false
 	}
@@ -82,6 +90,10 @@ func (pr *PullRequest) Ready(config *config.Config) bool {
 	if !pr.MergeStatus.NoConflicts {
 		return false
 	}
+	// a reviewer asking for changes blocks the merge even when approval isn't required
+	if len(pr.MergeStatus.Reviews.ChangesRequestedBy) > 0 {
+		return false
+	}
 	if config.Repo.RequireChecks && pr.MergeStatus.ChecksPass != CheckStatusPass {
 		return false
 	}
@@ -145,7 +157,9 @@ func (pr *PullRequest) StatusString(config *config.Config) string {
 
 	statusString += pr.MergeStatus.ChecksPass.String(config)
 
-	if config.Repo.RequireApproval {
+	if len(pr.MergeStatus.Reviews.ChangesRequestedBy) > 0 {
+		statusString += icons["warning"]
+	} else if config.Repo.RequireApproval {
 		if pr.MergeStatus.ReviewApproved {
 			statusString += icons["checkmark"]
 		} else {
@@ -194,8 +208,7 @@ func (pr *PullRequest) String(config *config.Config) string {
 
 	prInfo := padding(fmt.Sprintf("%3d", pr.Number))
 	if config.User.ShowPRLink {
-		prInfo = fmt.Sprintf("https://%s/%s/%s/pull/%s",
-			config.Repo.GitHubHost, config.Repo.GitHubRepoOwner, config.Repo.GitHubRepoName, padding(fmt.Sprintf("%d", pr.Number)))
+		prInfo = pullRequestURLPrefix(config) + padding(fmt.Sprintf("%d", pr.Number))
 	}
 
 	var mq string
@@ -216,6 +229,28 @@ func (pr *PullRequest) String(config *config.Config) string {
 	return TrimToTerminal(config, line)
 }
 
+// PullRequestURL returns the web url of the pull request, or merge request on GitLab
+func PullRequestURL(cfg *config.Config, number int) string {
+	return fmt.Sprintf("%s%d", pullRequestURLPrefix(cfg), number)
+}
+
+func pullRequestURLPrefix(cfg *config.Config) string {
+	if cfg.Repo.Forge == config.ForgeGitLab {
+		return fmt.Sprintf("https://%s/%s/%s/-/merge_requests/",
+			cfg.Repo.GitHubHost, cfg.Repo.GitHubRepoOwner, cfg.Repo.GitHubRepoName)
+	}
+	return fmt.Sprintf("https://%s/%s/%s/pull/",
+		cfg.Repo.GitHubHost, cfg.Repo.GitHubRepoOwner, cfg.Repo.GitHubRepoName)
+}
+
+// PullRequestReference returns the markdown reference to the pull request, #N on GitHub and !N on GitLab
+func PullRequestReference(cfg *config.Config, number int) string {
+	if cfg.Repo.Forge == config.ForgeGitLab {
+		return fmt.Sprintf("!%d", number)
+	}
+	return fmt.Sprintf("#%d", number)
+}
+
 func TrimToTerminal(config *config.Config, line string) string {
 	// trim line to terminal width
 	terminalWidth, err := terminal.Width()
@@ -235,7 +270,21 @@ func TrimToTerminal(config *config.Config, line string) string {
 	return line
 }
 
-func (cs checkStatus) String(config *config.Config) string {
+// Name returns a stable lower case name of the check status
+func (cs CheckStatus) Name() string {
+	switch cs {
+	case CheckStatusPending:
+		return "pending"
+	case CheckStatusPass:
+		return "pass"
+	case CheckStatusFail:
+		return "fail"
+	default:
+		return "unknown"
+	}
+}
+
+func (cs CheckStatus) String(config *config.Config) string {
 	icons := StatusBitIcons(config)
 	if config.Repo.RequireChecks {
 		switch cs {
@@ -25,7 +25,7 @@ func TestMergable(t *testing.T) {
 		}
 	}
 
-	pr := func(checks checkStatus, approved bool, noConflics bool, stacked bool) *PullRequest {
+	pr := func(checks CheckStatus, approved bool, noConflics bool, stacked bool) *PullRequest {
 		return &PullRequest{
 			MergeStatus: PullRequestMergeStatus{
 				ChecksPass:     checks,
@@ -51,6 +51,12 @@ func TestMergable(t *testing.T) {
 	for i, test := range tests {
 		assert.Equal(t, test.expect, test.pr.Mergeable(test.cfg), fmt.Sprintf("case %d failed", i))
 	}
+
+	// changes requested block the merge even when approval isn't required
+	changesRequested := pr(CheckStatusPass, false, true, true)
+	changesRequested.MergeStatus.Reviews.ChangesRequestedBy = []string{"alice"}
+	assert.False(t, changesRequested.Mergeable(cfg(true, false)))
+	assert.False(t, changesRequested.Ready(cfg(true, false)))
 }
 
 func TestReady(t *testing.T) {
@@ -69,7 +75,7 @@ func TestReady(t *testing.T) {
 		}
 	}
 
-	pr := func(checks checkStatus, wip bool, approved bool, noConflics bool, stacked bool) *PullRequest {
+	pr := func(checks CheckStatus, wip bool, approved bool, noConflics bool, stacked bool) *PullRequest {
 		return &PullRequest{
 			MergeStatus: PullRequestMergeStatus{
 				ChecksPass:     checks,
@@ -117,7 +123,7 @@ func TestStatusString(t *testing.T) {
 		}
 	}
 
-	pr := func(checks checkStatus, approved bool, noConflics bool, stacked bool) *PullRequest {
+	pr := func(checks CheckStatus, approved bool, noConflics bool, stacked bool) *PullRequest {
 		return &PullRequest{
 			MergeStatus: PullRequestMergeStatus{
 				ChecksPass:     checks,
@@ -142,6 +148,11 @@ func TestStatusString(t *testing.T) {
 	for i, test := range tests {
 		assert.Equal(t, test.expect, test.pr.StatusString(test.cfg), fmt.Sprintf("case %d failed", i))
 	}
+
+	changesRequested := pr(CheckStatusPass, false, true, true)
+	changesRequested.MergeStatus.Reviews.ChangesRequestedBy = []string{"alice"}
+	assert.Equal(t, "[v!vv]", changesRequested.StatusString(cfg(true, true)))
+	assert.Equal(t, "[-!vv]", changesRequested.StatusString(cfg(false, false)))
 }
 
 func TestString(t *testing.T) {
@@ -179,3 +190,18 @@ func TestString(t *testing.T) {
 		assert.Equal(t, test.expect, test.pr.String(test.cfg), fmt.Sprintf("case %d failed", i))
 	}
 }
+
+func TestPullRequestURL(t *testing.T) {
+	cfg := config.EmptyConfig()
+	cfg.Repo.GitHubHost = "github.com"
+	cfg.Repo.GitHubRepoOwner = "owner"
+	cfg.Repo.GitHubRepoName = "repo"
+	assert.Equal(t, "https://github.com/owner/repo/pull/7", PullRequestURL(cfg, 7))
+	assert.Equal(t, "#7", PullRequestReference(cfg, 7))
+
+	cfg.Repo.Forge = config.ForgeGitLab
+	cfg.Repo.GitHubHost = "gitlab.com"
+	cfg.Repo.GitHubRepoOwner = "group/sub"
+	assert.Equal(t, "https://gitlab.com/group/sub/repo/-/merge_requests/7", PullRequestURL(cfg, 7))
+	assert.Equal(t, "!7", PullRequestReference(cfg, 7))
+}
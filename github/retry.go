@@ -0,0 +1,224 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/config"
+)
+
+const (
+	retryMaxAttempts = 5
+	retryBaseDelay   = time.Second
+	retryMaxDelay    = 30 * time.Second
+
+	// requests rate limited for longer than maxRateLimitWait fail instead of waiting
+	maxRateLimitWait = 5 * time.Minute
+)
+
+// RetryTransport retries the GitHub API requests failing on a rate limit or a transient error.
+//
+//	Rate limited requests are retried once the Retry-After or X-RateLimit-Reset
+//	headers allow, other failures with a jittered exponential backoff. Server and
+//	network errors are only retried for idempotent requests: REST requests other
+//	than POST and PATCH, and GraphQL queries. The remaining quota of every response
+//	is printed when github calls are logged.
+type RetryTransport struct {
+	base   http.RoundTripper
+	config *config.Config
+
+	// sleep waits for d unless ctx is done first
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryTransport returns a RetryTransport sending requests with base, http.DefaultTransport when nil
+func NewRetryTransport(cfg *config.Config, base http.RoundTripper) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{base: base, config: cfg, sleep: sleep}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// the body can only be sent again when it can be read again
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	idempotent := replayable && isIdempotent(req)
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		var wait time.Duration
+		var retry bool
+		reason := "rate limited"
+		switch {
+		case err != nil:
+			retry = idempotent && ctx.Err() == nil
+			wait = backoff(attempt)
+			reason = err.Error()
+		default:
+			t.logQuota(resp)
+			wait, retry, err = rateLimitWait(resp, attempt)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			retry = retry && replayable
+			if !retry && idempotent && isTransient(resp.StatusCode) {
+				retry, wait, reason = true, backoff(attempt), resp.Status
+			}
+		}
+		if !retry || attempt >= retryMaxAttempts || wait > maxRateLimitWait {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if t.config.User.LogGitHubCalls {
+			fmt.Printf("> github %s, retrying in %s (attempt %d of %d)\n",
+				reason, wait.Round(time.Second), attempt+1, retryMaxAttempts)
+		}
+		err = t.sleep(ctx, wait)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// logQuota prints the remaining rate limit quota of the response
+func (t *RetryTransport) logQuota(resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if !t.config.User.LogGitHubCalls || remaining == "" {
+		return
+	}
+	quota := fmt.Sprintf("> github quota %s/%s remaining", remaining, resp.Header.Get("X-RateLimit-Limit"))
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" {
+		quota += " for " + resource
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		quota += ", resets at " + time.Unix(reset, 0).Format(time.TimeOnly)
+	}
+	fmt.Println(quota)
+}
+
+// rateLimitWait returns how long to wait before retrying a rate limited response,
+//
+//	retry is false when the response isn't rate limited. GitHub rejects requests
+//	over the secondary rate limits with 403 or 429 and Retry-After, and requests
+//	over the primary rate limit with 403 or 429 and no remaining quota. GraphQL
+//	queries over the limit succeed with a RATE_LIMITED error instead.
+func rateLimitWait(resp *http.Response, attempt int) (wait time.Duration, retry bool, err error) {
+	status := resp.StatusCode
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0"
+	switch {
+	case status == http.StatusTooManyRequests:
+	case status == http.StatusForbidden:
+		if resp.Header.Get("Retry-After") == "" && !exhausted {
+			// a 403 is a rate limit only when the message says so, not a missing permission
+			body, err := peekBody(resp)
+			if err != nil || !strings.Contains(strings.ToLower(body), "rate limit") {
+				return 0, false, err
+			}
+		}
+	case status == http.StatusOK && exhausted:
+		body, err := peekBody(resp)
+		if err != nil || !strings.Contains(body, "RATE_LIMITED") {
+			return 0, false, err
+		}
+	default:
+		return 0, false, nil
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true, nil
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Retry-After")); err == nil {
+		return max(time.Until(date), 0), true, nil
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil && exhausted {
+		// the reset time is in whole seconds, a second is added so the limit has surely reset
+		return max(time.Until(time.Unix(reset, 0)), 0) + time.Second, true, nil
+	}
+	return backoff(attempt), true, nil
+}
+
+// peekBody reads the body of the response and puts it back to be read again
+func peekBody(resp *http.Response) (string, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return string(body), err
+}
+
+// isIdempotent returns true when sending the request again has no other effect than sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost:
+		if !strings.HasSuffix(req.URL.Path, "/graphql") || req.GetBody == nil {
+			return false
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return false
+		}
+		defer body.Close()
+		var gql struct {
+			Query string `json:"query"`
+		}
+		if json.NewDecoder(body).Decode(&gql) != nil {
+			return false
+		}
+		query := strings.TrimSpace(gql.Query)
+		return strings.HasPrefix(query, "query") || strings.HasPrefix(query, "{")
+	case http.MethodPatch:
+		return false
+	default:
+		return true
+	}
+}
+
+// isTransient returns true for the server errors a retry can succeed after
+func isTransient(status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered delay before the given attempt, doubling with every attempt
+func backoff(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
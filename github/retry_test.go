@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/stretchr/testify/require"
+)
+
+// retryServer answers requests with the handlers in turn, recording the request bodies and the waits between retries
+func retryServer(t *testing.T, handlers ...http.HandlerFunc) (*http.Client, string, *[]time.Duration, *[]string) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		require.NotEmpty(t, handlers, "unexpected request")
+		handlers[0](w, r)
+		handlers = handlers[1:]
+	}))
+	t.Cleanup(server.Close)
+
+	var waits []time.Duration
+	transport := NewRetryTransport(config.EmptyConfig(), nil)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return &http.Client{Transport: transport}, server.URL, &waits, &bodies
+}
+
+func status(code int, header ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func TestRetryRateLimit(t *testing.T) {
+	client, url, waits, _ := retryServer(t,
+		status(http.StatusForbidden, "Retry-After", "7"),
+		status(http.StatusTooManyRequests, "X-RateLimit-Remaining", "0",
+			"X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)),
+		status(http.StatusOK))
+	resp, err := client.Post(url, "application/json", strings.NewReader(`{"query":"mutation M {}"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, *waits, 2)
+	require.Equal(t, 7*time.Second, (*waits)[0])
+	require.InDelta(t, time.Minute, (*waits)[1], float64(2*time.Second))
+}
+
+func TestRetryForbidden(t *testing.T) {
+	client, url, waits, _ := retryServer(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit"}`))
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+		})
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Len(t, *waits, 1)
+}
+
+func TestRetryGraphQLRateLimited(t *testing.T) {
+	client, url, waits, bodies := retryServer(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Write([]byte(`{"errors":[{"type":"RATE_LIMITED"}]}`))
+		},
+		status(http.StatusOK))
+	resp, err := client.Post(url+"/graphql", "application/json", strings.NewReader(`{"query":"query Q {}"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, *waits, 1)
+	require.Equal(t, []string{`{"query":"query Q {}"}`, `{"query":"query Q {}"}`}, *bodies)
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	// queries are retried after server errors
+	client, url, waits, _ := retryServer(t,
+		status(http.StatusBadGateway), status(http.StatusServiceUnavailable), status(http.StatusOK))
+	resp, err := client.Post(url+"/graphql", "application/json", strings.NewReader(`{"query":"query Q {}"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, *waits, 2)
+	require.LessOrEqual(t, (*waits)[0], retryBaseDelay)
+	require.GreaterOrEqual(t, (*waits)[1], retryBaseDelay)
+
+	// mutations aren't
+	client, url, waits, _ = retryServer(t, status(http.StatusBadGateway))
+	resp, err = client.Post(url+"/graphql", "application/json", strings.NewReader(`{"query":"mutation M {}"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Empty(t, *waits)
+
+	// retries give up after the last attempt
+	var handlers []http.HandlerFunc
+	for i := 0; i < retryMaxAttempts; i++ {
+		handlers = append(handlers, status(http.StatusInternalServerError))
+	}
+	client, url, waits, _ = retryServer(t, handlers...)
+	resp, err = client.Get(url)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Len(t, *waits, retryMaxAttempts-1)
+}
@@ -0,0 +1,126 @@
+package github
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Review is a review submitted on a pull request
+type Review struct {
+	Author string
+
+	// State is APPROVED, CHANGES_REQUESTED, COMMENTED or DISMISSED
+	State string
+
+	// DismissedState is the state a dismissed review had before, empty when it isn't known
+	DismissedState string
+
+	// CommitHash is the head of the pull request the review was submitted on
+	CommitHash string
+}
+
+// ReviewStatus is the review state of a pull request
+type ReviewStatus struct {
+	// ApprovedBy are the reviewers whose latest review approves the pull request
+	ApprovedBy []string
+
+	// RequiredApprovals is the number of approvals branch protection of the target branch requires
+	RequiredApprovals int
+
+	// ChangesRequestedBy are the reviewers whose latest review requests changes
+	ChangesRequestedBy []string
+
+	// PendingReviewers are the users and org/team names review was requested from who haven't reviewed yet
+	PendingReviewers []string
+
+	// StaleApprovedBy are the reviewers whose approval was dismissed because commits were pushed after it
+	StaleApprovedBy []string
+
+	// CommentedBy are the other reviewers, whose reviews only comment or were dismissed
+	CommentedBy []string
+}
+
+// NewReviewStatus returns the review status from the reviews in submission order.
+//
+//	The latest approval or change request of each reviewer counts, comments don't
+//	change it. A dismissed approval on an older head than head is a stale approval,
+//	GitHub dismisses approvals when new commits are pushed if branch protection
+//	asks for it. A dismissed review of unknown DismissedState was an approval when
+//	the reviewer's last review before it approved.
+func NewReviewStatus(reviews []Review, head string, requiredApprovals int, pending []string) ReviewStatus {
+	latest := map[string]Review{}
+	approved := map[string]bool{}
+	authors := map[string]bool{}
+	for _, review := range reviews {
+		authors[review.Author] = true
+		switch review.State {
+		case "APPROVED", "CHANGES_REQUESTED":
+			latest[review.Author] = review
+			approved[review.Author] = review.State == "APPROVED"
+		case "DISMISSED":
+			latest[review.Author] = review
+			if review.DismissedState != "" {
+				approved[review.Author] = review.DismissedState == "APPROVED"
+			}
+		}
+	}
+
+	status := ReviewStatus{
+		RequiredApprovals: requiredApprovals,
+		PendingReviewers:  pending,
+	}
+	for author, review := range latest {
+		switch {
+		case review.State == "APPROVED":
+			status.ApprovedBy = append(status.ApprovedBy, author)
+		case review.State == "CHANGES_REQUESTED":
+			status.ChangesRequestedBy = append(status.ChangesRequestedBy, author)
+		case review.CommitHash != head && approved[author]:
+			status.StaleApprovedBy = append(status.StaleApprovedBy, author)
+		}
+	}
+	for author := range authors {
+		if !slices.Contains(status.ApprovedBy, author) && !slices.Contains(status.ChangesRequestedBy, author) &&
+			!slices.Contains(status.StaleApprovedBy, author) {
+			status.CommentedBy = append(status.CommentedBy, author)
+		}
+	}
+	slices.Sort(status.ApprovedBy)
+	slices.Sort(status.ChangesRequestedBy)
+	slices.Sort(status.StaleApprovedBy)
+	slices.Sort(status.CommentedBy)
+	return status
+}
+
+// Reviewers returns everyone who reviewed the pull request or was asked to review it
+func (rs ReviewStatus) Reviewers() []string {
+	return slices.Concat(rs.ApprovedBy, rs.ChangesRequestedBy, rs.PendingReviewers, rs.StaleApprovedBy, rs.CommentedBy)
+}
+
+// Approved is true when nobody requests changes and the pull request has the required approvals, at least one
+func (rs ReviewStatus) Approved() bool {
+	return len(rs.ChangesRequestedBy) == 0 && len(rs.ApprovedBy) >= max(rs.RequiredApprovals, 1)
+}
+
+// Empty is true when nobody reviewed or was asked to review and no approvals are required,
+//
+//	like for pull requests of a forge which doesn't report reviews.
+func (rs ReviewStatus) Empty() bool {
+	return len(rs.Reviewers()) == 0 && rs.RequiredApprovals == 0
+}
+
+// String summarizes the review status, like "1/2 approvals, changes requested by alice"
+func (rs ReviewStatus) String() string {
+	parts := []string{fmt.Sprintf("%d/%d approvals", len(rs.ApprovedBy), max(rs.RequiredApprovals, 1))}
+	if len(rs.ChangesRequestedBy) > 0 {
+		parts = append(parts, "changes requested by "+strings.Join(rs.ChangesRequestedBy, ", "))
+	}
+	if len(rs.PendingReviewers) > 0 {
+		parts = append(parts, "waiting on "+strings.Join(rs.PendingReviewers, ", "))
+	}
+	if len(rs.StaleApprovedBy) > 0 {
+		parts = append(parts, "stale approval by "+strings.Join(rs.StaleApprovedBy, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,62 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReviewStatus(t *testing.T) {
+	reviews := []Review{
+		{Author: "alice", State: "APPROVED", CommitHash: "h1"},
+		{Author: "bob", State: "APPROVED", CommitHash: "h1"},
+		{Author: "alice", State: "CHANGES_REQUESTED", CommitHash: "h2"},
+		{Author: "bob", State: "COMMENTED", CommitHash: "h2"},
+		{Author: "carol", State: "APPROVED", CommitHash: "h1"},
+		{Author: "carol", State: "DISMISSED", CommitHash: "h1"},
+	}
+
+	// the latest review of alice requests changes, comments don't undo the approval of bob
+	status := NewReviewStatus(reviews, "h2", 2, []string{"dave", "org/team"})
+	require.Equal(t, ReviewStatus{
+		ApprovedBy:         []string{"bob"},
+		RequiredApprovals:  2,
+		ChangesRequestedBy: []string{"alice"},
+		PendingReviewers:   []string{"dave", "org/team"},
+		StaleApprovedBy:    []string{"carol"},
+	}, status)
+	require.Equal(t, []string{"bob", "alice", "dave", "org/team", "carol"}, status.Reviewers())
+	require.False(t, status.Approved())
+	require.Equal(t, "1/2 approvals, changes requested by alice, waiting on dave, org/team, stale approval by carol", status.String())
+
+	status = NewReviewStatus(append(reviews, Review{Author: "alice", State: "APPROVED", CommitHash: "h2"}), "h2", 2, nil)
+	require.Equal(t, []string{"alice", "bob"}, status.ApprovedBy)
+	require.True(t, status.Approved())
+	require.Equal(t, "2/2 approvals, stale approval by carol", status.String())
+
+	// one approval is needed without branch protection
+	require.False(t, NewReviewStatus(nil, "h2", 0, nil).Approved())
+	require.True(t, NewReviewStatus(reviews[:1], "h2", 0, nil).Approved())
+	require.True(t, NewReviewStatus(nil, "h2", 0, nil).Empty())
+	commented := NewReviewStatus([]Review{{Author: "erin", State: "COMMENTED", CommitHash: "h2"}}, "h2", 0, nil)
+	require.Equal(t, []string{"erin"}, commented.CommentedBy)
+	require.False(t, commented.Empty())
+	require.False(t, NewReviewStatus(nil, "h2", 1, nil).Empty())
+}
+
+func TestNewReviewStatusDismissed(t *testing.T) {
+	reviews := []Review{
+		{Author: "alice", State: "DISMISSED", DismissedState: "APPROVED", CommitHash: "h1"},
+		{Author: "bob", State: "DISMISSED", DismissedState: "CHANGES_REQUESTED", CommitHash: "h1"},
+		{Author: "carol", State: "CHANGES_REQUESTED", CommitHash: "h1"},
+		{Author: "carol", State: "DISMISSED", CommitHash: "h1"},
+		{Author: "dave", State: "DISMISSED", CommitHash: "h1"},
+	}
+
+	// dismissed change requests aren't stale approvals, neither are dismissals of unknown reviews
+	status := NewReviewStatus(reviews, "h2", 1, nil)
+	require.Equal(t, []string{"alice"}, status.StaleApprovedBy)
+	require.Equal(t, []string{"bob", "carol", "dave"}, status.CommentedBy)
+	require.Empty(t, status.ChangesRequestedBy)
+	require.Equal(t, "0/1 approvals, stale approval by alice", status.String())
+}
@@ -0,0 +1,84 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+)
+
+// MatchStack returns the stack of pull requests ending with the topmost commit of
+//
+//	localCommitStack which has a pull request, ordered from the bottom of the stack.
+//	pullRequests maps commit-ids to the open pull requests of their branch, the
+//	stack is followed down through base branches until it reaches the target branch.
+func MatchStack(cfg *config.Config, localCommitStack []git.Commit, pullRequests map[string]*PullRequest) ([]*PullRequest, error) {
+	var stack []*PullRequest
+	targetBranch := cfg.Repo.GitHubBranch
+
+	// find top pr
+	var currpr *PullRequest
+	var found bool
+	for i := len(localCommitStack) - 1; i >= 0; i-- {
+		currpr, found = pullRequests[localCommitStack[i].CommitID]
+		if found {
+			break
+		}
+	}
+
+	// The list of commits from the command line actually starts at the
+	//  most recent commit. In order to reverse the list we use a
+	//  custom prepend function instead of append
+	prepend := func(l []*PullRequest, pr *PullRequest) []*PullRequest {
+		l = append(l, &PullRequest{})
+		copy(l[1:], l)
+		l[0] = pr
+		return l
+	}
+
+	// build pr stack
+	for currpr != nil {
+		stack = prepend(stack, currpr)
+		if currpr.ToBranch == targetBranch {
+			break
+		}
+
+		nextCommitID := git.CommitIDFromBranch(cfg, currpr.ToBranch)
+		if nextCommitID == "" {
+			return nil, fmt.Errorf("invalid base branch for pull request:%s", currpr.ToBranch)
+		}
+
+		currpr = pullRequests[nextCommitID]
+	}
+
+	return stack, nil
+}
+
+// WalkStack returns the stack of open pull requests ending with top, ordered from the bottom of the stack.
+//
+//	The stack is followed down through base branches the same way as MatchStack,
+//	byHead returns the open pull request of a branch or nil. Branches of any
+//	user are followed, the walk stops at a base branch which is not a pull
+//	request branch, usually the target branch.
+func WalkStack(cfg *config.Config, top *PullRequest, byHead func(branch string) (*PullRequest, error)) ([]*PullRequest, error) {
+	stack := []*PullRequest{top}
+	seen := map[int]bool{top.Number: true}
+	for pr := top; ; {
+		if _, _, ok := git.ParseBranchName(cfg, pr.ToBranch); !ok {
+			return stack, nil
+		}
+		next, err := byHead(pr.ToBranch)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return nil, fmt.Errorf("no open pull request for branch %s, the base of #%d", pr.ToBranch, pr.Number)
+		}
+		if seen[next.Number] {
+			return nil, fmt.Errorf("pull request #%d is its own base", next.Number)
+		}
+		seen[next.Number] = true
+		stack = append([]*PullRequest{next}, stack...)
+		pr = next
+	}
+}
@@ -0,0 +1,458 @@
+package fakegitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	// DefaultOwner is the group of the project used by New
+	DefaultOwner = "spr-group"
+
+	// DefaultRepoName is the project name used by New
+	DefaultRepoName = "spr-repo"
+
+	// DefaultUsername is the username of the authenticated user
+	DefaultUsername = "spr-user"
+
+	// DefaultBranch is the branch the remote repository is initialized with
+	DefaultBranch = "main"
+
+	// Token is the only personal access token the server accepts
+	Token = "fake-gitlab-token"
+)
+
+// Merge request states
+const (
+	StateOpened = "opened"
+	StateClosed = "closed"
+	StateMerged = "merged"
+)
+
+// Project merge methods
+const (
+	MergeMethodMerge       = "merge"
+	MergeMethodFastForward = "ff"
+)
+
+// Server is an in-process stand in for the GitLab v4 rest api.
+//
+//	The server is paired with a local bare git repository which is used as
+//	the git remote. Branches pushed to the remote are visible to merge requests
+//	and merging a merge request updates the target branch in the remote.
+type Server struct {
+	// URL is the base url of the fake, use it as the repo GitHubHost.
+	URL string
+
+	// RemoteDir is the path of the bare git repository used as the remote.
+	RemoteDir string
+
+	Owner    string
+	Name     string
+	Username string
+
+	// Users is the list of project members
+	Users []User
+
+	// Milestones is the list of active project milestones
+	Milestones []Milestone
+
+	// MergeMethod is the project merge method, merge commits by default like GitLab
+	MergeMethod string
+
+	// MaxPageSize caps the number of items returned in one page of a listing,
+	//  lower it to exercise pagination. Defaults to 100 like GitLab.
+	MaxPageSize int
+
+	t      testing.TB
+	server *httptest.Server
+
+	mu            sync.Mutex
+	mergeRequests []*MergeRequest
+	pipelines     map[string]string
+	pipelineSHAs  []string
+	jobs          map[string][]Job
+}
+
+// Job is a job of the pipeline of a commit
+type Job struct {
+	Name         string
+	Stage        string
+	Status       string
+	AllowFailure bool
+	WebURL       string
+}
+
+// User is a GitLab user
+type User struct {
+	ID       int64
+	Username string
+	Name     string
+}
+
+// Milestone is a project milestone
+type Milestone struct {
+	ID    int64
+	Title string
+}
+
+// MergeRequest is the fake server side representation of a merge request
+type MergeRequest struct {
+	IID          int
+	Title        string
+	Description  string
+	Author       string
+	SourceBranch string
+	TargetBranch string
+	State        string
+	Squash       bool
+	Approved     bool
+
+	// InTrain is set when the merge request was added to the merge train
+	InTrain bool
+
+	// ReviewerIDs are the ids of the users review was requested from
+	ReviewerIDs []int64
+
+	// Notes are the bodies of all comments added to the merge request
+	Notes []string
+
+	// Labels are the label names of the merge request
+	Labels []string
+
+	// AssigneeIDs are the ids of the users assigned to the merge request
+	AssigneeIDs []int64
+
+	// Milestone is the title of the milestone of the merge request
+	Milestone string
+}
+
+// ID returns the global id of the merge request
+func (mr *MergeRequest) ID() int64 {
+	return int64(1000 + mr.IID)
+}
+
+// New creates a fake GitLab server along with a bare remote repository
+//
+//	holding a single initial commit on the default branch.
+//	The server is shut down and all files are removed when the test ends.
+func New(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		RemoteDir: filepath.Join(t.TempDir(), "remote.git"),
+		Owner:     DefaultOwner,
+		Name:      DefaultRepoName,
+		Username:  DefaultUsername,
+		Users: []User{
+			{ID: 1, Username: DefaultUsername, Name: "Spr User"},
+			{ID: 2, Username: "reviewer", Name: "Re Viewer"},
+		},
+		Milestones: []Milestone{
+			{ID: 1, Title: "v1.0"},
+		},
+		MergeMethod: MergeMethodMerge,
+		MaxPageSize: 100,
+		t:           t,
+		pipelines:   map[string]string{},
+		jobs:        map[string][]Job{},
+	}
+
+	s.mustRun("", "init", "--bare", "--initial-branch="+DefaultBranch, s.RemoteDir)
+	// merges create commits in the remote
+	configureUser(s, s.RemoteDir)
+
+	seed := t.TempDir()
+	s.mustRun(seed, "clone", s.RemoteDir, ".")
+	configureUser(s, seed)
+	err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("fake gitlab repo\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mustRun(seed, "add", "README.md")
+	s.mustRun(seed, "commit", "-m", "initial commit")
+	s.mustRun(seed, "push", "origin", "HEAD:"+DefaultBranch)
+
+	mux := http.NewServeMux()
+	s.registerREST(mux)
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+// Clone clones the remote into a new temporary directory and returns its path.
+//
+//	The clone has a committer identity configured so commits can be created.
+func (s *Server) Clone(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	s.mustRun(dir, "clone", s.RemoteDir, ".")
+	configureUser(s, dir)
+	return dir
+}
+
+// MergeRequests returns a copy of all merge requests ordered by iid
+func (s *Server) MergeRequests() []MergeRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var mrs []MergeRequest
+	for _, mr := range s.mergeRequests {
+		mrs = append(mrs, *mr)
+	}
+	return mrs
+}
+
+// OpenMergeRequests returns a copy of the opened merge requests ordered by iid
+func (s *Server) OpenMergeRequests() []MergeRequest {
+	var open []MergeRequest
+	for _, mr := range s.MergeRequests() {
+		if mr.State == StateOpened {
+			open = append(open, mr)
+		}
+	}
+	return open
+}
+
+// MergeRequest returns a copy of the merge request with the given iid
+func (s *Server) MergeRequest(iid int) (MergeRequest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mr := s.findByIID(iid)
+	if mr == nil {
+		return MergeRequest{}, false
+	}
+	return *mr, true
+}
+
+// ApproveAll approves all opened merge requests
+func (s *Server) ApproveAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, mr := range s.mergeRequests {
+		if mr.State == StateOpened {
+			mr.Approved = true
+		}
+	}
+}
+
+// SetPipelineStatus sets the status (success, running, failed, ...) of the pipeline
+//
+//	of the given commit sha. Commits without a pipeline are reported without a head pipeline.
+func (s *Server) SetPipelineStatus(sha string, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pipelines[sha]; !ok {
+		s.pipelineSHAs = append(s.pipelineSHAs, sha)
+	}
+	s.pipelines[sha] = status
+}
+
+// AddPipelineJob adds a job to the pipeline of the given commit sha, the pipeline status is set separately
+func (s *Server) AddPipelineJob(sha string, job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[sha] = append(s.jobs[sha], job)
+}
+
+// RunMergeTrain merges the merge requests in the merge train in the order they were added
+func (s *Server) RunMergeTrain() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, mr := range s.mergeRequests {
+		if !mr.InTrain {
+			continue
+		}
+		mr.InTrain = false
+		err := s.mergeMergeRequest(mr, mr.Squash)
+		if err != nil {
+			return fmt.Errorf("merge train: %w", err)
+		}
+	}
+	return nil
+}
+
+// BranchHead returns the commit sha the given remote branch points to
+//
+//	or an empty string if the branch doesn't exist.
+func (s *Server) BranchHead(branch string) string {
+	out, err := s.git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// Branches returns the names of all remote branches
+func (s *Server) Branches() []string {
+	out, err := s.git("for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if out == "" {
+		return nil
+	}
+	branches := strings.Split(out, "\n")
+	sort.Strings(branches)
+	return branches
+}
+
+func (s *Server) findByIID(iid int) *MergeRequest {
+	for _, mr := range s.mergeRequests {
+		if mr.IID == iid {
+			return mr
+		}
+	}
+	return nil
+}
+
+// createMergeRequest must be called with the lock held
+func (s *Server) createMergeRequest(title, description, target, source string) (*MergeRequest, error) {
+	if s.BranchHead(target) == "" {
+		return nil, fmt.Errorf("target branch %q does not exist", target)
+	}
+	if s.BranchHead(source) == "" {
+		return nil, fmt.Errorf("source branch %q does not exist", source)
+	}
+	for _, mr := range s.mergeRequests {
+		if mr.State == StateOpened && mr.SourceBranch == source {
+			return nil, fmt.Errorf("another open merge request already exists for this source branch: !%d", mr.IID)
+		}
+	}
+	mr := &MergeRequest{
+		IID:          len(s.mergeRequests) + 1,
+		Title:        title,
+		Description:  description,
+		Author:       s.Username,
+		SourceBranch: source,
+		TargetBranch: target,
+		State:        StateOpened,
+	}
+	s.mergeRequests = append(s.mergeRequests, mr)
+	return mr, nil
+}
+
+// mergeMergeRequest must be called with the lock held
+func (s *Server) mergeMergeRequest(mr *MergeRequest, squash bool) error {
+	if mr.State != StateOpened {
+		return fmt.Errorf("merge request !%d is not open", mr.IID)
+	}
+	if s.hasConflicts(mr) {
+		return fmt.Errorf("merge request !%d has conflicts", mr.IID)
+	}
+
+	target := s.BranchHead(mr.TargetBranch)
+	source := s.BranchHead(mr.SourceBranch)
+	_, ancestorErr := s.git("merge-base", "--is-ancestor", target, source)
+	var merged string
+	var err error
+	switch {
+	case squash:
+		merged, err = s.git("commit-tree", source+"^{tree}", "-p", target, "-m", mr.Title)
+	case s.MergeMethod == MergeMethodFastForward:
+		if ancestorErr != nil {
+			return fmt.Errorf("merge request !%d can't be fast-forward merged", mr.IID)
+		}
+		merged = source
+	default:
+		merged, err = s.git("commit-tree", source+"^{tree}", "-p", target, "-p", source,
+			"-m", fmt.Sprintf("Merge branch '%s' into '%s'", mr.SourceBranch, mr.TargetBranch))
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.git("update-ref", "refs/heads/"+mr.TargetBranch, merged, target)
+	if err != nil {
+		return err
+	}
+	mr.State = StateMerged
+	mr.Squash = squash
+	return nil
+}
+
+// hasConflicts returns true when the source branch can't be merged into the target branch
+func (s *Server) hasConflicts(mr *MergeRequest) bool {
+	target := s.BranchHead(mr.TargetBranch)
+	source := s.BranchHead(mr.SourceBranch)
+	if target == "" || source == "" {
+		return false
+	}
+	if _, err := s.git("merge-base", "--is-ancestor", target, source); err == nil {
+		return false
+	}
+	_, err := s.git("merge-tree", "--write-tree", target, source)
+	return err != nil
+}
+
+type commit struct {
+	sha     string
+	title   string
+	message string
+}
+
+// commits returns the merge request commits with the newest commit first, like GitLab
+func (s *Server) commits(mr *MergeRequest) []commit {
+	source := s.BranchHead(mr.SourceBranch)
+	if source == "" {
+		return nil
+	}
+	revRange := source
+	if target := s.BranchHead(mr.TargetBranch); target != "" {
+		revRange = target + ".." + source
+	}
+	out, err := s.git("log", "--format=%H%x1f%s%x1f%B%x1e", revRange)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	var commits []commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, commit{
+			sha:     fields[0],
+			title:   fields[1],
+			message: fields[2],
+		})
+	}
+	return commits
+}
+
+func (s *Server) git(args ...string) (string, error) {
+	args = append([]string{"--git-dir", s.RemoteDir}, args...)
+	cmd := exec.Command("git", args...)
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func (s *Server) mustRun(dir string, args ...string) {
+	s.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		s.t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func configureUser(s *Server, dir string) {
+	s.mustRun(dir, "config", "user.name", "Spr User")
+	s.mustRun(dir, "config", "user.email", "spr-user@example.com")
+	s.mustRun(dir, "config", "commit.gpgsign", "false")
+}
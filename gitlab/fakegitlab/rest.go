@@ -0,0 +1,471 @@
+package fakegitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type object map[string]interface{}
+
+// registerREST adds the v4 endpoints used by gitlabclient
+func (s *Server) registerREST(mux *http.ServeMux) {
+	project := "/api/v4/projects/{project}"
+	mr := project + "/merge_requests/{iid}"
+	mux.HandleFunc("GET /api/v4/user", s.restHandler(s.getUser))
+	mux.HandleFunc("GET "+project+"/members/all", s.projectHandler(s.listMembers))
+	mux.HandleFunc("GET "+project+"/milestones", s.projectHandler(s.listMilestones))
+	mux.HandleFunc("GET "+project+"/merge_requests", s.projectHandler(s.listMergeRequests))
+	mux.HandleFunc("POST "+project+"/merge_requests", s.projectHandler(s.createMergeRequestREST))
+	mux.HandleFunc("GET "+mr, s.projectHandler(s.getMergeRequest))
+	mux.HandleFunc("PUT "+mr, s.projectHandler(s.updateMergeRequest))
+	mux.HandleFunc("GET "+mr+"/commits", s.projectHandler(s.listCommits))
+	mux.HandleFunc("GET "+mr+"/approvals", s.projectHandler(s.getApprovals))
+	mux.HandleFunc("POST "+mr+"/notes", s.projectHandler(s.createNote))
+	mux.HandleFunc("PUT "+mr+"/merge", s.projectHandler(s.mergeMergeRequestREST))
+	mux.HandleFunc("GET "+project+"/pipelines/{id}/jobs", s.projectHandler(s.listJobs))
+	mux.HandleFunc("GET "+project+"/merge_trains", s.projectHandler(s.listMergeTrain))
+	mux.HandleFunc("POST "+project+"/merge_trains/merge_requests/{iid}", s.projectHandler(s.addToMergeTrain))
+}
+
+type restError struct {
+	status int
+	err    error
+}
+
+func (e *restError) Error() string {
+	return e.err.Error()
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &restError{status: http.StatusNotFound, err: fmt.Errorf(format, args...)}
+}
+
+func badRequest(err error) error {
+	return &restError{status: http.StatusBadRequest, err: err}
+}
+
+// restPage is one page of a listing, restHandler sets the next page header when there is one
+type restPage struct {
+	items interface{}
+	next  int
+}
+
+// restHandler checks the token, serializes access to the server state and encodes the result
+func (s *Server) restHandler(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != Token {
+			writeJSON(w, http.StatusUnauthorized, object{"message": "401 Unauthorized"})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		res, err := fn(r)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if rerr, ok := err.(*restError); ok {
+				status = rerr.status
+			}
+			writeJSON(w, status, object{"message": err.Error()})
+			return
+		}
+		if page, ok := res.(restPage); ok {
+			if page.next != 0 {
+				w.Header().Set("X-Next-Page", strconv.Itoa(page.next))
+			}
+			res = page.items
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// projectHandler is a restHandler for endpoints under the project path
+func (s *Server) projectHandler(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return s.restHandler(func(r *http.Request) (interface{}, error) {
+		fullPath := s.Owner + "/" + s.Name
+		if r.PathValue("project") != fullPath {
+			return nil, notFound("404 Project Not Found")
+		}
+		return fn(r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// paginate returns the page of items asked for by the page and per_page parameters,
+//
+//	along with the number of the next page or 0 when it's the last page.
+func paginate[T any](s *Server, r *http.Request, items []T) ([]T, int) {
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 20
+	}
+	perPage = min(perPage, s.MaxPageSize)
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	if end < len(items) {
+		return items[start:end], page + 1
+	}
+	return items[start:end], 0
+}
+
+func (s *Server) mergeRequestFromPath(r *http.Request) (*MergeRequest, error) {
+	iid, err := strconv.Atoi(r.PathValue("iid"))
+	if err != nil {
+		return nil, notFound("404 Not found")
+	}
+	mr := s.findByIID(iid)
+	if mr == nil {
+		return nil, notFound("404 Not found")
+	}
+	return mr, nil
+}
+
+func restUser(u User) object {
+	return object{"id": u.ID, "username": u.Username, "name": u.Name}
+}
+
+// restUsers returns the users with the given ids
+func (s *Server) restUsers(ids []int64) []object {
+	users := []object{}
+	for _, id := range ids {
+		for _, u := range s.Users {
+			if u.ID == id {
+				users = append(users, restUser(u))
+			}
+		}
+	}
+	return users
+}
+
+func (s *Server) restMergeRequest(mr *MergeRequest, details bool) object {
+	res := object{
+		"id":            mr.ID(),
+		"iid":           mr.IID,
+		"title":         mr.Title,
+		"description":   mr.Description,
+		"state":         mr.State,
+		"source_branch": mr.SourceBranch,
+		"target_branch": mr.TargetBranch,
+		"sha":           s.BranchHead(mr.SourceBranch),
+		"author":        object{"username": mr.Author},
+		"reviewers":     s.restUsers(mr.ReviewerIDs),
+		"assignees":     s.restUsers(mr.AssigneeIDs),
+		"labels":        append([]string{}, mr.Labels...),
+		"has_conflicts": s.hasConflicts(mr),
+	}
+	if details {
+		res["head_pipeline"] = nil
+		sha := s.BranchHead(mr.SourceBranch)
+		if status, ok := s.pipelines[sha]; ok {
+			id := slices.Index(s.pipelineSHAs, sha) + 1
+			res["head_pipeline"] = object{"id": id, "sha": sha, "status": status}
+		}
+	}
+	return res
+}
+
+func (s *Server) getUser(r *http.Request) (interface{}, error) {
+	for _, u := range s.Users {
+		if u.Username == s.Username {
+			return restUser(u), nil
+		}
+	}
+	return object{"id": 0, "username": s.Username}, nil
+}
+
+func (s *Server) listMembers(r *http.Request) (interface{}, error) {
+	page, next := paginate(s, r, s.Users)
+	res := []object{}
+	for _, u := range page {
+		res = append(res, restUser(u))
+	}
+	return restPage{items: res, next: next}, nil
+}
+
+func (s *Server) listMilestones(r *http.Request) (interface{}, error) {
+	title := r.URL.Query().Get("title")
+	res := []object{}
+	for _, m := range s.Milestones {
+		if title == "" || m.Title == title {
+			res = append(res, object{"id": m.ID, "title": m.Title, "state": "active"})
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) listMergeRequests(r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	var mrs []*MergeRequest
+	for _, mr := range s.mergeRequests {
+		if state := query.Get("state"); state != "" && state != "all" && mr.State != state {
+			continue
+		}
+		if author := query.Get("author_username"); author != "" && mr.Author != author {
+			continue
+		}
+		if source := query.Get("source_branch"); source != "" && mr.SourceBranch != source {
+			continue
+		}
+		mrs = append(mrs, mr)
+	}
+	page, next := paginate(s, r, mrs)
+	res := []object{}
+	for _, mr := range page {
+		res = append(res, s.restMergeRequest(mr, false))
+	}
+	return restPage{items: res, next: next}, nil
+}
+
+// mergeRequestInput holds the create and update parameters, unset fields are left unchanged
+type mergeRequestInput struct {
+	SourceBranch *string `json:"source_branch"`
+	TargetBranch *string `json:"target_branch"`
+	Title        *string `json:"title"`
+	Description  *string `json:"description"`
+	StateEvent   *string `json:"state_event"`
+	ReviewerIDs  []int64 `json:"reviewer_ids"`
+	Labels       *string `json:"labels"`
+	AddLabels    *string `json:"add_labels"`
+	RemoveLabels *string `json:"remove_labels"`
+	AssigneeIDs  []int64 `json:"assignee_ids"`
+	MilestoneID  *int64  `json:"milestone_id"`
+}
+
+// setAttributes sets the labels, assignees and milestone of the input on the merge request
+func (s *Server) setAttributes(mr *MergeRequest, input mergeRequestInput) error {
+	if input.Labels != nil {
+		mr.Labels = nil
+	}
+	for _, labels := range []*string{input.Labels, input.AddLabels} {
+		if labels == nil {
+			continue
+		}
+		for _, label := range strings.Split(*labels, ",") {
+			if label != "" && !slices.Contains(mr.Labels, label) {
+				mr.Labels = append(mr.Labels, label)
+			}
+		}
+	}
+	if input.RemoveLabels != nil {
+		for _, label := range strings.Split(*input.RemoveLabels, ",") {
+			mr.Labels = slices.DeleteFunc(mr.Labels, func(l string) bool { return l == label })
+		}
+	}
+	if input.AssigneeIDs != nil {
+		for _, id := range input.AssigneeIDs {
+			if !slices.ContainsFunc(s.Users, func(u User) bool { return u.ID == id }) {
+				return fmt.Errorf("user %d is not a project member", id)
+			}
+		}
+		mr.AssigneeIDs = input.AssigneeIDs
+	}
+	if input.MilestoneID != nil {
+		i := slices.IndexFunc(s.Milestones, func(m Milestone) bool { return m.ID == *input.MilestoneID })
+		if i < 0 {
+			return fmt.Errorf("milestone %d not found", *input.MilestoneID)
+		}
+		mr.Milestone = s.Milestones[i].Title
+	}
+	return nil
+}
+
+func (s *Server) createMergeRequestREST(r *http.Request) (interface{}, error) {
+	var input mergeRequestInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, badRequest(err)
+	}
+	if input.SourceBranch == nil || input.TargetBranch == nil || input.Title == nil {
+		return nil, badRequest(fmt.Errorf("source_branch, target_branch and title are required"))
+	}
+	description := ""
+	if input.Description != nil {
+		description = *input.Description
+	}
+	mr, err := s.createMergeRequest(*input.Title, description, *input.TargetBranch, *input.SourceBranch)
+	if err != nil {
+		return nil, &restError{status: http.StatusConflict, err: err}
+	}
+	if err := s.setAttributes(mr, input); err != nil {
+		return nil, badRequest(err)
+	}
+	return s.restMergeRequest(mr, true), nil
+}
+
+func (s *Server) getMergeRequest(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.restMergeRequest(mr, true), nil
+}
+
+func (s *Server) updateMergeRequest(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input mergeRequestInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, badRequest(err)
+	}
+
+	if input.StateEvent != nil {
+		switch *input.StateEvent {
+		case "close":
+			if mr.State == StateMerged {
+				return nil, badRequest(fmt.Errorf("merge request !%d is merged", mr.IID))
+			}
+			mr.State = StateClosed
+		case "reopen":
+			if mr.State == StateMerged {
+				return nil, badRequest(fmt.Errorf("merge request !%d is merged and can't be reopened", mr.IID))
+			}
+			if s.BranchHead(mr.SourceBranch) == "" {
+				return nil, badRequest(fmt.Errorf("source branch %q was deleted", mr.SourceBranch))
+			}
+			mr.State = StateOpened
+		default:
+			return nil, badRequest(fmt.Errorf("unknown state_event %q", *input.StateEvent))
+		}
+	}
+	if input.TargetBranch != nil {
+		if s.BranchHead(*input.TargetBranch) == "" {
+			return nil, badRequest(fmt.Errorf("target branch %q does not exist", *input.TargetBranch))
+		}
+		mr.TargetBranch = *input.TargetBranch
+	}
+	if input.Title != nil {
+		mr.Title = *input.Title
+	}
+	if input.Description != nil {
+		mr.Description = *input.Description
+	}
+	if input.ReviewerIDs != nil {
+		mr.ReviewerIDs = input.ReviewerIDs
+	}
+	if err := s.setAttributes(mr, input); err != nil {
+		return nil, badRequest(err)
+	}
+	return s.restMergeRequest(mr, true), nil
+}
+
+func (s *Server) listCommits(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	page, next := paginate(s, r, s.commits(mr))
+	res := []object{}
+	for _, c := range page {
+		res = append(res, object{"id": c.sha, "title": c.title, "message": c.message})
+	}
+	return restPage{items: res, next: next}, nil
+}
+
+func (s *Server) listJobs(r *http.Request) (interface{}, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 || id > len(s.pipelineSHAs) {
+		return nil, notFound("404 Not found")
+	}
+	page, next := paginate(s, r, s.jobs[s.pipelineSHAs[id-1]])
+	res := []object{}
+	for _, job := range page {
+		res = append(res, object{
+			"name":          job.Name,
+			"stage":         job.Stage,
+			"status":        job.Status,
+			"allow_failure": job.AllowFailure,
+			"web_url":       job.WebURL,
+		})
+	}
+	return restPage{items: res, next: next}, nil
+}
+
+func (s *Server) getApprovals(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	approvedBy := []object{}
+	if mr.Approved {
+		approvedBy = append(approvedBy, object{"user": object{"username": "reviewer"}})
+	}
+	return object{"iid": mr.IID, "approved": mr.Approved, "approvals_required": 0, "approved_by": approvedBy}, nil
+}
+
+func (s *Server) createNote(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, badRequest(err)
+	}
+	mr.Notes = append(mr.Notes, input.Body)
+	return object{"id": len(mr.Notes), "body": input.Body}, nil
+}
+
+func (s *Server) mergeMergeRequestREST(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Squash bool `json:"squash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, badRequest(err)
+	}
+	if err := s.mergeMergeRequest(mr, input.Squash); err != nil {
+		return nil, &restError{status: http.StatusMethodNotAllowed, err: err}
+	}
+	return s.restMergeRequest(mr, true), nil
+}
+
+func (s *Server) listMergeTrain(r *http.Request) (interface{}, error) {
+	var cars []object
+	for _, mr := range s.mergeRequests {
+		if mr.InTrain {
+			cars = append(cars, object{"merge_request": object{"iid": mr.IID}, "status": "running"})
+		}
+	}
+	page, next := paginate(s, r, cars)
+	return restPage{items: page, next: next}, nil
+}
+
+func (s *Server) addToMergeTrain(r *http.Request) (interface{}, error) {
+	mr, err := s.mergeRequestFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Squash bool `json:"squash"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, badRequest(err)
+	}
+	if mr.State != StateOpened {
+		return nil, badRequest(fmt.Errorf("merge request !%d is not open", mr.IID))
+	}
+	mr.InTrain = true
+	mr.Squash = input.Squash
+	return []object{{"merge_request": object{"iid": mr.IID}, "status": "idle"}}, nil
+}
@@ -0,0 +1,193 @@
+package gitlabclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/github"
+)
+
+// perPage is the page size asked for when listing, the maximum GitLab allows
+const perPage = 100
+
+type user struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type pipeline struct {
+	ID     int64  `json:"id"`
+	SHA    string `json:"sha"`
+	Status string `json:"status"`
+}
+
+type job struct {
+	Name         string     `json:"name"`
+	Stage        string     `json:"stage"`
+	Status       string     `json:"status"`
+	AllowFailure bool       `json:"allow_failure"`
+	StartedAt    *time.Time `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	WebURL       string     `json:"web_url"`
+}
+
+type mergeRequest struct {
+	ID           int64     `json:"id"`
+	IID          int       `json:"iid"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	State        string    `json:"state"`
+	SourceBranch string    `json:"source_branch"`
+	TargetBranch string    `json:"target_branch"`
+	HasConflicts bool      `json:"has_conflicts"`
+	HeadPipeline *pipeline `json:"head_pipeline"`
+	Reviewers    []user    `json:"reviewers"`
+	Assignees    []user    `json:"assignees"`
+}
+
+type milestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type commit struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+type approvals struct {
+	Approved          bool `json:"approved"`
+	ApprovalsRequired int  `json:"approvals_required"`
+	ApprovedBy        []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"approved_by"`
+}
+
+// reviewStatus returns the approvals as review status, the approvals api has no change requests
+func (a approvals) reviewStatus() github.ReviewStatus {
+	var reviews []github.Review
+	for _, approver := range a.ApprovedBy {
+		reviews = append(reviews, github.Review{Author: approver.User.Username, State: "APPROVED"})
+	}
+	return github.NewReviewStatus(reviews, "", a.ApprovalsRequired, nil)
+}
+
+type mergeTrainCar struct {
+	MergeRequest struct {
+		IID int `json:"iid"`
+	} `json:"merge_request"`
+}
+
+// mergeRequestInput is the body of merge request create and update calls, unset fields are left unchanged
+type mergeRequestInput struct {
+	SourceBranch *string `json:"source_branch,omitempty"`
+	TargetBranch *string `json:"target_branch,omitempty"`
+	Title        *string `json:"title,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	StateEvent   *string `json:"state_event,omitempty"`
+	ReviewerIDs  []int64 `json:"reviewer_ids,omitempty"`
+	Labels       *string `json:"labels,omitempty"`
+	AddLabels    *string `json:"add_labels,omitempty"`
+	RemoveLabels *string `json:"remove_labels,omitempty"`
+	AssigneeIDs  []int64 `json:"assignee_ids,omitempty"`
+	MilestoneID  *int64  `json:"milestone_id,omitempty"`
+}
+
+// projectPath returns the api path of the project, its full path is url encoded as a single segment
+func (c *client) projectPath() string {
+	fullPath := c.config.Repo.GitHubRepoOwner + "/" + c.config.Repo.GitHubRepoName
+	return "/projects/" + url.PathEscape(fullPath)
+}
+
+func (c *client) mergeRequestPath(iid int) string {
+	return fmt.Sprintf("%s/merge_requests/%d", c.projectPath(), iid)
+}
+
+// do sends a request to the GitLab api and decodes the json response into res when it isn't nil.
+//
+//	It returns the number of the next page of a listing, or 0 on the last page.
+//	Rejected tokens are reported as github.ErrUnauthorized and missing merge
+//	requests as github.ErrPullRequestNotFound.
+func (c *client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, res interface{}) (int, error) {
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+
+	reqURL := c.baseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized:
+			return 0, fmt.Errorf("%w: %w\n make sure GITLAB_TOKEN env variable is set with a valid token", github.ErrUnauthorized, err)
+		case resp.StatusCode == http.StatusNotFound && strings.Contains(path, "/merge_requests/"):
+			return 0, fmt.Errorf("%w: %w", github.ErrPullRequestNotFound, err)
+		}
+		return 0, err
+	}
+
+	if res != nil {
+		err = json.NewDecoder(resp.Body).Decode(res)
+		if err != nil {
+			return 0, fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+		}
+	}
+	next, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return next, nil
+}
+
+// listPages fetches a listing page by page, truncated is set when there were more than github.MaxPages pages
+func listPages[T any](ctx context.Context, c *client, path string, query url.Values) (items []T, truncated bool, err error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+	for page := 1; page != 0; {
+		if page > github.MaxPages {
+			return items, true, nil
+		}
+		query.Set("page", strconv.Itoa(page))
+		var pageItems []T
+		page, err = c.do(ctx, http.MethodGet, path, query, nil, &pageItems)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, pageItems...)
+	}
+	return items, false, nil
+}
@@ -0,0 +1,869 @@
+// Package gitlabclient implements github.Forge with GitLab merge requests.
+//
+//	Each commit in the stack gets a merge request whose target branch is the
+//	 branch of the commit below it. Approvals are read from the approvals api,
+//	 the head pipeline status is used as the check status, and merges go through
+//	 the merge api or the merge train when mergeQueue is set.
+package gitlabclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ejoffe/spr/bl/concurrent"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/github/githubclient"
+	"github.com/rs/zerolog/log"
+)
+
+const tokenHelpText = `
+No GitLab token found! Create a personal access token with the "api" scope
+at https://%s/-/user_settings/personal_access_tokens and set the GITLAB_TOKEN
+environment variable.
+`
+
+// draftPrefix marks a merge request as a draft when its title starts with it
+const draftPrefix = "Draft: "
+
+// FindToken returns the GitLab personal access token set in the environment
+func FindToken() string {
+	return os.Getenv("GITLAB_TOKEN")
+}
+
+func NewGitLabClient(ctx context.Context, config *config.Config) (*client, error) {
+	token := FindToken()
+	if token == "" {
+		return nil, fmt.Errorf("%w: no token found\n%s",
+			github.ErrUnauthorized, fmt.Sprintf(tokenHelpText, config.Repo.GitHubHost))
+	}
+
+	var scheme, host string
+	gitLabRemoteUrl, err := url.Parse(config.Repo.GitHubHost)
+	if err != nil || gitLabRemoteUrl.Host == "" {
+		host = config.Repo.GitHubHost
+		scheme = "https"
+	} else {
+		host = gitLabRemoteUrl.Host
+		scheme = gitLabRemoteUrl.Scheme
+	}
+	return &client{
+		config:  config,
+		http:    http.DefaultClient,
+		baseURL: fmt.Sprintf("%s://%s/api/v4", scheme, host),
+		token:   token,
+	}, nil
+}
+
+type client struct {
+	config  *config.Config
+	http    *http.Client
+	baseURL string
+	token   string
+}
+
+func (c *client) GetInfo(ctx context.Context, gitcmd git.GitInterface) (*github.ForgeInfo, error) {
+	login, mergeRequests, truncated, err := c.fetchMergeRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	localCommitStack, err := git.GetLocalCommitStack(c.config, gitcmd)
+	if err != nil {
+		return nil, err
+	}
+	// only a stack checked out with spr checkout has merge requests opened by other users
+	if git.StackOwner(gitcmd) != "" {
+		others, err := c.fetchOtherUsersMergeRequests(ctx, localCommitStack, mergeRequests)
+		if err != nil {
+			return nil, err
+		}
+		mergeRequests = append(mergeRequests, others...)
+	}
+
+	inTrain := map[int]bool{}
+	if c.config.Repo.MergeQueue {
+		cars, _, err := listPages[mergeTrainCar](ctx, c, c.projectPath()+"/merge_trains", url.Values{
+			"scope": {"active"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching merge trains: %w", err)
+		}
+		for _, car := range cars {
+			inTrain[car.MergeRequest.IID] = true
+		}
+	}
+
+	fetched, commitsTruncated, err := c.fetchPullRequests(ctx, mergeRequests)
+	if err != nil {
+		return nil, err
+	}
+	truncated = truncated || commitsTruncated
+
+	// pullRequestMap is a map from commit-id to pull request
+	pullRequestMap := make(map[string]*github.PullRequest)
+	for _, pullRequest := range fetched {
+		pullRequest.InQueue = inTrain[pullRequest.Number]
+		pullRequestMap[pullRequest.Commit.CommitID] = pullRequest
+	}
+
+	pullRequests := []*github.PullRequest{}
+	if len(localCommitStack) != 0 {
+		pullRequests, err = github.MatchStack(c.config, localCommitStack, pullRequestMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, pr := range pullRequests {
+		if pr.Ready(c.config) {
+			pr.MergeStatus.Stacked = true
+		} else {
+			break
+		}
+	}
+
+	localBranch, err := git.GetLocalBranchName(gitcmd)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &github.ForgeInfo{
+		UserName:     login,
+		RepositoryID: c.config.Repo.GitHubRepoOwner + "/" + c.config.Repo.GitHubRepoName,
+		LocalBranch:  localBranch,
+		PullRequests: pullRequests,
+		Truncated:    truncated,
+	}
+
+	log.Debug().Interface("Info", info).Msg("GetInfo")
+	return info, nil
+}
+
+// fetchMergeRequests returns the login of the user and their open merge requests
+func (c *client) fetchMergeRequests(ctx context.Context) (string, []mergeRequest, bool, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab fetch merge requests\n")
+	}
+
+	login, err := c.GetLogin(ctx)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	mergeRequests, truncated, err := listPages[mergeRequest](ctx, c, c.projectPath()+"/merge_requests", url.Values{
+		"state":           {"opened"},
+		"author_username": {login},
+	})
+	if err != nil {
+		return "", nil, false, fmt.Errorf("fetching merge requests: %w", err)
+	}
+	return login, mergeRequests, truncated, nil
+}
+
+// GetOpenPullRequests returns the open merge requests of the user on branches named by spr
+func (c *client) GetOpenPullRequests(ctx context.Context) ([]*github.PullRequest, bool, error) {
+	_, mergeRequests, truncated, err := c.fetchMergeRequests(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	pullRequests, commitsTruncated, err := c.fetchPullRequests(ctx, mergeRequests)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated = truncated || commitsTruncated
+	slices.SortFunc(pullRequests, func(a, b *github.PullRequest) int {
+		return a.Number - b.Number
+	})
+	return pullRequests, truncated, nil
+}
+
+// fetchPullRequests fetches the merge requests on branches named by spr in parallel.
+//
+//	Each one takes requests for the merge request, its commits and its approvals,
+//	 truncated is set when the commits of one of them could not all be listed.
+func (c *client) fetchPullRequests(ctx context.Context, mergeRequests []mergeRequest) ([]*github.PullRequest, bool, error) {
+	type fetched struct {
+		pr        *github.PullRequest
+		truncated bool
+	}
+	results, err := concurrent.SliceMap(ctx, c.config.Repo.Concurrency, mergeRequests,
+		func(ctx context.Context, mr mergeRequest) (fetched, error) {
+			commitID := git.CommitIDFromBranch(c.config, mr.SourceBranch)
+			if commitID == "" {
+				return fetched{}, nil
+			}
+			pr, truncated, err := c.fetchPullRequest(ctx, mr.IID, commitID)
+			return fetched{pr: pr, truncated: truncated}, err
+		})
+	if err != nil {
+		return nil, false, err
+	}
+	var pullRequests []*github.PullRequest
+	truncated := false
+	for _, result := range results {
+		truncated = truncated || result.truncated
+		if result.pr != nil {
+			pullRequests = append(pullRequests, result.pr)
+		}
+	}
+	return pullRequests, truncated, nil
+}
+
+// fetchOtherUsersMergeRequests returns the merge requests of local commits which were opened by
+//
+//	other users, like a stack checked out with spr checkout. Merge requests are looked
+//	up by branch from the bottom of the stack up to the first commit without one.
+func (c *client) fetchOtherUsersMergeRequests(ctx context.Context,
+	localCommitStack []git.Commit, mergeRequests []mergeRequest) ([]mergeRequest, error) {
+	sources := map[string]bool{}
+	for _, mr := range mergeRequests {
+		sources[mr.SourceBranch] = true
+	}
+	var others []mergeRequest
+	for _, commit := range localCommitStack {
+		branch := git.BranchNameFromCommit(c.config, commit)
+		if sources[branch] {
+			continue
+		}
+		mr, err := c.fetchMergeRequestBySource(ctx, branch)
+		if err != nil {
+			return nil, err
+		}
+		if mr == nil {
+			break
+		}
+		others = append(others, *mr)
+	}
+	return others, nil
+}
+
+// fetchMergeRequestBySource returns the open merge request of the source branch, or nil when there is none
+func (c *client) fetchMergeRequestBySource(ctx context.Context, branch string) (*mergeRequest, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab fetch merge request of %s\n", branch)
+	}
+	var mergeRequests []mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.projectPath()+"/merge_requests", url.Values{
+		"state":         {"opened"},
+		"source_branch": {branch},
+	}, nil, &mergeRequests)
+	if err != nil {
+		return nil, fmt.Errorf("fetching merge request of %s: %w", branch, err)
+	}
+	if len(mergeRequests) == 0 {
+		return nil, nil
+	}
+	return &mergeRequests[0], nil
+}
+
+// GetPullRequestStack returns the open merge request with the given iid and the merge requests below it
+func (c *client) GetPullRequestStack(ctx context.Context, number int) ([]*github.PullRequest, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab fetch merge request !%d\n", number)
+	}
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.mergeRequestPath(number), nil, nil, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("fetching merge request !%d: %w", number, err)
+	}
+	if mr.State != "opened" {
+		return nil, fmt.Errorf("merge request !%d is %s", number, mr.State)
+	}
+	pullRequest := func(mr *mergeRequest) *github.PullRequest {
+		return &github.PullRequest{
+			ID:         strconv.FormatInt(mr.ID, 10),
+			Number:     mr.IID,
+			Title:      mr.Title,
+			FromBranch: mr.SourceBranch,
+			ToBranch:   mr.TargetBranch,
+		}
+	}
+	return github.WalkStack(c.config, pullRequest(&mr), func(branch string) (*github.PullRequest, error) {
+		mr, err := c.fetchMergeRequestBySource(ctx, branch)
+		if err != nil || mr == nil {
+			return nil, err
+		}
+		return pullRequest(mr), nil
+	})
+}
+
+// fetchPullRequest fetches the merge request with its commits, approvals and pipeline.
+//
+//	commitID is the commit-id of the merge request branch, nil is returned
+//	when the merge request has no commits.
+func (c *client) fetchPullRequest(ctx context.Context, iid int, commitID string) (*github.PullRequest, bool, error) {
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.mergeRequestPath(iid), nil, nil, &mr)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching merge request !%d: %w", iid, err)
+	}
+
+	mrCommits, truncated, err := listPages[commit](ctx, c, c.mergeRequestPath(iid)+"/commits", nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching commits of merge request !%d: %w", iid, err)
+	}
+	if len(mrCommits) == 0 {
+		return nil, truncated, nil
+	}
+	// GitLab lists the newest commit first
+	slices.Reverse(mrCommits)
+
+	var approval approvals
+	_, err = c.do(ctx, http.MethodGet, c.mergeRequestPath(iid)+"/approvals", nil, nil, &approval)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching approvals of merge request !%d: %w", iid, err)
+	}
+
+	var commits []git.Commit
+	for _, v := range mrCommits {
+		for _, line := range strings.Split(v.Message, "\n") {
+			if strings.HasPrefix(line, "commit-id:") {
+				commits = append(commits, git.Commit{
+					CommitID:   strings.Split(line, ":")[1],
+					CommitHash: v.ID,
+					Subject:    v.Title,
+					Body:       commitBody(v),
+				})
+			}
+		}
+	}
+
+	// merge requests without a pipeline have no checks to wait for
+	checkStatus := github.CheckStatusPass
+	if mr.HeadPipeline != nil {
+		checkStatus = pipelineStatus(mr.HeadPipeline.Status)
+	}
+
+	head := mrCommits[len(mrCommits)-1]
+	pr := &github.PullRequest{
+		ID:         strconv.FormatInt(mr.ID, 10),
+		Number:     mr.IID,
+		Title:      mr.Title,
+		Body:       mr.Description,
+		FromBranch: mr.SourceBranch,
+		ToBranch:   mr.TargetBranch,
+		Commits:    commits,
+		Commit: git.Commit{
+			CommitID:   commitID,
+			CommitHash: head.ID,
+			Subject:    head.Title,
+			Body:       commitBody(head),
+		},
+		Draft: strings.HasPrefix(mr.Title, draftPrefix),
+		MergeStatus: github.PullRequestMergeStatus{
+			ChecksPass:     checkStatus,
+			ReviewApproved: approval.Approved,
+			Reviews:        approval.reviewStatus(),
+			NoConflicts:    !mr.HasConflicts,
+		},
+	}
+	pr.Commit.ParseTrailers()
+	return pr, truncated, nil
+}
+
+// pipelineStatus returns the check status of a pipeline or job status
+func pipelineStatus(status string) github.CheckStatus {
+	switch status {
+	case "success", "skipped", "manual":
+		return github.CheckStatusPass
+	case "failed", "canceled":
+		return github.CheckStatusFail
+	default:
+		return github.CheckStatusPending
+	}
+}
+
+// GetChecks returns the jobs of the head pipeline of the merge request.
+//
+//	Jobs which aren't allowed to fail are required, as their failure fails the pipeline.
+func (c *client) GetChecks(ctx context.Context, pr *github.PullRequest) ([]github.Check, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab fetch checks !%d\n", pr.Number)
+	}
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.mergeRequestPath(pr.Number), nil, nil, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("fetching merge request !%d: %w", pr.Number, err)
+	}
+	if mr.HeadPipeline == nil {
+		return nil, nil
+	}
+	jobs, _, err := listPages[job](ctx, c, fmt.Sprintf("%s/pipelines/%d/jobs", c.projectPath(), mr.HeadPipeline.ID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs of merge request !%d: %w", pr.Number, err)
+	}
+	var checks []github.Check
+	for _, j := range jobs {
+		check := github.Check{
+			Name:       j.Name,
+			Suite:      j.Stage,
+			Status:     pipelineStatus(j.Status),
+			Required:   !j.AllowFailure,
+			DetailsURL: j.WebURL,
+		}
+		if j.StartedAt != nil {
+			check.StartedAt = *j.StartedAt
+		}
+		if j.FinishedAt != nil {
+			check.CompletedAt = *j.FinishedAt
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// commitBody returns the commit message without its subject line
+func commitBody(c commit) string {
+	_, body, _ := strings.Cut(c.Message, "\n")
+	return strings.TrimSpace(body)
+}
+
+// GetLogin returns the username of the user the token belongs to
+func (c *client) GetLogin(ctx context.Context) (string, error) {
+	var viewer user
+	_, err := c.do(ctx, http.MethodGet, "/user", nil, nil, &viewer)
+	if err != nil {
+		return "", fmt.Errorf("fetching user: %w", err)
+	}
+	return viewer.Username, nil
+}
+
+func (c *client) GetAssignableUsers(ctx context.Context) ([]github.RepoAssignee, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab get project members\n")
+	}
+
+	members, _, err := listPages[user](ctx, c, c.projectPath()+"/members/all", nil)
+	if err != nil {
+		return nil, fmt.Errorf("get project members failed: %w", err)
+	}
+	users := []github.RepoAssignee{}
+	for _, member := range members {
+		users = append(users, github.RepoAssignee{
+			ID:    strconv.FormatInt(member.ID, 10),
+			Login: member.Username,
+			Name:  member.Name,
+		})
+	}
+	return users, nil
+}
+
+func (c *client) CreatePullRequest(ctx context.Context, gitcmd git.GitInterface,
+	info *github.ForgeInfo, commit git.Commit, prevCommit *git.Commit) (*github.PullRequest, error) {
+
+	targetBranch := c.config.Repo.GitHubBranch
+	if prevCommit != nil {
+		targetBranch = git.BranchNameFromCommit(c.config, *prevCommit)
+	}
+	sourceBranch := git.BranchNameFromCommit(c.config, commit)
+
+	log.Debug().Interface("Commit", commit).
+		Str("FromBranch", sourceBranch).Str("ToBranch", targetBranch).
+		Msg("CreatePullRequest")
+
+	var stack []*github.PullRequest
+	if info != nil {
+		stack = info.PullRequests
+	}
+	body, err := c.formatBody(gitcmd, commit, stack, nil)
+	if err != nil {
+		return nil, err
+	}
+	title := commit.Subject
+	draft := c.config.User.CreateDraftPRs
+	if commit.Draft != nil {
+		draft = *commit.Draft
+	}
+	if draft {
+		title = draftPrefix + title
+	}
+
+	input := mergeRequestInput{
+		SourceBranch: &sourceBranch,
+		TargetBranch: &targetBranch,
+		Title:        &title,
+		Description:  &body,
+	}
+	err = c.trailerInput(ctx, commit, nil, &input)
+	if err != nil {
+		return nil, err
+	}
+	var mr mergeRequest
+	_, err = c.do(ctx, http.MethodPost, c.projectPath()+"/merge_requests", nil, input, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("merge request create failed for commit %s: %w", commit.CommitID, err)
+	}
+
+	pr := &github.PullRequest{
+		ID:         strconv.FormatInt(mr.ID, 10),
+		Number:     mr.IID,
+		FromBranch: sourceBranch,
+		ToBranch:   targetBranch,
+		Commit:     commit,
+		Title:      mr.Title,
+		Draft:      draft,
+		MergeStatus: github.PullRequestMergeStatus{
+			ChecksPass:     github.CheckStatusUnknown,
+			ReviewApproved: false,
+			NoConflicts:    false,
+			Stacked:        false,
+		},
+	}
+
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab create %d : %s\n", pr.Number, pr.Title)
+	}
+
+	return pr, nil
+}
+
+// formatBody returns the merge request description with the stack listed as merge request references
+func (c *client) formatBody(gitcmd git.GitInterface, commit git.Commit, stack []*github.PullRequest, pr *github.PullRequest) (string, error) {
+	body := githubclient.FormatStackBody(commit, stack, c.config.Repo.ShowPrTitlesInStack, "!")
+	if c.config.Repo.PRTemplatePath == "" {
+		return body, nil
+	}
+	pullRequestTemplate, err := githubclient.ReadPRTemplate(gitcmd, c.config.Repo.PRTemplatePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PR template: %w", err)
+	}
+	body, err = githubclient.InsertBodyIntoPRTemplate(body, pullRequestTemplate, c.config.Repo, pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to insert body into PR template: %w", err)
+	}
+	return body, nil
+}
+
+func (c *client) UpdatePullRequest(ctx context.Context, gitcmd git.GitInterface, pullRequests []*github.PullRequest, pr *github.PullRequest, commit git.Commit, prevCommit *git.Commit) error {
+
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab update %d : %s\n", pr.Number, pr.Title)
+	}
+
+	targetBranch := c.config.Repo.GitHubBranch
+	if prevCommit != nil {
+		targetBranch = git.BranchNameFromCommit(c.config, *prevCommit)
+	}
+
+	log.Debug().Interface("Commit", commit).
+		Str("FromBranch", pr.FromBranch).Str("ToBranch", targetBranch).
+		Interface("PR", pr).Msg("UpdatePullRequest")
+
+	body, err := c.formatBody(gitcmd, commit, pullRequests, pr)
+	if err != nil {
+		return err
+	}
+	// keep drafts as drafts unless the commit says otherwise, a title without
+	// the prefix marks the merge request ready
+	title := commit.Subject
+	draft := strings.HasPrefix(pr.Title, draftPrefix)
+	if commit.Draft != nil {
+		draft = *commit.Draft
+	}
+	if draft {
+		title = draftPrefix + title
+	}
+
+	input := mergeRequestInput{
+		Title:       &title,
+		Description: &body,
+	}
+	if !pr.InQueue {
+		input.TargetBranch = &targetBranch
+	}
+	if c.config.User.PreserveTitleAndBody {
+		input.Title = nil
+		input.Description = nil
+	}
+	err = c.trailerInput(ctx, commit, pr, &input)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, input, nil)
+	if err != nil {
+		return fmt.Errorf("merge request update failed for !%d: %w", pr.Number, err)
+	}
+	return nil
+}
+
+// trailerInput sets the labels, assignees and milestone of the commit trailers on input.
+//
+//	pr is the merge request being updated, or nil when it is created. Labels and
+//	assignees already on the merge request are kept.
+func (c *client) trailerInput(ctx context.Context, commit git.Commit, pr *github.PullRequest, input *mergeRequestInput) error {
+	if len(commit.Labels) > 0 {
+		labels := strings.Join(commit.Labels, ",")
+		if pr == nil {
+			input.Labels = &labels
+		} else {
+			input.AddLabels = &labels
+		}
+	}
+
+	if len(commit.Assignees) > 0 {
+		assigneeIDs, err := c.assigneeIDs(ctx, pr, commit.Assignees)
+		if err != nil {
+			return err
+		}
+		input.AssigneeIDs = assigneeIDs
+	}
+
+	if commit.Milestone != "" {
+		milestoneID, err := c.milestoneID(ctx, commit.Milestone)
+		if err != nil {
+			return err
+		}
+		input.MilestoneID = &milestoneID
+	}
+	return nil
+}
+
+// assigneeIDs returns the user ids of the merge request assignees together with the
+//
+//	users with the given logins, pr is nil when the merge request is created.
+//	assignee_ids replaces the assignees so the current ones have to be kept.
+func (c *client) assigneeIDs(ctx context.Context, pr *github.PullRequest, logins []string) ([]int64, error) {
+	var assigneeIDs []int64
+	if pr != nil {
+		var mr mergeRequest
+		_, err := c.do(ctx, http.MethodGet, c.mergeRequestPath(pr.Number), nil, nil, &mr)
+		if err != nil {
+			return nil, fmt.Errorf("add assignees %v failed for !%d: %w", logins, pr.Number, err)
+		}
+		for _, assignee := range mr.Assignees {
+			assigneeIDs = append(assigneeIDs, assignee.ID)
+		}
+	}
+	members, err := c.GetAssignableUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, login := range logins {
+		i := slices.IndexFunc(members, func(u github.RepoAssignee) bool { return strings.EqualFold(login, u.Login) })
+		if i < 0 {
+			return nil, fmt.Errorf("unable to add assignee, user %q not found", login)
+		}
+		id, err := strconv.ParseInt(members[i].ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to add assignee %q: invalid user id %q", login, members[i].ID)
+		}
+		if !slices.Contains(assigneeIDs, id) {
+			assigneeIDs = append(assigneeIDs, id)
+		}
+	}
+	return assigneeIDs, nil
+}
+
+// milestoneID returns the id of the active milestone with the given title
+func (c *client) milestoneID(ctx context.Context, title string) (int64, error) {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab get milestone %s\n", title)
+	}
+	var milestones []milestone
+	_, err := c.do(ctx, http.MethodGet, c.projectPath()+"/milestones", url.Values{
+		"title": {title},
+		"state": {"active"},
+	}, nil, &milestones)
+	if err != nil {
+		return 0, fmt.Errorf("get milestone %s failed: %w", title, err)
+	}
+	if len(milestones) == 0 {
+		return 0, fmt.Errorf("unable to set milestone, active milestone %q not found", title)
+	}
+	return milestones[0].ID, nil
+}
+
+// AddLabels adds labels to the merge request, labels which don't exist yet are created by GitLab
+func (c *client) AddLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab add labels %d : %s - %v\n", pr.Number, pr.Title, labels)
+	}
+	joined := strings.Join(labels, ",")
+	_, err := c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		AddLabels: &joined,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("add labels %v failed for !%d: %w", labels, pr.Number, err)
+	}
+	return nil
+}
+
+func (c *client) RemoveLabels(ctx context.Context, pr *github.PullRequest, labels []string) error {
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab remove labels %d : %s - %v\n", pr.Number, pr.Title, labels)
+	}
+	joined := strings.Join(labels, ",")
+	_, err := c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		RemoveLabels: &joined,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("remove labels %v failed for !%d: %w", labels, pr.Number, err)
+	}
+	return nil
+}
+
+func (c *client) AddAssignees(ctx context.Context, pr *github.PullRequest, logins []string) error {
+	assigneeIDs, err := c.assigneeIDs(ctx, pr, logins)
+	if err != nil {
+		return err
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab add assignees %d : %s - %v\n", pr.Number, pr.Title, logins)
+	}
+	_, err = c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		AssigneeIDs: assigneeIDs,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("add assignees %v failed for !%d: %w", logins, pr.Number, err)
+	}
+	return nil
+}
+
+func (c *client) SetMilestone(ctx context.Context, pr *github.PullRequest, title string) error {
+	milestoneID, err := c.milestoneID(ctx, title)
+	if err != nil {
+		return err
+	}
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab set milestone %d : %s - %s\n", pr.Number, pr.Title, title)
+	}
+	_, err = c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		MilestoneID: &milestoneID,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("set milestone %s failed for !%d: %w", title, pr.Number, err)
+	}
+	return nil
+}
+
+// GetTeamID fails, GitLab merge requests can only be reviewed by users
+func (c *client) GetTeamID(ctx context.Context, team string) (string, error) {
+	return "", fmt.Errorf("unable to add reviewer %q, team reviewers are not supported on GitLab", team)
+}
+
+// AddReviewers adds reviewers to the merge request, userIDs are GitLab user ids
+//
+//	as returned by GetAssignableUsers. Reviewers which were requested before are kept.
+func (c *client) AddReviewers(ctx context.Context, pr *github.PullRequest, userIDs []string, teamIDs []string) error {
+	log.Debug().Strs("userIDs", userIDs).Msg("AddReviewers")
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab add reviewers %d : %s - %+v\n", pr.Number, pr.Title, userIDs)
+	}
+	if len(teamIDs) > 0 {
+		return fmt.Errorf("add reviewers failed for !%d: team reviewers are not supported on GitLab", pr.Number)
+	}
+
+	// reviewer_ids replaces the reviewers, start from the current ones
+	var mr mergeRequest
+	_, err := c.do(ctx, http.MethodGet, c.mergeRequestPath(pr.Number), nil, nil, &mr)
+	if err != nil {
+		return fmt.Errorf("add reviewers %v failed for !%d: %w", userIDs, pr.Number, err)
+	}
+	var reviewerIDs []int64
+	for _, reviewer := range mr.Reviewers {
+		reviewerIDs = append(reviewerIDs, reviewer.ID)
+	}
+	for _, userID := range userIDs {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("add reviewers %v failed for !%d: invalid user id %q", userIDs, pr.Number, userID)
+		}
+		if !slices.Contains(reviewerIDs, id) {
+			reviewerIDs = append(reviewerIDs, id)
+		}
+	}
+	_, err = c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		ReviewerIDs: reviewerIDs,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("add reviewers %v failed for !%d: %w", userIDs, pr.Number, err)
+	}
+	return nil
+}
+
+func (c *client) CommentPullRequest(ctx context.Context, pr *github.PullRequest, comment string) error {
+	_, err := c.do(ctx, http.MethodPost, c.mergeRequestPath(pr.Number)+"/notes", nil, map[string]string{
+		"body": comment,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("merge request comment failed for !%d: %w", pr.Number, err)
+	}
+
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab add comment %d : %s\n", pr.Number, pr.Title)
+	}
+	return nil
+}
+
+// MergePullRequest merges the merge request, or adds it to the merge train when mergeQueue is set.
+//
+//	GitLab merges with the merge method configured for the project, squash is
+//	 the only merge method which is passed on.
+func (c *client) MergePullRequest(ctx context.Context,
+	pr *github.PullRequest, mergeMethod github.MergeMethod) error {
+	log.Debug().
+		Interface("PR", pr).
+		Str("mergeMethod", string(mergeMethod)).
+		Msg("MergePullRequest")
+
+	input := map[string]bool{
+		"squash": mergeMethod == github.MergeMethodSquash,
+	}
+	var err error
+	if c.config.Repo.MergeQueue {
+		_, err = c.do(ctx, http.MethodPost,
+			fmt.Sprintf("%s/merge_trains/merge_requests/%d", c.projectPath(), pr.Number), nil, input, nil)
+	} else {
+		_, err = c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number)+"/merge", nil, input, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("merge request merge failed for !%d: %w", pr.Number, err)
+	}
+
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab merge %d : %s\n", pr.Number, pr.Title)
+	}
+	return nil
+}
+
+func (c *client) ClosePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	log.Debug().Interface("PR", pr).Msg("ClosePullRequest")
+	stateEvent := "close"
+	_, err := c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		StateEvent: &stateEvent,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("merge request close failed for !%d: %w", pr.Number, err)
+	}
+
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab close %d : %s\n", pr.Number, pr.Title)
+	}
+	return nil
+}
+
+func (c *client) RestorePullRequest(ctx context.Context, pr *github.PullRequest) error {
+	log.Debug().Interface("PR", pr).Msg("RestorePullRequest")
+	if c.config.User.LogGitHubCalls {
+		fmt.Printf("> gitlab restore %d : %s\n", pr.Number, pr.Title)
+	}
+	stateEvent := "reopen"
+	_, err := c.do(ctx, http.MethodPut, c.mergeRequestPath(pr.Number), nil, mergeRequestInput{
+		StateEvent:   &stateEvent,
+		TargetBranch: &pr.ToBranch,
+		Title:        &pr.Title,
+		Description:  &pr.Body,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("merge request restore failed for !%d: %w", pr.Number, err)
+	}
+	return nil
+}
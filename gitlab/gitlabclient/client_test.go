@@ -0,0 +1,243 @@
+package gitlabclient
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/gitlab/fakegitlab"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestClient(t *testing.T) (*client, *fakegitlab.Server) {
+	fake := fakegitlab.New(t)
+	t.Setenv("GITLAB_TOKEN", fakegitlab.Token)
+
+	cfg := config.DefaultConfig()
+	cfg.Repo.Forge = config.ForgeGitLab
+	cfg.Repo.GitHubHost = fake.URL
+	cfg.Repo.GitHubRepoOwner = fake.Owner
+	cfg.Repo.GitHubRepoName = fake.Name
+	cfg.Repo.GitHubBranch = fakegitlab.DefaultBranch
+
+	c, err := NewGitLabClient(context.Background(), cfg)
+	require.NoError(t, err)
+	return c, fake
+}
+
+// pushCommits pushes commits with the given subjects to branch and returns their hashes
+func pushCommits(t *testing.T, fake *fakegitlab.Server, branch string, subjects ...string) []string {
+	dir := fake.Clone(t)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+		return strings.TrimSpace(string(out))
+	}
+	var hashes []string
+	for i, subject := range subjects {
+		run("commit", "--allow-empty", "-m", fmt.Sprintf("%s\n\nbody %d\n\ncommit-id:0000000%d", subject, i, i))
+		hashes = append(hashes, run("rev-parse", "HEAD"))
+	}
+	run("push", "origin", "HEAD:refs/heads/"+branch)
+	return hashes
+}
+
+func TestNewGitLabClient(t *testing.T) {
+	t.Setenv("GITLAB_TOKEN", "")
+	cfg := config.DefaultConfig()
+	cfg.Repo.GitHubHost = "gitlab.example.com"
+	_, err := NewGitLabClient(context.Background(), cfg)
+	require.ErrorIs(t, err, github.ErrUnauthorized)
+
+	t.Setenv("GITLAB_TOKEN", "token")
+	c, err := NewGitLabClient(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Equal(t, "https://gitlab.example.com/api/v4", c.baseURL)
+
+	cfg.Repo.GitHubHost = "http://localhost:8080"
+	c, err = NewGitLabClient(context.Background(), cfg)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/api/v4", c.baseURL)
+}
+
+func TestProjectPath(t *testing.T) {
+	cfg := config.EmptyConfig()
+	cfg.Repo.GitHubRepoOwner = "group/sub-group"
+	cfg.Repo.GitHubRepoName = "repo"
+	c := &client{config: cfg}
+	require.Equal(t, "/projects/group%2Fsub-group%2Frepo", c.projectPath())
+	require.Equal(t, "/projects/group%2Fsub-group%2Frepo/merge_requests/7", c.mergeRequestPath(7))
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := makeTestClient(t)
+	ctx := context.Background()
+
+	err := c.ClosePullRequest(ctx, &github.PullRequest{Number: 42})
+	require.ErrorIs(t, err, github.ErrPullRequestNotFound)
+
+	c.token = "bad-token"
+	_, err = c.GetAssignableUsers(ctx)
+	require.ErrorIs(t, err, github.ErrUnauthorized)
+}
+
+func TestGetAssignableUsersPagination(t *testing.T) {
+	c, fake := makeTestClient(t)
+	fake.MaxPageSize = 1
+
+	users, err := c.GetAssignableUsers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []github.RepoAssignee{
+		{ID: "1", Login: "spr-user", Name: "Spr User"},
+		{ID: "2", Login: "reviewer", Name: "Re Viewer"},
+	}, users)
+}
+
+func TestFetchPullRequest(t *testing.T) {
+	c, fake := makeTestClient(t)
+	ctx := context.Background()
+	c.config.User.CreateDraftPRs = true
+
+	hashes := pushCommits(t, fake, "spr/main/00000002", "first", "second", "third")
+	pr, err := c.CreatePullRequest(ctx, nil, &github.ForgeInfo{},
+		git.Commit{CommitID: "00000002", Subject: "third"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, pr.Number)
+	require.Equal(t, "Draft: third", pr.Title)
+	require.Equal(t, "spr/main/00000002", pr.FromBranch)
+	require.Equal(t, "main", pr.ToBranch)
+
+	// commits are listed one per page and come back oldest first
+	fake.MaxPageSize = 1
+	fetched, truncated, err := c.fetchPullRequest(ctx, pr.Number, "00000002")
+	require.NoError(t, err)
+	require.False(t, truncated)
+	require.Len(t, fetched.Commits, 3)
+	for i, hash := range hashes {
+		require.Equal(t, hash, fetched.Commits[i].CommitHash)
+	}
+	require.Equal(t, git.Commit{
+		CommitID:   "00000002",
+		CommitHash: hashes[2],
+		Subject:    "third",
+		Body:       "body 2",
+	}, fetched.Commit)
+	require.True(t, fetched.Draft)
+	require.Equal(t, github.PullRequestMergeStatus{
+		ChecksPass:     github.CheckStatusPass,
+		ReviewApproved: false,
+		NoConflicts:    true,
+	}, fetched.MergeStatus)
+
+	for status, expected := range map[string]interface{}{
+		"success":  github.CheckStatusPass,
+		"manual":   github.CheckStatusPass,
+		"running":  github.CheckStatusPending,
+		"pending":  github.CheckStatusPending,
+		"failed":   github.CheckStatusFail,
+		"canceled": github.CheckStatusFail,
+	} {
+		fake.SetPipelineStatus(hashes[2], status)
+		fetched, _, err = c.fetchPullRequest(ctx, pr.Number, "00000002")
+		require.NoError(t, err)
+		require.Equal(t, expected, fetched.MergeStatus.ChecksPass, status)
+	}
+
+	fake.ApproveAll()
+	fetched, _, err = c.fetchPullRequest(ctx, pr.Number, "00000002")
+	require.NoError(t, err)
+	require.True(t, fetched.MergeStatus.ReviewApproved)
+
+	// updates keep the merge request a draft
+	err = c.UpdatePullRequest(ctx, nil, nil, fetched, git.Commit{CommitID: "00000002", Subject: "third v2"}, nil)
+	require.NoError(t, err)
+	mr, _ := fake.MergeRequest(pr.Number)
+	require.Equal(t, "Draft: third v2", mr.Title)
+}
+
+func TestGetChecks(t *testing.T) {
+	c, fake := makeTestClient(t)
+	ctx := context.Background()
+
+	hashes := pushCommits(t, fake, "spr/main/00000000", "first")
+	pr, err := c.CreatePullRequest(ctx, nil, &github.ForgeInfo{},
+		git.Commit{CommitID: "00000000", Subject: "first"}, nil)
+	require.NoError(t, err)
+
+	// merge requests without a pipeline have no checks
+	checks, err := c.GetChecks(ctx, pr)
+	require.NoError(t, err)
+	require.Empty(t, checks)
+
+	fake.SetPipelineStatus(hashes[0], "failed")
+	fake.AddPipelineJob(hashes[0], fakegitlab.Job{Name: "build", Stage: "test", Status: "success", WebURL: "https://ci/1"})
+	fake.AddPipelineJob(hashes[0], fakegitlab.Job{Name: "lint", Stage: "test", Status: "failed", AllowFailure: true})
+	fake.MaxPageSize = 1
+	checks, err = c.GetChecks(ctx, pr)
+	require.NoError(t, err)
+	require.Equal(t, []github.Check{
+		{Name: "build", Suite: "test", Status: github.CheckStatusPass, Required: true, DetailsURL: "https://ci/1"},
+		{Name: "lint", Suite: "test", Status: github.CheckStatusFail},
+	}, checks)
+}
+
+func TestTrailers(t *testing.T) {
+	c, fake := makeTestClient(t)
+	ctx := context.Background()
+	pushCommits(t, fake, "spr/main/00000001", "first")
+
+	draft := true
+	commit := git.Commit{CommitID: "00000001", Subject: "first",
+		Labels: []string{"backend"}, Assignees: []string{"reviewer"}, Milestone: "v1.0", Draft: &draft}
+	pr, err := c.CreatePullRequest(ctx, nil, &github.ForgeInfo{}, commit, nil)
+	require.NoError(t, err)
+	require.True(t, pr.Draft)
+	mr, _ := fake.MergeRequest(pr.Number)
+	require.Equal(t, "Draft: first", mr.Title)
+	require.Equal(t, []string{"backend"}, mr.Labels)
+	require.Equal(t, []int64{2}, mr.AssigneeIDs)
+	require.Equal(t, "v1.0", mr.Milestone)
+
+	// labels and assignees are added to the current ones
+	draft = false
+	commit.Labels = []string{"bug"}
+	commit.Assignees = []string{"spr-user"}
+	err = c.UpdatePullRequest(ctx, nil, nil, pr, commit, nil)
+	require.NoError(t, err)
+	mr, _ = fake.MergeRequest(pr.Number)
+	require.Equal(t, "first", mr.Title)
+	require.Equal(t, []string{"backend", "bug"}, mr.Labels)
+	require.Equal(t, []int64{2, 1}, mr.AssigneeIDs)
+
+	commit.Milestone = "v2.0"
+	err = c.UpdatePullRequest(ctx, nil, nil, pr, commit, nil)
+	require.ErrorContains(t, err, `milestone "v2.0" not found`)
+}
+
+func TestLabelsAssigneesMilestone(t *testing.T) {
+	c, fake := makeTestClient(t)
+	ctx := context.Background()
+	pushCommits(t, fake, "spr/main/00000001", "first")
+	pr, err := c.CreatePullRequest(ctx, nil, &github.ForgeInfo{},
+		git.Commit{CommitID: "00000001", Subject: "first"}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.AddLabels(ctx, pr, []string{"backend", "bug"}))
+	require.NoError(t, c.RemoveLabels(ctx, pr, []string{"backend"}))
+	require.NoError(t, c.AddAssignees(ctx, pr, []string{"reviewer"}))
+	require.NoError(t, c.AddAssignees(ctx, pr, []string{"spr-user"}))
+	require.NoError(t, c.SetMilestone(ctx, pr, "v1.0"))
+	mr, _ := fake.MergeRequest(pr.Number)
+	require.Equal(t, []string{"bug"}, mr.Labels)
+	require.Equal(t, []int64{2, 1}, mr.AssigneeIDs)
+	require.Equal(t, "v1.0", mr.Milestone)
+
+	require.ErrorContains(t, c.AddAssignees(ctx, pr, []string{"nobody"}), `user "nobody" not found`)
+}
@@ -17,12 +17,10 @@ import (
 	"github.com/ejoffe/spr/config"
 	"github.com/ejoffe/spr/config/config_parser"
 	"github.com/ejoffe/spr/git/realgit"
-	"github.com/ejoffe/spr/github"
 	"github.com/ejoffe/spr/github/githubclient"
 	"github.com/ejoffe/spr/spr"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	gogithub "github.com/google/go-github/v69/github"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,13 +64,15 @@ func initialize(t *testing.T, cfgfn func(*config.Config)) *resources {
 
 	// Parse the config then overwrite the state and the global settings
 	// This is so we can re-use the repos settings.
-	gitcmd := realgit.NewGitCmd(config.DefaultConfig())
+	gitcmd, err := realgit.NewGitCmd(config.DefaultConfig())
+	require.NoError(t, err)
 	//  check that we are inside a git dir
 	var output string
 	err = gitcmd.Git("status --porcelain", &output)
 	require.NoError(t, err)
 
-	cfg := config_parser.ParseConfig(gitcmd)
+	cfg, err := config_parser.ParseConfig(gitcmd)
+	require.NoError(t, err)
 	// Overwrite State and User so the test has a consistent experience.
 	cfgdefault := config.DefaultConfig()
 	cfg.State = cfgdefault.State
@@ -83,18 +83,18 @@ func initialize(t *testing.T, cfgfn func(*config.Config)) *resources {
 	err = config_parser.CheckConfig(cfg)
 	require.NoError(t, err)
 
-	gitcmd = realgit.NewGitCmd(cfg)
+	gitcmd, err = realgit.NewGitCmd(cfg)
+	require.NoError(t, err)
 	wd, err := os.Getwd()
 	require.NoError(t, err)
 
 	repo, err := git.PlainOpen(wd)
 	require.NoError(t, err)
 
-	goghclient := gogithub.NewClient(nil).WithAuthToken(github.FindToken(cfg.Repo.GitHubHost))
-
 	ctx := context.Background()
-	client := githubclient.NewGitHubClient(ctx, cfg)
-	stackedpr := spr.NewStackedPR(cfg, client, gitcmd, repo, goghclient)
+	client, err := githubclient.NewGitHubClient(ctx, cfg, nil)
+	require.NoError(t, err)
+	stackedpr := spr.NewStackedPR(cfg, client, gitcmd, repo)
 
 	// Direct the output to a strings.Builder so we can test against the output
 	var sb strings.Builder
@@ -102,13 +102,14 @@ func initialize(t *testing.T, cfgfn func(*config.Config)) *resources {
 
 	// Create a cleanup function to try and reset the repo
 	cleanupFn := func() {
-		state, err := bl.NewReadState(ctx, cfg, goghclient, repo)
+		state, err := bl.NewReadState(ctx, cfg, client, repo)
 		require.NoError(t, err)
 
-		gitapi := gitapi.New(cfg, repo, goghclient)
+		gitapi := gitapi.New(cfg, repo)
 		for _, commit := range state.Commits {
 			if commit.PullRequest != nil {
-				gitapi.DeletePullRequest(ctx, commit.PullRequest)
+				client.ClosePullRequest(ctx, commit.PullRequest)
+				gitapi.DeleteRemoteBranch(ctx, commit.PullRequest.FromBranch)
 			}
 		}
 
@@ -183,7 +184,7 @@ func TestBasicCommitUpdateMergeWithNoSubsetPRSets(t *testing.T) {
 	name := prefix + t.Name()
 
 	t.Run("Starts in expected state", func(t *testing.T) {
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, ".*no local commits.*", resources.sb.String())
 		resources.sb.Reset()
 	})
@@ -202,7 +203,7 @@ func TestBasicCommitUpdateMergeWithNoSubsetPRSets(t *testing.T) {
 			},
 		})
 
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "2.*No Pull Request Created", resources.sb.String())
 		require.Regexp(t, "1.*No Pull Request Created", resources.sb.String())
 		require.Regexp(t, "0.*No Pull Request Created", resources.sb.String())
@@ -210,9 +211,9 @@ func TestBasicCommitUpdateMergeWithNoSubsetPRSets(t *testing.T) {
 	})
 
 	t.Run("Can create PRs with spr update", func(t *testing.T) {
-		resources.stackedpr.UpdatePRSets(ctx, "0-2")
+		require.NoError(t, resources.stackedpr.UpdatePRSets(ctx, "0-2"))
 
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "2.*s0.*github.com", resources.sb.String())
 		require.Regexp(t, "1.*s0.*github.com", resources.sb.String())
 		require.Regexp(t, "0.*s0.*github.com", resources.sb.String())
@@ -220,8 +221,8 @@ func TestBasicCommitUpdateMergeWithNoSubsetPRSets(t *testing.T) {
 	})
 
 	t.Run("Can merge PRs with spr merge", func(t *testing.T) {
-		resources.stackedpr.MergePRSet(ctx, "s0")
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.MergePRSet(ctx, "s0"))
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, ".*no local commits.*", resources.sb.String())
 		resources.sb.Reset()
 	})
@@ -236,7 +237,7 @@ func TestBasicCommitUpdateMergeWithMultiplePRSets(t *testing.T) {
 	name := prefix + t.Name()
 
 	t.Run("Starts in expected state", func(t *testing.T) {
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, ".*no local commits.*", resources.sb.String())
 		resources.sb.Reset()
 	})
@@ -258,7 +259,7 @@ func TestBasicCommitUpdateMergeWithMultiplePRSets(t *testing.T) {
 			},
 		})
 
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "3.*No Pull Request Created", resources.sb.String())
 		require.Regexp(t, "2.*No Pull Request Created", resources.sb.String())
 		require.Regexp(t, "1.*No Pull Request Created", resources.sb.String())
@@ -267,11 +268,11 @@ func TestBasicCommitUpdateMergeWithMultiplePRSets(t *testing.T) {
 	})
 
 	t.Run("Can create PR sets with spr update", func(t *testing.T) {
-		resources.stackedpr.UpdatePRSets(ctx, "0-1")
-		resources.stackedpr.UpdatePRSets(ctx, "2")
-		resources.stackedpr.UpdatePRSets(ctx, "3")
+		require.NoError(t, resources.stackedpr.UpdatePRSets(ctx, "0-1"))
+		require.NoError(t, resources.stackedpr.UpdatePRSets(ctx, "2"))
+		require.NoError(t, resources.stackedpr.UpdatePRSets(ctx, "3"))
 
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "3.*s2.*github.com", resources.sb.String())
 		require.Regexp(t, "2.*s1.*github.com", resources.sb.String())
 		require.Regexp(t, "1.*s0.*github.com", resources.sb.String())
@@ -280,21 +281,21 @@ func TestBasicCommitUpdateMergeWithMultiplePRSets(t *testing.T) {
 	})
 
 	t.Run("Can merge PR sets with spr merge", func(t *testing.T) {
-		resources.stackedpr.MergePRSet(ctx, "s2")
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.MergePRSet(ctx, "s2"))
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "2.*s1.*github.com", resources.sb.String())
 		require.Regexp(t, "1.*s0.*github.com", resources.sb.String())
 		require.Regexp(t, "0.*s0.*github.com", resources.sb.String())
 		resources.sb.Reset()
 
-		resources.stackedpr.MergePRSet(ctx, "s1")
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.MergePRSet(ctx, "s1"))
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, "1.*s0.*github.com", resources.sb.String())
 		require.Regexp(t, "0.*s0.*github.com", resources.sb.String())
 		resources.sb.Reset()
 
-		resources.stackedpr.MergePRSet(ctx, "s0")
-		resources.stackedpr.StatusCommitsAndPRSets(ctx)
+		require.NoError(t, resources.stackedpr.MergePRSet(ctx, "s0"))
+		require.NoError(t, resources.stackedpr.StatusCommitsAndPRSets(ctx))
 		require.Regexp(t, ".*no local commits.*", resources.sb.String())
 		resources.sb.Reset()
 	})
@@ -0,0 +1,290 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// maxRuns is the number of spr commands kept in the journal, older ones are dropped
+const maxRuns = 20
+
+// Op is the kind of remote mutation recorded in the journal
+type Op string
+
+const (
+	// OpPushBranch is a (force) push of a branch
+	OpPushBranch Op = "push_branch"
+
+	// OpDeleteBranch is the deletion of a remote branch
+	OpDeleteBranch Op = "delete_branch"
+
+	// OpCreatePullRequest is the creation of a pull request
+	OpCreatePullRequest Op = "create_pr"
+
+	// OpUpdatePullRequest is a change of the base branch, title or body of a pull request
+	OpUpdatePullRequest Op = "update_pr"
+
+	// OpClosePullRequest is the closing of a pull request
+	OpClosePullRequest Op = "close_pr"
+
+	// OpMergePullRequest is the merge of a pull request, merges can't be undone
+	OpMergePullRequest Op = "merge_pr"
+)
+
+// Entry is a single remote mutation done by spr
+type Entry struct {
+	// Run identifies the spr command which did the mutation
+	Run     string    `json:"run"`
+	Command string    `json:"command"`
+	Time    time.Time `json:"time"`
+	Op      Op        `json:"op"`
+
+	// Branch is the pushed or deleted branch, OldSHA is empty when the branch didn't exist
+	Branch string `json:"branch,omitempty"`
+	OldSHA string `json:"oldSha,omitempty"`
+	NewSHA string `json:"newSha,omitempty"`
+
+	// PullRequest is the state of the pull request before the mutation
+	PullRequest *PullRequest `json:"pullRequest,omitempty"`
+}
+
+// PullRequest is the part of a pull request needed to restore it
+type PullRequest struct {
+	ID         string `json:"id"`
+	Number     int    `json:"number"`
+	FromBranch string `json:"fromBranch"`
+	ToBranch   string `json:"toBranch"`
+	Title      string `json:"title"`
+	Body       string `json:"body"`
+}
+
+// GitHub returns the pull request in the form used by the github interfaces
+func (pr *PullRequest) GitHub() *github.PullRequest {
+	return &github.PullRequest{
+		ID:         pr.ID,
+		Number:     pr.Number,
+		FromBranch: pr.FromBranch,
+		ToBranch:   pr.ToBranch,
+		Title:      pr.Title,
+		Body:       pr.Body,
+	}
+}
+
+// Journal records the remote mutations of a single spr command in a per repository file.
+//
+//	Entries are appended as soon as a mutation succeeds so the journal is
+//	 complete even when a command fails midway.
+//	All methods can be called on a nil journal, in which case nothing is recorded.
+type Journal struct {
+	path    string
+	run     string
+	command string
+	started bool
+	mu      sync.Mutex
+}
+
+// FilePath returns the path of the journal of the repository
+func FilePath(gitcmd git.GitInterface) (string, error) {
+	var gitdir string
+	err := gitcmd.Git("rev-parse --git-common-dir", &gitdir)
+	if err != nil {
+		return "", err
+	}
+	gitdir, err = filepath.Abs(strings.TrimSpace(gitdir))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitdir, "spr-journal.jsonl"), nil
+}
+
+// New returns a journal writing to path, command describes the spr command being run
+func New(path string, command string) *Journal {
+	return &Journal{
+		path:    path,
+		run:     fmt.Sprintf("%d", time.Now().UnixNano()),
+		command: command,
+	}
+}
+
+// PushBranch records a push of branch from oldSHA to newSHA
+func (j *Journal) PushBranch(branch string, oldSHA string, newSHA string) error {
+	return j.record(Entry{Op: OpPushBranch, Branch: branch, OldSHA: oldSHA, NewSHA: newSHA})
+}
+
+// DeleteBranch records the deletion of branch which pointed at oldSHA
+func (j *Journal) DeleteBranch(branch string, oldSHA string) error {
+	return j.record(Entry{Op: OpDeleteBranch, Branch: branch, OldSHA: oldSHA})
+}
+
+// CreatePullRequest records the creation of pr
+func (j *Journal) CreatePullRequest(pr *github.PullRequest) error {
+	return j.record(Entry{Op: OpCreatePullRequest, PullRequest: newPullRequest(pr)})
+}
+
+// UpdatePullRequest records an update of pr, pr holds the state before the update
+func (j *Journal) UpdatePullRequest(pr *github.PullRequest) error {
+	return j.record(Entry{Op: OpUpdatePullRequest, PullRequest: newPullRequest(pr)})
+}
+
+// ClosePullRequest records that pr was closed
+func (j *Journal) ClosePullRequest(pr *github.PullRequest) error {
+	return j.record(Entry{Op: OpClosePullRequest, PullRequest: newPullRequest(pr)})
+}
+
+// MergePullRequest records that pr was merged
+func (j *Journal) MergePullRequest(pr *github.PullRequest) error {
+	return j.record(Entry{Op: OpMergePullRequest, PullRequest: newPullRequest(pr)})
+}
+
+func newPullRequest(pr *github.PullRequest) *PullRequest {
+	return &PullRequest{
+		ID:         pr.ID,
+		Number:     pr.Number,
+		FromBranch: pr.FromBranch,
+		ToBranch:   pr.ToBranch,
+		Title:      pr.Title,
+		Body:       pr.Body,
+	}
+}
+
+func (j *Journal) record(e Entry) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.started {
+		// drop the oldest commands before recording a new one
+		err := j.prune()
+		if err != nil {
+			return err
+		}
+		j.started = true
+	}
+
+	e.Run = j.run
+	e.Command = j.command
+	e.Time = time.Now()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	return errors.Join(err, f.Close())
+}
+
+func (j *Journal) prune() error {
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+	runs := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if i == len(entries)-1 || entries[i].Run != entries[i+1].Run {
+			runs++
+		}
+		if runs == maxRuns {
+			return j.write(entries[i+1:])
+		}
+	}
+	return nil
+}
+
+// LastRun returns the entries of the last recorded spr command in the order they
+//
+//	were recorded, along with the number of entries recorded before them.
+func (j *Journal) LastRun() ([]Entry, int, error) {
+	if j == nil {
+		return nil, 0, nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	start := len(entries)
+	for start > 0 && entries[start-1].Run == entries[len(entries)-1].Run {
+		start--
+	}
+	return entries[start:], start, nil
+}
+
+// Truncate keeps only the first n entries of the journal followed by keep
+func (j *Journal) Truncate(n int, keep ...Entry) error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries, err := j.read()
+	if err != nil {
+		return err
+	}
+	if n > len(entries) {
+		n = len(entries)
+	}
+	return j.write(append(entries[:n:n], keep...))
+}
+
+func (j *Journal) read() ([]Entry, error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			return nil, fmt.Errorf("reading journal %s: %w", j.path, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func (j *Journal) write(entries []Entry) error {
+	var sb strings.Builder
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+	err := os.WriteFile(j.path, []byte(sb.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
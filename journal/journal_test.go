@@ -0,0 +1,95 @@
+package journal
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ejoffe/spr/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalLastRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	entries, start, err := New(path, "update").LastRun()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, 0, start)
+
+	j1 := New(path, "update")
+	j1.run = "1"
+	require.NoError(t, j1.PushBranch("spr/main/00000001", "", "aaa"))
+	require.NoError(t, j1.CreatePullRequest(&github.PullRequest{Number: 1, FromBranch: "spr/main/00000001", ToBranch: "main"}))
+
+	j2 := New(path, "merge")
+	j2.run = "2"
+	require.NoError(t, j2.MergePullRequest(&github.PullRequest{Number: 1}))
+	require.NoError(t, j2.DeleteBranch("spr/main/00000001", "aaa"))
+
+	entries, start, err = j1.LastRun()
+	require.NoError(t, err)
+	require.Equal(t, 2, start)
+	require.Len(t, entries, 2)
+	require.Equal(t, "merge", entries[0].Command)
+	require.Equal(t, OpMergePullRequest, entries[0].Op)
+	require.Equal(t, 1, entries[0].PullRequest.Number)
+	require.Equal(t, OpDeleteBranch, entries[1].Op)
+	require.Equal(t, "aaa", entries[1].OldSHA)
+
+	require.NoError(t, j1.Truncate(start))
+	entries, start, err = j1.LastRun()
+	require.NoError(t, err)
+	require.Equal(t, 0, start)
+	require.Len(t, entries, 2)
+	require.Equal(t, "update", entries[0].Command)
+	require.Equal(t, OpPushBranch, entries[0].Op)
+	require.Equal(t, OpCreatePullRequest, entries[1].Op)
+	require.Equal(t, "main", entries[1].PullRequest.GitHub().ToBranch)
+}
+
+func TestJournalTruncateKeep(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j := New(path, "update")
+	require.NoError(t, j.PushBranch("a", "", "1"))
+	require.NoError(t, j.PushBranch("b", "", "2"))
+	require.NoError(t, j.PushBranch("c", "", "3"))
+
+	entries, start, err := j.LastRun()
+	require.NoError(t, err)
+	require.NoError(t, j.Truncate(start, entries[1]))
+
+	entries, _, err = j.LastRun()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "b", entries[0].Branch)
+}
+
+func TestJournalPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	for i := 0; i < maxRuns+5; i++ {
+		j := New(path, fmt.Sprintf("update %d", i))
+		j.run = fmt.Sprintf("%d", i)
+		require.NoError(t, j.PushBranch("a", "", "1"))
+		require.NoError(t, j.PushBranch("b", "", "2"))
+	}
+
+	entries, err := New(path, "").read()
+	require.NoError(t, err)
+	require.Len(t, entries, 2*maxRuns)
+	require.Equal(t, "5", entries[0].Run)
+	require.Equal(t, fmt.Sprintf("%d", maxRuns+4), entries[len(entries)-1].Run)
+}
+
+func TestJournalNil(t *testing.T) {
+	var j *Journal
+	require.NoError(t, j.PushBranch("a", "", "1"))
+	require.NoError(t, j.ClosePullRequest(&github.PullRequest{Number: 1}))
+	require.NoError(t, j.Truncate(0))
+	entries, start, err := j.LastRun()
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, 0, start)
+}
@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// SchemaVersion is the version of the machine readable status schema.
+//
+//	It is bumped whenever a field is removed or changes meaning, new fields
+//	can be added without changing the version.
+const SchemaVersion = 1
+
+const (
+	// WorkflowStack is the classic one pull request per commit workflow
+	WorkflowStack = "stack"
+
+	// WorkflowPRSets is the pull request sets workflow
+	WorkflowPRSets = "prsets"
+)
+
+// Report is the machine readable status of the local commit stack
+type Report struct {
+	SchemaVersion int        `json:"schemaVersion"`
+	Workflow      string     `json:"workflow"`
+	Repository    Repository `json:"repository"`
+
+	// Commits are ordered like the text output, the top of the stack first
+	Commits []Commit `json:"commits"`
+}
+
+// Repository identifies the github repository the stack belongs to
+type Repository struct {
+	Host   string `json:"host"`
+	Owner  string `json:"owner"`
+	Name   string `json:"name"`
+	Remote string `json:"remote"`
+	Branch string `json:"branch"`
+}
+
+// Commit is a single commit in the stack and its pull request
+type Commit struct {
+	// Index is the position of the commit in the stack, the bottom commit has index 0
+	Index      int    `json:"index"`
+	CommitID   string `json:"commitId"`
+	CommitHash string `json:"commitHash"`
+	Subject    string `json:"subject"`
+	WIP        bool   `json:"wip"`
+
+	// PRSet is the pull request set index, only set in the prsets workflow
+	PRSet *int `json:"prSet"`
+
+	PullRequest *PullRequest `json:"pullRequest"`
+}
+
+// PullRequest is the github pull request of a commit
+type PullRequest struct {
+	Number      int         `json:"number"`
+	URL         string      `json:"url"`
+	Title       string      `json:"title"`
+	FromBranch  string      `json:"fromBranch"`
+	ToBranch    string      `json:"toBranch"`
+	MergeStatus MergeStatus `json:"mergeStatus"`
+	Mergeable   bool        `json:"mergeable"`
+	Merged      bool        `json:"merged"`
+	InQueue     bool        `json:"inQueue"`
+	CommitCount int         `json:"commitCount"`
+}
+
+// MergeStatus holds the merge status bits of a pull request
+type MergeStatus struct {
+	// Checks is one of "unknown", "pending", "pass" or "fail"
+	Checks         string  `json:"checks"`
+	ReviewApproved bool    `json:"reviewApproved"`
+	Reviews        Reviews `json:"reviews"`
+	NoConflicts    bool    `json:"noConflicts"`
+	Stacked        bool    `json:"stacked"`
+}
+
+// Reviews holds who approved, requested changes or is still asked to review a pull request
+type Reviews struct {
+	ApprovedBy         []string `json:"approvedBy,omitempty"`
+	RequiredApprovals  int      `json:"requiredApprovals"`
+	ChangesRequestedBy []string `json:"changesRequestedBy,omitempty"`
+	PendingReviewers   []string `json:"pendingReviewers,omitempty"`
+	StaleApprovedBy    []string `json:"staleApprovedBy,omitempty"`
+}
+
+func newReport(cfg *config.Config) *Report {
+	workflow := WorkflowStack
+	if cfg.User.PRSetWorkflows {
+		workflow = WorkflowPRSets
+	}
+	return &Report{
+		SchemaVersion: SchemaVersion,
+		Workflow:      workflow,
+		Repository: Repository{
+			Host:   cfg.Repo.GitHubHost,
+			Owner:  cfg.Repo.GitHubRepoOwner,
+			Name:   cfg.Repo.GitHubRepoName,
+			Remote: cfg.Repo.GitHubRemote,
+			Branch: cfg.Repo.GitHubBranch,
+		},
+		Commits: []Commit{},
+	}
+}
+
+// FromPullRequests builds a report from a stack of pull requests ordered bottom first
+func FromPullRequests(cfg *config.Config, pullRequests []*github.PullRequest) *Report {
+	r := newReport(cfg)
+	for i := len(pullRequests) - 1; i >= 0; i-- {
+		pr := pullRequests[i]
+		r.Commits = append(r.Commits, Commit{
+			Index:       i,
+			CommitID:    pr.Commit.CommitID,
+			CommitHash:  pr.Commit.CommitHash,
+			Subject:     pr.Commit.Subject,
+			WIP:         pr.Commit.WIP,
+			PullRequest: newPullRequest(cfg, pr),
+		})
+	}
+	return r
+}
+
+// FromCommits builds a report from the local commit stack and its pull requests, both
+//
+//	ordered bottom first. Commits without a pull request have a nil PullRequest.
+func FromCommits(cfg *config.Config, commits []git.Commit, pullRequests []*github.PullRequest) *Report {
+	byCommitID := map[string]*github.PullRequest{}
+	for _, pr := range pullRequests {
+		byCommitID[pr.Commit.CommitID] = pr
+	}
+	r := newReport(cfg)
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		r.Commits = append(r.Commits, Commit{
+			Index:       i,
+			CommitID:    c.CommitID,
+			CommitHash:  c.CommitHash,
+			Subject:     c.Subject,
+			WIP:         c.WIP,
+			PullRequest: newPullRequest(cfg, byCommitID[c.CommitID]),
+		})
+	}
+	return r
+}
+
+// FromPRCommits builds a report from the given commits ordered head first
+func FromPRCommits(cfg *config.Config, commits []*bl.PRCommit) *Report {
+	r := newReport(cfg)
+	for _, c := range commits {
+		var prSet *int
+		if c.PRIndex != nil {
+			index := *c.PRIndex
+			prSet = &index
+		}
+		r.Commits = append(r.Commits, Commit{
+			Index:       c.Index,
+			CommitID:    c.CommitID,
+			CommitHash:  c.CommitHash,
+			Subject:     c.Subject,
+			WIP:         c.WIP,
+			PRSet:       prSet,
+			PullRequest: newPullRequest(cfg, c.PullRequest),
+		})
+	}
+	return r
+}
+
+func newPullRequest(cfg *config.Config, pr *github.PullRequest) *PullRequest {
+	if pr == nil {
+		return nil
+	}
+	return &PullRequest{
+		Number:     pr.Number,
+		URL:        github.PullRequestURL(cfg, pr.Number),
+		Title:      pr.Title,
+		FromBranch: pr.FromBranch,
+		ToBranch:   pr.ToBranch,
+		MergeStatus: MergeStatus{
+			Checks:         pr.MergeStatus.ChecksPass.Name(),
+			ReviewApproved: pr.MergeStatus.ReviewApproved,
+			Reviews: Reviews{
+				ApprovedBy:         pr.MergeStatus.Reviews.ApprovedBy,
+				RequiredApprovals:  pr.MergeStatus.Reviews.RequiredApprovals,
+				ChangesRequestedBy: pr.MergeStatus.Reviews.ChangesRequestedBy,
+				PendingReviewers:   pr.MergeStatus.Reviews.PendingReviewers,
+				StaleApprovedBy:    pr.MergeStatus.Reviews.StaleApprovedBy,
+			},
+			NoConflicts: pr.MergeStatus.NoConflicts,
+			Stacked:     pr.MergeStatus.Stacked,
+		},
+		Mergeable:   pr.Mergeable(cfg),
+		Merged:      pr.Merged,
+		InQueue:     pr.InQueue,
+		CommitCount: len(pr.Commits),
+	}
+}
+
+// Formatter writes reports in a machine readable format
+type Formatter struct {
+	tmpl *template.Template
+}
+
+// NewFormatter returns a formatter for the given format.
+//
+//	The format is either "json" or a go template which is executed once for
+//	 every commit in the report, for example '{{.CommitID}} {{.Subject}}'.
+//	An empty format and "text" return a nil formatter, meaning the regular
+//	 text output should be used.
+func NewFormatter(format string) (*Formatter, error) {
+	switch strings.TrimSpace(format) {
+	case "", "text":
+		return nil, nil
+	case "json":
+		return &Formatter{}, nil
+	}
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			out, err := json.Marshal(v)
+			return string(out), err
+		},
+	}).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %w", err)
+	}
+	return &Formatter{tmpl: tmpl}, nil
+}
+
+// Write renders the report to the given writer
+func (f *Formatter) Write(w io.Writer, r *Report) error {
+	if f.tmpl == nil {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+
+	for _, c := range r.Commits {
+		err := f.tmpl.Execute(w, c)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
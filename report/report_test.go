@@ -0,0 +1,197 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *config.Config {
+	cfg := config.EmptyConfig()
+	cfg.Repo.GitHubHost = "github.com"
+	cfg.Repo.GitHubRepoOwner = "owner"
+	cfg.Repo.GitHubRepoName = "repo"
+	cfg.Repo.GitHubRemote = "origin"
+	cfg.Repo.GitHubBranch = "main"
+	cfg.Repo.RequireChecks = true
+	cfg.Repo.RequireApproval = true
+	return cfg
+}
+
+func testPullRequests() []*github.PullRequest {
+	return []*github.PullRequest{
+		{
+			Number:     1,
+			FromBranch: "spr/main/00000001",
+			ToBranch:   "main",
+			Title:      "commit 1",
+			Commit:     git.Commit{CommitID: "00000001", CommitHash: "h1", Subject: "commit 1"},
+			MergeStatus: github.PullRequestMergeStatus{
+				ChecksPass:     github.CheckStatusPass,
+				ReviewApproved: true,
+				NoConflicts:    true,
+				Stacked:        true,
+			},
+		},
+		{
+			Number:     2,
+			FromBranch: "spr/main/00000002",
+			ToBranch:   "spr/main/00000001",
+			Title:      "commit 2",
+			Commit:     git.Commit{CommitID: "00000002", CommitHash: "h2", Subject: "commit 2"},
+			MergeStatus: github.PullRequestMergeStatus{
+				ChecksPass:  github.CheckStatusFail,
+				NoConflicts: true,
+			},
+		},
+	}
+}
+
+func TestFromPullRequests(t *testing.T) {
+	cfg := testConfig()
+	r := FromPullRequests(cfg, testPullRequests())
+
+	require.Equal(t, &Report{
+		SchemaVersion: SchemaVersion,
+		Workflow:      WorkflowStack,
+		Repository:    Repository{Host: "github.com", Owner: "owner", Name: "repo", Remote: "origin", Branch: "main"},
+		Commits: []Commit{
+			{
+				Index:      1,
+				CommitID:   "00000002",
+				CommitHash: "h2",
+				Subject:    "commit 2",
+				PullRequest: &PullRequest{
+					Number:      2,
+					URL:         "https://github.com/owner/repo/pull/2",
+					Title:       "commit 2",
+					FromBranch:  "spr/main/00000002",
+					ToBranch:    "spr/main/00000001",
+					MergeStatus: MergeStatus{Checks: "fail", NoConflicts: true},
+				},
+			},
+			{
+				Index:      0,
+				CommitID:   "00000001",
+				CommitHash: "h1",
+				Subject:    "commit 1",
+				PullRequest: &PullRequest{
+					Number:      1,
+					URL:         "https://github.com/owner/repo/pull/1",
+					Title:       "commit 1",
+					FromBranch:  "spr/main/00000001",
+					ToBranch:    "main",
+					MergeStatus: MergeStatus{Checks: "pass", ReviewApproved: true, NoConflicts: true, Stacked: true},
+					Mergeable:   true,
+				},
+			},
+		},
+	}, r)
+}
+
+func TestFromCommits(t *testing.T) {
+	cfg := testConfig()
+	prs := testPullRequests()
+	commits := []git.Commit{
+		prs[0].Commit,
+		prs[1].Commit,
+		{CommitID: "00000003", CommitHash: "h3", Subject: "WIP commit 3", WIP: true},
+	}
+
+	r := FromCommits(cfg, commits, prs)
+	require.Equal(t, WorkflowStack, r.Workflow)
+	require.Equal(t, []Commit{
+		{Index: 2, CommitID: "00000003", CommitHash: "h3", Subject: "WIP commit 3", WIP: true},
+		{Index: 1, CommitID: "00000002", CommitHash: "h2", Subject: "commit 2", PullRequest: newPullRequest(cfg, prs[1])},
+		{Index: 0, CommitID: "00000001", CommitHash: "h1", Subject: "commit 1", PullRequest: newPullRequest(cfg, prs[0])},
+	}, r.Commits)
+
+	var buf bytes.Buffer
+	f, err := NewFormatter("json")
+	require.NoError(t, err)
+	require.NoError(t, f.Write(&buf, r))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	top := decoded["commits"].([]interface{})[0].(map[string]interface{})
+	require.Contains(t, top, "pullRequest")
+	require.Nil(t, top["pullRequest"])
+}
+
+func TestFromPRCommits(t *testing.T) {
+	cfg := testConfig()
+	cfg.User.PRSetWorkflows = true
+	prs := testPullRequests()
+	prSet := 0
+	commits := []*bl.PRCommit{
+		{Commit: prs[1].Commit, PullRequest: prs[1], Index: 2, PRIndex: &prSet},
+		{Commit: git.Commit{CommitID: "0000000a", CommitHash: "ha", Subject: "WIP local", WIP: true}, Index: 1},
+		{Commit: prs[0].Commit, PullRequest: prs[0], Index: 0, PRIndex: &prSet},
+	}
+
+	r := FromPRCommits(cfg, commits)
+	require.Equal(t, WorkflowPRSets, r.Workflow)
+	require.Len(t, r.Commits, 3)
+	require.Equal(t, 2, r.Commits[0].Index)
+	require.Equal(t, 0, *r.Commits[0].PRSet)
+	require.Equal(t, 2, r.Commits[0].PullRequest.Number)
+	require.Nil(t, r.Commits[1].PRSet)
+	require.Nil(t, r.Commits[1].PullRequest)
+	require.True(t, r.Commits[1].WIP)
+	require.Equal(t, "00000001", r.Commits[2].CommitID)
+}
+
+func TestFormatterJSON(t *testing.T) {
+	f, err := NewFormatter("json")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = f.Write(&buf, FromPullRequests(testConfig(), testPullRequests()))
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, float64(SchemaVersion), decoded["schemaVersion"])
+	commits := decoded["commits"].([]interface{})
+	require.Len(t, commits, 2)
+	pr := commits[0].(map[string]interface{})["pullRequest"].(map[string]interface{})
+	require.Equal(t, "fail", pr["mergeStatus"].(map[string]interface{})["checks"])
+}
+
+func TestFormatterEmptyStack(t *testing.T) {
+	f, err := NewFormatter("json")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = f.Write(&buf, FromPullRequests(testConfig(), nil))
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `"commits": []`)
+}
+
+func TestFormatterTemplate(t *testing.T) {
+	f, err := NewFormatter("{{.CommitID}} {{with .PullRequest}}#{{.Number}} {{.MergeStatus.Checks}}{{end}}")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = f.Write(&buf, FromPullRequests(testConfig(), testPullRequests()))
+	require.NoError(t, err)
+	require.Equal(t, "00000002 #2 fail\n00000001 #1 pass\n", buf.String())
+}
+
+func TestNewFormatter(t *testing.T) {
+	f, err := NewFormatter("")
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	f, err = NewFormatter("text")
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	_, err = NewFormatter("{{.CommitID")
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package spr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/github"
+)
+
+var errAuthGitLab = errors.New("spr auth only supports GitHub, set the GITLAB_TOKEN environment variable for GitLab")
+
+// AuthLogin gets an oauth token for the github host with the device flow and stores it in the hosts file.
+//
+//	The user is asked to enter a code on the host, the token is only stored once
+//	it is granted the scopes spr needs.
+func (sd *Stackediff) AuthLogin(ctx context.Context, host string) error {
+	if sd.config.Repo.Forge == config.ForgeGitLab {
+		return errAuthGitLab
+	}
+	if sd.config.User.AuthClientID == "" {
+		return errors.New("no oauth app client id, register an oauth app with the device flow enabled " +
+			"and set authClientID in ~/.spr.yml")
+	}
+
+	flow := github.NewDeviceFlow(host, sd.config.User.AuthClientID)
+	code, err := flow.RequestCode(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(sd.Output, "copy the one-time code %s and enter it at %s\n", code.UserCode, code.VerificationURI)
+	fmt.Fprintf(sd.Output, "waiting for authorization...\n")
+	token, err := flow.PollToken(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	info, err := github.ValidateToken(ctx, host, token)
+	if err != nil {
+		return err
+	}
+	if missing := info.MissingScopes(); len(missing) > 0 {
+		return fmt.Errorf("the token is missing the %s scopes and wasn't stored", strings.Join(missing, ", "))
+	}
+	err = github.StoreToken(host, info.Login, token)
+	if err != nil {
+		return fmt.Errorf("storing token: %w", err)
+	}
+	file, _ := github.HostsFile()
+	fmt.Fprintf(sd.Output, "logged in to %s as %s, the token is stored in %s\n", github.AuthHost(host), info.Login, file)
+
+	// environment variables are looked up before the hosts file
+	if used := github.LookupToken(host); used.Value != token {
+		fmt.Fprintf(sd.Output, "the token from %s is used instead until it is unset\n", used.Source)
+	}
+	return nil
+}
+
+// AuthLogout removes the token of the github host stored by AuthLogin
+func (sd *Stackediff) AuthLogout(host string) error {
+	if sd.config.Repo.Forge == config.ForgeGitLab {
+		return errAuthGitLab
+	}
+	deleted, err := github.DeleteToken(host)
+	if err != nil {
+		return fmt.Errorf("removing token: %w", err)
+	}
+	if deleted {
+		fmt.Fprintf(sd.Output, "logged out of %s\n", github.AuthHost(host))
+	} else {
+		fmt.Fprintf(sd.Output, "no token of %s stored by spr auth login\n", github.AuthHost(host))
+	}
+	if token := github.LookupToken(host); token.Value != "" {
+		fmt.Fprintf(sd.Output, "the token from %s is still used\n", token.Source)
+	}
+	return nil
+}
+
+// AuthStatus prints the user the token of the github host belongs to, where it was found and its scopes.
+//
+//	It fails when there is no token or the host rejects it.
+func (sd *Stackediff) AuthStatus(ctx context.Context, host string) error {
+	if sd.config.Repo.Forge == config.ForgeGitLab {
+		return errAuthGitLab
+	}
+	token := github.LookupToken(host)
+	if token.Value == "" {
+		return fmt.Errorf("%w: not logged in to %s, run spr auth login", github.ErrUnauthorized, github.AuthHost(host))
+	}
+	info, err := github.ValidateToken(ctx, host, token.Value)
+	if err != nil {
+		return fmt.Errorf("token from %s: %w", token.Source, err)
+	}
+
+	fmt.Fprintf(sd.Output, "%s\n", github.AuthHost(host))
+	fmt.Fprintf(sd.Output, "  logged in as %s, token from %s\n", info.Login, token.Source)
+	if info.Scopes == nil {
+		fmt.Fprintf(sd.Output, "  scopes: not reported, fine-grained and app tokens can't be checked\n")
+		return nil
+	}
+	scopes := strings.Join(info.Scopes, ", ")
+	if scopes == "" {
+		scopes = "none"
+	}
+	fmt.Fprintf(sd.Output, "  scopes: %s\n", scopes)
+	if missing := info.MissingScopes(); len(missing) > 0 {
+		fmt.Fprintf(sd.Output, "  missing scopes: %s, run spr auth login for a new token\n", strings.Join(missing, ", "))
+	}
+	return nil
+}
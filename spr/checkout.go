@@ -0,0 +1,86 @@
+package spr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/ejoffe/spr/git"
+)
+
+// errCheckoutPRSets is returned for checked out stacks with PR sets, which only list the viewer's pull requests
+var errCheckoutPRSets = errors.New("spr checkout doesn't support PR sets, set prSetWorkflows = false to work on a checked out stack")
+
+var pullRequestRefRegex = regexp.MustCompile(`^[#!]?(\d+)$|/(?:pull|pulls|merge_requests)/(\d+)(?:[/?#].*)?$`)
+
+// ParsePullRequestRef returns the pull request number of a number like 12, #12 or !12,
+//
+//	or of a GitHub pull request or GitLab merge request url.
+func ParsePullRequestRef(ref string) (int, error) {
+	matches := pullRequestRefRegex.FindStringSubmatch(ref)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid pull request %q, expected a number or url", ref)
+	}
+	number := matches[1]
+	if number == "" {
+		number = matches[2]
+	}
+	return strconv.Atoi(number)
+}
+
+// CheckoutPullRequest creates a local branch with the stack of pull requests ending with ref.
+//
+//	ref is a pull request number or url. The stack is found by following base
+//	branches down to the target branch, the top branch is checked out on a new
+//	local branch tracking the target branch, so status and update work on it as
+//	on a local stack, even when the pull requests were opened by another user.
+//	branch defaults to pr-<number>. PR sets aren't supported.
+func (sd *Stackediff) CheckoutPullRequest(ctx context.Context, ref string, branch string) error {
+	if sd.config.User.PRSetWorkflows {
+		return errCheckoutPRSets
+	}
+	number, err := ParsePullRequestRef(ref)
+	if err != nil {
+		return err
+	}
+	stack, err := sd.github.GetPullRequestStack(ctx, number)
+	if err != nil {
+		return err
+	}
+	top := stack[len(stack)-1]
+	target := stack[0].ToBranch
+	if branch == "" {
+		branch = fmt.Sprintf("pr-%d", number)
+	}
+
+	remote := sd.config.Repo.GitHubRemote
+	err = sd.gitcmd.Git(fmt.Sprintf("fetch %s +refs/heads/%s:refs/remotes/%s/%s +refs/heads/%s:refs/remotes/%s/%s",
+		remote, target, remote, target, top.FromBranch, remote, top.FromBranch), nil)
+	if err != nil {
+		return err
+	}
+	err = sd.gitcmd.Git(fmt.Sprintf("checkout --no-track -b %s %s/%s", branch, remote, top.FromBranch), nil)
+	if err != nil {
+		return err
+	}
+	err = sd.gitcmd.Git(fmt.Sprintf("branch --set-upstream-to=%s/%s", remote, target), nil)
+	if err != nil {
+		return err
+	}
+
+	// branch names of the stack keep the login of its author
+	sd.config.Repo.GitHubBranch = target
+	if login, _, _ := git.ParseBranchName(sd.config, top.FromBranch); login != "" {
+		err = git.SetStackOwner(sd.gitcmd, branch, login)
+		if err != nil {
+			return err
+		}
+		sd.config.User.Login = login
+	}
+
+	fmt.Fprintf(sd.messageOutput(), "checked out %d pull requests on %s, tracking %s/%s\n",
+		len(stack), branch, remote, target)
+	return sd.StatusPullRequests(ctx)
+}
@@ -0,0 +1,119 @@
+package spr
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/github"
+)
+
+// ShowChecks prints the checks of the pull requests in the stack, or only of the commit at index.
+//
+//	Each check is listed with its status, how long it ran and the url of its
+//	details, failed checks first. Checks required by branch protection are
+//	marked with a *.
+func (sd *Stackediff) ShowChecks(ctx context.Context, index *int) error {
+	commits, err := sd.Stack(ctx)
+	if err != nil {
+		return err
+	}
+
+	var selected []*bl.PRCommit
+	for _, commit := range commits {
+		if index == nil && commit.PullRequest != nil {
+			selected = append(selected, commit)
+		}
+		if index != nil && commit.Index == *index {
+			if commit.PullRequest == nil {
+				return fmt.Errorf("commit %d has no pull request, run update first", *index)
+			}
+			selected = append(selected, commit)
+		}
+	}
+	if index != nil && len(selected) == 0 {
+		return fmt.Errorf("commit index %d is out of range, the stack has %d commits", *index, len(commits))
+	}
+	if len(selected) == 0 {
+		fmt.Fprintf(sd.Output, "pull request stack is empty\n")
+		return nil
+	}
+
+	for i, commit := range selected {
+		pr := commit.PullRequest
+		checks, err := sd.github.GetChecks(ctx, pr)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(sd.Output)
+		}
+		fmt.Fprintf(sd.Output, "%s : %s\n", github.PullRequestReference(sd.config, pr.Number), pr.Title)
+		if len(checks) == 0 {
+			fmt.Fprintf(sd.Output, "  no checks\n")
+			continue
+		}
+		slices.SortStableFunc(checks, func(a, b github.Check) int {
+			return checkRank(a.Status) - checkRank(b.Status)
+		})
+		width := 0
+		for _, check := range checks {
+			width = max(width, len(checkName(check)))
+		}
+		for _, check := range checks {
+			required := " "
+			if check.Required {
+				required = "*"
+			}
+			line := fmt.Sprintf("  %s%s %-*s  %7s  %s", sd.checkIcon(check.Status), required,
+				width, checkName(check), checkDuration(check), check.DetailsURL)
+			fmt.Fprintln(sd.Output, strings.TrimRight(line, " "))
+		}
+	}
+	return nil
+}
+
+// checkName is the name of the check prefixed by its workflow, like GitHub shows it
+func checkName(check github.Check) string {
+	if check.Suite == "" {
+		return check.Name
+	}
+	return check.Suite + " / " + check.Name
+}
+
+func checkDuration(check github.Check) string {
+	d := check.Duration()
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// checkRank orders failed checks first, then running and passed checks
+func checkRank(status github.CheckStatus) int {
+	switch status {
+	case github.CheckStatusFail:
+		return 0
+	case github.CheckStatusPending, github.CheckStatusUnknown:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (sd *Stackediff) checkIcon(status github.CheckStatus) string {
+	icons := github.StatusBitIcons(sd.config)
+	switch status {
+	case github.CheckStatusPass:
+		return icons["checkmark"]
+	case github.CheckStatusFail:
+		return icons["crossmark"]
+	case github.CheckStatusPending:
+		return icons["pending"]
+	default:
+		return icons["questionmark"]
+	}
+}
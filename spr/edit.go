@@ -0,0 +1,465 @@
+package spr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// editAction is what spr edit does with a commit of the stack
+type editAction string
+
+const (
+	editPick   editAction = "pick"
+	editReword editAction = "reword"
+	editDrop   editAction = "drop"
+	editSquash editAction = "squash"
+	editMove   editAction = "move"
+)
+
+var editActions = map[string]editAction{
+	"p": editPick, "pick": editPick,
+	"r": editReword, "reword": editReword,
+	"d": editDrop, "drop": editDrop,
+	"s": editSquash, "squash": editSquash,
+	"m": editMove, "move": editMove,
+}
+
+const editHelp = `
+# Edit the stack, the bottom commit comes first. Lines can be reordered.
+#
+# Commands:
+# p, pick <commit-id> = keep the commit and its pull request
+# r, reword <commit-id> <subject> = keep the commit with the subject written after it
+# d, drop <commit-id> = remove the commit and close its pull request
+# s, squash <commit-id> = fold the commit into the commit above it and close its pull request
+# m, move <commit-id> = move the commit and its pull request to a new stack on branch %s
+#
+# Every commit has to be listed, removing all the lines aborts the edit.
+`
+
+// editStep is a line of the edited todo list
+type editStep struct {
+	action editAction
+	commit git.Commit
+
+	// subject is the new subject of a reworded commit
+	subject string
+
+	// into is the commit-id of the commit a squashed commit is folded into
+	into string
+}
+
+// EditStack opens the stack as a todo list in the editor to reorder, drop, squash, reword
+//
+//	or move commits, then rewrites the local stack and reconciles the pull requests
+//	in one go. Pull requests of dropped and squashed commits are closed with a
+//	comment, moved commits are put with their pull requests on newBranch, a new
+//	stack on the target branch. The local stack is left unchanged when a commit
+//	can't be applied.
+func (sd *Stackediff) EditStack(ctx context.Context, newBranch string) error {
+	githubInfo, err := sd.fetchAndGetGitHubInfo(ctx)
+	if err != nil {
+		return err
+	}
+	localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return err
+	}
+	if len(localCommits) == 0 {
+		fmt.Fprintf(sd.Output, "no local commits\n")
+		return nil
+	}
+	err = sd.checkCleanTree("editing the stack")
+	if err != nil {
+		return err
+	}
+
+	pullRequests := map[string]*github.PullRequest{}
+	for _, pr := range githubInfo.PullRequests {
+		pullRequests[pr.Commit.CommitID] = pr
+	}
+	annotations := map[string]string{}
+	for _, commit := range localCommits {
+		if pr := pullRequests[commit.CommitID]; pr != nil {
+			annotations[commit.CommitID] = github.PullRequestReference(sd.config, pr.Number) + " " + pr.StatusString(sd.config)
+		}
+	}
+	branchHelp := newBranch
+	if branchHelp == "" {
+		branchHelp = githubInfo.LocalBranch + "-<commit-id>"
+	}
+
+	var gitDir string
+	err = sd.gitcmd.Git("rev-parse --absolute-git-dir", &gitDir)
+	if err != nil {
+		return err
+	}
+	todoPath := filepath.Join(gitDir, "SPR_EDIT_TODO")
+	err = os.WriteFile(todoPath, []byte(editTodo(localCommits, annotations, branchHelp)), 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(todoPath)
+	err = sd.editor(todoPath)
+	if err != nil {
+		return err
+	}
+	todo, err := os.ReadFile(todoPath)
+	if err != nil {
+		return err
+	}
+	steps, err := parseEditTodo(string(todo), localCommits, annotations)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintf(sd.Output, "edit aborted, the todo list is empty\n")
+		return nil
+	}
+	if editUnchanged(steps, localCommits) {
+		fmt.Fprintf(sd.Output, "nothing to do\n")
+		return nil
+	}
+
+	// squashed commits go where the commit they are folded into goes
+	var kept, moved, dropped []editStep
+	var into *[]editStep
+	for _, step := range steps {
+		switch step.action {
+		case editDrop:
+			dropped = append(dropped, step)
+			continue
+		case editMove:
+			into = &moved
+		case editPick, editReword:
+			into = &kept
+		}
+		*into = append(*into, step)
+	}
+	if len(moved) > 0 && newBranch == "" {
+		newBranch = githubInfo.LocalBranch + "-" + moved[0].commit.CommitID
+	}
+
+	err = sd.rewriteStack(localCommits[0], githubInfo.LocalBranch, kept, newBranch, moved, filepath.Join(gitDir, "SPR_EDIT_MSG"))
+	if err != nil {
+		return err
+	}
+
+	for _, step := range dropped {
+		if pr := pullRequests[step.commit.CommitID]; pr != nil {
+			err = sd.closeEditedPullRequest(ctx, pr, "Closing pull request: commit was dropped from the stack")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, step := range steps {
+		pr := pullRequests[step.commit.CommitID]
+		if step.action != editSquash || pr == nil {
+			continue
+		}
+		comment := fmt.Sprintf("Closing pull request: squashed into commit %s", step.into)
+		if target := pullRequests[step.into]; target != nil {
+			comment = fmt.Sprintf("Closing pull request: squashed into %s", github.PullRequestReference(sd.config, target.Number))
+			err = sd.github.CommentPullRequest(ctx, target,
+				fmt.Sprintf("%s was squashed into this pull request", github.PullRequestReference(sd.config, pr.Number)))
+			if err != nil {
+				return err
+			}
+		}
+		err = sd.closeEditedPullRequest(ctx, pr, comment)
+		if err != nil {
+			return err
+		}
+	}
+
+	// pull requests of moved commits are reconciled on the new branch first so they
+	//  aren't closed as gone away by the update of the remaining stack
+	if len(moved) > 0 {
+		err = sd.gitcmd.Git("checkout --quiet "+newBranch, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(sd.Output, "moved to branch %s:\n", newBranch)
+		movedInfo := *githubInfo
+		movedInfo.LocalBranch = newBranch
+		movedInfo.PullRequests = editedPullRequests(githubInfo.PullRequests, moved)
+		err = errors.Join(
+			sd.updatePullRequests(ctx, &movedInfo, nil, nil),
+			sd.gitcmd.Git("checkout --quiet "+githubInfo.LocalBranch, nil))
+		if err != nil {
+			return err
+		}
+	}
+
+	keptInfo := *githubInfo
+	keptInfo.PullRequests = editedPullRequests(githubInfo.PullRequests, kept)
+	return sd.updatePullRequests(ctx, &keptInfo, nil, nil)
+}
+
+// editTodo returns the todo list of the stack, annotated with the pull request numbers and status bits
+func editTodo(commits []git.Commit, annotations map[string]string, branchHelp string) string {
+	var todo strings.Builder
+	for _, commit := range commits {
+		if annotation := annotations[commit.CommitID]; annotation != "" {
+			fmt.Fprintf(&todo, "pick %s %s %s\n", commit.CommitID, annotation, commit.Subject)
+		} else {
+			fmt.Fprintf(&todo, "pick %s %s\n", commit.CommitID, commit.Subject)
+		}
+	}
+	fmt.Fprintf(&todo, editHelp, branchHelp)
+	return todo.String()
+}
+
+// parseEditTodo reads the edited todo list, no steps are returned when all lines were removed
+func parseEditTodo(todo string, commits []git.Commit, annotations map[string]string) ([]editStep, error) {
+	byID := map[string]git.Commit{}
+	for _, commit := range commits {
+		byID[commit.CommitID] = commit
+	}
+
+	var steps []editStep
+	listed := map[string]bool{}
+	for _, line := range strings.Split(todo, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		action, found := editActions[fields[0]]
+		if !found {
+			return nil, fmt.Errorf("unknown action %q in line: %s", fields[0], line)
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("missing commit-id in line: %s", line)
+		}
+		commit, found := byID[fields[1]]
+		if !found {
+			return nil, fmt.Errorf("commit %s is not in the stack", fields[1])
+		}
+		if listed[commit.CommitID] {
+			return nil, fmt.Errorf("commit %s is listed more than once", commit.CommitID)
+		}
+		listed[commit.CommitID] = true
+
+		step := editStep{action: action, commit: commit}
+		switch action {
+		case editReword:
+			subject := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+			subject = strings.TrimSpace(strings.TrimPrefix(subject, fields[1]))
+			subject = strings.TrimSpace(strings.TrimPrefix(subject, annotations[commit.CommitID]))
+			if subject == "" {
+				return nil, fmt.Errorf("reword of commit %s needs a subject", commit.CommitID)
+			}
+			step.subject = subject
+		case editSquash:
+			for i := len(steps) - 1; i >= 0 && step.into == ""; i-- {
+				switch steps[i].action {
+				case editDrop:
+				case editSquash:
+					step.into = steps[i].into
+				default:
+					step.into = steps[i].commit.CommitID
+				}
+			}
+			if step.into == "" {
+				return nil, fmt.Errorf("squash of commit %s has no commit above it to fold into", commit.CommitID)
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	for _, commit := range commits {
+		if !listed[commit.CommitID] {
+			return nil, fmt.Errorf("commit %s is missing from the todo list, use drop to remove it", commit.CommitID)
+		}
+	}
+	return steps, nil
+}
+
+// editUnchanged returns true when the steps pick all the commits in their current order
+func editUnchanged(steps []editStep, commits []git.Commit) bool {
+	for i, step := range steps {
+		if step.action != editPick || step.commit.CommitID != commits[i].CommitID {
+			return false
+		}
+	}
+	return true
+}
+
+// editedPullRequests returns the pull requests of the picked, reworded and moved commits of steps
+func editedPullRequests(pullRequests []*github.PullRequest, steps []editStep) []*github.PullRequest {
+	var result []*github.PullRequest
+	for _, step := range steps {
+		if step.action == editSquash {
+			continue
+		}
+		for _, pr := range pullRequests {
+			if pr.Commit.CommitID == step.commit.CommitID {
+				result = append(result, pr)
+			}
+		}
+	}
+	return result
+}
+
+// rewriteStack replays the kept commits on the base of the stack and resets branch to them,
+//
+//	the moved commits are replayed on the same base and put on newBranch. Nothing
+//	is changed when a commit can't be applied.
+func (sd *Stackediff) rewriteStack(bottom git.Commit, branch string, kept []editStep,
+	newBranch string, moved []editStep, messagePath string) error {
+	var base string
+	err := sd.gitcmd.Git("rev-parse "+bottom.CommitHash+"^", &base)
+	if err != nil {
+		return err
+	}
+
+	if len(moved) > 0 {
+		var existing string
+		err = sd.gitcmd.Git("branch --list "+newBranch, &existing)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			return fmt.Errorf("branch %s already exists, pick another one with --branch", newBranch)
+		}
+		head, err := sd.replay(base, moved, messagePath)
+		if err != nil {
+			return sd.abortRewrite("edit", branch, err)
+		}
+		err = sd.gitcmd.Git(fmt.Sprintf("branch %s %s", newBranch, head), nil)
+		if err != nil {
+			return sd.abortRewrite("edit", branch, err)
+		}
+	}
+
+	head, err := sd.replay(base, kept, messagePath)
+	if err == nil {
+		err = sd.gitcmd.Git(fmt.Sprintf("checkout --quiet -B %s %s", branch, head), nil)
+	}
+	if err != nil {
+		if len(moved) > 0 {
+			err = errors.Join(err, sd.gitcmd.Git("branch -D "+newBranch, nil))
+		}
+		return sd.abortRewrite("edit", branch, err)
+	}
+	return nil
+}
+
+// replay applies the steps on base on a detached head and returns the resulting commit
+func (sd *Stackediff) replay(base string, steps []editStep, messagePath string) (string, error) {
+	err := sd.gitcmd.Git("checkout --quiet --detach "+base, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, step := range steps {
+		hash := step.commit.CommitHash
+		switch step.action {
+		case editSquash:
+			err = sd.gitcmd.Git("cherry-pick --no-commit "+hash, nil)
+			if err == nil {
+				err = sd.gitcmd.Git("commit --amend --no-edit --allow-empty", nil)
+			}
+		case editReword:
+			err = sd.gitcmd.Git("cherry-pick --allow-empty "+hash, nil)
+			if err == nil {
+				err = sd.rewordHead(step.subject, messagePath)
+			}
+		default:
+			err = sd.gitcmd.Git("cherry-pick --allow-empty "+hash, nil)
+		}
+		if err != nil {
+			return "", fmt.Errorf("applying commit %s failed: %w", step.commit.CommitID, err)
+		}
+	}
+	var head string
+	err = sd.gitcmd.Git("rev-parse HEAD", &head)
+	return head, err
+}
+
+// rewordHead replaces the subject of the head commit, the rest of the message with its commit-id is kept
+func (sd *Stackediff) rewordHead(subject string, messagePath string) error {
+	var message string
+	err := sd.gitcmd.Git("log -1 --format=%B HEAD", &message)
+	if err != nil {
+		return err
+	}
+	if _, rest, found := strings.Cut(message, "\n"); found {
+		message = subject + "\n" + rest
+	} else {
+		message = subject
+	}
+	err = os.WriteFile(messagePath, []byte(message+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(messagePath)
+	return sd.gitcmd.Git("commit --amend --allow-empty --cleanup=verbatim -F "+messagePath, nil)
+}
+
+// checkCleanTree returns an error when tracked files have changes the rewrite of the stack would lose
+func (sd *Stackediff) checkCleanTree(doing string) error {
+	var status string
+	err := sd.gitcmd.Git("status --porcelain --untracked-files=no", &status)
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		return fmt.Errorf("local changes would be lost, commit or stash them before %s", doing)
+	}
+	return nil
+}
+
+// abortRewrite drops the partly replayed commits and checks out branch again, which wasn't moved yet
+func (sd *Stackediff) abortRewrite(command string, branch string, err error) error {
+	return errors.Join(
+		fmt.Errorf("%s aborted, the stack is unchanged: %w", command, err),
+		sd.gitcmd.Git("reset --quiet --hard", nil),
+		sd.gitcmd.Git("checkout --quiet "+branch, nil))
+}
+
+// closeEditedPullRequest comments on the pull request of a commit removed from the stack and closes it
+func (sd *Stackediff) closeEditedPullRequest(ctx context.Context, pr *github.PullRequest, comment string) error {
+	err := sd.github.CommentPullRequest(ctx, pr, comment)
+	if err != nil {
+		return err
+	}
+	err = sd.github.ClosePullRequest(ctx, pr)
+	if err != nil {
+		return err
+	}
+	return sd.Journal.ClosePullRequest(pr)
+}
+
+// runEditor opens path in the editor set by GIT_EDITOR, VISUAL or EDITOR, vi by default
+func runEditor(path string) error {
+	editor := "vi"
+	for _, env := range []string{"GIT_EDITOR", "VISUAL", "EDITOR"} {
+		if value := os.Getenv(env); value != "" {
+			editor = value
+			break
+		}
+	}
+	// the editor can have arguments, it is run by the shell like git does
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("running editor %s: %w", editor, err)
+	}
+	return nil
+}
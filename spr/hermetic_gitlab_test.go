@@ -0,0 +1,167 @@
+package spr
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git/realgit"
+	"github.com/ejoffe/spr/gitlab/fakegitlab"
+	"github.com/ejoffe/spr/gitlab/gitlabclient"
+	ngit "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func makeHermeticGitLabObjects(t *testing.T) *hermetic {
+	fake := fakegitlab.New(t)
+	dir := fake.Clone(t)
+
+	// realgit runs commands in the current working directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("GITLAB_TOKEN", fakegitlab.Token)
+	// make interactive rebases used to add commit-ids non interactive
+	t.Setenv("GIT_EDITOR", "true")
+
+	cfg := config.DefaultConfig()
+	cfg.Repo.Forge = config.ForgeGitLab
+	cfg.Repo.GitHubHost = fake.URL
+	cfg.Repo.GitHubRepoOwner = fake.Owner
+	cfg.Repo.GitHubRepoName = fake.Name
+	cfg.Repo.GitHubRemote = "origin"
+	cfg.Repo.GitHubBranch = fakegitlab.DefaultBranch
+	cfg.Repo.MergeMethod = "merge"
+	cfg.User.ShowPRLink = false
+	cfg.User.StatusBitsHeader = false
+	cfg.User.StatusBitsEmojis = false
+
+	repo, err := ngit.PlainOpen(dir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	client, err := gitlabclient.NewGitLabClient(ctx, cfg)
+	require.NoError(t, err)
+	gitcmd, err := realgit.NewGitCmd(cfg)
+	require.NoError(t, err)
+	sd := NewStackedPR(cfg, client, gitcmd, repo)
+	output := &bytes.Buffer{}
+	sd.Output = output
+
+	return &hermetic{t: t, sd: sd, cfg: cfg, gitlab: fake, dir: dir, output: output,
+		journalPath: filepath.Join(t.TempDir(), "journal.jsonl")}
+}
+
+func TestHermeticGitLabUpdateAndMerge(t *testing.T) {
+	h := makeHermeticGitLabObjects(t)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	c3 := h.commit("test commit 3", "00000003")
+
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"reviewer"}, nil))
+	assert.Equal([]string{
+		"[vxvx]   3 : test commit 3",
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	mrs := h.gitlab.OpenMergeRequests()
+	assert.Len(mrs, 3)
+	assert.Equal("spr/main/00000001", mrs[0].SourceBranch)
+	assert.Equal("main", mrs[0].TargetBranch)
+	assert.Equal("spr/main/00000002", mrs[1].SourceBranch)
+	assert.Equal("spr/main/00000001", mrs[1].TargetBranch)
+	assert.Equal("spr/main/00000003", mrs[2].SourceBranch)
+	assert.Equal("spr/main/00000002", mrs[2].TargetBranch)
+	assert.Equal(c3, h.gitlab.BranchHead("spr/main/00000003"))
+	for _, mr := range mrs {
+		assert.Equal([]int64{2}, mr.ReviewerIDs)
+		assert.Contains(mr.Description, "- !1")
+	}
+	assert.Contains(mrs[2].Description, "- !3 ⬅")
+
+	// a failed pipeline on the top commit stops the merge below it
+	h.gitlab.ApproveAll()
+	h.gitlab.SetPipelineStatus(c3, "failed")
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{
+		"[xvvx]   3 : test commit 3",
+		"[vvvv]   2 : test commit 2",
+		"[vvvv]   1 : test commit 1",
+	}, h.lines())
+
+	h.gitlab.SetPipelineStatus(c3, "running")
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal("[.vvx]   3 : test commit 3", stripColors(h.lines()[0]))
+
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{
+		"MERGED   1 : test commit 1",
+		"MERGED   2 : test commit 2",
+	}, h.lines())
+
+	mr1, _ := h.gitlab.MergeRequest(1)
+	mr2, _ := h.gitlab.MergeRequest(2)
+	assert.Equal(fakegitlab.StateClosed, mr1.State)
+	assert.Equal([]string{"✓ Commit merged in pull request [!2](https://" + h.gitlab.URL +
+		"/spr-group/spr-repo/-/merge_requests/2)"}, mr1.Notes)
+	assert.Equal(fakegitlab.StateMerged, mr2.State)
+	assert.False(mr2.Squash)
+
+	// the remaining merge request is retargeted onto main after the merge
+	h.gitlab.SetPipelineStatus(c3, "success")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Equal([]string{
+		"[vvvv]   3 : test commit 3",
+	}, h.lines())
+	mr3, _ := h.gitlab.MergeRequest(3)
+	assert.Equal("main", mr3.TargetBranch)
+
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{
+		"MERGED   3 : test commit 3",
+	}, h.lines())
+	assert.Empty(h.gitlab.OpenMergeRequests())
+}
+
+func TestHermeticGitLabMergeTrain(t *testing.T) {
+	h := makeHermeticGitLabObjects(t)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.cfg.Repo.MergeQueue = true
+	h.cfg.Repo.MergeMethod = "squash"
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	h.gitlab.ApproveAll()
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{
+		"MERGED   1 : test commit 1",
+		"MERGED   2 : test commit 2",
+	}, h.lines())
+
+	mr2, _ := h.gitlab.MergeRequest(2)
+	assert.True(mr2.InTrain)
+	assert.Equal("main", mr2.TargetBranch)
+
+	// merge requests in the train keep their target branch on update
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Contains(stripColors(h.lines()[0]), "2 : test commit 2")
+
+	assert.NoError(h.gitlab.RunMergeTrain())
+	mr2, _ = h.gitlab.MergeRequest(2)
+	assert.Equal(fakegitlab.StateMerged, mr2.State)
+	assert.True(mr2.Squash)
+	assert.Empty(h.gitlab.OpenMergeRequests())
+}
@@ -0,0 +1,1608 @@
+package spr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/bl/selector"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/dryrun"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/git/realgit"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/github/fakegithub"
+	"github.com/ejoffe/spr/github/githubclient"
+	"github.com/ejoffe/spr/gitlab/fakegitlab"
+	"github.com/ejoffe/spr/journal"
+	"github.com/ejoffe/spr/report"
+	ngit "github.com/go-git/go-git/v5"
+	gogithub "github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/require"
+)
+
+// hermetic holds a Stackediff wired to a fake github or gitlab server and a local clone of its remote
+type hermetic struct {
+	t      *testing.T
+	sd     *Stackediff
+	cfg    *config.Config
+	fake   *fakegithub.Server
+	gitlab *fakegitlab.Server
+	rest   *gogithub.Client
+	dir    string
+	output *bytes.Buffer
+
+	journalPath string
+}
+
+func makeHermeticObjects(t *testing.T, prSetWorkflows bool) *hermetic {
+	fake := fakegithub.New(t)
+	dir := fake.Clone(t)
+
+	// realgit runs commands in the current working directory
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	t.Setenv("GITHUB_TOKEN", "fake-token")
+	// make interactive rebases used to add commit-ids non interactive
+	t.Setenv("GIT_EDITOR", "true")
+
+	cfg := config.DefaultConfig()
+	cfg.Repo.GitHubHost = fake.URL
+	cfg.Repo.GitHubRepoOwner = fake.Owner
+	cfg.Repo.GitHubRepoName = fake.Name
+	cfg.Repo.GitHubRemote = "origin"
+	cfg.Repo.GitHubBranch = fakegithub.DefaultBranch
+	cfg.Repo.MergeMethod = "rebase"
+	cfg.User.ShowPRLink = false
+	cfg.User.StatusBitsHeader = false
+	cfg.User.StatusBitsEmojis = false
+	cfg.User.PRSetWorkflows = prSetWorkflows
+
+	repo, err := ngit.PlainOpen(dir)
+	require.NoError(t, err)
+	rest, err := gogithub.NewClient(nil).WithAuthToken("fake-token").
+		WithEnterpriseURLs(fake.RESTBaseURL(), fake.RESTBaseURL())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	client, err := githubclient.NewGitHubClient(ctx, cfg, nil)
+	require.NoError(t, err)
+	gitcmd, err := realgit.NewGitCmd(cfg)
+	require.NoError(t, err)
+	sd := NewStackedPR(cfg, client, gitcmd, repo)
+	output := &bytes.Buffer{}
+	sd.Output = output
+
+	return &hermetic{t: t, sd: sd, cfg: cfg, fake: fake, rest: rest, dir: dir, output: output,
+		journalPath: filepath.Join(t.TempDir(), "journal.jsonl")}
+}
+
+// journal starts journaling a new command, like a new spr process would
+func (h *hermetic) journal(command string) {
+	h.sd.Journal = journal.New(h.journalPath, command)
+}
+
+// commit creates a new commit with a commit-id and returns its hash
+func (h *hermetic) commit(subject string, commitID string) string {
+	h.t.Helper()
+	filename := strings.ReplaceAll(subject, " ", "_")
+	err := os.WriteFile(filepath.Join(h.dir, filename), []byte(subject+"\n"), 0644)
+	require.NoError(h.t, err)
+	h.git("add", filename)
+	h.git("commit", "-m", fmt.Sprintf("%s\n\ncommit-id:%s", subject, commitID))
+	return h.git("rev-parse", "HEAD")
+}
+
+func (h *hermetic) git(args ...string) string {
+	h.t.Helper()
+	return h.gitIn(h.dir, args...)
+}
+
+// gitIn runs git in the given directory, used to work in other clones of the remote
+func (h *hermetic) gitIn(dir string, args ...string) string {
+	h.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(h.t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func (h *hermetic) lines() []string {
+	out := strings.TrimSpace(h.output.String())
+	h.output.Reset()
+	return strings.Split(out, "\n")
+}
+
+var colorRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripColors(line string) string {
+	return colorRegex.ReplaceAllString(line, "")
+}
+
+func TestHermeticUpdateAndMergePullRequests(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	c3 := h.commit("test commit 3", "00000003")
+
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"reviewer"}, nil))
+	assert.Equal([]string{
+		"[vxvx]   3 : test commit 3",
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 3)
+	assert.Equal("spr/main/00000001", prs[0].HeadRefName)
+	assert.Equal("main", prs[0].BaseRefName)
+	assert.Equal("spr/main/00000002", prs[1].HeadRefName)
+	assert.Equal("spr/main/00000001", prs[1].BaseRefName)
+	assert.Equal("spr/main/00000003", prs[2].HeadRefName)
+	assert.Equal("spr/main/00000002", prs[2].BaseRefName)
+	assert.Equal(c3, h.fake.BranchHead("spr/main/00000003"))
+	for _, pr := range prs {
+		assert.Equal([]string{"U_reviewer"}, pr.ReviewerIDs)
+		assert.Contains(pr.Body, fmt.Sprintf("#%d ⬅", pr.Number))
+	}
+
+	// a failing check on the top commit stops the merge below it
+	h.fake.ApproveAll()
+	h.fake.SetCommitStatus(c3, "FAILURE")
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{
+		"[xvvx]   3 : test commit 3",
+		"[vvvv]   2 : test commit 2",
+		"[vvvv]   1 : test commit 1",
+	}, h.lines())
+
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{
+		"MERGED   1 : test commit 1",
+		"MERGED   2 : test commit 2",
+	}, h.lines())
+
+	pr1, _ := h.fake.PullRequest(1)
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateClosed, pr1.State)
+	assert.Equal([]string{"✓ Commit merged in pull request [#2](https://" + h.fake.URL +
+		"/spr-owner/spr-repo/pull/2)"}, pr1.Comments)
+	assert.Equal(fakegithub.StateMerged, pr2.State)
+	assert.Equal("rebase", pr2.MergeMethod)
+	assert.Equal(h.fake.BranchHead("spr/main/00000002"), h.fake.BranchHead("main"))
+
+	// the remaining pull request is rebased on top of the merged commits
+	h.fake.SetCommitStatus(c3, "SUCCESS")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Equal([]string{
+		"[vvvv]   3 : test commit 3",
+	}, h.lines())
+	pr3, _ := h.fake.PullRequest(3)
+	assert.Equal("main", pr3.BaseRefName)
+
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{
+		"MERGED   3 : test commit 3",
+	}, h.lines())
+	assert.Empty(h.fake.OpenPullRequests())
+	assert.Equal(h.fake.BranchHead("spr/main/00000003"), h.fake.BranchHead("main"))
+}
+
+func TestHermeticDeletedCommitClosesPullRequest(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Len(h.fake.OpenPullRequests(), 3)
+	h.output.Reset()
+
+	// drop the middle commit from the stack
+	h.git("rebase", "--onto", c2+"^", c2)
+
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Equal([]string{
+		"[vxvx]   3 : test commit 3",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateClosed, pr2.State)
+	assert.Equal([]string{"Closing pull request: commit has gone away"}, pr2.Comments)
+	pr3, _ := h.fake.PullRequest(3)
+	assert.Equal("spr/main/00000001", pr3.BaseRefName)
+}
+
+func TestHermeticRebaseConflict(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("conflict", "00000001")
+
+	// someone else pushes a conflicting change to main
+	other := h.fake.Clone(t)
+	err := os.WriteFile(filepath.Join(other, "conflict"), []byte("other change\n"), 0644)
+	assert.NoError(err)
+	h.gitIn(other, "add", "conflict")
+	h.gitIn(other, "commit", "-m", "other change")
+	h.gitIn(other, "push", "origin", "HEAD:"+fakegithub.DefaultBranch)
+
+	err = h.sd.UpdatePullRequests(ctx, nil, nil)
+	assert.ErrorIs(err, git.ErrRebaseConflict)
+	assert.Empty(h.fake.PullRequests())
+}
+
+func TestHermeticPushRejected(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+
+	hook := filepath.Join(h.fake.RemoteDir, "hooks", "pre-receive")
+	err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755)
+	assert.NoError(err)
+
+	// local changes are stashed during the push and must be restored on failure
+	err = os.WriteFile(filepath.Join(h.dir, "README.md"), []byte("local change\n"), 0644)
+	assert.NoError(err)
+
+	err = h.sd.UpdatePullRequests(ctx, nil, nil)
+	assert.ErrorIs(err, git.ErrPushRejected)
+	assert.Empty(h.fake.PullRequests())
+
+	content, err := os.ReadFile(filepath.Join(h.dir, "README.md"))
+	assert.NoError(err)
+	assert.Equal("local change\n", string(content))
+	assert.Empty(h.git("stash", "list"))
+}
+
+func TestHermeticUndoUpdate(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	h.journal("update")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+	pr2, _ := h.fake.PullRequest(2)
+	body2 := pr2.Body
+
+	// amend the top commit and update again
+	h.git("commit", "--amend", "-m", "test commit 2 amended\n\ncommit-id:00000002")
+	h.journal("update")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+	assert.NotEqual(c2, h.fake.BranchHead("spr/main/00000002"))
+	pr2, _ = h.fake.PullRequest(2)
+	assert.Equal("test commit 2 amended", pr2.Title)
+
+	// the first undo reverts the amend
+	assert.NoError(h.sd.Undo(ctx))
+	assert.Contains(h.lines(), "restore branch spr/main/00000002 to "+c2)
+	assert.Equal(c2, h.fake.BranchHead("spr/main/00000002"))
+	assert.Equal(c1, h.fake.BranchHead("spr/main/00000001"))
+	pr2, _ = h.fake.PullRequest(2)
+	assert.Equal("test commit 2", pr2.Title)
+	assert.Equal(body2, pr2.Body)
+	assert.Len(h.fake.OpenPullRequests(), 2)
+
+	// the second undo removes the pull requests
+	assert.NoError(h.sd.Undo(ctx))
+	h.output.Reset()
+	assert.Empty(h.fake.OpenPullRequests())
+	assert.Empty(h.fake.BranchHead("spr/main/00000001"))
+	assert.Empty(h.fake.BranchHead("spr/main/00000002"))
+
+	assert.NoError(h.sd.Undo(ctx))
+	assert.Equal([]string{"nothing to undo"}, h.lines())
+}
+
+func TestHermeticUndoClosedPullRequest(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+	h.journal("update")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	h.git("rebase", "--onto", c2+"^", c2)
+	h.journal("update")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateClosed, pr2.State)
+
+	assert.NoError(h.sd.Undo(ctx))
+	pr2, _ = h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateOpen, pr2.State)
+	assert.Equal("spr/main/00000001", pr2.BaseRefName)
+	pr3, _ := h.fake.PullRequest(3)
+	assert.Equal("spr/main/00000002", pr3.BaseRefName)
+	assert.Len(h.fake.OpenPullRequests(), 3)
+}
+
+func TestHermeticUndoMerge(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	h.journal("update")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.fake.SetCommitStatus(c1, "SUCCESS")
+	h.fake.SetCommitStatus(c2, "SUCCESS")
+	h.fake.ApproveAll()
+	h.journal("merge")
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	h.output.Reset()
+
+	assert.NoError(h.sd.Undo(ctx))
+	assert.Contains(h.lines(), "pull request #2 was merged and can't be undone")
+	pr1, _ := h.fake.PullRequest(1)
+	assert.Equal(fakegithub.StateOpen, pr1.State)
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateMerged, pr2.State)
+}
+
+// dryRun returns a stack on the same repository which plans remote changes instead of making them
+func (h *hermetic) dryRun() (*Stackediff, *dryrun.Plan) {
+	plan := dryrun.NewPlan(h.cfg)
+	sd := NewStackedPR(h.cfg, plan.GitHub(h.sd.github), plan.Git(h.sd.gitcmd), h.sd.repo)
+	sd.Output = h.output
+	return sd, plan
+}
+
+func TestHermeticDryRunUpdate(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	old3 := h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+
+	// drop the middle commit and add a new one on top
+	h.git("rebase", "--onto", c2+"^", c2)
+	c4 := h.commit("test commit 4", "00000004")
+
+	// the stack is rebased onto a new commit on main before it is pushed
+	other := h.fake.Clone(t)
+	h.gitIn(other, "commit", "--allow-empty", "-m", "other change")
+	h.gitIn(other, "push", "origin", "HEAD:"+fakegithub.DefaultBranch)
+	h.git("fetch")
+	remote := h.git("ls-remote", "origin")
+
+	sd, plan := h.dryRun()
+	assert.NoError(sd.UpdatePullRequests(ctx, nil, nil))
+	assert.NoError(plan.Close())
+	changes := plan.Changes()
+
+	rebased := func(commitID string) string {
+		return h.git("log", "--format=%H", "-1", "--grep", "commit-id:"+commitID,
+			"origin/"+fakegithub.DefaultBranch+".."+changes.Pushes[len(changes.Pushes)-1].NewSHA)
+	}
+	new3 := rebased("00000003")
+	assert.NotEqual(old3, new3)
+	assert.Equal("other change", h.git("log", "-1", "--format=%s", new3+"~2"))
+	new4 := rebased("00000004")
+	assert.NotEqual(c4, new4)
+	assert.Equal([]dryrun.BranchPush{
+		{Branch: "spr/main/00000001", OldSHA: c1, NewSHA: rebased("00000001")},
+		{Branch: "spr/main/00000003", OldSHA: old3, NewSHA: new3},
+		{Branch: "spr/main/00000004", NewSHA: new4},
+	}, changes.Pushes)
+	assert.Len(changes.Create, 1)
+	assert.Equal(dryrun.PullRequest{Title: "test commit 4", FromBranch: "spr/main/00000004", ToBranch: "spr/main/00000003"}, *changes.Create[0])
+	assert.Len(changes.Retarget, 1)
+	assert.Equal(3, changes.Retarget[0].Number)
+	assert.Equal("spr/main/00000002", changes.Retarget[0].OldToBranch)
+	assert.Equal("spr/main/00000001", changes.Retarget[0].ToBranch)
+	assert.Len(changes.Close, 1)
+	assert.Equal(2, changes.Close[0].Number)
+	assert.Nil(changes.Merge)
+
+	// nothing changed locally or remotely, and the worktree is removed
+	assert.Equal(c4, h.git("rev-parse", "HEAD"))
+	assert.Equal(remote, h.git("ls-remote", "origin"))
+	assert.Len(strings.Split(h.git("worktree", "list"), "\n"), 1)
+	assert.Len(h.fake.OpenPullRequests(), 3)
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Empty(pr2.Comments)
+
+	h.output.Reset()
+	assert.NoError(plan.Write(h.output))
+	assert.Equal([]string{
+		fmt.Sprintf("push spr/main/00000001 %s → %s", c1[:8], rebased("00000001")[:8]),
+		fmt.Sprintf("push spr/main/00000003 %s → %s", old3[:8], new3[:8]),
+		fmt.Sprintf("push spr/main/00000004 (new) → %s", new4[:8]),
+		"create pull request spr/main/00000004 → spr/main/00000003 : test commit 4",
+		"retarget #3 spr/main/00000002 → spr/main/00000001 : test commit 3",
+		"close #2 : test commit 2",
+	}, h.lines())
+}
+
+func TestHermeticDryRunMerge(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.fake.SetCommitStatus(c1, "SUCCESS")
+	h.fake.SetCommitStatus(c2, "SUCCESS")
+	h.fake.ApproveAll()
+	h.cfg.User.DeleteMergedBranches = true
+
+	sd, plan := h.dryRun()
+	assert.NoError(sd.MergePullRequests(ctx, nil))
+	assert.Len(h.fake.OpenPullRequests(), 2)
+	assert.Contains(h.fake.Branches(), "spr/main/00000001")
+
+	h.output.Reset()
+	assert.NoError(plan.Write(h.output))
+	assert.Equal([]string{
+		fmt.Sprintf("delete branch spr/main/00000002 %s", c2[:8]),
+		fmt.Sprintf("delete branch spr/main/00000001 %s", c1[:8]),
+		"retarget #2 spr/main/00000001 → main : test commit 2",
+		"close #1 : test commit 1",
+		"merge #2 into main (REBASE) : test commit 2",
+	}, h.lines())
+}
+
+func TestHermeticDryRunNothingToDo(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+
+	sd, plan := h.dryRun()
+	assert.NoError(sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+	assert.NoError(plan.Write(h.output))
+	assert.Equal([]string{"nothing to do"}, h.lines())
+}
+
+func TestHermeticUpdateAndMergePRSets(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0,2"))
+	lines := h.lines()
+	assert.Len(lines, 3)
+	assert.Equal(" 2 s0 [vxvx]   2   : test commit 2", stripColors(lines[0]))
+	assert.Equal(" 1 -- [----] No Pull Request Created                                     : test commit 1", stripColors(lines[1]))
+	assert.Equal(" 0 s0 [vxvx]   1   : test commit 0", stripColors(lines[2]))
+
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 2)
+	assert.Equal("spr/main/00000000", prs[0].HeadRefName)
+	assert.Equal("main", prs[0].BaseRefName)
+	assert.Equal("spr/main/00000002", prs[1].HeadRefName)
+	assert.Equal("spr/main/00000000", prs[1].BaseRefName)
+
+	h.fake.ApproveAll()
+	assert.NoError(h.sd.MergePRSet(ctx, "s0"))
+	assert.Empty(h.fake.OpenPullRequests())
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateMerged, pr2.State)
+	assert.NotContains(h.fake.Branches(), "spr/main/00000000")
+	assert.NotContains(h.fake.Branches(), "spr/main/00000002")
+	assert.Equal(2, len(strings.Split(h.git("log", "--format=%s", "origin/main~2..origin/main"), "\n")))
+}
+
+func TestHermeticPRSetTemplateAndMergeQueue(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.cfg.Repo.PRTemplatePath = "pull_request_template.md"
+	h.cfg.Repo.PRTemplateInsertStart = "<!-- start -->"
+	h.cfg.Repo.PRTemplateInsertEnd = "<!-- end -->"
+	h.cfg.Repo.MergeQueue = true
+	h.cfg.User.CreateDraftPRs = true
+
+	template := "## Summary\n<!-- start -->\n<!-- end -->\n## Testing\n"
+	require.NoError(t, os.WriteFile(filepath.Join(h.dir, "pull_request_template.md"), []byte(template), 0644))
+	h.git("add", "pull_request_template.md")
+	h.git("commit", "-m", "add template")
+	h.git("push", "origin", "HEAD:main")
+	h.git("fetch", "origin")
+	h.git("branch", "--set-upstream-to", "origin/main")
+
+	require.NoError(t, os.WriteFile(filepath.Join(h.dir, "feature"), []byte("feature\n"), 0644))
+	h.git("add", "feature")
+	h.git("commit", "-m", "test commit 1\n\nFirst body.\n\ncommit-id:00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 2)
+	assert.True(strings.HasPrefix(prs[0].Body, "## Summary\n<!-- start -->\nFirst body."), prs[0].Body)
+	assert.True(strings.HasSuffix(prs[0].Body, "<!-- end -->\n## Testing\n"), prs[0].Body)
+	assert.True(prs[0].Draft)
+	assert.True(prs[1].Draft)
+
+	h.fake.ApproveAll()
+	assert.NoError(h.sd.MergePRSet(ctx, "s0"))
+	top, _ := h.fake.PullRequest(prs[1].Number)
+	assert.True(top.AutoMerge)
+	// the queued pull request and the one below stay open until it is merged
+	assert.Contains(h.fake.Branches(), "spr/main/00000002")
+	assert.Contains(h.fake.Branches(), "spr/main/00000001")
+	below, _ := h.fake.PullRequest(prs[0].Number)
+	assert.Equal(fakegithub.StateOpen, below.State)
+}
+
+func TestHermeticPagination(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.fake.MaxPageSize = 1
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Equal([]string{
+		"[vxvx]   3 : test commit 3",
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	// a pull request with more commits than fit in a page uses its last commit
+	pr3, _ := h.fake.PullRequest(3)
+	_, _, err := h.rest.PullRequests.Edit(ctx, h.fake.Owner, h.fake.Name, 3,
+		&gogithub.PullRequest{Base: &gogithub.PullRequestBranch{Ref: gogithub.Ptr("main")}})
+	assert.NoError(err)
+	info, err := h.sd.github.GetInfo(ctx, h.sd.gitcmd)
+	assert.NoError(err)
+	assert.False(info.Truncated)
+	assert.Len(info.PullRequests, 1)
+	assert.Equal(3, info.PullRequests[0].Number)
+	assert.Equal(h.fake.BranchHead(pr3.HeadRefName), info.PullRequests[0].Commit.CommitHash)
+}
+
+func TestHermeticChecks(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.lines()
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	h.fake.AddCheckRun(c2, fakegithub.CheckRun{Name: "build", Workflow: "CI", Status: "COMPLETED", Conclusion: "SUCCESS",
+		StartedAt: start, CompletedAt: start.Add(92 * time.Second), DetailsURL: "https://ci/build"})
+	h.fake.AddCheckRun(c2, fakegithub.CheckRun{Name: "lint", Workflow: "CI", Status: "COMPLETED", Conclusion: "FAILURE",
+		StartedAt: start, CompletedAt: start.Add(5 * time.Second), DetailsURL: "https://ci/lint"})
+	h.fake.SetCommitStatus(c2, "PENDING")
+	h.fake.RequiredChecks = []string{"build"}
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{
+		"[xxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	// failed checks are listed first, required ones are marked
+	index := 1
+	assert.NoError(h.sd.ShowChecks(ctx, &index))
+	assert.Equal([]string{
+		"#2 : test commit 2",
+		"  x  CI / lint        5s  https://ci/lint",
+		"  .  fake/ci           -",
+		"  v* CI / build    1m32s  https://ci/build",
+	}, h.lines())
+
+	assert.NoError(h.sd.ShowChecks(ctx, nil))
+	lines := h.lines()
+	assert.Equal([]string{"", "#1 : test commit 1", "  .* build        -"}, lines[len(lines)-3:])
+	index = 2
+	assert.ErrorContains(h.sd.ShowChecks(ctx, &index), "out of range")
+
+	// only the required build check counts, it didn't report on #1 yet
+	h.cfg.Repo.RequiredChecksOnly = true
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{
+		"[vxvx]   2 : test commit 2",
+		"[.xvx]   1 : test commit 1",
+	}, h.lines())
+}
+
+func TestHermeticCachedMerge(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+	cache := github.NewCacheTransport(h.cfg, t.TempDir(), nil)
+	client, err := githubclient.NewGitHubClient(ctx, h.cfg, cache)
+	assert.NoError(err)
+	h.sd.github = client
+	h.sd.Cache = cache
+
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	assert.Equal([]string{"[vxvx]   1 : test commit 1"}, h.lines())
+
+	// the cached status doesn't see the approval yet
+	h.fake.ApproveAll()
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{"[vxvx]   1 : test commit 1"}, h.lines())
+
+	// merging decides on the current state
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{"MERGED   1 : test commit 1"}, h.lines())
+}
+
+func TestHermeticRequiredCheckNotReported(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.fake.ApproveAll()
+	h.lines()
+
+	// the required build check hasn't started yet, so the pull request isn't merged
+	h.cfg.Repo.RequiredChecksOnly = true
+	h.fake.RequiredChecks = []string{"build"}
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Empty(h.output.String())
+	pr1, _ := h.fake.PullRequest(1)
+	assert.Equal(fakegithub.StateOpen, pr1.State)
+
+	h.fake.AddCheckRun(c1, fakegithub.CheckRun{Name: "build", Workflow: "CI", Status: "COMPLETED", Conclusion: "SUCCESS"})
+	assert.NoError(h.sd.MergePullRequests(ctx, nil))
+	assert.Equal([]string{"MERGED   1 : test commit 1"}, h.lines())
+}
+
+func TestHermeticPRSetChecks(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+	h.lines()
+
+	pr1, _ := h.fake.PullRequest(1)
+	head := h.fake.BranchHead(pr1.HeadRefName)
+	h.fake.AddCheckRun(head, fakegithub.CheckRun{Name: "build", Workflow: "CI", Status: "COMPLETED", Conclusion: "SUCCESS"})
+	h.fake.AddCheckRun(head, fakegithub.CheckRun{Name: "e2e", Workflow: "CI", Status: "IN_PROGRESS"})
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	assert.Equal(" 0 s0 [.xvx]   1   : test commit 0", stripColors(h.lines()[0]))
+
+	h.cfg.Repo.RequiredChecksOnly = true
+	h.fake.RequiredChecks = []string{"build"}
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	assert.Equal(" 0 s0 [vxvx]   1   : test commit 0", stripColors(h.lines()[0]))
+
+	index := 0
+	assert.NoError(h.sd.ShowChecks(ctx, &index))
+	assert.Equal([]string{
+		"#1 : test commit 0",
+		"  .  CI / e2e          -",
+		"  v* CI / build        -",
+	}, h.lines())
+}
+
+func TestHermeticPRSetClosedPullRequest(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+	h.lines()
+
+	// the commit stays in its PR set when its pull request is closed on GitHub
+	h.fake.Close(2)
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	lines := h.lines()
+	assert.Equal(" 1 s0 [----] No Pull Request Created                                     : test commit 1", stripColors(lines[0]))
+	assert.Equal(" 0 s0 [vxvx]   1   : test commit 0", stripColors(lines[1]))
+	assert.Equal(map[string]int{"00000000": 0, "00000001": 0}, h.cfg.State.RepoToCommitIdToPRSet[h.cfg.Repo.GitHubRepoName])
+
+	// the PR set isn't merged without a pull request for its top commit
+	h.fake.ApproveAll()
+	err := h.sd.MergePRSet(ctx, "s0")
+	assert.ErrorIs(err, github.ErrPullRequestNotFound)
+	pr1, _ := h.fake.PullRequest(1)
+	assert.Equal(fakegithub.StateOpen, pr1.State)
+
+	// updating the PR set opens a new pull request for it
+	assert.NoError(h.sd.UpdatePRSets(ctx, "s0"))
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 2)
+	assert.Equal(1, prs[0].Number)
+	assert.Equal(3, prs[1].Number)
+}
+
+func TestHermeticChecksNotListed(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+	h.lines()
+
+	// only the first check run of each suite is listed
+	h.fake.MaxPageSize = 1
+	pr1, _ := h.fake.PullRequest(1)
+	head := h.fake.BranchHead(pr1.HeadRefName)
+	h.fake.AddCheckRun(head, fakegithub.CheckRun{Name: "build", Workflow: "CI", Status: "COMPLETED", Conclusion: "SUCCESS"})
+	h.fake.AddCheckRun(head, fakegithub.CheckRun{Name: "lint", Workflow: "CI", Status: "COMPLETED", Conclusion: "FAILURE"})
+
+	// the status check rollup accounts for the check runs which aren't listed
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	assert.Equal(" 0 s0 [xxvx]   1   : test commit 0", stripColors(h.lines()[0]))
+
+	// a required check could be among them, so they are pending
+	h.cfg.Repo.RequiredChecksOnly = true
+	h.fake.RequiredChecks = []string{"build"}
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	assert.Equal(" 0 s0 [.xvx]   1   : test commit 0", stripColors(h.lines()[0]))
+
+	index := 0
+	assert.NoError(h.sd.ShowChecks(ctx, &index))
+	assert.Equal([]string{
+		"#1 : test commit 0",
+		"  ?* CI / 1 more check runs not listed        -",
+		"  v* CI / build                               -",
+	}, h.lines())
+}
+
+func TestHermeticReviews(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"reviewer", "spr-owner/reviewers"}, nil))
+	h.lines()
+
+	// changes requested on the second pull request keep it from merging
+	h.fake.RequiredApprovals = 1
+	h.fake.Approve(1)
+	h.fake.AddReview(2, "reviewer", "CHANGES_REQUESTED")
+	h.sd.DetailEnabled = true
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	lines := h.lines()
+	assert.Equal([]string{
+		"[v!vx]   2 : test commit 2",
+		"        reviews: 0/1 approvals, changes requested by reviewer, waiting on spr-owner/reviewers",
+		"[vvvv]   1 : test commit 1",
+		"        reviews: 1/1 approvals, waiting on spr-owner/reviewers",
+	}, lines[len(lines)-4:])
+
+	// two approvals are required now
+	h.fake.RequiredApprovals = 2
+	h.fake.AddReview(2, "reviewer", "APPROVED")
+	h.sd.DetailEnabled = false
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+}
+
+func TestHermeticDismissedReviews(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.fake.Approve(1)
+	h.fake.AddReview(2, "reviewer", "CHANGES_REQUESTED")
+	h.fake.DismissReviews(1, "reviewer")
+	h.fake.DismissReviews(2, "reviewer")
+
+	// new commits are pushed after the reviews were dismissed
+	other := h.fake.Clone(t)
+	h.gitIn(other, "commit", "--allow-empty", "-m", "other change")
+	h.gitIn(other, "push", "origin", "HEAD:"+fakegithub.DefaultBranch)
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.lines()
+
+	// only the dismissed approval is stale, not the dismissed change request
+	h.sd.DetailEnabled = true
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	lines := h.lines()
+	assert.Equal([]string{
+		"[vxvx]   2 : test commit 2",
+		"        reviews: 0/1 approvals",
+		"[vxvx]   1 : test commit 1",
+		"        reviews: 0/1 approvals, stale approval by reviewer",
+	}, lines[len(lines)-4:])
+}
+
+func TestHermeticReviewPagination(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.fake.MaxPageSize = 1
+	h.fake.Users = append(h.fake.Users, fakegithub.User{ID: "U_teammate", Login: "teammate", Name: "Team Mate"})
+
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"teammate", "spr-owner/reviewers"}, nil))
+	h.lines()
+
+	// the approval and the team review request are on the second page
+	h.fake.RequiredApprovals = 1
+	h.fake.AddReview(1, "teammate", "COMMENTED")
+	h.fake.Approve(1)
+	h.sd.DetailEnabled = true
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	lines := h.lines()
+	assert.Equal([]string{
+		"[vvvv]   1 : test commit 1",
+		"        reviews: 1/1 approvals, waiting on spr-owner/reviewers",
+	}, lines[len(lines)-2:])
+}
+
+func TestHermeticPRSetPagination(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.fake.MaxPageSize = 1
+
+	h.commit("test commit 0", "00000000")
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0-2"))
+	assert.Len(h.fake.OpenPullRequests(), 3)
+	assert.NoError(h.sd.StatusCommitsAndPRSets(ctx))
+	lines := h.lines()
+	assert.Len(lines, 6)
+	assert.Equal(" 2 s0 [vxvx]   3   : test commit 2", stripColors(lines[3]))
+	assert.Equal(" 1 s0 [vxvx]   2   : test commit 1", stripColors(lines[4]))
+	assert.Equal(" 0 s0 [vxvx]   1   : test commit 0", stripColors(lines[5]))
+}
+
+func TestHermeticPRSetTruncatedListing(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c0 := h.commit("test commit 0", "00000000")
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+	assert.Len(h.fake.OpenPullRequests(), 1)
+
+	// more pull requests than can be listed
+	var refs []string
+	for i := 0; i < github.MaxPages; i++ {
+		refs = append(refs, fmt.Sprintf("%s:refs/heads/other/%d", c0, i))
+	}
+	h.git(append([]string{"push", "origin"}, refs...)...)
+	for i := 0; i < github.MaxPages; i++ {
+		_, _, err := h.rest.PullRequests.Create(ctx, h.fake.Owner, h.fake.Name, &gogithub.NewPullRequest{
+			Title: gogithub.Ptr(fmt.Sprintf("other %d", i)),
+			Head:  gogithub.Ptr(fmt.Sprintf("other/%d", i)),
+			Base:  gogithub.Ptr("main"),
+		})
+		assert.NoError(err)
+	}
+	h.fake.MaxPageSize = 1
+
+	// drop the commit of the pull request so it looks orphaned
+	h.git("rebase", "--onto", c0+"^", c0)
+	err := h.sd.UpdatePRSets(ctx, "0")
+	assert.ErrorIs(err, github.ErrListingTruncated)
+	pr1, _ := h.fake.PullRequest(1)
+	assert.Equal(fakegithub.StateOpen, pr1.State)
+}
+
+func TestHermeticStatusJSON(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	c2 := h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	formatter, err := report.NewFormatter("json")
+	assert.NoError(err)
+	h.sd.Formatter = formatter
+	h.fake.Approve(1)
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+
+	var r report.Report
+	assert.NoError(json.Unmarshal(h.output.Bytes(), &r))
+	assert.Equal(report.SchemaVersion, r.SchemaVersion)
+	assert.Equal(report.WorkflowStack, r.Workflow)
+	assert.Len(r.Commits, 2)
+	assert.Equal(1, r.Commits[0].Index)
+	assert.Equal("00000002", r.Commits[0].CommitID)
+	assert.Equal(c2, r.Commits[0].CommitHash)
+	assert.Equal(2, r.Commits[0].PullRequest.Number)
+	assert.Equal("https://"+h.fake.URL+"/spr-owner/spr-repo/pull/2", r.Commits[0].PullRequest.URL)
+	assert.Equal("spr/main/00000002", r.Commits[0].PullRequest.FromBranch)
+	assert.Equal("spr/main/00000001", r.Commits[0].PullRequest.ToBranch)
+	assert.False(r.Commits[0].PullRequest.MergeStatus.ReviewApproved)
+	assert.True(r.Commits[1].PullRequest.MergeStatus.ReviewApproved)
+	assert.True(r.Commits[1].PullRequest.Mergeable)
+
+	// local commits without a pull request are listed too
+	c3 := h.commit("test commit 3", "00000003")
+	h.output.Reset()
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	r = report.Report{}
+	assert.NoError(json.Unmarshal(h.output.Bytes(), &r))
+	assert.Len(r.Commits, 3)
+	assert.Equal(2, r.Commits[0].Index)
+	assert.Equal(c3, r.Commits[0].CommitHash)
+	assert.Nil(r.Commits[0].PullRequest)
+	assert.Equal(2, r.Commits[1].PullRequest.Number)
+}
+
+func TestHermeticPRSetStatusJSON(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	h.commit("WIP test commit 1", "00000001")
+
+	formatter, err := report.NewFormatter("{{.Index}} {{.PRSet}} {{.WIP}} {{with .PullRequest}}{{.Number}}{{end}}")
+	assert.NoError(err)
+	h.sd.Formatter = formatter
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+	assert.Equal([]string{
+		"1 <nil> true ",
+		"0 0 false 1",
+	}, h.lines())
+}
+
+func TestHermeticStackAndFixup(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	c1 := h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+	count := uint(2)
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, &count))
+	h.output.Reset()
+
+	commits, err := h.sd.Stack(ctx)
+	assert.NoError(err)
+	assert.Len(commits, 3)
+	assert.Equal("00000003", commits[0].CommitID)
+	assert.Equal(2, commits[0].Index)
+	assert.Nil(commits[0].PullRequest)
+	assert.Equal(commits[1], commits[0].Parent)
+	assert.Equal("00000002", commits[1].CommitID)
+	assert.Equal(2, commits[1].PullRequest.Number)
+	assert.Equal("00000001", commits[2].CommitID)
+	assert.Equal(0, commits[2].Index)
+	assert.Equal(1, commits[2].PullRequest.Number)
+	assert.Nil(commits[2].Parent)
+
+	// staged changes are folded into the bottom commit and the stack is rebased on top
+	err = os.WriteFile(filepath.Join(h.dir, "test_commit_1"), []byte("amended\n"), 0644)
+	assert.NoError(err)
+	h.git("add", "test_commit_1")
+	assert.NoError(h.sd.FixupCommit(ctx, c1))
+	assert.Equal("test commit 3\ntest commit 2\ntest commit 1",
+		h.git("log", "--format=%s", "origin/main..HEAD"))
+	assert.Equal("amended", h.git("show", "HEAD~2:test_commit_1"))
+	assert.Empty(h.git("status", "--porcelain"))
+}
+
+func TestHermeticReviewers(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.cfg.Repo.DefaultReviewers = []string{"spr-owner/reviewers"}
+	h.cfg.Repo.CodeOwnersReviewers = true
+	// CODEOWNERS is read from the target branch
+	other := h.fake.Clone(t)
+	assert.NoError(os.MkdirAll(filepath.Join(other, ".github"), 0755))
+	assert.NoError(os.WriteFile(filepath.Join(other, ".github", "CODEOWNERS"),
+		[]byte("* @spr-user\n/test_commit_2 @reviewer @spr-owner/reviewers\n"), 0644))
+	h.gitIn(other, "add", ".github/CODEOWNERS")
+	h.gitIn(other, "commit", "-m", "add code owners")
+	h.gitIn(other, "push", "origin", "HEAD:"+fakegithub.DefaultBranch)
+
+	// the author is never requested, default reviewers and code owners are
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+	pr1, _ := h.fake.PullRequest(1)
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Empty(pr1.ReviewerIDs)
+	assert.Equal([]string{"T_reviewers"}, pr1.TeamReviewerIDs)
+	assert.Equal([]string{"U_reviewer"}, pr2.ReviewerIDs)
+	assert.Equal([]string{"T_reviewers"}, pr2.TeamReviewerIDs)
+
+	// explicit reviewers are planned on existing pull requests in a dry run,
+	//  unless review was already requested from them
+	sd, plan := h.dryRun()
+	assert.NoError(sd.UpdatePullRequests(ctx, []string{"@Reviewer"}, nil))
+	assert.NoError(plan.Close())
+	h.output.Reset()
+	assert.NoError(plan.Write(h.output))
+	assert.Equal([]string{
+		"request review #1 from reviewer : test commit 1",
+	}, h.lines())
+	pr1, _ = h.fake.PullRequest(1)
+	assert.Empty(pr1.ReviewerIDs)
+
+	// and added to existing pull requests, keeping earlier reviewers
+	h.cfg.Repo.DefaultReviewers = nil
+	h.cfg.Repo.CodeOwnersReviewers = false
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"reviewer"}, nil))
+	pr1, _ = h.fake.PullRequest(1)
+	pr2, _ = h.fake.PullRequest(2)
+	assert.Equal([]string{"U_reviewer"}, pr1.ReviewerIDs)
+	assert.Equal([]string{"T_reviewers"}, pr1.TeamReviewerIDs)
+	assert.Equal([]string{"U_reviewer"}, pr2.ReviewerIDs)
+	assert.Equal(2, pr1.ReviewRequests)
+	assert.Equal(1, pr2.ReviewRequests)
+
+	// reviewers who are pending or already reviewed aren't notified again on later updates
+	h.fake.Approve(1)
+	h.fake.AddReview(2, "reviewer", "COMMENTED")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, []string{"reviewer", "spr-owner/Reviewers"}, nil))
+	pr1, _ = h.fake.PullRequest(1)
+	pr2, _ = h.fake.PullRequest(2)
+	assert.Equal(2, pr1.ReviewRequests)
+	assert.Equal(1, pr2.ReviewRequests)
+	assert.Empty(pr1.ReviewerIDs)
+
+	err := h.sd.UpdatePullRequests(ctx, []string{"spr-owner/missing"}, nil)
+	assert.ErrorContains(err, `team "spr-owner/missing" not found`)
+	err = h.sd.UpdatePullRequests(ctx, []string{"nobody"}, nil)
+	assert.ErrorContains(err, `user "nobody" not found`)
+}
+
+func TestHermeticAuth(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GITHUB_TOKEN", "")
+	host := h.cfg.Repo.GitHubHost
+	hostName := strings.TrimPrefix(host, "http://")
+
+	assert.ErrorIs(h.sd.AuthStatus(ctx, host), github.ErrUnauthorized)
+	assert.ErrorContains(h.sd.AuthLogin(ctx, host), "authClientID")
+
+	h.cfg.User.AuthClientID = "spr-client"
+	assert.NoError(h.sd.AuthLogin(ctx, host))
+	file := filepath.Join(home, ".config", "spr", "hosts.yml")
+	assert.Equal([]string{
+		"copy the one-time code ABCD-1234 and enter it at " + host + "/login/device",
+		"waiting for authorization...",
+		"logged in to " + hostName + " as spr-user, the token is stored in " + file,
+	}, h.lines())
+
+	assert.NoError(h.sd.AuthStatus(ctx, host))
+	assert.Equal([]string{
+		hostName,
+		"  logged in as spr-user, token from " + file,
+		"  scopes: read:org, repo",
+	}, h.lines())
+
+	// tokens without the scopes spr needs are reported and not stored
+	h.fake.TokenScopes = []string{"repo"}
+	assert.NoError(h.sd.AuthStatus(ctx, host))
+	assert.Equal("  missing scopes: read:org, run spr auth login for a new token", h.lines()[3])
+	assert.ErrorContains(h.sd.AuthLogin(ctx, host), "missing the read:org scopes")
+	h.lines()
+
+	assert.NoError(h.sd.AuthLogout(host))
+	assert.Equal([]string{"logged out of " + hostName}, h.lines())
+	assert.ErrorIs(h.sd.AuthStatus(ctx, host), github.ErrUnauthorized)
+
+	// environment variables come first
+	t.Setenv("GH_ENTERPRISE_TOKEN", "fake-token")
+	assert.NoError(h.sd.AuthLogout(host))
+	assert.Equal([]string{
+		"no token of " + hostName + " stored by spr auth login",
+		"the token from GH_ENTERPRISE_TOKEN is still used",
+	}, h.lines())
+}
+
+func TestHermeticBranchNameTemplate(t *testing.T) {
+	for _, prSetWorkflows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prSetWorkflows=%v", prSetWorkflows), func(t *testing.T) {
+			h := makeHermeticObjects(t, prSetWorkflows)
+			assert := require.New(t)
+			ctx := context.Background()
+			h.cfg.Repo.BranchNameTemplate = "users/{login}/{target}/{commitId}"
+			h.cfg.User.Login = fakegithub.DefaultLogin
+
+			h.commit("test commit 1", "00000001")
+			h.commit("test commit 2", "00000002")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+			h.output.Reset()
+
+			prs := h.fake.OpenPullRequests()
+			assert.Len(prs, 2)
+			assert.Equal("users/spr-user/main/00000001", prs[0].HeadRefName)
+			assert.Equal("main", prs[0].BaseRefName)
+			assert.Equal("users/spr-user/main/00000002", prs[1].HeadRefName)
+			assert.Equal("users/spr-user/main/00000001", prs[1].BaseRefName)
+
+			// the pull requests are found again from their branch names
+			commits, err := h.sd.Stack(ctx)
+			assert.NoError(err)
+			assert.Equal(2, commits[0].PullRequest.Number)
+			assert.Equal(1, commits[1].PullRequest.Number)
+
+			if !prSetWorkflows {
+				// pull request branches are recognized locally too
+				h.git("checkout", "-b", "users/spr-user/main/00000002")
+				err = h.sd.UpdatePullRequests(ctx, nil, nil)
+				assert.ErrorContains(err, "don't run spr in a remote pr branch")
+			}
+		})
+	}
+}
+
+func TestHermeticTargetBranchWithSlashes(t *testing.T) {
+	for _, prSetWorkflows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prSetWorkflows=%v", prSetWorkflows), func(t *testing.T) {
+			h := makeHermeticObjects(t, prSetWorkflows)
+			assert := require.New(t)
+			ctx := context.Background()
+			h.git("push", "origin", "HEAD:refs/heads/release/1.2")
+			h.git("fetch", "origin")
+			h.cfg.Repo.GitHubBranch = "release/1.2"
+
+			h.commit("test commit 1", "00000001")
+			h.commit("test commit 2", "00000002")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+			h.output.Reset()
+
+			prs := h.fake.OpenPullRequests()
+			assert.Len(prs, 2)
+			assert.Equal("spr/release/1.2/00000001", prs[0].HeadRefName)
+			assert.Equal("release/1.2", prs[0].BaseRefName)
+			assert.Equal("spr/release/1.2/00000002", prs[1].HeadRefName)
+			assert.Equal("spr/release/1.2/00000001", prs[1].BaseRefName)
+
+			h.fake.ApproveAll()
+			if prSetWorkflows {
+				assert.NoError(h.sd.MergePRSet(ctx, "s0"))
+			} else {
+				assert.NoError(h.sd.MergePullRequests(ctx, nil))
+			}
+			assert.Empty(h.fake.OpenPullRequests())
+			h.git("fetch", "origin")
+			assert.Equal("test commit 2\ntest commit 1",
+				h.git("log", "--format=%s", "origin/release/1.2~2..origin/release/1.2"))
+		})
+	}
+}
+
+func TestHermeticCheckoutPullRequest(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+	h.cfg.Repo.BranchNameTemplate = "users/{login}/{target}/{commitId}"
+
+	// a teammate opens a stack of two pull requests
+	h.fake.Login = "teammate"
+	h.cfg.User.Login = "teammate"
+	h.commit("test commit 1", "00000001")
+	top := h.commit("test commit 2", "00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.git("checkout", "-q", "-b", "other", "origin/main")
+	h.fake.Login = fakegithub.DefaultLogin
+	h.cfg.User.Login = fakegithub.DefaultLogin
+	h.output.Reset()
+
+	assert.NoError(h.sd.CheckoutPullRequest(ctx, h.fake.URL+"/owner/repo/pull/2", ""))
+	assert.Equal("pr-2", h.git("rev-parse", "--abbrev-ref", "HEAD"))
+	assert.Equal("origin/main", h.git("rev-parse", "--abbrev-ref", "@{upstream}"))
+	assert.Equal(top, h.git("rev-parse", "HEAD"))
+	assert.Equal("teammate", h.cfg.User.Login)
+	assert.Equal("teammate", git.StackOwner(h.sd.gitcmd))
+	assert.Equal([]string{
+		"checked out 2 pull requests on pr-2, tracking origin/main",
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+	}, h.lines())
+
+	// the checked out stack is updated like a local one
+	h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 3)
+	assert.Equal("users/teammate/main/00000003", prs[2].HeadRefName)
+	assert.Equal("users/teammate/main/00000002", prs[2].BaseRefName)
+
+	// without a stack owner only the viewer's pull requests are listed
+	h.git("config", "--unset", "branch.pr-2.sprStackOwner")
+	h.output.Reset()
+	assert.NoError(h.sd.StatusPullRequests(ctx))
+	assert.Equal([]string{"[vxvx]   3 : test commit 3"}, h.lines())
+}
+
+func TestHermeticCheckoutPRSets(t *testing.T) {
+	h := makeHermeticObjects(t, true)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 0", "00000000")
+	assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+	assert.ErrorIs(h.sd.CheckoutPullRequest(ctx, "1", ""), errCheckoutPRSets)
+
+	// a teammate's stack checked out without PR sets can't be updated with them
+	h.cfg.User.PRSetWorkflows = false
+	assert.NoError(h.sd.CheckoutPullRequest(ctx, "1", ""))
+	assert.NoError(git.SetStackOwner(h.sd.gitcmd, "pr-1", "teammate"))
+	h.cfg.User.PRSetWorkflows = true
+	h.commit("test commit 1", "00000001")
+	assert.ErrorIs(h.sd.UpdatePRSets(ctx, "1"), errCheckoutPRSets)
+	assert.Len(h.fake.OpenPullRequests(), 1)
+}
+
+func TestParsePullRequestRef(t *testing.T) {
+	for ref, expected := range map[string]int{
+		"12":                                    12,
+		"#12":                                   12,
+		"!12":                                   12,
+		"https://github.com/ejoffe/spr/pull/12": 12,
+		"https://github.com/ejoffe/spr/pull/12/files":             12,
+		"https://gitlab.com/group/project/-/merge_requests/12":    12,
+		"https://gitlab.com/group/project/-/merge_requests/12#n1": 12,
+	} {
+		number, err := ParsePullRequestRef(ref)
+		require.NoError(t, err, ref)
+		require.Equal(t, expected, number, ref)
+	}
+	for _, ref := range []string{"", "pr-12", "https://github.com/ejoffe/spr/issues/12"} {
+		_, err := ParsePullRequestRef(ref)
+		require.Error(t, err, ref)
+	}
+}
+
+func TestHermeticCommitTrailers(t *testing.T) {
+	for _, prSetWorkflows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prSetWorkflows=%v", prSetWorkflows), func(t *testing.T) {
+			h := makeHermeticObjects(t, prSetWorkflows)
+			assert := require.New(t)
+			ctx := context.Background()
+
+			require.NoError(t, os.WriteFile(filepath.Join(h.dir, "feature"), []byte("feature\n"), 0644))
+			h.git("add", "feature")
+			h.git("commit", "-m", "test commit 1\n\nSome body.\n\n"+
+				"Reviewers: @reviewer\nLabels: backend\nAssignees: reviewer\nMilestone: v1.0\nDraft: true\n"+
+				"commit-id:00000001")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "0"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+
+			prs := h.fake.OpenPullRequests()
+			assert.Len(prs, 1)
+			assert.Equal("Some body.", prs[0].Body)
+			assert.Equal([]string{"backend"}, prs[0].Labels)
+			assert.Equal([]string{"reviewer"}, prs[0].Assignees)
+			assert.Equal([]string{"U_reviewer"}, prs[0].ReviewerIDs)
+			assert.Equal("v1.0", prs[0].Milestone)
+			assert.True(prs[0].Draft)
+			assert.Equal(1, prs[0].ReviewRequests)
+
+			// existing pull requests follow the trailers of the amended commit,
+			//  reviewers who are pending or reviewed aren't requested again
+			h.git("commit", "--amend", "-m", "test commit 1\n\nSome body.\n\n"+
+				"Reviewers: reviewer, spr-owner/reviewers\nLabels: bug\nDraft: false\ncommit-id:00000001")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "s0"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+			pr, _ := h.fake.PullRequest(prs[0].Number)
+			assert.Equal([]string{"backend", "bug"}, pr.Labels)
+			assert.False(pr.Draft)
+			assert.Equal([]string{"T_reviewers"}, pr.TeamReviewerIDs)
+			assert.Equal(2, pr.ReviewRequests)
+
+			h.fake.Approve(pr.Number)
+			h.git("commit", "--amend", "--allow-empty", "-m", "test commit 1 amended\n\n"+
+				"Reviewers: reviewer, spr-owner/reviewers\ncommit-id:00000001")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "s1"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+			pr, _ = h.fake.PullRequest(prs[0].Number)
+			assert.Equal(2, pr.ReviewRequests)
+		})
+	}
+}
+
+func TestHermeticLabelAssignMilestone(t *testing.T) {
+	for _, prSetWorkflows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prSetWorkflows=%v", prSetWorkflows), func(t *testing.T) {
+			h := makeHermeticObjects(t, prSetWorkflows)
+			assert := require.New(t)
+			ctx := context.Background()
+
+			h.commit("test commit 1", "00000001")
+			h.commit("test commit 2", "00000002")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+			prs := h.fake.OpenPullRequests()
+			assert.Len(prs, 2)
+
+			all := "0-1"
+			if prSetWorkflows {
+				all = "s0"
+			}
+			assert.NoError(h.sd.LabelPullRequests(ctx, all, []string{"backend", "bug"}, false))
+			assert.NoError(h.sd.LabelPullRequests(ctx, "1", []string{"bug"}, true))
+			assert.NoError(h.sd.AssignPullRequests(ctx, "0", []string{"reviewer"}))
+			assert.NoError(h.sd.SetPullRequestsMilestone(ctx, all, "v1.0"))
+
+			bottom, _ := h.fake.PullRequest(prs[0].Number)
+			top, _ := h.fake.PullRequest(prs[1].Number)
+			assert.Equal([]string{"backend", "bug"}, bottom.Labels)
+			assert.Equal([]string{"backend"}, top.Labels)
+			assert.Equal([]string{"reviewer"}, bottom.Assignees)
+			assert.Empty(top.Assignees)
+			assert.Equal("v1.0", bottom.Milestone)
+			assert.Equal("v1.0", top.Milestone)
+
+			h.commit("test commit 3", "00000003")
+			err := h.sd.LabelPullRequests(ctx, "2", []string{"bug"}, false)
+			assert.ErrorIs(err, github.ErrPullRequestNotFound)
+			err = h.sd.LabelPullRequests(ctx, "3", []string{"bug"}, false)
+			assert.ErrorIs(err, selector.ErrInvalidSelector)
+			err = h.sd.SetPullRequestsMilestone(ctx, "0", "v2.0")
+			assert.ErrorContains(err, `"v2.0" not found`)
+		})
+	}
+}
+
+func TestHermeticNewPullRequestLabels(t *testing.T) {
+	for _, prSetWorkflows := range []bool{false, true} {
+		t.Run(fmt.Sprintf("prSetWorkflows=%v", prSetWorkflows), func(t *testing.T) {
+			h := makeHermeticObjects(t, prSetWorkflows)
+			assert := require.New(t)
+			ctx := context.Background()
+			h.cfg.Repo.DefaultLabels = []string{"bug"}
+			h.cfg.Repo.PathLabels = map[string][]string{
+				"docs/":   {"backend"},
+				"*.proto": {"backend", "bug"},
+			}
+
+			h.commit("test commit 1", "00000001")
+			require.NoError(t, os.MkdirAll(filepath.Join(h.dir, "docs"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(h.dir, "docs", "guide.md"), []byte("guide\n"), 0644))
+			h.git("add", "docs")
+			h.git("commit", "-m", "test commit 2\n\ncommit-id:00000002")
+			if prSetWorkflows {
+				assert.NoError(h.sd.UpdatePRSets(ctx, "0-1"))
+			} else {
+				assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+			}
+
+			prs := h.fake.OpenPullRequests()
+			assert.Len(prs, 2)
+			assert.Equal([]string{"bug"}, prs[0].Labels)
+			assert.Equal([]string{"bug", "backend"}, prs[1].Labels)
+		})
+	}
+}
+
+// editTodo makes the spr edit editor replace the todo list with todo
+func (h *hermetic) editTodo(todo string) {
+	h.sd.editor = func(path string) error {
+		return os.WriteFile(path, []byte(todo), 0644)
+	}
+}
+
+func TestHermeticEditStack(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+	h.commit("test commit 4", "00000004")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	var listed string
+	h.sd.editor = func(path string) error {
+		todo, err := os.ReadFile(path)
+		listed = string(todo)
+		return err
+	}
+	assert.NoError(h.sd.EditStack(ctx, ""))
+	assert.True(strings.HasPrefix(listed, "pick 00000001 #1 [") && strings.Contains(listed, "] test commit 1\n"), listed)
+	assert.Equal([]string{"nothing to do"}, h.lines())
+
+	h.editTodo("pick 00000002\n" +
+		"reword 00000001 #1 [vxvx] reworded commit 1\n" +
+		"s 00000003\n" +
+		"drop 00000004\n")
+	assert.NoError(h.sd.EditStack(ctx, ""))
+	assert.Equal("reworded commit 1\ntest commit 2", h.git("log", "--format=%s", "origin/main..HEAD"))
+	assert.Contains(h.git("log", "-1", "--format=%B"), "commit-id:00000001")
+	assert.Contains(h.git("show", "--name-only", "--format=", "HEAD"), "test_commit_3")
+
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 2)
+	assert.Equal(2, prs[1].Number)
+	assert.Equal("main", prs[1].BaseRefName)
+	assert.Equal(1, prs[0].Number)
+	assert.Equal("reworded commit 1", prs[0].Title)
+	assert.Equal("spr/main/00000002", prs[0].BaseRefName)
+	assert.Equal([]string{"#3 was squashed into this pull request"}, prs[0].Comments)
+
+	pr, _ := h.fake.PullRequest(3)
+	assert.Equal(fakegithub.StateClosed, pr.State)
+	assert.Equal([]string{"Closing pull request: squashed into #1"}, pr.Comments)
+	pr, _ = h.fake.PullRequest(4)
+	assert.Equal(fakegithub.StateClosed, pr.State)
+	assert.Equal([]string{"Closing pull request: commit was dropped from the stack"}, pr.Comments)
+}
+
+func TestHermeticEditStackMove(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+
+	h.editTodo("pick 00000001\nmove 00000002\npick 00000003\n")
+	assert.NoError(h.sd.EditStack(ctx, ""))
+	assert.Equal("main", h.git("branch", "--show-current"))
+	assert.Equal("test commit 3\ntest commit 1", h.git("log", "--format=%s", "origin/main..HEAD"))
+	assert.Equal("test commit 2", h.git("log", "--format=%s", "origin/main..main-00000002"))
+
+	for _, pr := range h.fake.PullRequests() {
+		assert.Equal(fakegithub.StateOpen, pr.State, pr.Number)
+		switch pr.Number {
+		case 1, 2:
+			assert.Equal("main", pr.BaseRefName)
+		case 3:
+			assert.Equal("spr/main/00000001", pr.BaseRefName)
+		}
+	}
+}
+
+func TestHermeticEditStackConflict(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	require.NoError(t, os.WriteFile(filepath.Join(h.dir, "test_commit_1"), []byte("changed\n"), 0644))
+	h.git("commit", "-am", "test commit 2\n\ncommit-id:00000002")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	head := h.git("rev-parse", "HEAD")
+
+	h.editTodo("pick 00000002\npick 00000001\n")
+	err := h.sd.EditStack(ctx, "")
+	assert.ErrorContains(err, "edit aborted, the stack is unchanged")
+	assert.Equal(head, h.git("rev-parse", "HEAD"))
+	assert.Equal("main", h.git("branch", "--show-current"))
+	assert.Empty(h.git("status", "--porcelain"))
+
+	h.editTodo("pick 00000002\n")
+	assert.ErrorContains(h.sd.EditStack(ctx, ""), "commit 00000001 is missing from the todo list")
+	h.editTodo("squash 00000002\npick 00000001\n")
+	assert.ErrorContains(h.sd.EditStack(ctx, ""), "has no commit above it")
+	h.editTodo("fixup 00000002\npick 00000001\n")
+	assert.ErrorContains(h.sd.EditStack(ctx, ""), `unknown action "fixup"`)
+}
+
+func TestHermeticSplitCommit(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, os.WriteFile(filepath.Join(h.dir, name), []byte(name+"\n"), 0644))
+	}
+	h.git("add", "a", "b")
+	h.git("commit", "-m", "test commit 2\n\ncommit-id:00000002")
+	h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+
+	// the hunks of file a are picked, the ones of b are left
+	h.sd.input = strings.NewReader("y\nn\n")
+	h.editTodo("split out a\n# ignored\n")
+	assert.NoError(h.sd.SplitCommit(ctx, 1, nil, ""))
+	assert.Equal("main", h.git("branch", "--show-current"))
+	assert.Equal("test commit 3\ntest commit 2\nsplit out a\ntest commit 1",
+		h.git("log", "--format=%s", "origin/main..HEAD"))
+	assert.Equal("b", h.git("show", "--name-only", "--format=", "HEAD~1"))
+	assert.Contains(h.git("log", "-1", "--format=%B", "HEAD~1"), "commit-id:00000002")
+	assert.Equal("a", h.git("show", "--name-only", "--format=", "HEAD~2"))
+	assert.Regexp(`^split out a\n\ncommit-id:[a-f0-9]{8}$`, h.git("log", "-1", "--format=%B", "HEAD~2"))
+
+	prs := h.fake.OpenPullRequests()
+	assert.Len(prs, 4)
+	assert.Equal("split out a", prs[3].Title)
+	assert.Equal("spr/main/00000001", prs[3].BaseRefName)
+	assert.Equal(2, prs[1].Number)
+	assert.Equal(prs[3].HeadRefName, prs[1].BaseRefName)
+
+	head := h.git("rev-parse", "HEAD")
+	err := h.sd.SplitCommit(ctx, 2, []string{"b"}, "everything")
+	assert.ErrorContains(err, "split aborted, the stack is unchanged")
+	assert.ErrorContains(err, "nothing would be left on it")
+	assert.Equal(head, h.git("rev-parse", "HEAD"))
+	assert.Equal("main", h.git("branch", "--show-current"))
+	assert.Empty(h.git("status", "--porcelain"))
+	assert.ErrorContains(h.sd.SplitCommit(ctx, 2, []string{"a"}, "other"), "file a isn't changed by commit 00000002")
+	assert.ErrorContains(h.sd.SplitCommit(ctx, 4, nil, "other"), "out of range")
+}
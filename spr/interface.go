@@ -3,7 +3,7 @@ package spr
 import "context"
 
 type SPRInterface interface {
-	StatusPullRequests(ctx context.Context)
-	UpdatePullRequests(ctx context.Context)
-	MergePullRequests(ctx context.Context)
+	StatusPullRequests(ctx context.Context) error
+	UpdatePullRequests(ctx context.Context) error
+	MergePullRequests(ctx context.Context) error
 }
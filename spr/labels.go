@@ -0,0 +1,128 @@
+package spr
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ejoffe/spr/bl/selector"
+	"github.com/ejoffe/spr/codeowners"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// LabelPullRequests adds the labels to, or removes them from, the pull requests of the commits picked by sel
+func (sd *Stackediff) LabelPullRequests(ctx context.Context, sel string, labels []string, remove bool) error {
+	return sd.editPullRequests(ctx, sel, func(pr *github.PullRequest) error {
+		if remove {
+			return sd.github.RemoveLabels(ctx, pr, labels)
+		}
+		return sd.github.AddLabels(ctx, pr, labels)
+	})
+}
+
+// AssignPullRequests assigns the pull requests of the commits picked by sel to the given users
+func (sd *Stackediff) AssignPullRequests(ctx context.Context, sel string, logins []string) error {
+	return sd.editPullRequests(ctx, sel, func(pr *github.PullRequest) error {
+		return sd.github.AddAssignees(ctx, pr, logins)
+	})
+}
+
+// SetPullRequestsMilestone sets the milestone of the pull requests of the commits picked by sel
+func (sd *Stackediff) SetPullRequestsMilestone(ctx context.Context, sel string, title string) error {
+	return sd.editPullRequests(ctx, sel, func(pr *github.PullRequest) error {
+		return sd.github.SetMilestone(ctx, pr, title)
+	})
+}
+
+// editPullRequests calls edit in parallel for the pull requests of the commits picked by sel.
+//
+//	The selector takes commit indices and ranges, and PR sets with the pull
+//	request sets workflow. Every selected commit must have a pull request.
+func (sd *Stackediff) editPullRequests(ctx context.Context, sel string,
+	edit func(pr *github.PullRequest) error) error {
+	commits, err := sd.Stack(ctx)
+	if err != nil {
+		return err
+	}
+	indices, err := selector.Evaluate(commits, sel)
+	if err != nil {
+		return err
+	}
+	if indices.DestinationPRIndex != nil {
+		return fmt.Errorf("%w: a destination PR set can't be given", selector.ErrInvalidSelector)
+	}
+
+	var pullRequests []*github.PullRequest
+	for _, commit := range commits {
+		if !indices.CommitIndexes.Contains(commit.Index) {
+			continue
+		}
+		if commit.PullRequest == nil {
+			return fmt.Errorf("%w: commit %d has no pull request", github.ErrPullRequestNotFound, commit.Index)
+		}
+		// commits of a PR set share their pull request
+		if !slices.Contains(pullRequests, commit.PullRequest) {
+			pullRequests = append(pullRequests, commit.PullRequest)
+		}
+	}
+
+	return sd.forEach(len(pullRequests), func(i int) error {
+		return edit(pullRequests[i])
+	})
+}
+
+// labelNewPullRequest adds the default labels and the labels of the path patterns
+//
+//	matching the files changed by commit to a pull request spr just created.
+func (sd *Stackediff) labelNewPullRequest(ctx context.Context, pr *github.PullRequest, commit git.Commit) error {
+	labels := slices.Clone(sd.config.Repo.DefaultLabels)
+	if len(sd.config.Repo.PathLabels) > 0 {
+		files, err := sd.changedFiles(commit)
+		if err != nil {
+			return err
+		}
+		patterns := make([]string, 0, len(sd.config.Repo.PathLabels))
+		for pattern := range sd.config.Repo.PathLabels {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			matches := slices.ContainsFunc(files, func(file string) bool {
+				return codeowners.Match(pattern, file)
+			})
+			if matches {
+				labels = append(labels, sd.config.Repo.PathLabels[pattern]...)
+			}
+		}
+	}
+
+	var unique []string
+	for _, label := range labels {
+		if !slices.ContainsFunc(unique, func(l string) bool { return strings.EqualFold(l, label) }) {
+			unique = append(unique, label)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	return sd.github.AddLabels(ctx, pr, unique)
+}
+
+// changedFiles returns the paths of the files changed by commit, relative to the repository root
+func (sd *Stackediff) changedFiles(commit git.Commit) ([]string, error) {
+	var out string
+	err := sd.gitcmd.Git("diff-tree --no-commit-id --name-only -r "+commit.CommitHash, &out)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(out, "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
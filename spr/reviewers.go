@@ -0,0 +1,140 @@
+package spr
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ejoffe/spr/codeowners"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+)
+
+// reviewers resolves reviewer names to forge ids during one update.
+//
+//	Names are user logins or org/team names, the assignable users, team ids
+//	and CODEOWNERS file are only fetched when they are first needed.
+type reviewers struct {
+	sd     *Stackediff
+	author string
+
+	assignable []github.RepoAssignee
+	teamIDs    map[string]string
+
+	owners       *codeowners.CodeOwners
+	ownersLoaded bool
+}
+
+func newReviewers(sd *Stackediff, author string) *reviewers {
+	return &reviewers{sd: sd, author: author, teamIDs: map[string]string{}}
+}
+
+// forNewPullRequest returns the explicit reviewers and the reviewers of the commit trailer
+//
+//	together with the default reviewers and, when enabled, the code owners of the
+//	files changed by commit. Existing pull requests don't get the default reviewers
+//	and code owners, so reviewers removed by hand aren't requested again.
+func (r *reviewers) forNewPullRequest(commit git.Commit, explicit []string) ([]string, error) {
+	names := slices.Concat(explicit, commit.Reviewers, r.sd.config.Repo.DefaultReviewers)
+	if r.sd.config.Repo.CodeOwnersReviewers {
+		owners, err := r.codeOwners(commit)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, owners...)
+	}
+	return names, nil
+}
+
+// codeOwners returns the owners of the files changed by commit
+func (r *reviewers) codeOwners(commit git.Commit) ([]string, error) {
+	if !r.ownersLoaded {
+		target := r.sd.config.Repo.GitHubRemote + "/" + r.sd.config.Repo.GitHubBranch
+		owners, err := codeowners.Load(r.sd.gitcmd, target)
+		if err != nil {
+			return nil, fmt.Errorf("reading CODEOWNERS: %w", err)
+		}
+		r.owners, r.ownersLoaded = owners, true
+	}
+	if r.owners == nil {
+		return nil, nil
+	}
+
+	files, err := r.sd.changedFiles(commit)
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	for _, file := range files {
+		owners = append(owners, r.owners.Owners(file)...)
+	}
+	return owners, nil
+}
+
+// add requests review of pr from the named users and teams.
+//
+//	The pull request author and the users and teams of known, who already
+//	reviewed or were asked to, are skipped so they aren't notified again.
+func (r *reviewers) add(ctx context.Context, pr *github.PullRequest, known github.ReviewStatus, names []string) error {
+	var userIDs, teamIDs []string
+	seen := map[string]bool{strings.ToLower(r.author): true}
+	for _, reviewer := range known.Reviewers() {
+		seen[strings.ToLower(reviewer)] = true
+	}
+	for _, name := range names {
+		name = strings.TrimPrefix(name, "@")
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if strings.Contains(name, "/") {
+			id, err := r.teamID(ctx, name)
+			if err != nil {
+				return err
+			}
+			teamIDs = append(teamIDs, id)
+			continue
+		}
+		id, err := r.userID(ctx, name)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, id)
+	}
+	if len(userIDs) == 0 && len(teamIDs) == 0 {
+		return nil
+	}
+	return r.sd.github.AddReviewers(ctx, pr, userIDs, teamIDs)
+}
+
+func (r *reviewers) userID(ctx context.Context, login string) (string, error) {
+	if r.assignable == nil {
+		assignable, err := r.sd.github.GetAssignableUsers(ctx)
+		if err != nil {
+			return "", err
+		}
+		r.assignable = assignable
+	}
+	for _, u := range r.assignable {
+		if strings.EqualFold(login, u.Login) {
+			return u.ID, nil
+		}
+	}
+	return "", fmt.Errorf("unable to add reviewer, user %q not found", login)
+}
+
+func (r *reviewers) teamID(ctx context.Context, team string) (string, error) {
+	key := strings.ToLower(team)
+	if id, found := r.teamIDs[key]; found {
+		return id, nil
+	}
+	id, err := r.sd.github.GetTeamID(ctx, team)
+	if err != nil {
+		return "", err
+	}
+	r.teamIDs[key] = id
+	return id, nil
+}
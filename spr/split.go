@@ -0,0 +1,201 @@
+package spr
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ejoffe/spr/git"
+	"github.com/google/uuid"
+)
+
+const splitHelp = `
+# Write the message of the new commit split out below commit %s:
+#   %s
+# Lines starting with '#' are ignored, an empty message aborts the split.
+`
+
+// SplitCommit breaks the commit at index of the stack into two commits.
+//
+//	The hunks picked interactively like git add -p, or the changes to files when
+//	they are given, go to a new commit with a fresh commit-id placed directly below
+//	it. The remaining changes stay on the commit, which keeps its commit-id and so
+//	its pull request. The stack is then updated so the new pull request is put in
+//	its place. The local stack is left unchanged when nothing or everything is picked.
+func (sd *Stackediff) SplitCommit(ctx context.Context, index int, files []string, message string) error {
+	githubInfo, err := sd.fetchAndGetGitHubInfo(ctx)
+	if err != nil {
+		return err
+	}
+	localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(localCommits) {
+		return fmt.Errorf("commit index %d is out of range, the stack has %d commits", index, len(localCommits))
+	}
+	err = sd.checkCleanTree("splitting a commit")
+	if err != nil {
+		return err
+	}
+	commit := localCommits[index]
+	if len(files) > 0 {
+		changed, err := sd.changedFiles(commit)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if !slices.Contains(changed, file) {
+				return fmt.Errorf("file %s isn't changed by commit %s", file, commit.CommitID)
+			}
+		}
+	}
+
+	var gitDir string
+	err = sd.gitcmd.Git("rev-parse --absolute-git-dir", &gitDir)
+	if err != nil {
+		return err
+	}
+	messagePath := filepath.Join(gitDir, "SPR_SPLIT_MSG")
+	if message == "" {
+		message, err = sd.splitMessage(commit, messagePath)
+		if err != nil {
+			return err
+		}
+		if message == "" {
+			fmt.Fprintf(sd.Output, "split aborted, the message is empty\n")
+			return nil
+		}
+	}
+
+	head, err := sd.splitCommit(commit, files, message, messagePath)
+	if err == nil {
+		head, err = sd.replay(head, editPicks(localCommits[index+1:]), messagePath)
+	}
+	if err == nil {
+		err = sd.gitcmd.Git(fmt.Sprintf("checkout --quiet -B %s %s", githubInfo.LocalBranch, head), nil)
+	}
+	if err != nil {
+		return sd.abortRewrite("split", githubInfo.LocalBranch, err)
+	}
+	return sd.updatePullRequests(ctx, githubInfo, nil, nil)
+}
+
+// splitMessage asks for the message of the new commit in the editor
+func (sd *Stackediff) splitMessage(commit git.Commit, messagePath string) (string, error) {
+	err := os.WriteFile(messagePath, []byte(fmt.Sprintf(splitHelp, commit.CommitID, commit.Subject)), 0644)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(messagePath)
+	err = sd.editor(messagePath)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(messagePath)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// splitCommit commits the picked changes of commit on its parent with a new commit-id,
+//
+//	then commits the rest with the message and author of commit on top. The head
+//	is left detached on the remainder, whose hash is returned.
+func (sd *Stackediff) splitCommit(commit git.Commit, files []string, message string, messagePath string) (string, error) {
+	err := sd.gitcmd.Git("checkout --quiet --detach "+commit.CommitHash, nil)
+	if err != nil {
+		return "", err
+	}
+	// the index goes back to the parent, new files are kept as intent to add so they can be picked
+	err = sd.gitcmd.Git("reset --quiet --intent-to-add "+commit.CommitHash+"^", nil)
+	if err != nil {
+		return "", err
+	}
+	if len(files) > 0 {
+		err = sd.gitInteractive(append([]string{"add", "--all", "--"}, files...)...)
+	} else {
+		err = sd.gitInteractive("add", "--patch")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var picked, left string
+	err = sd.gitcmd.Git("diff --cached --name-only", &picked)
+	if err != nil {
+		return "", err
+	}
+	if picked == "" {
+		return "", fmt.Errorf("no changes of commit %s were picked", commit.CommitID)
+	}
+	err = sd.gitcmd.Git("diff --name-only", &left)
+	if err != nil {
+		return "", err
+	}
+	if left == "" {
+		return "", fmt.Errorf("all changes of commit %s were picked, nothing would be left on it", commit.CommitID)
+	}
+
+	message = fmt.Sprintf("%s\n\ncommit-id:%s\n", message, uuid.New().String()[:8])
+	err = os.WriteFile(messagePath, []byte(message), 0644)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(messagePath)
+	err = sd.gitcmd.Git("commit --quiet --cleanup=verbatim -F "+messagePath, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// the remainder has the tree of the original commit
+	err = sd.gitcmd.Git("read-tree "+commit.CommitHash, nil)
+	if err == nil {
+		err = sd.gitcmd.Git("commit --quiet -C "+commit.CommitHash, nil)
+	}
+	if err == nil {
+		err = sd.gitcmd.Git("reset --quiet --hard", nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	var head string
+	err = sd.gitcmd.Git("rev-parse HEAD", &head)
+	return head, err
+}
+
+// editPicks returns the steps picking commits in order
+func editPicks(commits []git.Commit) []editStep {
+	steps := make([]editStep, 0, len(commits))
+	for _, commit := range commits {
+		steps = append(steps, editStep{action: editPick, commit: commit})
+	}
+	return steps
+}
+
+// gitInteractive runs git in the repository connected to the input and output of spr,
+//
+//	for the commands prompting the user like git add --patch.
+func (sd *Stackediff) gitInteractive(args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = sd.gitcmd.RootDir()
+	cmd.Stdin = sd.input
+	cmd.Stdout = sd.Output
+	cmd.Stderr = sd.Output
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
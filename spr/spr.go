@@ -16,7 +16,6 @@ import (
 	"syscall"
 
 	"github.com/ejoffe/profiletimer"
-	"github.com/ejoffe/rake"
 	"github.com/ejoffe/spr/bl"
 	"github.com/ejoffe/spr/bl/concurrent"
 	"github.com/ejoffe/spr/bl/gitapi"
@@ -25,48 +24,64 @@ import (
 	"github.com/ejoffe/spr/config/config_parser"
 	"github.com/ejoffe/spr/git"
 	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/journal"
+	"github.com/ejoffe/spr/report"
 	ngit "github.com/go-git/go-git/v5"
-	gogithub "github.com/google/go-github/v69/github"
 )
 
 // NewStackedPR constructs and returns a new stackediff instance.
-func NewStackedPR(config *config.Config, github github.GitHubInterface, gitcmd git.GitInterface, repo *ngit.Repository, goghclient *gogithub.Client) *Stackediff {
+func NewStackedPR(config *config.Config, github github.Forge, gitcmd git.GitInterface, repo *ngit.Repository) *Stackediff {
 
 	return &Stackediff{
 		config:       config,
 		github:       github,
 		gitcmd:       gitcmd,
 		repo:         repo,
-		goghclient:   goghclient,
 		profiletimer: profiletimer.StartNoopTimer(),
 
 		Output: os.Stdout,
 		input:  os.Stdin,
+		editor: runEditor,
 	}
 }
 
 type Stackediff struct {
 	config        *config.Config
-	github        github.GitHubInterface
+	github        github.Forge
 	gitcmd        git.GitInterface
 	repo          *ngit.Repository
-	goghclient    *gogithub.Client
 	profiletimer  profiletimer.Timer
 	DetailEnabled bool
 
+	// Formatter is set when status output should be machine readable
+	Formatter *report.Formatter
+
+	// Journal records remote mutations so they can be undone, nothing is recorded when it's nil
+	Journal *journal.Journal
+
+	// Cache has the cached github responses, it is invalidated after pushes and skipped
+	//  when deciding what to merge. Nothing is cached when it's nil.
+	Cache *github.CacheTransport
+
 	Output       io.Writer
 	input        io.Reader
 	synchronized bool // When true code is executed without goroutines. Allows test to be deterministic
+
+	// editor opens a file for the user to change, the todo list of spr edit or the message of spr split
+	editor func(path string) error
 }
 
 // AmendCommit enables one to easily amend a commit in the middle of a stack
 //
 //	of commits. A list of commits is printed and one can be chosen to be amended.
-func (sd *Stackediff) AmendCommit(ctx context.Context) {
-	localCommits := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+func (sd *Stackediff) AmendCommit(ctx context.Context) error {
+	localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 	if len(localCommits) == 0 {
 		fmt.Fprintf(sd.Output, "No commits to amend\n")
-		return
+		return nil
 	}
 
 	for i := len(localCommits) - 1; i >= 0; i-- {
@@ -86,34 +101,24 @@ func (sd *Stackediff) AmendCommit(ctx context.Context) {
 	commitIndex, err := strconv.Atoi(line)
 	if err != nil || commitIndex < 1 || commitIndex > len(localCommits) {
 		fmt.Fprint(sd.Output, "Invalid input\n")
-		return
+		return nil
 	}
 	commitIndex = commitIndex - 1
-	check(err)
-	sd.gitcmd.MustGit("commit --fixup "+localCommits[commitIndex].CommitHash, nil)
-
-	rebaseCmd := fmt.Sprintf("rebase -i --autosquash --autostash %s/%s",
-		sd.config.Repo.GitHubRemote, sd.config.Repo.GitHubBranch)
-	sd.gitcmd.MustGit(rebaseCmd, nil)
+	return sd.FixupCommit(ctx, localCommits[commitIndex].CommitHash)
 }
 
-func (sd *Stackediff) addReviewers(ctx context.Context,
-	pr *github.PullRequest, reviewers []string, assignable []github.RepoAssignee) {
-	userIDs := make([]string, 0, len(reviewers))
-	for _, r := range reviewers {
-		found := false
-		for _, u := range assignable {
-			if strings.EqualFold(r, u.Login) {
-				found = true
-				userIDs = append(userIDs, u.ID)
-				break
-			}
-		}
-		if !found {
-			check(fmt.Errorf("unable to add reviewer, user %q not found", r))
-		}
+// FixupCommit amends the staged changes into the commit with the given hash
+//
+//	and rebases the rest of the stack on top of it.
+func (sd *Stackediff) FixupCommit(ctx context.Context, commitHash string) error {
+	err := sd.gitcmd.Git("commit --fixup "+commitHash, nil)
+	if err != nil {
+		return err
 	}
-	sd.github.AddReviewers(ctx, pr, userIDs)
+
+	rebaseCmd := fmt.Sprintf("rebase -i --autosquash --autostash %s/%s",
+		sd.config.Repo.GitHubRemote, sd.config.Repo.GitHubBranch)
+	return sd.gitcmd.Git(rebaseCmd, nil)
 }
 
 func alignLocalCommits(commits []git.Commit, prs []*github.PullRequest) []git.Commit {
@@ -144,14 +149,26 @@ func alignLocalCommits(commits []git.Commit, prs []*github.PullRequest) []git.Co
 //	 pull request if a commit has been amended.
 //	In the case where commits are reordered, the corresponding pull requests
 //	 will also be reordered to match the commit stack order.
-func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string, count *uint) {
+func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string, count *uint) error {
 	sd.profiletimer.Step("UpdatePullRequests::Start")
-	githubInfo := sd.fetchAndGetGitHubInfo(ctx)
-	if githubInfo == nil {
-		return
+	githubInfo, err := sd.fetchAndGetGitHubInfo(ctx)
+	if err != nil {
+		return err
 	}
 	sd.profiletimer.Step("UpdatePullRequests::FetchAndGetGitHubInfo")
-	localCommits := alignLocalCommits(git.GetLocalCommitStack(sd.config, sd.gitcmd), githubInfo.PullRequests)
+	return sd.updatePullRequests(ctx, githubInfo, reviewers, count)
+}
+
+// updatePullRequests syncs the local stack of the checked out branch with the pull requests
+//
+//	of githubInfo, pull requests whose commit isn't in the stack anymore are closed.
+func (sd *Stackediff) updatePullRequests(ctx context.Context,
+	githubInfo *github.ForgeInfo, reviewers []string, count *uint) error {
+	localCommitStack, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return err
+	}
+	localCommits := alignLocalCommits(localCommitStack, githubInfo.PullRequests)
 	sd.profiletimer.Step("UpdatePullRequests::GetLocalCommitStack")
 
 	// close prs for deleted commits
@@ -162,8 +179,21 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 	}
 	for _, pr := range githubInfo.PullRequests {
 		if _, found := localCommitMap[pr.Commit.CommitID]; !found {
-			sd.github.CommentPullRequest(ctx, pr, "Closing pull request: commit has gone away")
-			sd.github.ClosePullRequest(ctx, pr)
+			if githubInfo.Truncated {
+				return fmt.Errorf("%w: not closing pull request #%d", github.ErrListingTruncated, pr.Number)
+			}
+			err := sd.github.CommentPullRequest(ctx, pr, "Closing pull request: commit has gone away")
+			if err != nil {
+				return err
+			}
+			err = sd.github.ClosePullRequest(ctx, pr)
+			if err != nil {
+				return err
+			}
+			err = sd.Journal.ClosePullRequest(pr)
+			if err != nil {
+				return err
+			}
 		} else {
 			validPullRequests = append(validPullRequests, pr)
 		}
@@ -171,31 +201,26 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 	githubInfo.PullRequests = validPullRequests
 
 	if commitsReordered(localCommits, githubInfo.PullRequests) {
-		wg := new(sync.WaitGroup)
-		wg.Add(len(githubInfo.PullRequests))
-
 		// if commits have been reordered :
 		//   first - rebase all pull requests to target branch
 		//   then - update all pull requests
-		for i := range githubInfo.PullRequests {
-			fn := func(i int) {
-				pr := githubInfo.PullRequests[i]
-				sd.github.UpdatePullRequest(ctx, sd.gitcmd, githubInfo.PullRequests, pr, pr.Commit, nil)
-				wg.Done()
-			}
-			if sd.synchronized {
-				fn(i)
-			} else {
-				go fn(i)
+		err := sd.forEach(len(githubInfo.PullRequests), func(i int) error {
+			pr := githubInfo.PullRequests[i]
+			err := sd.github.UpdatePullRequest(ctx, sd.gitcmd, githubInfo.PullRequests, pr, pr.Commit, nil)
+			if err != nil {
+				return err
 			}
+			return sd.Journal.UpdatePullRequest(pr)
+		})
+		if err != nil {
+			return err
 		}
-
-		wg.Wait()
 		sd.profiletimer.Step("UpdatePullRequests::ReparentPullRequestsToMaster")
 	}
 
-	if !sd.syncCommitStackToGitHub(ctx, localCommits, githubInfo) {
-		return
+	err = sd.syncCommitStackToGitHub(ctx, localCommits, githubInfo)
+	if err != nil {
+		return err
 	}
 	sd.profiletimer.Step("UpdatePullRequests::SyncCommitStackToGithub")
 
@@ -206,7 +231,7 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 	}
 
 	updateQueue := make([]prUpdate, 0)
-	var assignable []github.RepoAssignee
+	requested := newReviewers(sd, githubInfo.UserName)
 
 	// iterate through local_commits and update pull_requests
 	var prevCommit *git.Commit
@@ -220,8 +245,9 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 				prFound = true
 				updateQueue = append(updateQueue, prUpdate{pr, c, prevCommit})
 				pr.Commit = c
-				if len(reviewers) != 0 {
-					fmt.Fprintf(sd.Output, "warning: not updating reviewers for PR #%d\n", pr.Number)
+				err := requested.add(ctx, pr, pr.MergeStatus.Reviews, slices.Concat(reviewers, c.Reviewers))
+				if err != nil {
+					return err
 				}
 				prevCommit = &localCommits[commitIndex]
 				break
@@ -230,14 +256,27 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 		if !prFound {
 			// if pull request is not found for this commit_id it means the commit
 			//  is new and we need to create a new pull request
-			pr := sd.github.CreatePullRequest(ctx, sd.gitcmd, githubInfo, c, prevCommit)
+			pr, err := sd.github.CreatePullRequest(ctx, sd.gitcmd, githubInfo, c, prevCommit)
+			if err != nil {
+				return err
+			}
+			err = sd.Journal.CreatePullRequest(pr)
+			if err != nil {
+				return err
+			}
+			err = sd.labelNewPullRequest(ctx, pr, c)
+			if err != nil {
+				return err
+			}
 			githubInfo.PullRequests = append(githubInfo.PullRequests, pr)
 			updateQueue = append(updateQueue, prUpdate{pr, c, prevCommit})
-			if len(reviewers) != 0 {
-				if assignable == nil {
-					assignable = sd.github.GetAssignableUsers(ctx)
-				}
-				sd.addReviewers(ctx, pr, reviewers, assignable)
+			names, err := requested.forNewPullRequest(c, reviewers)
+			if err != nil {
+				return err
+			}
+			err = requested.add(ctx, pr, pr.MergeStatus.Reviews, names)
+			if err != nil {
+				return err
 			}
 			prevCommit = &localCommits[commitIndex]
 		}
@@ -248,29 +287,23 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 	}
 	sd.profiletimer.Step("UpdatePullRequests::updatePullRequests")
 
-	wg := new(sync.WaitGroup)
-	wg.Add(len(updateQueue))
-
 	// Sort the PR stack by the local commit order, in case some commits were reordered
 	sortedPullRequests := sortPullRequestsByLocalCommitOrder(githubInfo.PullRequests, localCommits)
-	for i := range updateQueue {
-		fn := func(i int) {
-			pr := updateQueue[i]
-			sd.github.UpdatePullRequest(ctx, sd.gitcmd, sortedPullRequests, pr.pr, pr.commit, pr.prevCommit)
-			wg.Done()
-		}
-		if sd.synchronized {
-			fn(i)
-		} else {
-			go fn(i)
+	err = sd.forEach(len(updateQueue), func(i int) error {
+		pr := updateQueue[i]
+		err := sd.github.UpdatePullRequest(ctx, sd.gitcmd, sortedPullRequests, pr.pr, pr.commit, pr.prevCommit)
+		if err != nil {
+			return err
 		}
+		return sd.Journal.UpdatePullRequest(pr.pr)
+	})
+	if err != nil {
+		return err
 	}
 
-	wg.Wait()
-
 	sd.profiletimer.Step("UpdatePullRequests::commitUpdateQueue")
 
-	sd.StatusPullRequests(ctx)
+	return sd.StatusPullRequests(ctx)
 }
 
 // MergePullRequests will go through all the current pull requests
@@ -291,22 +324,29 @@ func (sd *Stackediff) UpdatePullRequests(ctx context.Context, reviewers []string
 //	pull request. This one merge in effect merges all the commits in the stack.
 //	We than close all the pull requests which are below the merged request, as
 //	their commits have already been merged.
-func (sd *Stackediff) MergePullRequests(ctx context.Context, count *uint) {
+func (sd *Stackediff) MergePullRequests(ctx context.Context, count *uint) error {
 	sd.profiletimer.Step("MergePullRequests::Start")
-	githubInfo := sd.github.GetInfo(ctx, sd.gitcmd)
+	sd.Cache.Refresh()
+	githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("MergePullRequests::getGitHubInfo")
 
 	// MergeCheck
 	if sd.config.Repo.MergeCheck != "" {
-		localCommits := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+		localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+		if err != nil {
+			return err
+		}
 		if len(localCommits) > 0 {
 			lastCommit := localCommits[len(localCommits)-1]
 			checkedCommit, found := sd.config.State.MergeCheckCommit[githubInfo.Key()]
 
 			if !found {
-				check(errors.New("need to run merge check 'spr check' before merging"))
+				return errors.New("need to run merge check 'spr check' before merging")
 			} else if checkedCommit != "SKIP" && lastCommit.CommitHash != checkedCommit {
-				check(errors.New("need to run merge check 'spr check' before merging"))
+				return errors.New("need to run merge check 'spr check' before merging")
 			}
 		}
 	}
@@ -327,20 +367,39 @@ func (sd *Stackediff) MergePullRequests(ctx context.Context, count *uint) {
 		prIndex--
 	}
 	if prIndex == -1 {
-		return
+		return nil
 	}
 	prToMerge := githubInfo.PullRequests[prIndex]
 
 	// Update the base of the merging pr to target branch
-	sd.github.UpdatePullRequest(ctx, sd.gitcmd, githubInfo.PullRequests, prToMerge, prToMerge.Commit, nil)
+	err = sd.github.UpdatePullRequest(ctx, sd.gitcmd, githubInfo.PullRequests, prToMerge, prToMerge.Commit, nil)
+	if err != nil {
+		return err
+	}
+	err = sd.Journal.UpdatePullRequest(prToMerge)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("MergePullRequests::update pr base")
 
 	// Merge pull request
-	mergeMethod, err := sd.config.MergeMethod()
-	check(err)
-	sd.github.MergePullRequest(ctx, prToMerge, mergeMethod)
+	mergeMethod, err := github.ParseMergeMethod(sd.config.Repo.MergeMethod)
+	if err != nil {
+		return err
+	}
+	err = sd.github.MergePullRequest(ctx, prToMerge, mergeMethod)
+	if err != nil {
+		return err
+	}
+	err = sd.Journal.MergePullRequest(prToMerge)
+	if err != nil {
+		return err
+	}
 	if sd.config.User.DeleteMergedBranches {
-		git.DeleteRemoteBranch(sd.config, sd.gitcmd, prToMerge.FromBranch)
+		err = sd.deleteRemoteBranch(prToMerge)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Close all the pull requests in the stack below the merged pr
@@ -348,43 +407,68 @@ func (sd *Stackediff) MergePullRequests(ctx context.Context, count *uint) {
 	for i := 0; i < prIndex; i++ {
 		pr := githubInfo.PullRequests[i]
 		comment := fmt.Sprintf(
-			"✓ Commit merged in pull request [#%d](https://%s/%s/%s/pull/%d)",
-			prToMerge.Number, sd.config.Repo.GitHubHost, sd.config.Repo.GitHubRepoOwner, sd.config.Repo.GitHubRepoName, prToMerge.Number)
-		sd.github.CommentPullRequest(ctx, pr, comment)
-		sd.github.ClosePullRequest(ctx, pr)
+			"✓ Commit merged in pull request [%s](%s)",
+			github.PullRequestReference(sd.config, prToMerge.Number), github.PullRequestURL(sd.config, prToMerge.Number))
+		err = sd.github.CommentPullRequest(ctx, pr, comment)
+		if err != nil {
+			return err
+		}
+		err = sd.github.ClosePullRequest(ctx, pr)
+		if err != nil {
+			return err
+		}
+		err = sd.Journal.ClosePullRequest(pr)
+		if err != nil {
+			return err
+		}
 		if sd.config.User.DeleteMergedBranches {
-			git.DeleteRemoteBranch(sd.config, sd.gitcmd, pr.FromBranch)
+			err = sd.deleteRemoteBranch(pr)
+			if err != nil {
+				return err
+			}
 		}
 	}
 	sd.profiletimer.Step("MergePullRequests::close prs")
 
 	for i := 0; i <= prIndex; i++ {
-		pr := githubInfo.PullRequests[i]
-		pr.Merged = true
-		fmt.Fprintf(sd.Output, "%s\n", pr.String(sd.config))
+		githubInfo.PullRequests[i].Merged = true
+	}
+	if sd.Formatter != nil {
+		err = sd.writeReport(report.FromPullRequests(sd.config, githubInfo.PullRequests[:prIndex+1]))
+		if err != nil {
+			return err
+		}
+	} else {
+		for i := 0; i <= prIndex; i++ {
+			fmt.Fprintf(sd.Output, "%s\n", githubInfo.PullRequests[i].String(sd.config))
+		}
 	}
 
 	sd.profiletimer.Step("MergePullRequests::End")
+	return nil
 }
 
 // MergePRSet merges the given PR set
 // In order to merge a PRSet without conflicts we find the newest PR and update the PR to merge into main/master.
 // The newest PR branch has all of the commits of the others so this will land all commits into main/master.
-// We then close the other PRs.
-func (sd *Stackediff) MergePRSet(ctx context.Context, setIndex string) {
+// We then close the other PRs, with a merge queue they are left open as the newest PR is only queued.
+func (sd *Stackediff) MergePRSet(ctx context.Context, setIndex string) error {
 	sd.profiletimer.Step("MergePRSet::Start")
-	gitapi := gitapi.New(sd.config, sd.repo, sd.goghclient)
+	sd.Cache.Refresh()
+	gitapi := gitapi.New(sd.config, sd.repo)
 
 	index, ok := selector.AsPRSet(setIndex)
 	if !ok {
-		check(fmt.Errorf("unable to parse PR set index %s", setIndex))
+		return fmt.Errorf("unable to parse PR set index %s", setIndex)
 	}
 	sd.profiletimer.Step("MergePRSet::AsPRSet")
 
 	// Merge the newest commit into main as it has all of the commits.
 	// Close the remaining commits
-	state, err := bl.NewReadState(ctx, sd.config, sd.goghclient, sd.repo)
-	check(err)
+	state, err := bl.NewReadState(ctx, sd.config, sd.github, sd.repo)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("MergePRSet::NewReadState")
 
 	// MergeCheck
@@ -393,56 +477,101 @@ func (sd *Stackediff) MergePRSet(ctx context.Context, setIndex string) {
 		commits := state.CommitsByPRSet(index)
 		if len(commits) > 0 {
 			sd.profiletimer.Step("MergePRSet::GetInfo")
-			githubInfo := sd.github.GetInfo(ctx, sd.gitcmd)
+			githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+			if err != nil {
+				return err
+			}
 			sd.profiletimer.Step("MergePRSet::GotInfo")
 			// Get the newest commit
 			lastCommit := state.CommitsByPRSet(index)[0]
 			checkedCommit, found := sd.config.State.MergeCheckCommit[githubInfo.Key()]
 
 			if !found {
-				check(errors.New("need to run merge check 'spr check' before merging"))
+				return errors.New("need to run merge check 'spr check' before merging")
 			} else if checkedCommit != "SKIP" && lastCommit.CommitHash != checkedCommit {
-				check(errors.New("need to run merge check 'spr check' before merging"))
+				return errors.New("need to run merge check 'spr check' before merging")
 			}
 			sd.profiletimer.Step("MergePRSet::MergeChecked")
 		}
 	}
 
 	commits := state.CommitsByPRSet(index)
+	if len(bl.PullRequests(commits)) == 0 {
+		return fmt.Errorf("%w: no pull requests in PR set %s", github.ErrPullRequestNotFound, setIndex)
+	}
+	mergeMethod, err := github.ParseMergeMethod(sd.config.Repo.MergeMethod)
+	if err != nil {
+		return err
+	}
 	// We want the oldest PR first so we preserve the PR links when updating it to merge to main/master
 	slices.Reverse(commits)
 	pullRequests := bl.PullRequests(commits)
-	_, err = concurrent.SliceMapWithIndex(commits, func(cindex int, ci *bl.PRCommit) (struct{}, error) {
-		if cindex == len(commits)-1 {
-			err := gitapi.UpdatePullRequestToMain(ctx, pullRequests, ci.PullRequest, ci.Commit)
-			if err != nil {
-				return struct{}{}, fmt.Errorf("update PR to merge to main in preparation to merge PR set %w", err)
-			}
-
-			err = gitapi.MergePullRequest(ctx, ci.PullRequest)
-			if err != nil {
-				return struct{}{}, fmt.Errorf("unable to merge oldest PR in PR set %w", err)
-			}
+	top := commits[len(commits)-1]
+	if top.PullRequest == nil {
+		return fmt.Errorf("%w: commit %s of PR set %s has no pull request, update the PR set before merging",
+			github.ErrPullRequestNotFound, top.CommitID, setIndex)
+	}
+	err = sd.github.UpdatePullRequest(ctx, sd.gitcmd, pullRequests, top.PullRequest, top.Commit, nil)
+	if err != nil {
+		return fmt.Errorf("update PR to merge to main in preparation to merge PR set %w", err)
+	}
+	err = sd.Journal.UpdatePullRequest(top.PullRequest)
+	if err != nil {
+		return err
+	}
+	err = sd.github.MergePullRequest(ctx, top.PullRequest, mergeMethod)
+	if err != nil {
+		return fmt.Errorf("unable to merge oldest PR in PR set %w", err)
+	}
+	err = sd.Journal.MergePullRequest(top.PullRequest)
+	if err != nil {
+		return err
+	}
 
-			err = sd.repo.Fetch(&ngit.FetchOptions{
-				RemoteName: sd.config.Repo.GitHubRemote,
-				Prune:      true,
-			})
-			if err != nil {
-				return struct{}{}, fmt.Errorf("unable to fetch merge changes %w", err)
-			}
+	// The pull requests are closed and their branches deleted once the commits are merged.
+	//  A pull request added to the merge queue is merged later, it and the ones below it stay
+	//  open so their commits aren't lost when it leaves the queue without merging.
+	closed := commits
+	if sd.config.Repo.MergeQueue {
+		closed = nil
+	} else {
+		err = sd.repo.Fetch(&ngit.FetchOptions{
+			RemoteName: sd.config.Repo.GitHubRemote,
+			Prune:      true,
+		})
+		if err != nil && !errors.Is(err, ngit.NoErrAlreadyUpToDate) {
+			return fmt.Errorf("unable to fetch merge changes %w", err)
 		}
-
-		// Delete/close all pull requests
-		err := gitapi.DeletePullRequest(ctx, ci.PullRequest)
+	}
+	_, err = concurrent.SliceMap(ctx, sd.config.Repo.Concurrency, closed, func(ctx context.Context, ci *bl.PRCommit) (struct{}, error) {
+		if ci.PullRequest == nil {
+			return struct{}{}, nil
+		}
+		err := sd.deletePRSetPullRequest(ctx, gitapi, ci.PullRequest)
 		if err != nil {
 			return struct{}{}, fmt.Errorf("unable to close non-oldest PR in PR set %w", err)
 		}
-		return struct{}{}, err
+		return struct{}{}, nil
 	})
-	check(err)
+	if err != nil {
+		return err
+	}
+
+	if sd.Formatter != nil {
+		for _, ci := range append(closed, top) {
+			if ci.PullRequest != nil {
+				ci.PullRequest.Merged = true
+			}
+		}
+		slices.Reverse(commits)
+		err = sd.writeReport(report.FromPRCommits(sd.config, commits))
+		if err != nil {
+			return err
+		}
+	}
 
 	sd.profiletimer.Step("MergePRSet::NewReadState")
+	return nil
 }
 
 // UpdatePRSets updatest the PR Sets given the selection.
@@ -450,30 +579,42 @@ func (sd *Stackediff) MergePRSet(ctx context.Context, setIndex string) {
 //   - If there are more than one PR in a PR set an index is included in the PR message showing the other PRs in the PR set
 //     with an arrow pointing to where you are.
 //   - If a new PR set overlaps with an existing one. The overlapped commits are pulled into the new PR set.
-func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
+func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) error {
 	sd.profiletimer.Step("UpdatePRSets::Start")
-	gitapi := gitapi.New(sd.config, sd.repo, sd.goghclient)
+	if git.StackOwner(sd.gitcmd) != "" {
+		return errCheckoutPRSets
+	}
+	gitapi := gitapi.New(sd.config, sd.repo)
 
 	// Add the commit-id to any commits that don't have it yet.
-	gitapi.AppendCommitId()
+	err := gitapi.AppendCommitId()
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("UpdatePRSets::AppndCommitId")
 
 	// Fetch/Prune from github remote
-	awaitFetch := concurrent.Async1Ret1(
-		sd.repo.Fetch,
-		&ngit.FetchOptions{
-			RemoteName: sd.config.Repo.GitHubRemote,
-			Prune:      true,
-		},
-	)
+	// finish the fetch before reading state, go-git doesn't lock references against concurrent reads
+	err = sd.repo.Fetch(&ngit.FetchOptions{
+		RemoteName: sd.config.Repo.GitHubRemote,
+		Prune:      true,
+	})
+	if err != nil && !errors.Is(err, ngit.NoErrAlreadyUpToDate) {
+		return err
+	}
+	sd.profiletimer.Step("UpdatePRSets::Fetch")
 
-	state, err := bl.NewReadState(ctx, sd.config, sd.goghclient, sd.repo)
-	check(err)
+	state, err := bl.NewReadState(ctx, sd.config, sd.github, sd.repo)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("UpdatePRSets::NewReadState")
 
 	// Compute the indices that will be included in the updated PR
 	indices, err := selector.Evaluate(state.Commits, sel)
-	check(err)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("UpdatePRSets::Evaluate")
 
 	// Update the commits PRIndex and tracked orphaned and mutated PR sets.
@@ -482,14 +623,20 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 	sd.profiletimer.Step("UpdatePRSets::ApplyIndices")
 
 	// Delete orphaned PRs (along with the associated branches)
-	_, err = concurrent.SliceMap(state.OrphanedPRs.ToSlice(), func(pr *github.PullRequest) (struct{}, error) {
+	//  A PR only looks orphaned when its commit wasn't matched, which can't be trusted if some PRs weren't listed
+	if state.Truncated && state.OrphanedPRs.Cardinality() > 0 {
+		return fmt.Errorf("%w: not closing %d orphaned pull requests", github.ErrListingTruncated, state.OrphanedPRs.Cardinality())
+	}
+	_, err = concurrent.SliceMap(ctx, sd.config.Repo.Concurrency, state.OrphanedPRs.ToSlice(), func(ctx context.Context, pr *github.PullRequest) (struct{}, error) {
 		if pr == nil {
 			return struct{}{}, nil
 		}
-		err := gitapi.DeletePullRequest(ctx, pr)
+		err := sd.deletePRSetPullRequest(ctx, gitapi, pr)
 		return struct{}{}, err
 	})
-	check(err)
+	if err != nil {
+		return err
+	}
 	state.OrphanedPRs.Clear()
 	sd.profiletimer.Step("UpdatePRSets::DeleteOrphanedPRs")
 
@@ -506,23 +653,24 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 		// We want the oldest first so we create PRs for it first
 		slices.Reverse(commits)
 		pullRequests := bl.PullRequests(commits)
-		_, err = concurrent.SliceMapWithIndex(commits, func(cindex int, ci *bl.PRCommit) (struct{}, error) {
+		_, err = concurrent.SliceMapWithIndex(ctx, sd.config.Repo.Concurrency, commits, func(ctx context.Context, cindex int, ci *bl.PRCommit) (struct{}, error) {
 			// Don't need to rework if no PR exists
 			if ci.PullRequest == nil {
-				return struct{}{}, err
+				return struct{}{}, nil
 			}
 
-			err := gitapi.UpdatePullRequestToMain(ctx, pullRequests, ci.PullRequest, ci.Commit)
-			return struct{}{}, err
+			err := sd.github.UpdatePullRequest(ctx, sd.gitcmd, pullRequests, ci.PullRequest, ci.Commit, nil)
+			if err != nil {
+				return struct{}{}, err
+			}
+			return struct{}{}, sd.Journal.UpdatePullRequest(ci.PullRequest)
 		})
+		if err != nil {
+			return err
+		}
 	}
 	sd.profiletimer.Step("UpdatePRSets::HandleRedorderdCommits")
 
-	// Wait for the fetch/prune to complete
-	err = awaitFetch.Await()
-	check(err)
-	sd.profiletimer.Step("UpdatePRSets::Fetch")
-
 	// Update all branches of the mutated PR sets
 	for prSet := range state.MutatedPRSets.Iter() {
 		commits := state.CommitsByPRSet(prSet)
@@ -534,8 +682,16 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 		for c := len(commits) - 1; c >= 0; c-- {
 			branchName := git.BranchNameFromCommitId(sd.config, commits[c].CommitID)
 
+			oldSHA := gitapi.RemoteBranchHead(ctx, branchName)
 			err := gitapi.CreateRemoteBranchWithCherryPick(ctx, branchName, destBranchName, commits[c].CommitHash)
-			check(err)
+			sd.Cache.Invalidate()
+			if err != nil {
+				return err
+			}
+			err = sd.Journal.PushBranch(branchName, oldSHA, gitapi.RemoteBranchHead(ctx, branchName))
+			if err != nil {
+				return err
+			}
 
 			destBranchName = branchName
 		}
@@ -543,6 +699,17 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 	sd.profiletimer.Step("UpdatePRSets::UpdateAllBranches")
 
 	// Update PR sets for all impacted mutated PR sets.
+	var requested *reviewers
+	getRequested := func() (*reviewers, error) {
+		if requested == nil {
+			login, err := sd.github.GetLogin(ctx)
+			if err != nil {
+				return nil, err
+			}
+			requested = newReviewers(sd, login)
+		}
+		return requested, nil
+	}
 	for prSet := range state.MutatedPRSets.Iter() {
 		commits := state.CommitsByPRSet(prSet)
 		// We want the oldest first so we create PRs for it first
@@ -554,6 +721,17 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 		// We don't want to do this in parallel as we want the PR numbers to be sequential starting with the oldest first.
 		for cindex, ci := range commits {
 			if ci.PullRequest != nil {
+				if len(ci.Commit.Reviewers) == 0 {
+					continue
+				}
+				requested, err := getRequested()
+				if err != nil {
+					return err
+				}
+				err = requested.add(ctx, ci.PullRequest, ci.PullRequest.MergeStatus.Reviews, ci.Commit.Reviewers)
+				if err != nil {
+					return err
+				}
 				continue
 			}
 			var parentBaseCommit *git.Commit
@@ -561,22 +739,50 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 				parentBaseCommit = &commits[cindex-1].Commit
 			}
 
-			pr, err := gitapi.CreatePullRequest(ctx, ci.Commit, parentBaseCommit)
-			check(err)
+			pr, err := sd.github.CreatePullRequest(ctx, sd.gitcmd, nil, ci.Commit, parentBaseCommit)
+			if err != nil {
+				return err
+			}
+			err = sd.Journal.CreatePullRequest(pr)
+			if err != nil {
+				return err
+			}
+			err = sd.labelNewPullRequest(ctx, pr, ci.Commit)
+			if err != nil {
+				return err
+			}
+			requested, err := getRequested()
+			if err != nil {
+				return err
+			}
+			names, err := requested.forNewPullRequest(ci.Commit, nil)
+			if err != nil {
+				return err
+			}
+			err = requested.add(ctx, pr, pr.MergeStatus.Reviews, names)
+			if err != nil {
+				return err
+			}
 			ci.PullRequest = pr
 		}
 
 		// All commits should now have PRs
 		pullRequests := bl.PullRequests(commits)
 
-		_, err = concurrent.SliceMapWithIndex(commits, func(cindex int, ci *bl.PRCommit) (struct{}, error) {
+		_, err = concurrent.SliceMapWithIndex(ctx, sd.config.Repo.Concurrency, commits, func(ctx context.Context, cindex int, ci *bl.PRCommit) (struct{}, error) {
 			var parentBaseCommit *git.Commit
 			if cindex != 0 {
 				parentBaseCommit = &commits[cindex-1].Commit
 			}
-			err := gitapi.UpdatePullRequest(ctx, pullRequests, ci.PullRequest, ci.Commit, parentBaseCommit)
-			return struct{}{}, err
+			err := sd.github.UpdatePullRequest(ctx, sd.gitcmd, pullRequests, ci.PullRequest, ci.Commit, parentBaseCommit)
+			if err != nil {
+				return struct{}{}, err
+			}
+			return struct{}{}, sd.Journal.UpdatePullRequest(ci.PullRequest)
 		})
+		if err != nil {
+			return err
+		}
 	}
 	sd.profiletimer.Step("UpdatePRSets::Update/CreatePRSets")
 
@@ -585,21 +791,28 @@ func (sd *Stackediff) UpdatePRSets(ctx context.Context, sel string) {
 	sd.profiletimer.Step("UpdatePRSets::UpdatePRSetState")
 
 	// Display status
-	sd.StatusCommitsAndPRSets(ctx)
+	return sd.StatusCommitsAndPRSets(ctx)
 }
 
 // StatusCommitsAndPRSets outputs the status of all commits and PR sets.
-// If a PR set is stored in state but no PR exists (like it was manually deleted from the github UI) then it will be
-// removed from state.
-func (sd *Stackediff) StatusCommitsAndPRSets(ctx context.Context) {
+// If a PR set is stored in state but no PR exists (like it was manually closed from the github UI) then the commit
+// stays in the PR set and the PR is created again when the PR set is updated.
+func (sd *Stackediff) StatusCommitsAndPRSets(ctx context.Context) error {
 	sd.profiletimer.Step("StatusCommitsAndPRSets::Start")
-	state, err := bl.NewReadState(ctx, sd.config, sd.goghclient, sd.repo)
-	check(err)
+	state, err := bl.NewReadState(ctx, sd.config, sd.github, sd.repo)
+	if err != nil {
+		return err
+	}
 	sd.profiletimer.Step("StatusCommitsAndPRSets::NewReadState")
 
+	if sd.Formatter != nil {
+		err = sd.writeReport(report.FromPRCommits(sd.config, state.Commits))
+		sd.profiletimer.Step("StatusCommitsAndPRSets::OutputStatus")
+		return err
+	}
 	if state.Head() == nil {
 		fmt.Fprintf(sd.Output, "no local commits\n")
-		return
+		return nil
 	}
 	if sd.DetailEnabled {
 		fmt.Fprint(sd.Output, header(sd.config))
@@ -607,19 +820,77 @@ func (sd *Stackediff) StatusCommitsAndPRSets(ctx context.Context) {
 	sd.profiletimer.Step("StatusCommitsAndPRSets::PrintDetails")
 	for this := state.Head(); this != nil; this = this.Parent {
 		fmt.Fprintf(sd.Output, "%s\n", this.String(sd.config))
+		if line, ok := sd.reviewDetail(this.PullRequest); ok {
+			fmt.Fprintf(sd.Output, "%s\n", line)
+		}
 	}
 	sd.profiletimer.Step("StatusCommitsAndPRSets::OutputStatus")
+	return nil
+}
+
+// Stack returns the local commits with their pull requests, the top of the stack first.
+//
+//	In the pull request sets workflow commits carry their PR set index, in the
+//	classic workflow commits without a pull request have a nil PullRequest.
+func (sd *Stackediff) Stack(ctx context.Context) ([]*bl.PRCommit, error) {
+	if sd.config.User.PRSetWorkflows {
+		state, err := bl.NewReadState(ctx, sd.config, sd.github, sd.repo)
+		if err != nil {
+			return nil, err
+		}
+		return state.Commits, nil
+	}
+
+	githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return nil, err
+	}
+	localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return nil, err
+	}
+	pullRequests := make(map[string]*github.PullRequest)
+	for _, pr := range githubInfo.PullRequests {
+		pullRequests[pr.Commit.CommitID] = pr
+	}
+
+	commits := make([]*bl.PRCommit, 0, len(localCommits))
+	for i := len(localCommits) - 1; i >= 0; i-- {
+		commits = append(commits, &bl.PRCommit{
+			Commit:      localCommits[i],
+			Index:       i,
+			PullRequest: pullRequests[localCommits[i].CommitID],
+		})
+	}
+	for i := 1; i < len(commits); i++ {
+		commits[i-1].Parent = commits[i]
+		commits[i].Child = commits[i-1]
+	}
+	return commits, nil
 }
 
 // StatusPullRequests fetches all the users pull requests from github and
 //
 //	prints out the status of each. It does not make any updates locally or
 //	remotely on github.
-func (sd *Stackediff) StatusPullRequests(ctx context.Context) {
+func (sd *Stackediff) StatusPullRequests(ctx context.Context) error {
 	sd.profiletimer.Step("StatusPullRequests::Start")
-	githubInfo := sd.github.GetInfo(ctx, sd.gitcmd)
+	githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 
-	if len(githubInfo.PullRequests) == 0 {
+	if sd.Formatter != nil {
+		// the report has every local commit, including those without a pull request
+		localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+		if err != nil {
+			return err
+		}
+		err = sd.writeReport(report.FromCommits(sd.config, localCommits, githubInfo.PullRequests))
+		if err != nil {
+			return err
+		}
+	} else if len(githubInfo.PullRequests) == 0 {
 		fmt.Fprintf(sd.Output, "pull request stack is empty\n")
 	} else {
 		if sd.DetailEnabled {
@@ -628,45 +899,81 @@ func (sd *Stackediff) StatusPullRequests(ctx context.Context) {
 		for i := len(githubInfo.PullRequests) - 1; i >= 0; i-- {
 			pr := githubInfo.PullRequests[i]
 			fmt.Fprintf(sd.Output, "%s\n", pr.String(sd.config))
+			if line, ok := sd.reviewDetail(pr); ok {
+				fmt.Fprintf(sd.Output, "%s\n", line)
+			}
 		}
 	}
 	sd.profiletimer.Step("StatusPullRequests::End")
+	return nil
+}
+
+// reviewDetail returns the line listing approvals, change requests and pending reviewers
+//
+//	printed under a pull request with detail enabled, ok is false when there is nothing to list.
+func (sd *Stackediff) reviewDetail(pr *github.PullRequest) (line string, ok bool) {
+	if !sd.DetailEnabled || pr == nil || pr.MergeStatus.Reviews.Empty() {
+		return "", false
+	}
+	return "        reviews: " + pr.MergeStatus.Reviews.String(), true
+}
+
+func (sd *Stackediff) writeReport(r *report.Report) error {
+	return sd.Formatter.Write(sd.Output, r)
+}
+
+// messageOutput returns the writer for informational messages,
+//
+//	which are kept out of machine readable output.
+func (sd *Stackediff) messageOutput() io.Writer {
+	if sd.Formatter != nil {
+		return os.Stderr
+	}
+	return sd.Output
 }
 
 // SyncStack synchronizes your local stack with remote's
-func (sd *Stackediff) SyncStack(ctx context.Context) {
+func (sd *Stackediff) SyncStack(ctx context.Context) error {
 	sd.profiletimer.Step("SyncStack::Start")
 	defer sd.profiletimer.Step("SyncStack::End")
 
-	githubInfo := sd.github.GetInfo(ctx, sd.gitcmd)
+	githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 
 	if len(githubInfo.PullRequests) == 0 {
 		fmt.Fprintf(sd.Output, "pull request stack is empty\n")
-		return
+		return nil
 	}
 
 	lastPR := githubInfo.PullRequests[len(githubInfo.PullRequests)-1]
 	syncCommand := fmt.Sprintf("cherry-pick ..%s", lastPR.Commit.CommitHash)
-	err := sd.gitcmd.Git(syncCommand, nil)
-	check(err)
+	return sd.gitcmd.Git(syncCommand, nil)
 }
 
-func (sd *Stackediff) RunMergeCheck(ctx context.Context) {
+func (sd *Stackediff) RunMergeCheck(ctx context.Context) error {
 	sd.profiletimer.Step("RunMergeCheck::Start")
 	defer sd.profiletimer.Step("RunMergeCheck::End")
 
 	if sd.config.Repo.MergeCheck == "" {
 		fmt.Println("use MergeCheck to configure a pre merge check command to run")
-		return
+		return nil
 	}
 
-	localCommits := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	localCommits, err := git.GetLocalCommitStack(sd.config, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 	if len(localCommits) == 0 {
 		fmt.Println("no local commits - nothing to check")
-		return
+		return nil
 	}
 
-	githubInfo := sd.github.GetInfo(ctx, sd.gitcmd)
+	githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return err
+	}
 
 	sigch := make(chan os.Signal, 1)
 	signal.Notify(sigch, os.Interrupt, syscall.SIGTERM)
@@ -683,14 +990,16 @@ func (sd *Stackediff) RunMergeCheck(ctx context.Context) {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Start()
-	check(err)
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("starting merge check: %w", err)
+	}
 
 	go func() {
 		_, ok := <-sigch
 		if ok {
-			err := cmd.Process.Signal(syscall.SIGKILL)
-			check(err)
+			// the check may have already exited, in which case there is nothing to kill
+			cmd.Process.Signal(syscall.SIGKILL)
 		}
 	}()
 
@@ -698,17 +1007,18 @@ func (sd *Stackediff) RunMergeCheck(ctx context.Context) {
 
 	if err != nil {
 		sd.config.State.MergeCheckCommit[githubInfo.Key()] = ""
-		rake.LoadSources(sd.config.State,
-			rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
 		fmt.Printf("MergeCheck FAILED: %s\n", err)
-		return
+		return config_parser.WriteInternalState(sd.config.State)
 	}
 
 	lastCommit := localCommits[len(localCommits)-1]
 	sd.config.State.MergeCheckCommit[githubInfo.Key()] = lastCommit.CommitHash
-	rake.LoadSources(sd.config.State,
-		rake.YamlFileWriter(config_parser.InternalConfigFilePath()))
+	err = config_parser.WriteInternalState(sd.config.State)
+	if err != nil {
+		return err
+	}
 	fmt.Println("MergeCheck PASSED")
+	return nil
 }
 
 // ProfilingEnable enables stopwatch profiling
@@ -717,9 +1027,30 @@ func (sd *Stackediff) ProfilingEnable() {
 }
 
 // ProfilingSummary prints profiling info to stdout
-func (sd *Stackediff) ProfilingSummary() {
-	err := sd.profiletimer.ShowResults()
-	check(err)
+func (sd *Stackediff) ProfilingSummary() error {
+	return sd.profiletimer.ShowResults()
+}
+
+// forEach calls fn for every index, in parallel unless synchronized is set,
+//
+//	and returns the errors of all the calls joined together.
+func (sd *Stackediff) forEach(count int, fn func(i int) error) error {
+	errs := make([]error, count)
+	wg := new(sync.WaitGroup)
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		call := func(i int) {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}
+		if sd.synchronized {
+			call(i)
+		} else {
+			go call(i)
+		}
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 func commitsReordered(localCommits []git.Commit, pullRequests []*github.PullRequest) bool {
@@ -746,50 +1077,64 @@ func sortPullRequestsByLocalCommitOrder(pullRequests []*github.PullRequest, loca
 	return sortedPullRequests
 }
 
-func (sd *Stackediff) fetchAndGetGitHubInfo(ctx context.Context) *github.GitHubInfo {
+func (sd *Stackediff) fetchAndGetGitHubInfo(ctx context.Context) (*github.ForgeInfo, error) {
+	var err error
 	if sd.config.Repo.ForceFetchTags {
-		sd.gitcmd.MustGit("fetch --tags --force", nil)
+		err = sd.gitcmd.Git("fetch --tags --force", nil)
 	} else {
-		sd.gitcmd.MustGit("fetch", nil)
+		err = sd.gitcmd.Git("fetch", nil)
+	}
+	if err != nil {
+		return nil, err
 	}
 	rebaseCommand := fmt.Sprintf("rebase %s/%s --autostash",
 		sd.config.Repo.GitHubRemote, sd.config.Repo.GitHubBranch)
-	err := sd.gitcmd.Git(rebaseCommand, nil)
+	err = sd.gitcmd.Git(rebaseCommand, nil)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	info := sd.github.GetInfo(ctx, sd.gitcmd)
-	if git.BranchNameRegex.FindString(info.LocalBranch) != "" {
-		fmt.Printf("error: don't run spr in a remote pr branch\n")
-		fmt.Printf(" this could lead to weird duplicate pull requests getting created\n")
-		fmt.Printf(" in general there is no need to checkout remote branches used for prs\n")
-		fmt.Printf(" instead use local branches and run spr update to sync your commit stack\n")
-		fmt.Printf("  with your pull requests on github\n")
-		fmt.Printf("branch name: %s\n", info.LocalBranch)
-		return nil
+	info, err := sd.github.GetInfo(ctx, sd.gitcmd)
+	if err != nil {
+		return nil, err
+	}
+	if git.CommitIDFromBranch(sd.config, info.LocalBranch) != "" {
+		errmsg := "don't run spr in a remote pr branch\n"
+		errmsg += " this could lead to weird duplicate pull requests getting created\n"
+		errmsg += " in general there is no need to checkout remote branches used for prs\n"
+		errmsg += " instead use local branches and run spr update to sync your commit stack\n"
+		errmsg += "  with your pull requests on github\n"
+		errmsg += fmt.Sprintf("branch name: %s", info.LocalBranch)
+		return nil, errors.New(errmsg)
 	}
 
-	return info
+	return info, nil
 }
 
 // syncCommitStackToGitHub gets all the local commits in the given branch
 //
 //	which are new (on top of remote branch) and creates a corresponding
 //	branch on github for each commit.
+//
+//	Local changes are stashed during the push, and restored even when the push fails.
 func (sd *Stackediff) syncCommitStackToGitHub(ctx context.Context,
-	commits []git.Commit, info *github.GitHubInfo) bool {
+	commits []git.Commit, info *github.ForgeInfo) (err error) {
 
 	var output string
-	sd.gitcmd.MustGit("status --porcelain --untracked-files=no", &output)
+	err = sd.gitcmd.Git("status --porcelain --untracked-files=no", &output)
+	if err != nil {
+		return err
+	}
 	if output != "" {
-		err := sd.gitcmd.Git("stash", nil)
+		err = sd.gitcmd.Git("stash", nil)
 		if err != nil {
-			return false
+			return err
 		}
-		defer sd.gitcmd.MustGit("stash pop", nil)
+		defer func() {
+			err = errors.Join(err, sd.gitcmd.Git("stash pop", nil))
+		}()
 	}
 
-	commitUpdated := func(c git.Commit, info *github.GitHubInfo) bool {
+	commitUpdated := func(c git.Commit, info *github.ForgeInfo) bool {
 		for _, pr := range info.PullRequests {
 			if pr.Commit.CommitID == c.CommitID {
 				return pr.Commit.CommitHash != c.CommitHash
@@ -815,30 +1160,49 @@ func (sd *Stackediff) syncCommitStackToGitHub(ctx context.Context,
 			commit.CommitHash+":refs/heads/"+branchName)
 	}
 
+	// journalPush records the push of a commit along with the previous head of its branch
+	journalPush := func(commit git.Commit) error {
+		var oldSHA string
+		for _, pr := range info.PullRequests {
+			if pr.Commit.CommitID == commit.CommitID {
+				oldSHA = pr.Commit.CommitHash
+			}
+		}
+		return sd.Journal.PushBranch(git.BranchNameFromCommit(sd.config, commit), oldSHA, commit.CommitHash)
+	}
+
 	if len(updatedCommits) > 0 {
 		if sd.config.Repo.BranchPushIndividually {
-			for _, refName := range refNames {
+			for i, refName := range refNames {
 				pushCommand := fmt.Sprintf("push --force %s %s", sd.config.Repo.GitHubRemote, refName)
-				sd.gitcmd.MustGit(pushCommand, nil)
+				err = sd.gitcmd.Git(pushCommand, nil)
+				sd.Cache.Invalidate()
+				if err != nil {
+					return err
+				}
+				err = journalPush(updatedCommits[i])
+				if err != nil {
+					return err
+				}
 			}
 		} else {
 			pushCommand := fmt.Sprintf("push --force --atomic %s ", sd.config.Repo.GitHubRemote)
 			pushCommand += strings.Join(refNames, " ")
-			sd.gitcmd.MustGit(pushCommand, nil)
+			err = sd.gitcmd.Git(pushCommand, nil)
+			sd.Cache.Invalidate()
+			if err != nil {
+				return err
+			}
+			for _, commit := range updatedCommits {
+				err = journalPush(commit)
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 	sd.profiletimer.Step("SyncCommitStack::PushBranches")
-	return true
-}
-
-func check(err error) {
-	if err != nil {
-		if os.Getenv("SPR_DEBUG") == "1" {
-			panic(err)
-		}
-		fmt.Printf("error: %s\n", err)
-		os.Exit(1)
-	}
+	return nil
 }
 
 func header(config *config.Config) string {
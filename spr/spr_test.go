@@ -11,7 +11,6 @@ import (
 	"github.com/ejoffe/spr/git"
 	"github.com/ejoffe/spr/git/mockgit"
 	"github.com/ejoffe/spr/github"
-	"github.com/ejoffe/spr/github/githubclient/gen/genclient"
 	"github.com/ejoffe/spr/github/mockclient"
 	"github.com/stretchr/testify/require"
 )
@@ -27,12 +26,12 @@ func makeTestObjects(t *testing.T, synchronized bool) (
 	cfg.Repo.MergeMethod = "rebase"
 	gitmock = mockgit.NewMockGit(t)
 	githubmock = mockclient.NewMockClient(t)
-	githubmock.Info = &github.GitHubInfo{
+	githubmock.Info = &github.ForgeInfo{
 		UserName:     "TestSPR",
 		RepositoryID: "RepoID",
 		LocalBranch:  "master",
 	}
-	s = NewStackedPR(cfg, githubmock, gitmock, nil, nil)
+	s = NewStackedPR(cfg, githubmock, gitmock, nil)
 	output = &bytes.Buffer{}
 	s.Output = output
 	input = &bytes.Buffer{}
@@ -76,7 +75,7 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 
 		// 'git spr status' :: StatusPullRequest
 		githubmock.ExpectGetInfo()
-		s.StatusPullRequests(ctx)
+		assert.NoError(s.StatusPullRequests(ctx))
 		assert.Equal("pull request stack is empty\n", output.String())
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
@@ -92,7 +91,7 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal("[vvvv]   1 : test commit 1\n", output.String())
 		gitmock.ExpectationsMet()
@@ -104,18 +103,18 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		gitmock.ExpectFetch()
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c2, &c1})
 		gitmock.ExpectPushCommits([]*git.Commit{&c2})
-		githubmock.ExpectCreatePullRequest(c2, &c1)
 		githubmock.ExpectGetAssignableUsers()
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectCreatePullRequest(c2, &c1)
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines := strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
-		assert.Equal("warning: not updating reviewers for PR #1", lines[0])
-		assert.Equal("[vvvv]   1 : test commit 2", lines[1])
-		assert.Equal("[vvvv]   1 : test commit 1", lines[2])
+		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
+		assert.Equal("[vvvv]   1 : test commit 1", lines[1])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -126,12 +125,12 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c4, &c3, &c2, &c1})
 		gitmock.ExpectPushCommits([]*git.Commit{&c3, &c4})
 
-		// For the first "create" call we should call GetAssignableUsers
-		githubmock.ExpectCreatePullRequest(c3, &c2)
+		// Existing pull requests get the reviewers too, GetAssignableUsers is only called once
 		githubmock.ExpectGetAssignableUsers()
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
-
-		// For the first "create" call we should *not* call GetAssignableUsers
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectCreatePullRequest(c3, &c2)
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectCreatePullRequest(c4, &c3)
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 
@@ -140,17 +139,15 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c3, &c2)
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines = strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal([]string{
-			"warning: not updating reviewers for PR #1",
-			"warning: not updating reviewers for PR #1",
 			"[vvvv]   1 : test commit 4",
 			"[vvvv]   1 : test commit 3",
 			"[vvvv]   1 : test commit 2",
 			"[vvvv]   1 : test commit 1",
-		}, lines[:6])
+		}, lines[:4])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -158,11 +155,11 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		// 'git spr merge' :: MergePullRequest :: commits=[a1, a2]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c2, nil)
-		githubmock.ExpectMergePullRequest(c2, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c2, github.MergeMethodRebase)
 		githubmock.ExpectCommentPullRequest(c1)
 		githubmock.ExpectClosePullRequest(c1)
 		count := uint(2)
-		s.MergePullRequests(ctx, &count)
+		assert.NoError(s.MergePullRequests(ctx, &count))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED   1 : test commit 1", lines[0])
 		assert.Equal("MERGED   1 : test commit 2", lines[1])
@@ -175,6 +172,10 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		githubmock.Info.PullRequests[0].Merged = false
 		githubmock.Info.PullRequests[0].Commits = append(githubmock.Info.PullRequests[0].Commits, c1, c2)
 		githubmock.ExpectGetInfo()
+		githubmock.ExpectGetAssignableUsers()
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c2, nil)
 		githubmock.ExpectUpdatePullRequest(c3, &c2)
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
@@ -184,17 +185,14 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c4, &c3, &c2, &c1})
 		gitmock.ExpectStatus()
 
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines = strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal([]string{
-			"warning: not updating reviewers for PR #1",
-			"warning: not updating reviewers for PR #1",
-			"warning: not updating reviewers for PR #1",
 			"[vvvv]   1 : test commit 4",
 			"[vvvv]   1 : test commit 3",
 			"[vvvv] !   1 : test commit 2",
-		}, lines[:6])
+		}, lines[:3])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -202,7 +200,7 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 		// 'git spr merge' :: MergePullRequest :: commits=[a2, a3, a4]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c4, nil)
-		githubmock.ExpectMergePullRequest(c4, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c4, github.MergeMethodRebase)
 
 		githubmock.ExpectCommentPullRequest(c2)
 		githubmock.ExpectClosePullRequest(c2)
@@ -211,7 +209,7 @@ func testSPRBasicFlowFourCommitsQueue(t *testing.T, sync bool) {
 
 		githubmock.Info.PullRequests[0].InQueue = true
 
-		s.MergePullRequests(ctx, nil)
+		assert.NoError(s.MergePullRequests(ctx, nil))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED .   1 : test commit 2", lines[0])
 		assert.Equal("MERGED   1 : test commit 3", lines[1])
@@ -257,7 +255,7 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 
 		// 'git spr status' :: StatusPullRequest
 		githubmock.ExpectGetInfo()
-		s.StatusPullRequests(ctx)
+		assert.NoError(s.StatusPullRequests(ctx))
 		assert.Equal("pull request stack is empty\n", output.String())
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
@@ -273,7 +271,7 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal("[vvvv]   1 : test commit 1\n", output.String())
 		gitmock.ExpectationsMet()
@@ -285,18 +283,18 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 		gitmock.ExpectFetch()
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c2, &c1})
 		gitmock.ExpectPushCommits([]*git.Commit{&c2})
-		githubmock.ExpectCreatePullRequest(c2, &c1)
 		githubmock.ExpectGetAssignableUsers()
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectCreatePullRequest(c2, &c1)
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines := strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
-		assert.Equal("warning: not updating reviewers for PR #1", lines[0])
-		assert.Equal("[vvvv]   1 : test commit 2", lines[1])
-		assert.Equal("[vvvv]   1 : test commit 1", lines[2])
+		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
+		assert.Equal("[vvvv]   1 : test commit 1", lines[1])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -307,12 +305,12 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c4, &c3, &c2, &c1})
 		gitmock.ExpectPushCommits([]*git.Commit{&c3, &c4})
 
-		// For the first "create" call we should call GetAssignableUsers
-		githubmock.ExpectCreatePullRequest(c3, &c2)
+		// Existing pull requests get the reviewers too, GetAssignableUsers is only called once
 		githubmock.ExpectGetAssignableUsers()
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
-
-		// For the first "create" call we should *not* call GetAssignableUsers
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectCreatePullRequest(c3, &c2)
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectCreatePullRequest(c4, &c3)
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 
@@ -321,17 +319,15 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c3, &c2)
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines = strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal([]string{
-			"warning: not updating reviewers for PR #1",
-			"warning: not updating reviewers for PR #1",
 			"[vvvv]   1 : test commit 4",
 			"[vvvv]   1 : test commit 3",
 			"[vvvv]   1 : test commit 2",
 			"[vvvv]   1 : test commit 1",
-		}, lines[:6])
+		}, lines[:4])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -339,14 +335,14 @@ func testSPRBasicFlowFourCommits(t *testing.T, sync bool) {
 		// 'git spr merge' :: MergePullRequest :: commits=[a1, a2, a3, a4]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c4, nil)
-		githubmock.ExpectMergePullRequest(c4, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c4, github.MergeMethodRebase)
 		githubmock.ExpectCommentPullRequest(c1)
 		githubmock.ExpectClosePullRequest(c1)
 		githubmock.ExpectCommentPullRequest(c2)
 		githubmock.ExpectClosePullRequest(c2)
 		githubmock.ExpectCommentPullRequest(c3)
 		githubmock.ExpectClosePullRequest(c3)
-		s.MergePullRequests(ctx, nil)
+		assert.NoError(s.MergePullRequests(ctx, nil))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED   1 : test commit 1", lines[0])
 		assert.Equal("MERGED   1 : test commit 2", lines[1])
@@ -392,7 +388,7 @@ func testSPRBasicFlowDeleteBranch(t *testing.T, sync bool) {
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal("[vvvv]   1 : test commit 1\n", output.String())
 		gitmock.ExpectationsMet()
@@ -404,18 +400,18 @@ func testSPRBasicFlowDeleteBranch(t *testing.T, sync bool) {
 		gitmock.ExpectFetch()
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c2, &c1})
 		gitmock.ExpectPushCommits([]*git.Commit{&c2})
-		githubmock.ExpectCreatePullRequest(c2, &c1)
 		githubmock.ExpectGetAssignableUsers()
 		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
+		githubmock.ExpectCreatePullRequest(c2, &c1)
+		githubmock.ExpectAddReviewers([]string{mockclient.NobodyUserID})
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines := strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
-		assert.Equal("warning: not updating reviewers for PR #1", lines[0])
-		assert.Equal("[vvvv]   1 : test commit 2", lines[1])
-		assert.Equal("[vvvv]   1 : test commit 1", lines[2])
+		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
+		assert.Equal("[vvvv]   1 : test commit 1", lines[1])
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
 		output.Reset()
@@ -423,12 +419,12 @@ func testSPRBasicFlowDeleteBranch(t *testing.T, sync bool) {
 		// 'git spr merge' :: MergePullRequest :: commits=[a1, a2]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c2, nil)
-		githubmock.ExpectMergePullRequest(c2, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c2, github.MergeMethodRebase)
 		gitmock.ExpectDeleteBranch("from_branch") // <--- This is the key expectation of this test.
 		githubmock.ExpectCommentPullRequest(c1)
 		githubmock.ExpectClosePullRequest(c1)
 		gitmock.ExpectDeleteBranch("from_branch") // <--- This is the key expectation of this test.
-		s.MergePullRequests(ctx, nil)
+		assert.NoError(s.MergePullRequests(ctx, nil))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED   1 : test commit 1", lines[0])
 		fmt.Printf("OUT: %s\n", output.String())
@@ -490,7 +486,7 @@ func testSPRMergeCount(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c3, &c2)
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, []string{mockclient.NobodyLogin}, nil))
 		lines := strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal([]string{
@@ -506,10 +502,10 @@ func testSPRMergeCount(t *testing.T, sync bool) {
 		// 'git spr merge --count 2' :: MergePullRequest :: commits=[a1, a2, a3, a4]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c2, nil)
-		githubmock.ExpectMergePullRequest(c2, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c2, github.MergeMethodRebase)
 		githubmock.ExpectCommentPullRequest(c1)
 		githubmock.ExpectClosePullRequest(c1)
-		s.MergePullRequests(ctx, uintptr(2))
+		assert.NoError(s.MergePullRequests(ctx, uintptr(2)))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED   1 : test commit 1", lines[0])
 		assert.Equal("MERGED   1 : test commit 2", lines[1])
@@ -544,7 +540,7 @@ func testSPRAmendCommit(t *testing.T, sync bool) {
 
 		// 'git spr state' :: StatusPullRequest
 		githubmock.ExpectGetInfo()
-		s.StatusPullRequests(ctx)
+		assert.NoError(s.StatusPullRequests(ctx))
 		assert.Equal("pull request stack is empty\n", output.String())
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
@@ -560,7 +556,7 @@ func testSPRAmendCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		lines := strings.Split(output.String(), "\n")
 		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
@@ -579,7 +575,7 @@ func testSPRAmendCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		lines = strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
@@ -599,7 +595,7 @@ func testSPRAmendCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c1, nil)
 		githubmock.ExpectUpdatePullRequest(c2, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		lines = strings.Split(output.String(), "\n")
 		fmt.Printf("OUT: %s\n", output.String())
 		assert.Equal("[vvvv]   1 : test commit 2", lines[0])
@@ -611,10 +607,10 @@ func testSPRAmendCommit(t *testing.T, sync bool) {
 		// 'git spr merge' :: MergePullRequest :: commits=[a1, a2]
 		githubmock.ExpectGetInfo()
 		githubmock.ExpectUpdatePullRequest(c2, nil)
-		githubmock.ExpectMergePullRequest(c2, genclient.PullRequestMergeMethod_REBASE)
+		githubmock.ExpectMergePullRequest(c2, github.MergeMethodRebase)
 		githubmock.ExpectCommentPullRequest(c1)
 		githubmock.ExpectClosePullRequest(c1)
-		s.MergePullRequests(ctx, nil)
+		assert.NoError(s.MergePullRequests(ctx, nil))
 		lines = strings.Split(output.String(), "\n")
 		assert.Equal("MERGED   1 : test commit 1", lines[0])
 		assert.Equal("MERGED   1 : test commit 2", lines[1])
@@ -664,7 +660,7 @@ func testSPRReorderCommit(t *testing.T, sync bool) {
 
 		// 'git spr status' :: StatusPullRequest
 		githubmock.ExpectGetInfo()
-		s.StatusPullRequests(ctx)
+		assert.NoError(s.StatusPullRequests(ctx))
 		assert.Equal("pull request stack is empty\n", output.String())
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
@@ -684,7 +680,7 @@ func testSPRReorderCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c3, &c2)
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		lines := strings.Split(output.String(), "\n")
 		assert.Equal("[vvvv]   1 : test commit 4", lines[0])
@@ -714,7 +710,7 @@ func testSPRReorderCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c1, &c4)
 		githubmock.ExpectUpdatePullRequest(c3, &c1)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		// TODO : Need to update pull requests in GetInfo expect to get this check to work
 		// lines = strings.Split(output.String(), "\n")
@@ -747,7 +743,7 @@ func testSPRReorderCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c2, &c3)
 		githubmock.ExpectUpdatePullRequest(c1, &c2)
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		// TODO : Need to update pull requests in GetInfo expect to get this check to work
 		// lines = strings.Split(output.String(), "\n")
@@ -798,7 +794,7 @@ func testSPRDeleteCommit(t *testing.T, sync bool) {
 
 		// 'git spr status' :: StatusPullRequest
 		githubmock.ExpectGetInfo()
-		s.StatusPullRequests(ctx)
+		assert.NoError(s.StatusPullRequests(ctx))
 		assert.Equal("pull request stack is empty\n", output.String())
 		gitmock.ExpectationsMet()
 		githubmock.ExpectationsMet()
@@ -819,7 +815,7 @@ func testSPRDeleteCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c4, &c3)
 		githubmock.ExpectGetInfo()
 
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		lines := strings.Split(output.String(), "\n")
 		assert.Equal("[vvvv]   1 : test commit 4", lines[0])
@@ -845,7 +841,7 @@ func testSPRDeleteCommit(t *testing.T, sync bool) {
 		githubmock.ExpectUpdatePullRequest(c4, &c1)
 		gitmock.ExpectPushCommits([]*git.Commit{&c1, &c4})
 		githubmock.ExpectGetInfo()
-		s.UpdatePullRequests(ctx, nil, nil)
+		assert.NoError(s.UpdatePullRequests(ctx, nil, nil))
 		fmt.Printf("OUT: %s\n", output.String())
 		// TODO : Need to update pull requests in GetInfo expect to get this check to work
 		// lines = strings.Split(output.String(), "\n")
@@ -873,7 +869,7 @@ func testAmendNoCommits(t *testing.T, sync bool) {
 		ctx := context.Background()
 
 		gitmock.ExpectLogAndRespond([]*git.Commit{})
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal("No commits to amend\n", output.String())
 	})
 }
@@ -897,7 +893,7 @@ func testAmendOneCommit(t *testing.T, sync bool) {
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c1})
 		gitmock.ExpectFixup(c1.CommitHash)
 		input.WriteString("1")
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal(" 1 : 00000001 : test commit 1\nCommit to amend (1): ", output.String())
 	})
 }
@@ -926,7 +922,7 @@ func testAmendTwoCommits(t *testing.T, sync bool) {
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c1, &c2})
 		gitmock.ExpectFixup(c2.CommitHash)
 		input.WriteString("1")
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal(" 2 : 00000001 : test commit 1\n 1 : 00000002 : test commit 2\nCommit to amend (1-2): ", output.String())
 	})
 }
@@ -950,21 +946,21 @@ func testAmendInvalidInput(t *testing.T, sync bool) {
 
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c1})
 		input.WriteString("a")
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal(" 1 : 00000001 : test commit 1\nCommit to amend (1): Invalid input\n", output.String())
 		gitmock.ExpectationsMet()
 		output.Reset()
 
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c1})
 		input.WriteString("0")
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal(" 1 : 00000001 : test commit 1\nCommit to amend (1): Invalid input\n", output.String())
 		gitmock.ExpectationsMet()
 		output.Reset()
 
 		gitmock.ExpectLogAndRespond([]*git.Commit{&c1})
 		input.WriteString("2")
-		s.AmendCommit(ctx)
+		assert.NoError(s.AmendCommit(ctx))
 		assert.Equal(" 1 : 00000001 : test commit 1\nCommit to amend (1): Invalid input\n", output.String())
 		gitmock.ExpectationsMet()
 		output.Reset()
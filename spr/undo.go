@@ -0,0 +1,142 @@
+package spr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ejoffe/spr/bl/gitapi"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/journal"
+)
+
+// Undo reverts the remote side effects of the last spr command recorded in the journal.
+//
+//	Branches that existed before the command are restored first, so pull
+//	 requests can be reset to their previous base branch. Then the pull request
+//	 changes are replayed backwards: created pull requests are closed, and
+//	 updated or closed pull requests are reopened with their previous base,
+//	 title and body. Branches created by the command are deleted last.
+//	Merges can't be undone, merged pull requests are reported and skipped.
+//	Every call undoes one more command from the journal.
+func (sd *Stackediff) Undo(ctx context.Context) error {
+	entries, start, err := sd.Journal.LastRun()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(sd.Output, "nothing to undo\n")
+		return nil
+	}
+	fmt.Fprintf(sd.Output, "undoing 'spr %s' from %s\n",
+		entries[0].Command, entries[0].Time.Local().Format(time.DateTime))
+
+	merged := map[int]bool{}
+	for _, e := range entries {
+		if e.Op == journal.OpMergePullRequest {
+			merged[e.PullRequest.Number] = true
+		}
+	}
+
+	isRestoredBranch := func(e journal.Entry) bool {
+		return e.Op == journal.OpDeleteBranch || (e.Op == journal.OpPushBranch && e.OldSHA != "")
+	}
+	isPullRequest := func(e journal.Entry) bool {
+		return e.PullRequest != nil
+	}
+	isCreatedBranch := func(e journal.Entry) bool {
+		return e.Op == journal.OpPushBranch && e.OldSHA == ""
+	}
+
+	done := make([]bool, len(entries))
+	for _, pass := range []func(journal.Entry) bool{isRestoredBranch, isPullRequest, isCreatedBranch} {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !pass(entries[i]) {
+				continue
+			}
+			err := sd.undoEntry(ctx, entries[i], merged)
+			if err != nil {
+				// keep what wasn't undone so undo can be run again
+				var remaining []journal.Entry
+				for j, e := range entries {
+					if !done[j] {
+						remaining = append(remaining, e)
+					}
+				}
+				return errors.Join(err, sd.Journal.Truncate(start, remaining...))
+			}
+			done[i] = true
+		}
+	}
+
+	return sd.Journal.Truncate(start)
+}
+
+func (sd *Stackediff) undoEntry(ctx context.Context, e journal.Entry, merged map[int]bool) error {
+	switch e.Op {
+	case journal.OpPushBranch, journal.OpDeleteBranch:
+		if e.OldSHA != "" {
+			fmt.Fprintf(sd.Output, "restore branch %s to %s\n", e.Branch, e.OldSHA)
+			defer sd.Cache.Invalidate()
+			return git.RestoreRemoteBranch(sd.config, sd.gitcmd, e.Branch, e.OldSHA)
+		}
+		if e.Op == journal.OpDeleteBranch {
+			fmt.Fprintf(sd.Output, "branch %s can't be restored, its previous head is unknown\n", e.Branch)
+			return nil
+		}
+		fmt.Fprintf(sd.Output, "delete branch %s\n", e.Branch)
+		defer sd.Cache.Invalidate()
+		return git.DeleteRemoteBranch(sd.config, sd.gitcmd, e.Branch)
+
+	case journal.OpCreatePullRequest:
+		if merged[e.PullRequest.Number] {
+			return nil
+		}
+		fmt.Fprintf(sd.Output, "close pull request #%d\n", e.PullRequest.Number)
+		return sd.github.ClosePullRequest(ctx, e.PullRequest.GitHub())
+
+	case journal.OpUpdatePullRequest, journal.OpClosePullRequest:
+		if merged[e.PullRequest.Number] {
+			return nil
+		}
+		fmt.Fprintf(sd.Output, "restore pull request #%d onto %s\n", e.PullRequest.Number, e.PullRequest.ToBranch)
+		return sd.github.RestorePullRequest(ctx, e.PullRequest.GitHub())
+
+	case journal.OpMergePullRequest:
+		fmt.Fprintf(sd.Output, "pull request #%d was merged and can't be undone\n", e.PullRequest.Number)
+		return nil
+	}
+	return fmt.Errorf("unknown journal operation %q", e.Op)
+}
+
+// deleteRemoteBranch deletes the branch of the pull request and records it in the journal
+func (sd *Stackediff) deleteRemoteBranch(pr *github.PullRequest) error {
+	err := git.DeleteRemoteBranch(sd.config, sd.gitcmd, pr.FromBranch)
+	sd.Cache.Invalidate()
+	if err != nil {
+		return err
+	}
+	return sd.Journal.DeleteBranch(pr.FromBranch, pr.Commit.CommitHash)
+}
+
+// deletePRSetPullRequest closes the pull request and deletes its branch, recording both in the journal
+func (sd *Stackediff) deletePRSetPullRequest(ctx context.Context, gapi gitapi.GitApi, pr *github.PullRequest) error {
+	err := sd.github.ClosePullRequest(ctx, pr)
+	if err != nil {
+		return err
+	}
+	err = sd.Journal.ClosePullRequest(pr)
+	if err != nil {
+		return err
+	}
+
+	oldSHA := gapi.RemoteBranchHead(ctx, pr.FromBranch)
+	err = gapi.DeleteRemoteBranch(ctx, pr.FromBranch)
+	sd.Cache.Invalidate()
+	if err != nil {
+		return err
+	}
+	return sd.Journal.DeleteBranch(pr.FromBranch, oldSHA)
+}
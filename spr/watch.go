@@ -0,0 +1,135 @@
+package spr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/terminal"
+)
+
+// WatchPullRequests polls the pull request stack every interval and keeps its status on screen.
+//
+//	When the bottom count pull requests, or the whole stack when count is nil,
+//	are ready to merge it rings the terminal bell and returns, or merges them
+//	when merge is set. Watching stops without an error when ctx is cancelled.
+func (sd *Stackediff) WatchPullRequests(ctx context.Context, interval time.Duration, merge bool, count *uint) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+	// every poll sees the current state of the pull requests
+	sd.Cache.Refresh()
+	live := newLiveLines(sd.Output)
+	for {
+		githubInfo, err := sd.github.GetInfo(ctx, sd.gitcmd)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pullRequests := githubInfo.PullRequests
+		if len(pullRequests) == 0 {
+			fmt.Fprintf(sd.Output, "pull request stack is empty\n")
+			return nil
+		}
+		n := len(pullRequests)
+		if count != nil && int(*count) < n {
+			n = int(*count)
+		}
+		watched := pullRequests[n-1]
+
+		var lines []string
+		if sd.DetailEnabled {
+			lines = append(lines, strings.Split(strings.TrimSuffix(header(sd.config), "\n"), "\n")...)
+		}
+		for i := len(pullRequests) - 1; i >= 0; i-- {
+			lines = append(lines, pullRequests[i].String(sd.config))
+			if line, ok := sd.reviewDetail(pullRequests[i]); ok {
+				lines = append(lines, line)
+			}
+		}
+
+		switch {
+		case watched.MergeStatus.Stacked && merge:
+			live.draw(append(lines, fmt.Sprintf("merging %d pull requests", n)))
+			c := uint(n)
+			return sd.MergePullRequests(ctx, &c)
+		case watched.MergeStatus.Stacked:
+			live.draw(append(lines, fmt.Sprintf("%d pull requests are ready to merge\a", n)))
+			return nil
+		}
+		live.draw(append(lines, fmt.Sprintf("waiting for %d of %d pull requests to be ready to merge",
+			n-readyCount(sd, pullRequests[:n]), n)))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// readyCount returns the number of pull requests from the bottom of the stack which are ready to merge
+func readyCount(sd *Stackediff, pullRequests []*github.PullRequest) int {
+	for i, pr := range pullRequests {
+		if !pr.Ready(sd.config) {
+			return i
+		}
+	}
+	return len(pullRequests)
+}
+
+// liveLines keeps a block of lines up to date on the output.
+//
+//	On a terminal only the lines which changed are rewritten in place,
+//	otherwise the whole block is printed again whenever it changes.
+type liveLines struct {
+	w     io.Writer
+	tty   bool
+	lines []string
+}
+
+func newLiveLines(w io.Writer) *liveLines {
+	f, ok := w.(*os.File)
+	return &liveLines{w: w, tty: ok && terminal.IsTerminal(f)}
+}
+
+func (l *liveLines) draw(lines []string) {
+	if slices.Equal(lines, l.lines) {
+		return
+	}
+	if !l.tty {
+		if l.lines != nil {
+			fmt.Fprintln(l.w)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(l.w, line)
+		}
+		l.lines = lines
+		return
+	}
+
+	// move up to the first line of the block, the cursor is on the line after it
+	if len(l.lines) > 0 {
+		fmt.Fprintf(l.w, "\x1b[%dA", len(l.lines))
+	}
+	for i, line := range lines {
+		if i < len(l.lines) && l.lines[i] == line {
+			fmt.Fprint(l.w, "\x1b[1B")
+			continue
+		}
+		fmt.Fprintf(l.w, "\r\x1b[2K%s\n", line)
+	}
+	if len(lines) < len(l.lines) {
+		// clear the lines left over from the previous block
+		fmt.Fprint(l.w, "\x1b[J")
+	}
+	l.lines = lines
+}
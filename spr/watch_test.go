@@ -0,0 +1,139 @@
+package spr
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/github/fakegithub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveLines(t *testing.T) {
+	out := &bytes.Buffer{}
+	live := &liveLines{w: out, tty: true}
+
+	live.draw([]string{"a", "b", "c"})
+	require.Equal(t, "\r\x1b[2Ka\n\r\x1b[2Kb\n\r\x1b[2Kc\n", out.String())
+	out.Reset()
+
+	// unchanged blocks are not redrawn
+	live.draw([]string{"a", "b", "c"})
+	require.Empty(t, out.String())
+
+	// only the changed line is rewritten
+	live.draw([]string{"a", "B", "c"})
+	require.Equal(t, "\x1b[3A\x1b[1B\r\x1b[2KB\n\x1b[1B", out.String())
+	out.Reset()
+
+	// lines left over from a longer block are cleared
+	live.draw([]string{"a"})
+	require.Equal(t, "\x1b[3A\x1b[1B\x1b[J", out.String())
+	out.Reset()
+
+	// without a terminal the whole block is printed when it changes
+	live = &liveLines{w: out}
+	live.draw([]string{"a", "b"})
+	live.draw([]string{"a", "b"})
+	live.draw([]string{"a", "c"})
+	require.Equal(t, "a\nb\n\na\nc\n", out.String())
+}
+
+// syncBuffer is a buffer which can be read while the watch goroutine writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestHermeticWatchMerge(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	h.commit("test commit 1", "00000001")
+	h.commit("test commit 2", "00000002")
+	c3 := h.commit("test commit 3", "00000003")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	// approvals arrive while watching, the failing top commit is left out by the count
+	h.fake.SetCommitStatus(c3, "FAILURE")
+	output := &syncBuffer{}
+	h.sd.Output = output
+	done := make(chan error)
+	count := uint(2)
+	go func() {
+		done <- h.sd.WatchPullRequests(ctx, 10*time.Millisecond, true, &count)
+	}()
+	assert.Eventually(func() bool { return strings.Contains(output.String(), "waiting") },
+		30*time.Second, 10*time.Millisecond)
+	h.fake.ApproveAll()
+
+	select {
+	case err := <-done:
+		assert.NoError(err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("watch didn't merge")
+	}
+	h.output.WriteString(output.String())
+	assert.Equal([]string{
+		"[xxvx]   3 : test commit 3",
+		"[vxvx]   2 : test commit 2",
+		"[vxvx]   1 : test commit 1",
+		"waiting for 2 of 2 pull requests to be ready to merge",
+		"",
+		"[xvvx]   3 : test commit 3",
+		"[vvvv]   2 : test commit 2",
+		"[vvvv]   1 : test commit 1",
+		"merging 2 pull requests",
+		"MERGED   1 : test commit 1",
+		"MERGED   2 : test commit 2",
+	}, h.lines())
+	pr2, _ := h.fake.PullRequest(2)
+	assert.Equal(fakegithub.StateMerged, pr2.State)
+}
+
+func TestHermeticWatchStops(t *testing.T) {
+	h := makeHermeticObjects(t, false)
+	assert := require.New(t)
+	ctx := context.Background()
+
+	assert.NoError(h.sd.WatchPullRequests(ctx, time.Second, false, nil))
+	assert.Equal([]string{"pull request stack is empty"}, h.lines())
+
+	h.commit("test commit 1", "00000001")
+	assert.NoError(h.sd.UpdatePullRequests(ctx, nil, nil))
+	h.output.Reset()
+
+	// watching stops without an error when cancelled
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	assert.NoError(h.sd.WatchPullRequests(ctx, 10*time.Millisecond, false, nil))
+	assert.Equal([]string{
+		"[vxvx]   1 : test commit 1",
+		"waiting for 1 of 1 pull requests to be ready to merge",
+	}, h.lines())
+
+	// the bell rings once the stack is ready
+	h.fake.ApproveAll()
+	assert.NoError(h.sd.WatchPullRequests(context.Background(), time.Second, false, nil))
+	assert.Equal([]string{
+		"[vvvv]   1 : test commit 1",
+		"1 pull requests are ready to merge\a",
+	}, h.lines())
+}
@@ -0,0 +1,26 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+// MakeRaw is not supported on this platform
+func MakeRaw(f *os.File) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// IsTerminal always returns false on this platform
+func IsTerminal(f *os.File) bool {
+	return false
+}
+
+// Size is not supported on this platform
+func Size(f *os.File) (int, int, error) {
+	return 0, 0, errors.New("unimplemented")
+}
+
+// NotifyResize is a no-op on this platform
+func NotifyResize(c chan<- os.Signal) {}
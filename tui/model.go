@@ -0,0 +1,571 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/github"
+)
+
+// Keys as reported by ParseKeys, printable keys are reported as themselves
+const (
+	KeyUp        = "up"
+	KeyDown      = "down"
+	KeyEnter     = "enter"
+	KeyEscape    = "esc"
+	KeyBackspace = "backspace"
+	KeyCtrlC     = "ctrl-c"
+)
+
+// ActionKind is an operation on the stack run in response to a key
+type ActionKind int
+
+const (
+	// ActionRefresh reloads the stack
+	ActionRefresh ActionKind = iota
+
+	// ActionUpdate updates the pull requests of the stack, or the PR set of the commit
+	ActionUpdate
+
+	// ActionMerge merges the pull requests up to the commit, or the PR set of the commit
+	ActionMerge
+
+	// ActionAmend amends the staged changes into the commit
+	ActionAmend
+
+	// ActionOpen opens the pull request of the commit in the browser
+	ActionOpen
+
+	// ActionAddToPRSet adds commits to a PR set using the selector
+	ActionAddToPRSet
+)
+
+func (k ActionKind) String() string {
+	switch k {
+	case ActionRefresh:
+		return "refresh"
+	case ActionUpdate:
+		return "update"
+	case ActionMerge:
+		return "merge"
+	case ActionAmend:
+		return "amend"
+	case ActionOpen:
+		return "open"
+	case ActionAddToPRSet:
+		return "add to PR set"
+	default:
+		return "unknown"
+	}
+}
+
+// Action is an operation requested by the user
+type Action struct {
+	Kind ActionKind
+
+	// Commit is the commit under the cursor
+	Commit *bl.PRCommit
+
+	// Count is the number of pull requests from the bottom of the stack to merge
+	Count uint
+
+	// Selector is the PR set selector passed to update
+	Selector string
+
+	// Background actions are started by the ui itself and leave the message line alone
+	Background bool
+}
+
+// prompt is a question shown on the message line waiting for an answer
+type prompt struct {
+	label string
+
+	// confirm prompts run the action on 'y' and are cancelled by any other key
+	confirm bool
+	input   string
+	action  Action
+}
+
+// Model is the state of the terminal ui.
+//
+//	The model does no io, keys are fed in with HandleKey and the screen is
+//	drawn from Render, which keeps it testable without a terminal.
+type Model struct {
+	config  *config.Config
+	commits []*bl.PRCommit
+	cursor  int
+
+	// expanded commits show their pull request details, keyed by commit id
+	expanded map[string]bool
+
+	// marked commits are added to a PR set together, keyed by commit index
+	marked map[int]bool
+
+	prompt  *prompt
+	message string
+	running *Action
+	loaded  time.Time
+}
+
+// NewModel returns an empty model, the stack is set with SetCommits
+func NewModel(cfg *config.Config) *Model {
+	return &Model{
+		config:   cfg,
+		expanded: make(map[string]bool),
+		marked:   make(map[int]bool),
+	}
+}
+
+// SetCommits replaces the stack, the top commit first.
+//
+//	The cursor stays on the same commit when it is still in the stack.
+func (m *Model) SetCommits(commits []*bl.PRCommit, loaded time.Time) {
+	var cursorID string
+	if c := m.Current(); c != nil {
+		cursorID = c.CommitID
+	}
+	m.commits = commits
+	m.loaded = loaded
+	m.cursor = 0
+	for i, c := range commits {
+		if c.CommitID == cursorID {
+			m.cursor = i
+		}
+	}
+
+	for index := range m.marked {
+		if index >= len(commits) {
+			delete(m.marked, index)
+		}
+	}
+}
+
+// Current returns the commit under the cursor or nil when the stack is empty
+func (m *Model) Current() *bl.PRCommit {
+	if m.cursor < 0 || m.cursor >= len(m.commits) {
+		return nil
+	}
+	return m.commits[m.cursor]
+}
+
+// SetMessage sets the message line
+func (m *Model) SetMessage(format string, a ...interface{}) {
+	m.message = fmt.Sprintf(format, a...)
+}
+
+// Start marks the action as running, keys that start other actions are refused until it's done
+func (m *Model) Start(action Action) {
+	m.running = &action
+	if !action.Background {
+		m.message = action.Kind.String() + "..."
+	}
+}
+
+// Done marks the running action as finished with the given error
+func (m *Model) Done(action Action, err error) {
+	m.running = nil
+	switch {
+	case err != nil:
+		m.message = fmt.Sprintf("%s failed: %s", action.Kind, firstLine(err.Error()))
+	case action.Kind == ActionRefresh:
+		if !action.Background {
+			m.message = ""
+		}
+	case action.Kind == ActionAddToPRSet:
+		m.marked = make(map[int]bool)
+		m.message = action.Kind.String() + " done"
+	default:
+		m.message = action.Kind.String() + " done"
+	}
+}
+
+// Busy returns true while an action is running
+func (m *Model) Busy() bool {
+	return m.running != nil
+}
+
+// HandleKey updates the model for the given key.
+//
+//	It returns the action to run, if any, and whether the ui should quit.
+func (m *Model) HandleKey(key string) (*Action, bool) {
+	if key == KeyCtrlC {
+		return nil, true
+	}
+	if m.prompt != nil {
+		return m.handlePromptKey(key), false
+	}
+
+	switch key {
+	case "q":
+		return nil, true
+	case KeyUp, "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case KeyDown, "j":
+		if m.cursor < len(m.commits)-1 {
+			m.cursor++
+		}
+	case "g":
+		m.cursor = 0
+	case "G":
+		m.cursor = max(len(m.commits)-1, 0)
+	case KeyEnter, "l", "h":
+		if c := m.Current(); c != nil {
+			m.expanded[c.CommitID] = !m.expanded[c.CommitID]
+		}
+	case KeyEscape:
+		m.message = ""
+	case "r":
+		return m.start(Action{Kind: ActionRefresh}), false
+	case "u":
+		return m.update(), false
+	case "m":
+		return m.merge(), false
+	case "a":
+		return m.amend(), false
+	case "o":
+		return m.open(), false
+	case " ":
+		m.mark()
+	case "s":
+		return m.addToPRSet(), false
+	}
+	return nil, false
+}
+
+func (m *Model) handlePromptKey(key string) *Action {
+	p := m.prompt
+	if p.confirm {
+		m.prompt = nil
+		if key == "y" || key == "Y" {
+			return m.start(p.action)
+		}
+		m.message = p.action.Kind.String() + " cancelled"
+		return nil
+	}
+
+	switch key {
+	case KeyEscape:
+		m.prompt = nil
+		m.message = p.action.Kind.String() + " cancelled"
+	case KeyEnter:
+		m.prompt = nil
+		action := p.action
+		input := strings.TrimSpace(p.input)
+		if input != "" {
+			action.Selector = input + "+" + action.Selector
+		}
+		return m.start(action)
+	case KeyBackspace:
+		if len(p.input) > 0 {
+			_, size := utf8.DecodeLastRuneInString(p.input)
+			p.input = p.input[:len(p.input)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			p.input += key
+		}
+	}
+	return nil
+}
+
+// start returns the action unless another one is still running
+func (m *Model) start(action Action) *Action {
+	if m.running != nil {
+		m.message = fmt.Sprintf("busy, %s is still running", m.running.Kind)
+		return nil
+	}
+	return &action
+}
+
+func (m *Model) update() *Action {
+	c := m.Current()
+	if !m.config.User.PRSetWorkflows {
+		return m.start(Action{Kind: ActionUpdate, Commit: c})
+	}
+	if c == nil {
+		m.message = "no commit to update"
+		return nil
+	}
+	// refresh the PR set of the commit, or start a new one with just the commit
+	selector := strconv.Itoa(c.Index)
+	if c.PRIndex != nil {
+		selector = fmt.Sprintf("s%d:s%d", *c.PRIndex, *c.PRIndex)
+	}
+	return m.start(Action{Kind: ActionUpdate, Commit: c, Selector: selector})
+}
+
+func (m *Model) merge() *Action {
+	c := m.Current()
+	if c == nil || c.PullRequest == nil {
+		m.message = "no pull request to merge"
+		return nil
+	}
+
+	action := Action{Kind: ActionMerge, Commit: c}
+	label := ""
+	if m.config.User.PRSetWorkflows {
+		if c.PRIndex == nil {
+			m.message = "commit is not in a PR set"
+			return nil
+		}
+		label = fmt.Sprintf("merge PR set s%d?", *c.PRIndex)
+	} else {
+		for _, other := range m.commits {
+			if other.PullRequest != nil && other.Index <= c.Index {
+				action.Count++
+			}
+		}
+		label = fmt.Sprintf("merge %d pull requests up to %s?", action.Count,
+			github.PullRequestReference(m.config, c.PullRequest.Number))
+	}
+	m.prompt = &prompt{label: label + " [y/N]", confirm: true, action: action}
+	return nil
+}
+
+func (m *Model) amend() *Action {
+	c := m.Current()
+	if c == nil {
+		m.message = "no commit to amend"
+		return nil
+	}
+	m.prompt = &prompt{
+		label:   fmt.Sprintf("amend staged changes into %q? [y/N]", c.Subject),
+		confirm: true,
+		action:  Action{Kind: ActionAmend, Commit: c},
+	}
+	return nil
+}
+
+func (m *Model) open() *Action {
+	c := m.Current()
+	if c == nil || c.PullRequest == nil {
+		m.message = "no pull request to open"
+		return nil
+	}
+	return m.start(Action{Kind: ActionOpen, Commit: c})
+}
+
+func (m *Model) mark() {
+	c := m.Current()
+	if c == nil || !m.config.User.PRSetWorkflows {
+		return
+	}
+	if m.marked[c.Index] {
+		delete(m.marked, c.Index)
+	} else {
+		m.marked[c.Index] = true
+	}
+}
+
+// addToPRSet prompts for the PR set the marked commits, or the commit under the cursor, are added to
+func (m *Model) addToPRSet() *Action {
+	if !m.config.User.PRSetWorkflows {
+		m.message = "PR sets are not enabled, set prSetWorkflows in ~/.spr.yml"
+		return nil
+	}
+	var indices []int
+	for index := range m.marked {
+		indices = append(indices, index)
+	}
+	if len(indices) == 0 {
+		c := m.Current()
+		if c == nil {
+			m.message = "no commit to add"
+			return nil
+		}
+		indices = append(indices, c.Index)
+	}
+	sort.Ints(indices)
+	var selector []string
+	for _, index := range indices {
+		selector = append(selector, strconv.Itoa(index))
+	}
+
+	m.prompt = &prompt{
+		label:  fmt.Sprintf("add commits %s to PR set (empty for a new set): ", strings.Join(selector, ",")),
+		action: Action{Kind: ActionAddToPRSet, Selector: strings.Join(selector, ",")},
+	}
+	return nil
+}
+
+// Render draws the model into at most height lines of at most width characters
+func (m *Model) Render(width int, height int) []string {
+	header := fmt.Sprintf("spr %s/%s:%s", m.config.Repo.GitHubRepoOwner, m.config.Repo.GitHubRepoName,
+		m.config.Repo.GitHubBranch)
+	if !m.loaded.IsZero() {
+		header += " - updated " + m.loaded.Format("15:04:05")
+	}
+
+	var body []string
+	cursorLine := 0
+	for i, c := range m.commits {
+		if i == m.cursor {
+			cursorLine = len(body)
+		}
+		body = append(body, m.commitLine(i, c))
+		if m.expanded[c.CommitID] {
+			body = append(body, m.details(c)...)
+		}
+	}
+	if len(m.commits) == 0 {
+		body = append(body, "  no local commits")
+	}
+
+	message := m.message
+	if m.prompt != nil {
+		message = m.prompt.label + m.prompt.input
+	}
+
+	// scroll the body so the cursor stays visible between the header and the footer
+	bodyHeight := max(height-3, 1)
+	start := 0
+	if cursorLine >= bodyHeight {
+		start = cursorLine - bodyHeight + 1
+	}
+	end := min(start+bodyHeight, len(body))
+	body = body[start:end]
+
+	lines := []string{header}
+	lines = append(lines, body...)
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, message, m.help())
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	return lines
+}
+
+func (m *Model) commitLine(i int, c *bl.PRCommit) string {
+	prefix := "  "
+	if i == m.cursor {
+		prefix = "> "
+	}
+	if m.marked[c.Index] {
+		prefix = prefix[:1] + "*"
+	}
+
+	if m.config.User.PRSetWorkflows {
+		return prefix + c.String(m.config)
+	}
+	if c.PullRequest != nil {
+		return prefix + c.PullRequest.String(m.config)
+	}
+	empty := github.StatusBitIcons(m.config)["empty"]
+	subject := c.Subject
+	if c.WIP {
+		subject = "WIP " + subject
+	}
+	return fmt.Sprintf("%s[%s] %3s : %s", prefix, strings.Repeat(empty, 4), "-", subject)
+}
+
+// details are the lines shown under an expanded commit
+func (m *Model) details(c *bl.PRCommit) []string {
+	indent := "        "
+	lines := []string{
+		indent + fmt.Sprintf("commit     %s %s", shortHash(c.CommitHash), c.CommitID),
+	}
+	pr := c.PullRequest
+	if pr == nil {
+		return append(lines, indent+"no pull request, press u to create it")
+	}
+
+	review := "not approved"
+	if pr.MergeStatus.ReviewApproved {
+		review = "approved"
+	}
+	if !pr.MergeStatus.Reviews.Empty() {
+		review += ", " + pr.MergeStatus.Reviews.String()
+	}
+	if !m.config.Repo.RequireApproval {
+		review += " (not required)"
+	}
+	checks := pr.MergeStatus.ChecksPass.Name()
+	if !m.config.Repo.RequireChecks {
+		checks += " (not required)"
+	}
+	conflicts := "none"
+	if !pr.MergeStatus.NoConflicts {
+		conflicts = "merge conflicts with " + pr.ToBranch
+	}
+	stacked := "ready to merge with the commits below"
+	if !pr.MergeStatus.Stacked {
+		stacked = "commits below are not ready to merge"
+	}
+
+	lines = append(lines,
+		indent+fmt.Sprintf("checks     %s", checks),
+		indent+fmt.Sprintf("review     %s", review),
+		indent+fmt.Sprintf("conflicts  %s", conflicts),
+		indent+fmt.Sprintf("stack      %s", stacked),
+		indent+fmt.Sprintf("branch     %s -> %s", pr.FromBranch, pr.ToBranch),
+	)
+	if len(pr.Commits) > 1 {
+		lines = append(lines, indent+fmt.Sprintf("commits    %d, the pull request has more than one commit", len(pr.Commits)))
+	}
+	if pr.InQueue {
+		lines = append(lines, indent+"queue      in the merge queue")
+	}
+	return append(lines, indent+fmt.Sprintf("url        %s", github.PullRequestURL(m.config, pr.Number)))
+}
+
+func (m *Model) help() string {
+	help := "j/k move  enter details  u update  m merge to here  a amend  o open  r refresh  q quit"
+	if m.config.User.PRSetWorkflows {
+		help = "j/k move  enter details  u update set  m merge set  a amend  o open  space mark  s add to set  r refresh  q quit"
+	}
+	return help
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// truncate cuts s to width visible characters, ansi color sequences don't count toward the width
+func truncate(s string, width int) string {
+	var out strings.Builder
+	visible := 0
+	inEscape := false
+	truncated := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			out.WriteRune(r)
+			if r == 'm' {
+				inEscape = false
+			}
+		case r == '\x1b':
+			inEscape = true
+			out.WriteRune(r)
+		case visible < width:
+			out.WriteRune(r)
+			visible++
+		default:
+			truncated = true
+		}
+	}
+	if truncated {
+		out.WriteString(github.ColorReset)
+	}
+	return out.String()
+}
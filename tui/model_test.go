@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/git"
+	"github.com/ejoffe/spr/github"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(prSetWorkflows bool) *config.Config {
+	cfg := config.EmptyConfig()
+	cfg.Repo.GitHubHost = "github.com"
+	cfg.Repo.GitHubRepoOwner = "owner"
+	cfg.Repo.GitHubRepoName = "repo"
+	cfg.Repo.GitHubBranch = "main"
+	cfg.Repo.RequireChecks = true
+	cfg.Repo.RequireApproval = true
+	cfg.User.PRSetWorkflows = prSetWorkflows
+	return cfg
+}
+
+func testPullRequest(number int, commitID string) *github.PullRequest {
+	return &github.PullRequest{
+		Number:     number,
+		FromBranch: "spr/main/" + commitID,
+		ToBranch:   "main",
+		Title:      "commit " + commitID,
+		Commit:     git.Commit{CommitID: commitID, Subject: "commit " + commitID},
+		MergeStatus: github.PullRequestMergeStatus{
+			ChecksPass:     github.CheckStatusPass,
+			ReviewApproved: true,
+			NoConflicts:    true,
+			Stacked:        true,
+		},
+	}
+}
+
+// testCommits returns a stack of three commits, the top one has no pull request
+func testCommits() []*bl.PRCommit {
+	set := 0
+	return []*bl.PRCommit{
+		{Commit: git.Commit{CommitID: "00000003", CommitHash: "3333333333", Subject: "commit 3"}, Index: 2},
+		{Commit: git.Commit{CommitID: "00000002", CommitHash: "2222222222", Subject: "commit 2"}, Index: 1,
+			PullRequest: testPullRequest(2, "00000002"), PRIndex: &set},
+		{Commit: git.Commit{CommitID: "00000001", CommitHash: "1111111111", Subject: "commit 1"}, Index: 0,
+			PullRequest: testPullRequest(1, "00000001"), PRIndex: &set},
+	}
+}
+
+func keys(m *Model, keys ...string) *Action {
+	var action *Action
+	for _, key := range keys {
+		action, _ = m.HandleKey(key)
+	}
+	return action
+}
+
+func TestCursor(t *testing.T) {
+	m := NewModel(testConfig(false))
+	m.SetCommits(testCommits(), time.Time{})
+	require.Equal(t, "00000003", m.Current().CommitID)
+
+	keys(m, KeyUp)
+	require.Equal(t, "00000003", m.Current().CommitID)
+	keys(m, KeyDown, "j", "j")
+	require.Equal(t, "00000001", m.Current().CommitID)
+	keys(m, "k")
+	require.Equal(t, "00000002", m.Current().CommitID)
+
+	// the cursor follows its commit when the stack changes
+	m.SetCommits(testCommits()[1:], time.Time{})
+	require.Equal(t, "00000002", m.Current().CommitID)
+	m.SetCommits(testCommits()[2:], time.Time{})
+	require.Equal(t, "00000001", m.Current().CommitID)
+
+	_, quit := m.HandleKey("q")
+	require.True(t, quit)
+	_, quit = m.HandleKey(KeyCtrlC)
+	require.True(t, quit)
+}
+
+func TestRender(t *testing.T) {
+	m := NewModel(testConfig(false))
+	m.SetCommits(testCommits(), time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC))
+	keys(m, "j", KeyEnter)
+
+	lines := m.Render(200, 20)
+	require.Len(t, lines, 20)
+	require.Equal(t, "spr owner/repo:main - updated 10:30:00", lines[0])
+	require.Equal(t, "  [----]   - : commit 3", lines[1])
+	require.Contains(t, lines[2], "> ")
+	require.Contains(t, lines[2], "2 : commit 00000002")
+	require.Equal(t, "        commit     22222222 00000002", lines[3])
+	require.Equal(t, "        checks     pass", lines[4])
+	require.Equal(t, "        review     approved", lines[5])
+	require.Equal(t, "        conflicts  none", lines[6])
+	require.Equal(t, "        url        https://github.com/owner/repo/pull/2", lines[9])
+	require.Contains(t, lines[10], "1 : commit 00000001")
+	require.Equal(t, "", lines[18])
+	require.True(t, strings.HasPrefix(lines[19], "j/k move"))
+
+	// lines are cut to the width
+	for _, line := range m.Render(10, 20) {
+		require.LessOrEqual(t, len([]rune(stripColors(line))), 10)
+	}
+
+	// the body scrolls to keep the cursor on screen
+	keys(m, "G")
+	lines = m.Render(200, 5)
+	require.Len(t, lines, 5)
+	require.Contains(t, lines[1], "url")
+	require.Contains(t, lines[2], "> ")
+	require.Contains(t, lines[2], "commit 00000001")
+}
+
+func TestActions(t *testing.T) {
+	m := NewModel(testConfig(false))
+	m.SetCommits(testCommits(), time.Time{})
+
+	// no pull request to merge or open on the top commit
+	require.Nil(t, keys(m, "m"))
+	require.Equal(t, "no pull request to merge", m.message)
+	require.Nil(t, keys(m, "o"))
+
+	action := keys(m, "u")
+	require.Equal(t, ActionUpdate, action.Kind)
+
+	// merging asks for confirmation and counts the pull requests up to the cursor
+	require.Nil(t, keys(m, "j", "m"))
+	require.Equal(t, "merge 2 pull requests up to #2? [y/N]", m.Render(200, 10)[8])
+	require.Nil(t, keys(m, "n"))
+	require.Equal(t, "merge cancelled", m.message)
+	action = keys(m, "m", "y")
+	require.Equal(t, ActionMerge, action.Kind)
+	require.Equal(t, uint(2), action.Count)
+	require.Equal(t, "00000002", action.Commit.CommitID)
+
+	action = keys(m, "a", "y")
+	require.Equal(t, ActionAmend, action.Kind)
+	require.Equal(t, "2222222222", action.Commit.CommitHash)
+
+	// other actions are refused while one is running
+	m.Start(*action)
+	require.Nil(t, keys(m, "o"))
+	require.Equal(t, "busy, amend is still running", m.message)
+	m.Done(*action, errors.New("nothing to commit\nmore details"))
+	require.Equal(t, "amend failed: nothing to commit", m.message)
+
+	action = keys(m, "o")
+	require.Equal(t, ActionOpen, action.Kind)
+
+	// PR sets are only available in the PR set workflow
+	require.Nil(t, keys(m, "s"))
+	require.Contains(t, m.message, "PR sets are not enabled")
+}
+
+func TestPRSetActions(t *testing.T) {
+	m := NewModel(testConfig(true))
+	m.SetCommits(testCommits(), time.Time{})
+
+	// a commit without a PR set is updated into a new set, otherwise its set is updated
+	require.Equal(t, "2", keys(m, "u").Selector)
+	require.Equal(t, "s0:s0", keys(m, "j", "u").Selector)
+
+	action := keys(m, "m", "y")
+	require.Equal(t, ActionMerge, action.Kind)
+	require.Equal(t, 0, *action.Commit.PRIndex)
+
+	// marked commits are added to the typed PR set
+	action = keys(m, " ", "k", " ", "s", "s", "1", KeyEnter)
+	require.Equal(t, ActionAddToPRSet, action.Kind)
+	require.Equal(t, "s1+1,2", action.Selector)
+
+	// an empty PR set creates a new one
+	action = keys(m, "s", "s", KeyBackspace, KeyEnter)
+	require.Equal(t, "1,2", action.Selector)
+
+	m.Done(*action, nil)
+	action = keys(m, "s", KeyEnter)
+	require.Equal(t, "2", action.Selector)
+
+	require.Nil(t, keys(m, "s", KeyEscape))
+	require.Equal(t, "add to PR set cancelled", m.message)
+}
+
+func TestParseKeys(t *testing.T) {
+	require.Equal(t, []string{KeyUp, KeyDown, "j", KeyEnter, KeyBackspace, KeyCtrlC, KeyEscape, " ", "é"},
+		ParseKeys([]byte("\x1b[A\x1bOBj\r\x7f\x03\x1b é")))
+
+	// unknown escape sequences are skipped
+	require.Equal(t, []string{"q"}, ParseKeys([]byte("\x1b[1;5Cq")))
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "abc", truncate("abc", 5))
+	require.Equal(t, "ab"+github.ColorReset, truncate("abc", 2))
+	require.Equal(t, github.ColorRed+"ab"+github.ColorReset, truncate(github.ColorRed+"abcd", 2))
+}
+
+func stripColors(s string) string {
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "m")
+		s = s[:start] + s[start+end+1:]
+	}
+}
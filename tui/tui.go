@@ -0,0 +1,256 @@
+// Package tui is a full screen interactive view of the commit stack.
+//
+//	The view is drawn with plain ansi escape sequences, the model in model.go
+//	holds all the state and the run loop in this file feeds it keys, stack
+//	refreshes and the results of the actions it requests.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ejoffe/spr/bl"
+	"github.com/ejoffe/spr/config"
+	"github.com/ejoffe/spr/github"
+	"github.com/ejoffe/spr/spr"
+	"github.com/ejoffe/spr/terminal"
+)
+
+// Backend loads the stack and runs the actions requested from the ui
+type Backend interface {
+	// Stack returns the local commits and their pull requests, the top of the stack first
+	Stack(ctx context.Context) ([]*bl.PRCommit, error)
+
+	// Run runs the given action, the stack is reloaded after it completes
+	Run(ctx context.Context, action Action) error
+}
+
+// NewBackend returns a backend running actions on the given stack.
+//
+//	Command output is discarded, the ui reports when an action is done or failed.
+func NewBackend(cfg *config.Config, sd *spr.Stackediff) Backend {
+	sd.Output = io.Discard
+	return &stackBackend{config: cfg, sd: sd}
+}
+
+type stackBackend struct {
+	config *config.Config
+	sd     *spr.Stackediff
+}
+
+func (b *stackBackend) Stack(ctx context.Context) ([]*bl.PRCommit, error) {
+	return b.sd.Stack(ctx)
+}
+
+func (b *stackBackend) Run(ctx context.Context, action Action) error {
+	switch action.Kind {
+	case ActionRefresh:
+		return nil
+	case ActionUpdate:
+		if b.config.User.PRSetWorkflows {
+			return b.sd.UpdatePRSets(ctx, action.Selector)
+		}
+		return b.sd.UpdatePullRequests(ctx, nil, nil)
+	case ActionMerge:
+		if b.config.User.PRSetWorkflows {
+			return b.sd.MergePRSet(ctx, fmt.Sprintf("s%d", *action.Commit.PRIndex))
+		}
+		return b.sd.MergePullRequests(ctx, &action.Count)
+	case ActionAmend:
+		return b.sd.FixupCommit(ctx, action.Commit.CommitHash)
+	case ActionOpen:
+		return OpenBrowser(github.PullRequestURL(b.config, action.Commit.PullRequest.Number))
+	case ActionAddToPRSet:
+		return b.sd.UpdatePRSets(ctx, action.Selector)
+	}
+	return fmt.Errorf("unknown action %d", action.Kind)
+}
+
+// OpenBrowser opens the url with the default browser of the platform
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// ParseKeys splits the bytes read from a raw terminal into key names
+func ParseKeys(buf []byte) []string {
+	var keys []string
+	s := string(buf)
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "\x1b[A"), strings.HasPrefix(s, "\x1bOA"):
+			keys = append(keys, KeyUp)
+			s = s[3:]
+		case strings.HasPrefix(s, "\x1b[B"), strings.HasPrefix(s, "\x1bOB"):
+			keys = append(keys, KeyDown)
+			s = s[3:]
+		case strings.HasPrefix(s, "\x1b["), strings.HasPrefix(s, "\x1bO"):
+			// skip other escape sequences up to their final byte
+			end := strings.IndexFunc(s[2:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+			if end < 0 {
+				return keys
+			}
+			s = s[2+end+1:]
+		default:
+			r := []rune(s)[0]
+			switch r {
+			case '\x1b':
+				keys = append(keys, KeyEscape)
+			case '\r', '\n':
+				keys = append(keys, KeyEnter)
+			case '\x7f', '\b':
+				keys = append(keys, KeyBackspace)
+			case '\x03':
+				keys = append(keys, KeyCtrlC)
+			default:
+				if r >= ' ' {
+					keys = append(keys, string(r))
+				}
+			}
+			s = s[len(string(r)):]
+		}
+	}
+	return keys
+}
+
+// result is the outcome of an action run in the background
+type result struct {
+	action  Action
+	err     error
+	commits []*bl.PRCommit
+	loaded  time.Time
+}
+
+// Run shows the ui on the terminal until the user quits.
+//
+//	The stack is reloaded every refreshInterval and after every action.
+func Run(ctx context.Context, cfg *config.Config, backend Backend, refreshInterval time.Duration) error {
+	in, out := os.Stdin, os.Stdout
+	restore, err := terminal.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("spr tui needs an interactive terminal: %w", err)
+	}
+	defer restore()
+
+	screen := bufio.NewWriter(out)
+	// switch to the alternate screen and hide the cursor, undone on exit
+	fmt.Fprint(screen, "\x1b[?1049h\x1b[?25l")
+	screen.Flush()
+	defer func() {
+		fmt.Fprint(screen, "\x1b[?25h\x1b[?1049l")
+		screen.Flush()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keys := make(chan string)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			for _, key := range ParseKeys(buf[:n]) {
+				select {
+				case keys <- key:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	resize := make(chan os.Signal, 1)
+	terminal.NotifyResize(resize)
+
+	results := make(chan result)
+	run := func(action Action) {
+		go func() {
+			res := result{action: action}
+			res.err = backend.Run(ctx, action)
+			if action.Kind != ActionOpen {
+				commits, err := backend.Stack(ctx)
+				if err == nil {
+					res.commits, res.loaded = commits, time.Now()
+				}
+				res.err = errors.Join(res.err, err)
+			}
+			select {
+			case results <- res:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	model := NewModel(cfg)
+	model.Start(Action{Kind: ActionRefresh})
+	run(Action{Kind: ActionRefresh})
+
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		width, height, err := terminal.Size(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		draw(screen, model.Render(width, height))
+
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			action, quit := model.HandleKey(key)
+			if quit {
+				return nil
+			}
+			if action != nil {
+				model.Start(*action)
+				run(*action)
+			}
+		case res := <-results:
+			if !res.loaded.IsZero() {
+				model.SetCommits(res.commits, res.loaded)
+			}
+			model.Done(res.action, res.err)
+		case <-ticker.C:
+			if !model.Busy() {
+				refresh := Action{Kind: ActionRefresh, Background: true}
+				model.Start(refresh)
+				run(refresh)
+			}
+		case <-resize:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// draw redraws the whole screen, the terminal is in raw mode so every line is positioned explicitly
+func draw(w *bufio.Writer, lines []string) {
+	for i, line := range lines {
+		fmt.Fprintf(w, "\x1b[%d;1H\x1b[2K%s%s", i+1, line, github.ColorReset)
+	}
+	fmt.Fprint(w, "\x1b[J")
+	w.Flush()
+}